@@ -9,6 +9,13 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+const (
+	// SourceKindLogicalVolume is the source kind of LogicalVolume restored from a snapshot or cloned from a volume.
+	SourceKindLogicalVolume = "LogicalVolume"
+	// SourceKindLogicalVolumeBackup is the source kind of LogicalVolume restored from a LogicalVolumeBackup.
+	SourceKindLogicalVolumeBackup = "LogicalVolumeBackup"
+)
+
 // LogicalVolumeSpec defines the desired state of LogicalVolume
 type LogicalVolumeSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -25,11 +32,51 @@ type LogicalVolumeSpec struct {
 	//+kubebuilder:validation:Optional
 	Source string `json:"source,omitempty"`
 
+	// 'sourceKind' specifies the kind of the source; either "LogicalVolume" or "LogicalVolumeBackup".
+	// "LogicalVolume" is assumed if empty.
+	// This field is populated only when LogicalVolume has a source.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum="";LogicalVolume;LogicalVolumeBackup
+	SourceKind string `json:"sourceKind,omitempty"`
+
 	//'accessType' specifies how the user intends to consume the snapshot logical volume.
 	// Set to "ro" when creating a snapshot and to "rw" when restoring a snapshot or creating a clone.
 	// This field is populated only when LogicalVolume has a source.
 	//+kubebuilder:validation:Optional
 	AccessType string `json:"accessType,omitempty"`
+
+	// 'cacheMode' specifies the cache mode of the logical volume of a cached device-class; either "writethrough" or "writeback".
+	// The cache mode of the device-class is used if empty.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum="";writethrough;writeback
+	CacheMode string `json:"cacheMode,omitempty"`
+
+	// 'ioLimits' specifies the IO throttling of the logical volume applied to the pods using it.
+	//+kubebuilder:validation:Optional
+	IOLimits *IOLimits `json:"ioLimits,omitempty"`
+}
+
+// IOLimits defines the IO throttling of a logical volume. Zero or omitted fields mean unlimited.
+type IOLimits struct {
+	// 'readIOPS' is the maximum number of read operations per second.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	ReadIOPS int64 `json:"readIOPS,omitempty"`
+
+	// 'writeIOPS' is the maximum number of write operations per second.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	WriteIOPS int64 `json:"writeIOPS,omitempty"`
+
+	// 'readBytesPerSecond' is the maximum number of bytes read per second.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	ReadBytesPerSecond int64 `json:"readBytesPerSecond,omitempty"`
+
+	// 'writeBytesPerSecond' is the maximum number of bytes written per second.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	WriteBytesPerSecond int64 `json:"writeBytesPerSecond,omitempty"`
 }
 
 // LogicalVolumeStatus defines the observed state of LogicalVolume
@@ -40,6 +87,63 @@ type LogicalVolumeStatus struct {
 	Code        codes.Code         `json:"code,omitempty"`
 	Message     string             `json:"message,omitempty"`
 	CurrentSize *resource.Quantity `json:"currentSize,omitempty"`
+
+	// 'transfer' reports the progress of copying the data of the source from another node or a backup repository.
+	// This field is populated only when LogicalVolume is restored or cloned across nodes, or restored from a backup.
+	//+kubebuilder:validation:Optional
+	Transfer *TransferStatus `json:"transfer,omitempty"`
+
+	// 'health' reports the health of the logical volume checked periodically by topolvm-node.
+	//+kubebuilder:validation:Optional
+	Health *VolumeHealth `json:"health,omitempty"`
+
+	// 'currentLvcreateOptionClass' is the lvcreate-option-class applied to the logical volume.
+	// The logical volume is modified when this differs from spec.lvcreateOptionClass.
+	// This field is not populated for logical volumes created before the volume modification is supported.
+	//+kubebuilder:validation:Optional
+	CurrentLvcreateOptionClass *string `json:"currentLvcreateOptionClass,omitempty"`
+
+	// 'currentCacheMode' is the cache mode applied to the logical volume.
+	// The logical volume is modified when this differs from spec.cacheMode.
+	// This field is not populated for logical volumes created before the volume modification is supported.
+	//+kubebuilder:validation:Optional
+	CurrentCacheMode *string `json:"currentCacheMode,omitempty"`
+}
+
+// VolumeHealth defines the health of a logical volume derived from its LVM attributes.
+type VolumeHealth struct {
+	// 'abnormal' is set to true when the logical volume is not healthy.
+	Abnormal bool `json:"abnormal"`
+
+	// 'message' describes the health of the logical volume.
+	//+kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+// TransferStatus defines the observed state of a data transfer from the source on another node or a backup repository.
+type TransferStatus struct {
+	// 'sourceNodeName' is the node where the source logical volume exists.
+	// This field is empty when LogicalVolume is restored from a backup.
+	//+kubebuilder:validation:Optional
+	SourceNodeName string `json:"sourceNodeName,omitempty"`
+
+	// 'totalBytes' is the number of bytes to be transferred.
+	// Only the allocated regions are transferred for thin logical volumes.
+	//+kubebuilder:validation:Optional
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+
+	// 'transferredBytes' is the number of bytes transferred so far.
+	//+kubebuilder:validation:Optional
+	TransferredBytes int64 `json:"transferredBytes,omitempty"`
+
+	// 'completed' is set to true when all data has been transferred.
+	//+kubebuilder:validation:Optional
+	Completed bool `json:"completed,omitempty"`
+
+	// 'tokenHash' is the SHA-256 hash of the token which the node of this LogicalVolume presents
+	// to read the source from another node. The token itself is never stored.
+	//+kubebuilder:validation:Optional
+	TokenHash string `json:"tokenHash,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -63,6 +167,9 @@ func (lv *LogicalVolume) IsCompatibleWith(lv2 *LogicalVolume) bool {
 	if lv.Spec.Source != lv2.Spec.Source {
 		return false
 	}
+	if lv.Spec.SourceKind != lv2.Spec.SourceKind {
+		return false
+	}
 	if lv.Spec.Size.Cmp(lv2.Spec.Size) != 0 {
 		return false
 	}
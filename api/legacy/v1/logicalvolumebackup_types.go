@@ -0,0 +1,116 @@
+package v1
+
+import (
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogicalVolumeBackupSpec defines the desired state of LogicalVolumeBackup
+type LogicalVolumeBackupSpec struct {
+	// 'logicalVolume' is the name of the LogicalVolume to back up.
+	LogicalVolume string `json:"logicalVolume"`
+
+	// 'repository' specifies the S3-compatible object store where the backup is stored.
+	Repository BackupRepository `json:"repository"`
+
+	// 'base' is the name of a completed LogicalVolumeBackup of the same LogicalVolume.
+	// If present, only the regions changed since the base are read and uploaded.
+	//+kubebuilder:validation:Optional
+	Base string `json:"base,omitempty"`
+}
+
+// BackupRepository specifies a bucket of an S3-compatible object store.
+type BackupRepository struct {
+	// 'endpoint' is the URL of the object store, e.g. "https://minio.example.com:9000".
+	Endpoint string `json:"endpoint"`
+
+	// 'bucket' is the name of the bucket. The bucket is accessed with path-style URLs.
+	Bucket string `json:"bucket"`
+
+	// 'prefix' is prepended to the keys of the objects.
+	//+kubebuilder:validation:Optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// 'region' is the region of the bucket. "us-east-1" is used if empty.
+	//+kubebuilder:validation:Optional
+	Region string `json:"region,omitempty"`
+
+	// 'credentialsSecretRef' refers to a Secret having "accessKeyID" and "secretAccessKey" keys.
+	CredentialsSecretRef corev1.SecretReference `json:"credentialsSecretRef"`
+}
+
+// LogicalVolumeBackupStatus defines the observed state of LogicalVolumeBackup
+type LogicalVolumeBackupStatus struct {
+	// 'nodeName' is the node where the backup is taken.
+	//+kubebuilder:validation:Optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// 'deviceClass' is the device class of the backed up logical volume and the snapshot.
+	//+kubebuilder:validation:Optional
+	DeviceClass string `json:"deviceClass,omitempty"`
+
+	// 'snapshotID' is the name of the snapshot taken for the backup.
+	// A thin snapshot is kept until the LogicalVolumeBackup or the LogicalVolume is deleted so that it can be
+	// the base of the next backup. A thick snapshot is removed after the upload, and this field is cleared.
+	//+kubebuilder:validation:Optional
+	SnapshotID string `json:"snapshotID,omitempty"`
+
+	// 'manifest' is the key of the object describing the chunks of the backup.
+	//+kubebuilder:validation:Optional
+	Manifest string `json:"manifest,omitempty"`
+
+	// 'sizeBytes' is the size of the backed up logical volume.
+	//+kubebuilder:validation:Optional
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+
+	// 'totalBytes' is the number of bytes to be read from the snapshot.
+	//+kubebuilder:validation:Optional
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+
+	// 'readBytes' is the number of bytes read from the snapshot so far.
+	//+kubebuilder:validation:Optional
+	ReadBytes int64 `json:"readBytes,omitempty"`
+
+	// 'uploadedBytes' is the number of compressed bytes of the new chunks uploaded so far.
+	// Chunks already in the repository are not uploaded again.
+	//+kubebuilder:validation:Optional
+	UploadedBytes int64 `json:"uploadedBytes,omitempty"`
+
+	// 'completed' is set to true when the backup is ready to be restored.
+	//+kubebuilder:validation:Optional
+	Completed bool `json:"completed,omitempty"`
+
+	Code    codes.Code `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="LogicalVolume",type=string,JSONPath=`.spec.logicalVolume`
+//+kubebuilder:printcolumn:name="Base",type=string,JSONPath=`.spec.base`
+//+kubebuilder:printcolumn:name="Completed",type=boolean,JSONPath=`.status.completed`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// LogicalVolumeBackup is the Schema for the logicalvolumebackups API
+type LogicalVolumeBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LogicalVolumeBackupSpec   `json:"spec,omitempty"`
+	Status LogicalVolumeBackupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// LogicalVolumeBackupList contains a list of LogicalVolumeBackup
+type LogicalVolumeBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LogicalVolumeBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LogicalVolumeBackup{}, &LogicalVolumeBackupList{})
+}
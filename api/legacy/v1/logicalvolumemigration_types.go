@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogicalVolumeMigrationSpec defines the desired state of LogicalVolumeMigration
+type LogicalVolumeMigrationSpec struct {
+	// 'logicalVolume' is the name of the LogicalVolume to migrate.
+	// Its PersistentVolume must be bound to a PersistentVolumeClaim.
+	LogicalVolume string `json:"logicalVolume"`
+
+	// 'targetNodeName' is the name of the node where the volume is migrated to.
+	TargetNodeName string `json:"targetNodeName"`
+}
+
+// MigrationPhase is the phase of a LogicalVolumeMigration.
+type MigrationPhase string
+
+const (
+	// MigrationPhaseWaitingForDetach means that the migration waits for the pods using the volume to stop.
+	MigrationPhaseWaitingForDetach = MigrationPhase("WaitingForDetach")
+	// MigrationPhaseTransferring means that the data is being copied to the target node.
+	MigrationPhaseTransferring = MigrationPhase("Transferring")
+	// MigrationPhaseRebinding means that the PersistentVolumeClaim is being rebound to the new PersistentVolume.
+	MigrationPhaseRebinding = MigrationPhase("Rebinding")
+	// MigrationPhaseCompleted means that the migration has completed and the source volume has been deleted.
+	MigrationPhaseCompleted = MigrationPhase("Completed")
+	// MigrationPhaseFailed means that the migration has failed. The source volume is left untouched.
+	MigrationPhaseFailed = MigrationPhase("Failed")
+)
+
+// LogicalVolumeMigrationStatus defines the observed state of LogicalVolumeMigration
+type LogicalVolumeMigrationStatus struct {
+	// 'phase' is the current phase of the migration.
+	//+kubebuilder:validation:Optional
+	Phase MigrationPhase `json:"phase,omitempty"`
+
+	// 'sourceNodeName' is the node where the volume was before the migration.
+	//+kubebuilder:validation:Optional
+	SourceNodeName string `json:"sourceNodeName,omitempty"`
+
+	// 'claimNamespace' and 'claimName' identify the PersistentVolumeClaim of the volume.
+	//+kubebuilder:validation:Optional
+	ClaimNamespace string `json:"claimNamespace,omitempty"`
+	//+kubebuilder:validation:Optional
+	ClaimName string `json:"claimName,omitempty"`
+
+	// 'targetLogicalVolume' is the name of the LogicalVolume created on the target node.
+	// The PersistentVolume of the same name replaces the PersistentVolume of the source.
+	//+kubebuilder:validation:Optional
+	TargetLogicalVolume string `json:"targetLogicalVolume,omitempty"`
+
+	// 'reclaimPolicy' is the reclaim policy of the PersistentVolume before the migration.
+	// It is set to the new PersistentVolume after the PersistentVolumeClaim is bound to it.
+	//+kubebuilder:validation:Optional
+	ReclaimPolicy corev1.PersistentVolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+
+	// 'totalBytes' is the number of bytes to be copied.
+	//+kubebuilder:validation:Optional
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+
+	// 'transferredBytes' is the number of bytes copied so far.
+	//+kubebuilder:validation:Optional
+	TransferredBytes int64 `json:"transferredBytes,omitempty"`
+
+	Code    codes.Code `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="LogicalVolume",type=string,JSONPath=`.spec.logicalVolume`
+//+kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.targetNodeName`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// LogicalVolumeMigration is the Schema for the logicalvolumemigrations API
+type LogicalVolumeMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LogicalVolumeMigrationSpec   `json:"spec,omitempty"`
+	Status LogicalVolumeMigrationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// LogicalVolumeMigrationList contains a list of LogicalVolumeMigration
+type LogicalVolumeMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LogicalVolumeMigration `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LogicalVolumeMigration{}, &LogicalVolumeMigrationList{})
+}
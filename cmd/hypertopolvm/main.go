@@ -7,6 +7,7 @@ import (
 
 	lvmd "github.com/topolvm/topolvm/cmd/lvmd/app"
 	controller "github.com/topolvm/topolvm/cmd/topolvm-controller/app"
+	kubescheduler "github.com/topolvm/topolvm/cmd/topolvm-kube-scheduler/app"
 	node "github.com/topolvm/topolvm/cmd/topolvm-node/app"
 	scheduler "github.com/topolvm/topolvm/cmd/topolvm-scheduler/app"
 )
@@ -18,6 +19,8 @@ COMMAND:
     topolvm-controller:  TopoLVM CSI controller service.
     topolvm-node:        TopoLVM CSI node service.
     topolvm-scheduler:   Scheduler extender.
+    topolvm-kube-scheduler:
+                         kube-scheduler with the scheduler plugin of TopoLVM.
     lvmd:                gRPC service to manage LVM volumes.
 `)
 }
@@ -38,6 +41,8 @@ func main() {
 		lvmd.Execute()
 	case "topolvm-scheduler":
 		scheduler.Execute()
+	case "topolvm-kube-scheduler":
+		kubescheduler.Execute()
 	case "topolvm-node":
 		node.Execute()
 	case "topolvm-controller":
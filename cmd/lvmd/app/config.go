@@ -14,6 +14,10 @@ import (
 type Config struct {
 	// SocketName is Unix domain socket name
 	SocketName string `json:"socket-name"`
+	// ListenAddress is the TCP address to serve on with mutual TLS in addition to the Unix domain socket
+	ListenAddress string `json:"listen-address"`
+	// TLS holds the configuration of mutual TLS for ListenAddress
+	TLS *lvmdTypes.TLSConfig `json:"tls"`
 	// DeviceClasses is
 	DeviceClasses []*lvmdTypes.DeviceClass `json:"device-classes"`
 	// LvcreateOptionClasses are classes that define options for the lvcreate command
@@ -39,6 +43,7 @@ func loadConfFile(ctx context.Context, cfgFilePath string) error {
 	log.FromContext(ctx).Info("configuration file loaded",
 		"device_classes", config.DeviceClasses,
 		"socket_name", config.SocketName,
+		"listen_address", config.ListenAddress,
 		"file_name", cfgFilePath,
 	)
 	return nil
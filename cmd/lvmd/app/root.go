@@ -21,14 +21,15 @@ import (
 	"github.com/topolvm/topolvm/internal/lvmd"
 	"github.com/topolvm/topolvm/internal/lvmd/command"
 	"github.com/topolvm/topolvm/internal/profiling"
+	"github.com/topolvm/topolvm/internal/runners"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
-	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/yaml"
 )
 
 var (
@@ -37,6 +38,7 @@ var (
 	zapOpts              zap.Options
 	profilingBindAddress string
 	metricsBindAddress   string
+	provisionDryRun      bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -80,49 +82,71 @@ func subMain(parentCtx context.Context) error {
 		command.SetLVMCommandPrefix(config.LVMCommandPrefix)
 	}
 
-	vgs, err := command.ListVolumeGroups(parentCtx)
-	if err != nil {
-		logger.Error(err, "error while retrieving volume groups")
+	if err := lvmd.Provision(parentCtx, config.DeviceClasses, provisionDryRun); err != nil {
+		logger.Error(err, "failed to provision volume groups")
 		return err
 	}
-
-	for _, dc := range config.DeviceClasses {
-		vg, err := command.SearchVolumeGroupList(vgs, dc.VolumeGroup)
-		if err != nil {
-			logger.Error(err, "volume group not found", "volume_group", dc.VolumeGroup)
-			return err
-		}
-
-		if dc.Type == lvmdTypes.TypeThin {
-			_, err = vg.FindPool(parentCtx, dc.ThinPoolConfig.Name)
-			if err != nil {
-				logger.Error(err, "Thin pool not found:", "thinpool", dc.ThinPoolConfig.Name)
-				return err
-			}
-		}
+	if provisionDryRun {
+		return nil
 	}
 
-	// UNIX domain socket file should be removed before listening.
-	err = os.Remove(config.SocketName)
-	if err != nil && !os.IsNotExist(err) {
+	if err := lvmd.VerifyDeviceClasses(parentCtx, config.DeviceClasses); err != nil {
+		logger.Error(err, "failed to verify device-classes")
 		return err
 	}
 
-	lis, err := net.Listen("unix", config.SocketName)
+	lis, err := runners.Listen(config.SocketName)
 	if err != nil {
 		return err
 	}
 	grpcServer := grpc.NewServer()
+	servers := []*grpc.Server{grpcServer}
+
+	// The TCP server is separated as the UNIX domain socket is served without TLS.
+	var tcpServer *grpc.Server
+	var tcpLis net.Listener
+	if config.ListenAddress != "" {
+		if config.TLS == nil {
+			return errors.New("tls must be configured to serve on listen-address")
+		}
+		tcpServer, err = lvmd.NewTCPServer(config.TLS)
+		if err != nil {
+			return fmt.Errorf("failed to configure TLS: %w", err)
+		}
+		tcpLis, err = runners.Listen("tcp://" + config.ListenAddress)
+		if err != nil {
+			return err
+		}
+		servers = append(servers, tcpServer)
+	}
+
 	dcm := lvmd.NewDeviceClassManager(config.DeviceClasses)
 	ocm := lvmd.NewLvcreateOptionClassManager(config.LvcreateOptionClasses)
-	vgService, notifier := lvmd.NewVGService(dcm)
-	proto.RegisterVGServiceServer(grpcServer, vgService)
-	proto.RegisterLVServiceServer(grpcServer, lvmd.NewLVService(dcm, ocm, notifier))
-	grpc_health_v1.RegisterHealthServer(grpcServer, lvmd.NewHealthService())
+	extender := lvmd.NewThinPoolExtender(dcm)
+	vgService, notifier := lvmd.NewVGService(dcm, ocm, extender)
+	lvService := lvmd.NewLVService(dcm, ocm, notifier)
+	healthService := lvmd.NewHealthService()
+	for _, s := range servers {
+		proto.RegisterVGServiceServer(s, vgService)
+		proto.RegisterLVServiceServer(s, lvService)
+		grpc_health_v1.RegisterHealthServer(s, healthService)
+	}
 
 	ctx, stop := signal.NotifyContext(parentCtx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	lvmd.StartRAIDRepairer(ctx, dcm, notifier)
+	extender.Start(ctx, notifier)
+
+	reloader := lvmd.NewConfigReloader(dcm, ocm, notifier)
+	lvmd.WatchConfigFile(ctx, cfgFilePath, func(ctx context.Context, data []byte) error {
+		var c Config
+		if err := yaml.Unmarshal(data, &c); err != nil {
+			return err
+		}
+		return reloader.Reload(ctx, c.DeviceClasses, c.LvcreateOptionClasses)
+	})
+
 	wg, pprofServer, metricsServer := startMetricsAndProfilingServers(logger)
 
 	go func() {
@@ -141,7 +165,9 @@ func subMain(parentCtx context.Context) error {
 						logger.Error(err, "failed to shutdown metrics server")
 					}
 				}
-				grpcServer.GracefulStop()
+				for _, s := range servers {
+					s.GracefulStop()
+				}
 				wg.Wait()
 				return
 			case <-ticker.C:
@@ -150,6 +176,15 @@ func subMain(parentCtx context.Context) error {
 		}
 	}()
 
+	if tcpServer != nil {
+		logger.Info("serving on TCP with mutual TLS", "address", config.ListenAddress)
+		go func() {
+			if err := tcpServer.Serve(tcpLis); err != nil {
+				logger.Error(err, "TCP server error")
+			}
+		}()
+	}
+
 	return grpcServer.Serve(lis)
 }
 
@@ -205,6 +240,7 @@ func init() {
 	fs.StringVar(&lvmPath, "lvm-path", "", "lvm command path on the host OS. This is deprecated and users should use lvm-command-prefix setting instead.")
 	fs.StringVar(&profilingBindAddress, "profiling-bind-address", "", "bind address to expose pprof profiling. If empty, profiling is disabled")
 	fs.StringVar(&metricsBindAddress, "metrics-bind-address", ":8080", "bind address to expose prometheus metrics. If empty, metrics are disabled")
+	fs.BoolVar(&provisionDryRun, "provision-dry-run", false, "log the changes to provision volume groups and thin pools from the device-selector settings without making them, and exit")
 
 	klogFlags := flag.NewFlagSet("klog", flag.ExitOnError)
 	klog.InitFlags(klogFlags)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/topolvm/topolvm"
+	"github.com/topolvm/topolvm/internal/hook"
 	"github.com/topolvm/topolvm/pkg/driver"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog/v2"
@@ -46,6 +47,7 @@ var config struct {
 	leaderElectionRenewDeadline time.Duration
 	leaderElectionRetryPeriod   time.Duration
 	skipNodeFinalize            bool
+	missingPVCPolicy            string
 	zapOpts                     zap.Options
 	controllerServerSettings    driver.ControllerServerSettings
 	profilingBindAddress        string
@@ -89,6 +91,7 @@ func init() {
 	fs.DurationVar(&config.leaderElectionLeaseDuration, "leader-election-lease-duration", 15*time.Second, "Duration that non-leader candidates will wait to force acquire leadership. This is measured against time of last observed ack.")
 	fs.DurationVar(&config.leaderElectionRenewDeadline, "leader-election-renew-deadline", 10*time.Second, "Duration that the acting controlplane will retry refreshing leadership before giving up. This is measured against time of last observed ack.")
 	fs.DurationVar(&config.leaderElectionRetryPeriod, "leader-election-retry-period", 2*time.Second, "Duration the LeaderElector clients should wait between tries of actions.")
+	fs.StringVar(&config.missingPVCPolicy, "missing-pvc-policy", string(hook.MissingPVCIgnore), "Policy for the PVCs of pods which do not exist yet and are not created from the volumeClaimTemplates of StatefulSets: ignore, deny or default-class")
 	fs.BoolVar(&config.skipNodeFinalize, "skip-node-finalize", false, "skips automatic cleanup of PhysicalVolumeClaims when a Node is deleted")
 	fs.StringVar(&config.profilingBindAddress, "profiling-bind-address", "", "Bind pprof profiling to the given network address. If empty, profiling is disabled.")
 
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
 	"github.com/topolvm/topolvm"
 	topolvmlegacyv1 "github.com/topolvm/topolvm/api/legacy/v1"
 	topolvmv1 "github.com/topolvm/topolvm/api/v1"
@@ -41,6 +42,7 @@ func init() {
 
 	utilruntime.Must(topolvmv1.AddToScheme(scheme))
 	utilruntime.Must(topolvmlegacyv1.AddToScheme(scheme))
+	utilruntime.Must(snapapi.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -99,7 +101,13 @@ func subMain() error {
 		// admission.NewDecoder never returns non-nil error
 		dec := admission.NewDecoder(scheme)
 		wh := mgr.GetWebhookServer()
-		wh.Register("/pod/mutate", hook.PodMutator(client, apiReader, dec))
+		missingPVCPolicy, err := hook.ParseMissingPVCPolicy(config.missingPVCPolicy)
+		if err != nil {
+			return err
+		}
+		wh.Register("/pod/mutate", hook.PodMutator(client, apiReader, dec, missingPVCPolicy))
+		wh.Register("/storageclass/validate", hook.StorageClassValidator(client, dec))
+		wh.Register("/pvc/validate", hook.PVCValidator(client, apiReader, dec))
 		if err := mgr.AddReadyzCheck("webhook", wh.StartedChecker()); err != nil {
 			return err
 		}
@@ -116,6 +124,19 @@ func subMain() error {
 		return err
 	}
 
+	if err := controller.SetupImportedVolumeReconciler(mgr, client); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ImportedVolume")
+		return err
+	}
+
+	// LogicalVolumeMigration is not served in the legacy API group.
+	if !topolvm.UseLegacy() {
+		if err := controller.SetupLogicalVolumeMigrationReconciler(mgr, client, apiReader); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "LogicalVolumeMigration")
+			return err
+		}
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	// Add health checker to manager
@@ -140,6 +161,7 @@ func subMain() error {
 		return err
 	}
 	csi.RegisterControllerServer(grpcServer, controllerSever)
+	csi.RegisterGroupControllerServer(grpcServer, controllerSever)
 
 	// gRPC service itself should run even when the manager is *not* a leader
 	// because CSI sidecar containers choose a leader.
@@ -0,0 +1,17 @@
+package app
+
+import (
+	"os"
+
+	"github.com/topolvm/topolvm/cmd/topolvm-kube-scheduler/plugin"
+	"k8s.io/component-base/cli"
+	"k8s.io/kubernetes/cmd/kube-scheduler/app"
+)
+
+// Execute runs kube-scheduler with the scheduler plugin of TopoLVM.
+// This is called by main.main(). It only needs to happen once.
+func Execute() {
+	cmd := app.NewSchedulerCommand(app.WithPlugin(plugin.PluginName, plugin.NewPlugin))
+	cmd.Use = "topolvm-kube-scheduler"
+	os.Exit(cli.Run(cmd))
+}
@@ -0,0 +1,7 @@
+package main
+
+import "github.com/topolvm/topolvm/cmd/topolvm-kube-scheduler/app"
+
+func main() {
+	app.Execute()
+}
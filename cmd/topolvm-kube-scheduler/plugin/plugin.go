@@ -0,0 +1,324 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/topolvm/topolvm"
+	"github.com/topolvm/topolvm/internal/scheduler"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	storagelisters "k8s.io/client-go/listers/storage/v1"
+	fwk "k8s.io/kube-scheduler/framework"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+)
+
+// PluginName is the name of the scheduler plugin of TopoLVM.
+const PluginName = "TopoLVM"
+
+const (
+	stateKey             fwk.StateKey = PluginName
+	maxRemainingStateKey fwk.StateKey = PluginName + "/maxRemaining"
+
+	defaultReservationTTL = 2 * time.Minute
+)
+
+// PluginArgs represents the arguments of the scheduler plugin.
+type PluginArgs struct {
+	// DefaultDivisor is the default divisor value.
+	DefaultDivisor float64 `json:"default-divisor"`
+	// Divisors is a mapping between device-class names and their divisors.
+	Divisors map[string]float64 `json:"divisors"`
+	// DefaultStrategy is the default scoring strategy.
+	DefaultStrategy scheduler.ScoringStrategy `json:"default-strategy"`
+	// Strategies is a mapping between device-class names and their scoring strategies.
+	Strategies map[string]scheduler.ScoringStrategy `json:"strategies"`
+	// Weights is a mapping between device-class names and their weights to score nodes.
+	Weights map[string]float64 `json:"weights"`
+	// ThinPool is the thresholds of the physical usage of thin pools.
+	ThinPool scheduler.ThinPoolOptions `json:"thin-pool"`
+	// ReservationTTL is the duration to reserve the capacity for the pods after they are bound to nodes.
+	ReservationTTL metav1.Duration `json:"reservation-ttl"`
+}
+
+// Plugin is a kube-scheduler plugin which filters and scores nodes by the free capacity of their volume groups.
+//
+// Unlike the scheduler extender, the plugin reads the capacity requested by a pod from its PersistentVolumeClaims,
+// so the pod need not be mutated by the webhook of topolvm-controller.
+// The capacity is reserved on the node at the Reserve extension point, and the reservation expires
+// after the TTL from the PreBind extension point, where the volumes have been provisioned by the VolumeBinding plugin.
+type Plugin struct {
+	scoring   scheduler.ScoringOptions
+	thinPool  scheduler.ThinPoolOptions
+	ledger    *scheduler.Ledger
+	pvcLister corelisters.PersistentVolumeClaimLister
+	scLister  storagelisters.StorageClassLister
+}
+
+var (
+	_ fwk.PreFilterPlugin = &Plugin{}
+	_ fwk.FilterPlugin    = &Plugin{}
+	_ fwk.PreScorePlugin  = &Plugin{}
+	_ fwk.ScorePlugin     = &Plugin{}
+	_ fwk.ReservePlugin   = &Plugin{}
+	_ fwk.PreBindPlugin   = &Plugin{}
+)
+
+// NewPlugin returns a new Plugin. It is the factory of the plugin for the scheduler framework.
+func NewPlugin(_ context.Context, obj runtime.Object, h fwk.Handle) (fwk.Plugin, error) {
+	args := PluginArgs{
+		DefaultDivisor: 1,
+		ReservationTTL: metav1.Duration{Duration: defaultReservationTTL},
+	}
+	if err := frameworkruntime.DecodeInto(obj, &args); err != nil {
+		return nil, err
+	}
+	if args.DefaultDivisor <= 0 {
+		return nil, fmt.Errorf("invalid default divisor: %f", args.DefaultDivisor)
+	}
+	scoring := scheduler.ScoringOptions{
+		DefaultDivisor:  args.DefaultDivisor,
+		Divisors:        args.Divisors,
+		DefaultStrategy: args.DefaultStrategy,
+		Strategies:      args.Strategies,
+		Weights:         args.Weights,
+	}
+	if err := scoring.Validate(); err != nil {
+		return nil, err
+	}
+	if err := args.ThinPool.Validate(); err != nil {
+		return nil, err
+	}
+	if args.ReservationTTL.Duration <= 0 {
+		return nil, fmt.Errorf("invalid reservation TTL: %s", args.ReservationTTL.Duration)
+	}
+
+	informerFactory := h.SharedInformerFactory()
+	return &Plugin{
+		scoring:   scoring,
+		thinPool:  args.ThinPool,
+		ledger:    scheduler.NewLedger(args.ReservationTTL.Duration),
+		pvcLister: informerFactory.Core().V1().PersistentVolumeClaims().Lister(),
+		scLister:  informerFactory.Storage().V1().StorageClasses().Lister(),
+	}, nil
+}
+
+// Name returns the name of the plugin.
+func (p *Plugin) Name() string {
+	return PluginName
+}
+
+// requestedState is the capacity requested by a pod for each device-class.
+type requestedState map[string]int64
+
+func (s requestedState) Clone() fwk.StateData {
+	return s
+}
+
+// PreFilter computes the capacity requested by the pod. The plugin is skipped if the pod requests no capacity.
+func (p *Plugin) PreFilter(_ context.Context, state fwk.CycleState, pod *corev1.Pod, _ []fwk.NodeInfo) (*fwk.PreFilterResult, *fwk.Status) {
+	requested, err := p.requestedCapacity(pod)
+	if err != nil {
+		return nil, fwk.AsStatus(err)
+	}
+	if len(requested) == 0 {
+		return nil, fwk.NewStatus(fwk.Skip)
+	}
+	state.Write(stateKey, requestedState(requested))
+	return nil, nil
+}
+
+// PreFilterExtensions returns nil because the requested capacity does not depend on the other pods.
+func (p *Plugin) PreFilterExtensions() fwk.PreFilterExtensions {
+	return nil
+}
+
+// Filter filters out the node which does not have the capacity requested by the pod.
+func (p *Plugin) Filter(_ context.Context, state fwk.CycleState, pod *corev1.Pod, nodeInfo fwk.NodeInfo) *fwk.Status {
+	requested, err := readRequested(state)
+	if err != nil {
+		return fwk.AsStatus(err)
+	}
+	node := nodeInfo.Node()
+	if reason := scheduler.FilterNode(*node, requested, p.thinPool, p.ledger.Reserved(node.Name, pod.UID)); reason != "" {
+		return fwk.NewStatus(fwk.Unschedulable, reason)
+	}
+	return nil
+}
+
+// maxRemainingState is the most free capacity remaining among the filtered nodes for each device-class.
+type maxRemainingState map[string]uint64
+
+func (s maxRemainingState) Clone() fwk.StateData {
+	return s
+}
+
+// PreScore computes the most free capacity remaining among the filtered nodes, which scales the binpack strategy.
+func (p *Plugin) PreScore(_ context.Context, state fwk.CycleState, pod *corev1.Pod, nodes []fwk.NodeInfo) *fwk.Status {
+	requested, err := readRequested(state)
+	if err != nil {
+		return fwk.NewStatus(fwk.Skip)
+	}
+	items := make([]corev1.Node, 0, len(nodes))
+	for _, nodeInfo := range nodes {
+		items = append(items, *nodeInfo.Node())
+	}
+	reservedOf := func(node string) map[string]int64 {
+		return p.ledger.Reserved(node, pod.UID)
+	}
+	state.Write(maxRemainingStateKey, maxRemainingState(scheduler.MaxRemaining(items, requested, reservedOf)))
+	return nil
+}
+
+// Score scores the node by its free capacity in the same way as the scheduler extender.
+func (p *Plugin) Score(_ context.Context, state fwk.CycleState, pod *corev1.Pod, nodeInfo fwk.NodeInfo) (int64, *fwk.Status) {
+	requested, err := readRequested(state)
+	if err != nil {
+		// the plugin is skipped in PreFilter if the pod requests no capacity.
+		return 0, nil
+	}
+	var maxRemaining maxRemainingState
+	if data, err := state.Read(maxRemainingStateKey); err == nil {
+		maxRemaining, _ = data.(maxRemainingState)
+	}
+	node := nodeInfo.Node()
+	score := scheduler.ScoreNode(*node, requested, p.scoring, p.thinPool, p.ledger.Reserved(node.Name, pod.UID), maxRemaining)
+	// scoreNode returns a score from 0 to 10.
+	return int64(score) * fwk.MaxNodeScore / 10, nil
+}
+
+// ScoreExtensions returns nil because the score is already in the range of the node score.
+func (p *Plugin) ScoreExtensions() fwk.ScoreExtensions {
+	return nil
+}
+
+// Reserve reserves the capacity requested by the pod on the node.
+func (p *Plugin) Reserve(_ context.Context, state fwk.CycleState, pod *corev1.Pod, nodeName string) *fwk.Status {
+	requested, err := readRequested(state)
+	if err != nil {
+		return nil
+	}
+	p.ledger.Reserve(pod, requested, nodeName)
+	return nil
+}
+
+// Unreserve releases the capacity reserved for the pod.
+func (p *Plugin) Unreserve(_ context.Context, _ fwk.CycleState, pod *corev1.Pod, _ string) {
+	p.ledger.Unreserve(pod.UID)
+}
+
+// PreBindPreFlight skips PreBind if the pod has no reservation.
+func (p *Plugin) PreBindPreFlight(_ context.Context, state fwk.CycleState, _ *corev1.Pod, _ string) *fwk.Status {
+	if _, err := readRequested(state); err != nil {
+		return fwk.NewStatus(fwk.Skip)
+	}
+	return nil
+}
+
+// PreBind starts the TTL of the reservation of the pod.
+// The plugin should be run after the VolumeBinding plugin which waits for the volumes to be provisioned,
+// so that the reservation is kept until topolvm-node updates the capacity annotations of the node.
+func (p *Plugin) PreBind(_ context.Context, _ fwk.CycleState, pod *corev1.Pod, _ string) *fwk.Status {
+	p.ledger.Refresh(pod.UID)
+	return nil
+}
+
+func readRequested(state fwk.CycleState) (map[string]int64, error) {
+	data, err := state.Read(stateKey)
+	if err != nil {
+		return nil, err
+	}
+	requested, ok := data.(requestedState)
+	if !ok {
+		return nil, fmt.Errorf("unexpected state data: %T", data)
+	}
+	return requested, nil
+}
+
+// requestedCapacity returns the capacity requested by the pod for each device-class,
+// in the same way as the pod mutating webhook of topolvm-controller.
+// It returns nil if any of the PersistentVolumeClaims of the pod are already bound.
+func (p *Plugin) requestedCapacity(pod *corev1.Pod) (map[string]int64, error) {
+	capacities := make(map[string]int64)
+	for _, vol := range pod.Spec.Volumes {
+		var spec *corev1.PersistentVolumeClaimSpec
+		switch {
+		case vol.PersistentVolumeClaim != nil:
+			pvc, err := p.pvcLister.PersistentVolumeClaims(pod.Namespace).Get(vol.PersistentVolumeClaim.ClaimName)
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if pvc.Status.Phase != corev1.ClaimPending {
+				ok, err := p.isTopoLVM(pvc.Spec.StorageClassName)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					return nil, nil
+				}
+				continue
+			}
+			spec = &pvc.Spec
+		case vol.Ephemeral != nil && vol.Ephemeral.VolumeClaimTemplate != nil:
+			spec = &vol.Ephemeral.VolumeClaimTemplate.Spec
+		default:
+			continue
+		}
+
+		dc, requested, err := p.claimCapacity(spec)
+		if err != nil {
+			return nil, err
+		}
+		if len(dc) == 0 {
+			continue
+		}
+		capacities[dc] += requested
+	}
+	return capacities, nil
+}
+
+// claimCapacity returns the device-class and the capacity requested by the claim,
+// or an empty device-class if the claim is not for TopoLVM.
+func (p *Plugin) claimCapacity(spec *corev1.PersistentVolumeClaimSpec) (string, int64, error) {
+	ok, err := p.isTopoLVM(spec.StorageClassName)
+	if err != nil || !ok {
+		return "", 0, err
+	}
+	sc, err := p.scLister.Get(*spec.StorageClassName)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var requested = topolvm.DefaultSize
+	if req, ok := spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		if req.Value() != 0 {
+			requested = req.Value()
+		}
+	}
+	dc, ok := sc.Parameters[topolvm.GetDeviceClassKey()]
+	if !ok {
+		dc = topolvm.DefaultDeviceClassAnnotationName
+	}
+	return dc, requested, nil
+}
+
+func (p *Plugin) isTopoLVM(storageClassName *string) (bool, error) {
+	if storageClassName == nil || *storageClassName == "" {
+		return false, nil
+	}
+	sc, err := p.scLister.Get(*storageClassName)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return sc.Provisioner == topolvm.GetPluginName(), nil
+}
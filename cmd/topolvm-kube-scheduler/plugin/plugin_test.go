@@ -0,0 +1,272 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/topolvm/topolvm"
+	"github.com/topolvm/topolvm/internal/scheduler"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	fwk "k8s.io/kube-scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const deviceClass1 = "dc1"
+
+func testNode(name string, capGb int64) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				topolvm.GetCapacityKeyPrefix() + deviceClass1: fmt.Sprintf("%d", capGb<<30),
+			},
+		},
+	}
+}
+
+func testPodWithClaims(uid, name string, claims ...string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:       types.UID(uid),
+			Name:      name,
+			Namespace: "ns",
+		},
+	}
+	for _, claim := range claims {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: "vol-" + claim,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+	}
+	return pod
+}
+
+func testPlugin(t *testing.T, objects ...any) *Plugin {
+	t.Helper()
+
+	informerFactory := informers.NewSharedInformerFactory(fake.NewClientset(), 0)
+	pvcInformer := informerFactory.Core().V1().PersistentVolumeClaims()
+	scInformer := informerFactory.Storage().V1().StorageClasses()
+	for _, obj := range objects {
+		var err error
+		switch obj.(type) {
+		case *corev1.PersistentVolumeClaim:
+			err = pvcInformer.Informer().GetIndexer().Add(obj)
+		case *storagev1.StorageClass:
+			err = scInformer.Informer().GetIndexer().Add(obj)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &Plugin{
+		scoring:   scheduler.ScoringOptions{DefaultDivisor: 1},
+		ledger:    scheduler.NewLedger(time.Minute),
+		pvcLister: pvcInformer.Lister(),
+		scLister:  scInformer.Lister(),
+	}
+}
+
+func testPVC(name, storageClassName string, phase corev1.PersistentVolumeClaimPhase, requested string) *corev1.PersistentVolumeClaim {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClassName,
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+	if requested != "" {
+		pvc.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(requested)}
+	}
+	return pvc
+}
+
+func TestPlugin_requestedCapacity(t *testing.T) {
+	objects := []any{
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "ssd"},
+			Provisioner: topolvm.GetPluginName(),
+			Parameters:  map[string]string{topolvm.GetDeviceClassKey(): deviceClass1},
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "default"},
+			Provisioner: topolvm.GetPluginName(),
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "other"},
+			Provisioner: "other.example.com",
+		},
+		testPVC("pvc1", "ssd", corev1.ClaimPending, "5Gi"),
+		testPVC("pvc2", "ssd", corev1.ClaimPending, ""),
+		testPVC("pvc3", "default", corev1.ClaimPending, "2Gi"),
+		testPVC("pvc4", "other", corev1.ClaimPending, "10Gi"),
+		testPVC("bound", "ssd", corev1.ClaimBound, "1Gi"),
+		testPVC("bound-other", "other", corev1.ClaimBound, "1Gi"),
+	}
+	plugin := testPlugin(t, objects...)
+
+	storageClassName := "ssd"
+	pod := testPodWithClaims("uid1", "pod1", "pvc1", "pvc2", "pvc3", "pvc4", "bound-other", "missing")
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name: "generic",
+		VolumeSource: corev1.VolumeSource{
+			Ephemeral: &corev1.EphemeralVolumeSource{
+				VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+					Spec: corev1.PersistentVolumeClaimSpec{
+						StorageClassName: &storageClassName,
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("3Gi")},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	requested, err := plugin.requestedCapacity(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int64{
+		deviceClass1:                             5<<30 + topolvm.DefaultSize + 3<<30,
+		topolvm.DefaultDeviceClassAnnotationName: 2 << 30,
+	}
+	if !reflect.DeepEqual(requested, expected) {
+		t.Errorf("expected %v, but was %v", expected, requested)
+	}
+
+	requested, err = plugin.requestedCapacity(testPodWithClaims("uid2", "pod2", "pvc1", "bound"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 0 {
+		t.Errorf("pod with a bound PVC should not request capacity: %v", requested)
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	ctx := context.Background()
+	plugin := testPlugin(t,
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "ssd"},
+			Provisioner: topolvm.GetPluginName(),
+			Parameters:  map[string]string{topolvm.GetDeviceClassKey(): deviceClass1},
+		},
+		testPVC("pvc1", "ssd", corev1.ClaimPending, "3Gi"),
+		testPVC("pvc2", "ssd", corev1.ClaimPending, "3Gi"),
+	)
+	nodeInfo := framework.NewNodeInfo()
+	node := testNode("10.1.1.1", 5)
+	nodeInfo.SetNode(&node)
+
+	pod1 := testPodWithClaims("uid1", "pod1", "pvc1")
+	pod2 := testPodWithClaims("uid2", "pod2", "pvc2")
+	state1 := framework.NewCycleState()
+	state2 := framework.NewCycleState()
+	for _, tc := range []struct {
+		pod   *corev1.Pod
+		state fwk.CycleState
+	}{{pod1, state1}, {pod2, state2}} {
+		if _, status := plugin.PreFilter(ctx, tc.state, tc.pod, nil); !status.IsSuccess() {
+			t.Fatalf("PreFilter failed: %v", status)
+		}
+		if status := plugin.Filter(ctx, tc.state, tc.pod, nodeInfo); !status.IsSuccess() {
+			t.Fatalf("Filter failed: %v", status)
+		}
+	}
+	if score, status := plugin.Score(ctx, state1, pod1, nodeInfo); !status.IsSuccess() || score != 20 {
+		t.Errorf("unexpected score: %d, %v", score, status)
+	}
+
+	// the capacity reserved for pod1 is not available for pod2
+	if status := plugin.Reserve(ctx, state1, pod1, node.Name); !status.IsSuccess() {
+		t.Fatalf("Reserve failed: %v", status)
+	}
+	if status := plugin.Filter(ctx, state2, pod2, nodeInfo); status.Code() != fwk.Unschedulable {
+		t.Errorf("pod2 should be unschedulable: %v", status)
+	}
+	if status := plugin.Filter(ctx, state1, pod1, nodeInfo); !status.IsSuccess() {
+		t.Errorf("the reservation of pod1 should not be subtracted for itself: %v", status)
+	}
+
+	plugin.Unreserve(ctx, state1, pod1, node.Name)
+	if status := plugin.Filter(ctx, state2, pod2, nodeInfo); !status.IsSuccess() {
+		t.Errorf("pod2 should be schedulable after pod1 is unreserved: %v", status)
+	}
+
+	// the reservation expires after the TTL since PreBind
+	synctest.Test(t, func(t *testing.T) {
+		plugin.Reserve(ctx, state1, pod1, node.Name)
+		if status := plugin.PreBind(ctx, state1, pod1, node.Name); !status.IsSuccess() {
+			t.Fatalf("PreBind failed: %v", status)
+		}
+		if reserved := plugin.ledger.Reserved(node.Name, ""); reserved[deviceClass1] != 3<<30 {
+			t.Errorf("unexpected reservation: %v", reserved)
+		}
+		time.Sleep(2 * time.Minute)
+		if reserved := plugin.ledger.Reserved(node.Name, ""); len(reserved) != 0 {
+			t.Errorf("reservation should expire: %v", reserved)
+		}
+	})
+
+	// pods without TopoLVM volumes are skipped
+	if _, status := plugin.PreFilter(ctx, framework.NewCycleState(), testPodWithClaims("uid3", "pod3"), nil); status.Code() != fwk.Skip {
+		t.Errorf("PreFilter should skip the pod: %v", status)
+	}
+}
+
+func TestPlugin_Binpack(t *testing.T) {
+	ctx := context.Background()
+	plugin := testPlugin(t,
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "ssd"},
+			Provisioner: topolvm.GetPluginName(),
+			Parameters:  map[string]string{topolvm.GetDeviceClassKey(): deviceClass1},
+		},
+		testPVC("pvc1", "ssd", corev1.ClaimPending, "100Gi"),
+	)
+	plugin.scoring.DefaultStrategy = scheduler.ScoringBinpack
+
+	// all the nodes have more than 1TiB free
+	var nodes []fwk.NodeInfo
+	for name, capGb := range map[string]int64{"10.1.1.1": 2 << 10, "10.1.1.2": 8 << 10} {
+		nodeInfo := framework.NewNodeInfo()
+		node := testNode(name, capGb)
+		nodeInfo.SetNode(&node)
+		nodes = append(nodes, nodeInfo)
+	}
+
+	pod := testPodWithClaims("uid1", "pod1", "pvc1")
+	state := framework.NewCycleState()
+	if _, status := plugin.PreFilter(ctx, state, pod, nil); !status.IsSuccess() {
+		t.Fatalf("PreFilter failed: %v", status)
+	}
+	if status := plugin.PreScore(ctx, state, pod, nodes); !status.IsSuccess() {
+		t.Fatalf("PreScore failed: %v", status)
+	}
+	scores := make(map[string]int64)
+	for _, nodeInfo := range nodes {
+		score, status := plugin.Score(ctx, state, pod, nodeInfo)
+		if !status.IsSuccess() {
+			t.Fatalf("Score failed: %v", status)
+		}
+		scores[nodeInfo.Node().Name] = score
+	}
+	// 10 - ceil((2048 - 100) / (8192 - 100) * 10) = 7, and the node with the most remaining capacity is scored 0.
+	expected := map[string]int64{"10.1.1.1": 70, "10.1.1.2": 0}
+	if !reflect.DeepEqual(scores, expected) {
+		t.Errorf("expected %v, but was %v", expected, scores)
+	}
+}
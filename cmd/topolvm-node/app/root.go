@@ -5,25 +5,42 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/topolvm/topolvm"
 	lvmd "github.com/topolvm/topolvm/cmd/lvmd/app"
+	"github.com/topolvm/topolvm/internal/runners"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
 var config struct {
-	csiSocket            string
-	lvmdSocket           string
-	metricsAddr          string
-	secureMetricsServer  bool
-	zapOpts              zap.Options
-	embedLvmd            bool
-	lvmPath              string
-	lvmd                 lvmd.Config
-	profilingBindAddress string
+	csiSocket             string
+	lvmdSocket            string
+	lvmdAddress           string
+	lvmdTLSCertFile       string
+	lvmdTLSKeyFile        string
+	lvmdTLSCAFile         string
+	lvmdTLSServerName     string
+	metricsAddr           string
+	secureMetricsServer   bool
+	zapOpts               zap.Options
+	embedLvmd             bool
+	lvmPath               string
+	lvmd                  lvmd.Config
+	profilingBindAddress  string
+	transferBindAddress   string
+	transferAddress       string
+	transferTLSCertFile   string
+	transferTLSKeyFile    string
+	transferTLSCAFile     string
+	transferTLSServerName string
+	backupNamespace       string
+	orphanedLVMode        string
+	orphanedLVInterval    time.Duration
+	orphanedLVGrace       time.Duration
 }
 
 var rootCmd = &cobra.Command{
@@ -56,6 +73,11 @@ func init() {
 	fs := rootCmd.Flags()
 	fs.StringVar(&config.csiSocket, "csi-socket", topolvm.DefaultCSISocket, "UNIX domain socket filename for CSI")
 	fs.StringVar(&config.lvmdSocket, "lvmd-socket", topolvm.DefaultLVMdSocket, "UNIX domain socket of lvmd service")
+	fs.StringVar(&config.lvmdAddress, "lvmd-address", "", "TCP address of lvmd service with mutual TLS. If set, it is used instead of --lvmd-socket")
+	fs.StringVar(&config.lvmdTLSCertFile, "lvmd-tls-cert-file", "", "Client certificate file to connect to --lvmd-address")
+	fs.StringVar(&config.lvmdTLSKeyFile, "lvmd-tls-key-file", "", "Client private key file to connect to --lvmd-address")
+	fs.StringVar(&config.lvmdTLSCAFile, "lvmd-tls-ca-file", "", "CA certificate file to verify the certificate of lvmd")
+	fs.StringVar(&config.lvmdTLSServerName, "lvmd-tls-server-name", "", "Server name to verify the certificate of lvmd. The host of --lvmd-address, which may be an IP address, is used if empty")
 	fs.StringVar(&config.metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	fs.BoolVar(&config.secureMetricsServer, "secure-metrics-server", false, "Secures the metrics server")
 	fs.String("nodename", "", "The resource name of the running node")
@@ -63,6 +85,16 @@ func init() {
 	fs.StringVar(&config.lvmPath, "lvm-path", "", "lvm command path on the host OS. This is deprecated and users should use lvm-command-prefix setting instead.")
 	fs.StringVar(&cfgFilePath, "config", filepath.Join("/etc", "topolvm", "lvmd.yaml"), "config file")
 	fs.StringVar(&config.profilingBindAddress, "profiling-bind-address", "", "Bind pprof profiling to the given network address. If empty, profiling is disabled.")
+	fs.StringVar(&config.transferBindAddress, "transfer-bind-address", "", "The address the volume transfer server binds to. If empty, volumes on this node cannot be restored or cloned on other nodes.")
+	fs.StringVar(&config.transferAddress, "transfer-advertise-address", "", "The address other nodes use to connect to the volume transfer server. Required when --transfer-bind-address is set.")
+	fs.StringVar(&config.transferTLSCertFile, "transfer-tls-cert-file", "", "Certificate file of the volume transfer server and its clients. Required to restore or clone volumes across nodes")
+	fs.StringVar(&config.transferTLSKeyFile, "transfer-tls-key-file", "", "Private key file of the volume transfer server and its clients")
+	fs.StringVar(&config.transferTLSCAFile, "transfer-tls-ca-file", "", "CA certificate file to verify the certificates of the volume transfer servers and clients")
+	fs.StringVar(&config.transferTLSServerName, "transfer-tls-server-name", "", "Server name to verify the certificates of the volume transfer servers. The name of the Node is used if empty")
+	fs.StringVar(&config.backupNamespace, "backup-namespace", "topolvm-system", "Namespace of the credentials Secrets of the backup repositories")
+	fs.StringVar(&config.orphanedLVMode, "orphaned-lv-mode", string(runners.OrphanedLVDryRun), "Mode of the garbage collection of logical volumes without LogicalVolumes: disabled, dry-run or enforce")
+	fs.DurationVar(&config.orphanedLVInterval, "orphaned-lv-interval", 10*time.Minute, "Interval to look for logical volumes without LogicalVolumes")
+	fs.DurationVar(&config.orphanedLVGrace, "orphaned-lv-grace-period", 1*time.Hour, "Period for which a logical volume must have no LogicalVolume before it is removed in enforce mode")
 
 	_ = viper.BindEnv("nodename", "NODE_NAME")
 	_ = viper.BindPFlag("nodename", fs.Lookup("nodename"))
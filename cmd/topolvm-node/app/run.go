@@ -2,10 +2,13 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
@@ -15,12 +18,15 @@ import (
 	topolvmlegacyv1 "github.com/topolvm/topolvm/api/legacy/v1"
 	topolvmv1 "github.com/topolvm/topolvm/api/v1"
 	clientwrapper "github.com/topolvm/topolvm/internal/client"
+	"github.com/topolvm/topolvm/internal/filesystem"
 	"github.com/topolvm/topolvm/internal/runners"
+	"github.com/topolvm/topolvm/internal/transfer"
 	"github.com/topolvm/topolvm/pkg/controller"
 	"github.com/topolvm/topolvm/pkg/driver"
 	"github.com/topolvm/topolvm/pkg/lvmd"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -101,16 +107,43 @@ func subMain(ctx context.Context) error {
 			lvmd.SetLVMCommandPrefix(config.lvmd.LVMCommandPrefix)
 		}
 
-		lvService, vgService = lvmd.NewEmbeddedServiceClients(
+		if err := lvmd.Provision(ctx, config.lvmd.DeviceClasses, false); err != nil {
+			return err
+		}
+
+		var reload lvmd.ReloadFunc
+		lvService, vgService, reload = lvmd.NewEmbeddedServiceClientsWithReloader(
 			ctx,
 			config.lvmd.DeviceClasses,
 			config.lvmd.LvcreateOptionClasses,
 		)
+		lvmd.WatchConfigFile(ctx, cfgFilePath, func(ctx context.Context, data []byte) error {
+			c := config.lvmd
+			c.DeviceClasses, c.LvcreateOptionClasses = nil, nil
+			if err := yaml.Unmarshal(data, &c); err != nil {
+				return err
+			}
+			return reload(ctx, c.DeviceClasses, c.LvcreateOptionClasses)
+		})
 	} else {
-		conn, err := grpc.NewClient(
-			"unix:"+config.lvmdSocket,
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
-		)
+		target, creds := "unix:"+config.lvmdSocket, insecure.NewCredentials()
+		if config.lvmdAddress != "" {
+			serverName := config.lvmdTLSServerName
+			if serverName == "" {
+				host, _, err := net.SplitHostPort(config.lvmdAddress)
+				if err != nil {
+					return fmt.Errorf("invalid --lvmd-address %q: %w", config.lvmdAddress, err)
+				}
+				serverName = host
+			}
+			tlsConfig, err := lvmd.NewClientTLSConfig(
+				config.lvmdTLSCertFile, config.lvmdTLSKeyFile, config.lvmdTLSCAFile, serverName)
+			if err != nil {
+				return fmt.Errorf("failed to configure TLS for lvmd: %w", err)
+			}
+			target, creds = config.lvmdAddress, credentials.NewTLS(tlsConfig)
+		}
+		conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
 		if err != nil {
 			return err
 		}
@@ -119,11 +152,39 @@ func subMain(ctx context.Context) error {
 		health = grpc_health_v1.NewHealthClient(conn)
 	}
 
-	if err := controller.SetupLogicalVolumeReconcilerWithServices(
-		mgr, client, nodename, vgService, lvService); err != nil {
+	// The volume transfer between nodes is protected with mutual TLS.
+	var transferServerTLS, transferClientTLS *tls.Config
+	if config.transferTLSCertFile != "" {
+		transferServerTLS, transferClientTLS, err = transfer.NewTLSConfigs(
+			config.transferTLSCertFile, config.transferTLSKeyFile, config.transferTLSCAFile, config.transferTLSServerName)
+		if err != nil {
+			return fmt.Errorf("failed to configure TLS for volume transfer: %w", err)
+		}
+	}
+
+	// Thaw the filesystems which were left frozen for a group snapshot when topolvm-node died.
+	freezeStateFile := filepath.Join(filepath.Dir(config.csiSocket), "frozen-filesystems")
+	thawed, err := filesystem.ThawRecorded(freezeStateFile)
+	if err != nil {
+		setupLog.Error(err, "failed to thaw filesystems left frozen", "state_file", freezeStateFile)
+		return err
+	}
+	if len(thawed) > 0 {
+		setupLog.Info("thawed filesystems left frozen", "mountpoints", thawed)
+	}
+
+	if err := controller.SetupLogicalVolumeReconcilerWithTransfer(
+		mgr, client, nodename, vgService, lvService, transferClientTLS, config.backupNamespace, freezeStateFile); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "LogicalVolume")
 		return err
 	}
+	if !topolvm.UseLegacy() {
+		if err := controller.SetupLogicalVolumeBackupReconciler(
+			mgr, client, apiReader, nodename, config.backupNamespace, vgService, lvService); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "LogicalVolumeBackup")
+			return err
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	// Add health checker to manager
@@ -132,10 +193,33 @@ func subMain(ctx context.Context) error {
 		return err
 	}
 
+	// Add volume health checker to manager.
+	if err := mgr.Add(runners.NewVolumeHealthChecker(vgService, client, nodename, 1*time.Minute)); err != nil {
+		return err
+	}
+
+	// Add IO throttler to manager.
+	if err := mgr.Add(runners.NewIOThrottler(client, nodename, 1*time.Minute)); err != nil {
+		return err
+	}
+
+	// Add orphaned logical volume collector to manager.
+	orphanedLVMode, err := runners.ParseOrphanedLVMode(config.orphanedLVMode)
+	if err != nil {
+		return err
+	}
+	if orphanedLVMode != runners.OrphanedLVDisabled {
+		collector := runners.NewOrphanedLVCollector(vgService, lvService, client, nodename, mgr.GetEventRecorder("topolvm-node"),
+			orphanedLVMode, config.orphanedLVInterval, config.orphanedLVGrace)
+		if err := mgr.Add(collector); err != nil {
+			return err
+		}
+	}
+
 	// Add metrics exporter to manager.
 	// Note that grpc.ClientConn can be shared with multiple stubs/services.
 	// https://github.com/grpc/grpc-go/tree/master/examples/features/multiplex
-	if err := mgr.Add(runners.NewMetricsExporter(vgService, client, nodename)); err != nil { // adjusted signature
+	if err := mgr.Add(runners.NewMetricsExporter(vgService, client, nodename, mgr.GetEventRecorder("topolvm-node"))); err != nil { // adjusted signature
 		return err
 	}
 
@@ -152,6 +236,22 @@ func subMain(ctx context.Context) error {
 		return err
 	}
 
+	// Add volume transfer server to manager.
+	if config.transferBindAddress != "" {
+		if config.transferAddress == "" {
+			return errors.New("--transfer-advertise-address must be set to enable the volume transfer server")
+		}
+		if transferServerTLS == nil {
+			return errors.New("--transfer-tls-cert-file must be set to enable the volume transfer server")
+		}
+		transferServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(transferServerTLS)))
+		proto.RegisterLVServiceServer(transferServer, transfer.NewServer(client, apiReader, nodename, lvService))
+		err = mgr.Add(runners.NewTransferServerRunner(transferServer, client, nodename, config.transferBindAddress, config.transferAddress))
+		if err != nil {
+			return err
+		}
+	}
+
 	c := make(chan os.Signal, 2)
 	signal.Notify(c, []os.Signal{os.Interrupt, syscall.SIGTERM}...)
 	go func() {
@@ -171,6 +271,7 @@ func subMain(ctx context.Context) error {
 }
 
 //+kubebuilder:rbac:groups=storage.k8s.io,resources=csidrivers,verbs=get;list;watch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
 
 func checkFunc(health grpc_health_v1.HealthClient, r client.Reader) func() error {
 	return func() error {
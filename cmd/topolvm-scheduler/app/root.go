@@ -13,11 +13,20 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/topolvm/topolvm"
+	topolvmlegacyv1 "github.com/topolvm/topolvm/api/legacy/v1"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	clientwrapper "github.com/topolvm/topolvm/internal/client"
 	"github.com/topolvm/topolvm/internal/profiling"
 	"github.com/topolvm/topolvm/internal/scheduler"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/yaml"
 )
 
@@ -35,8 +44,22 @@ type Config struct {
 	Divisors map[string]float64 `json:"divisors"`
 	// DefaultDivisor is the default divisor value.
 	DefaultDivisor float64 `json:"default-divisor"`
+	// DefaultStrategy is the default scoring strategy.
+	DefaultStrategy scheduler.ScoringStrategy `json:"default-strategy"`
+	// Strategies is a mapping between device-class names and their scoring strategies.
+	Strategies map[string]scheduler.ScoringStrategy `json:"strategies"`
+	// Weights is a mapping between device-class names and their weights to score nodes.
+	Weights map[string]float64 `json:"weights"`
+	// ThinPool is the thresholds of the physical usage of thin pools.
+	ThinPool scheduler.ThinPoolOptions `json:"thin-pool"`
 	// ProfilingBindAddress is the bind address to expose pprof profiling. If empty, profiling is disabled.
 	ProfilingBindAddress string `json:"profiling-bind-address"`
+	// ReservationTTL is the duration to reserve the capacity for the pods admitted by the extender
+	// until their volumes are provisioned. If zero, the capacity is not reserved.
+	ReservationTTL metav1.Duration `json:"reservation-ttl"`
+	// ExplainBindAddress is the bind address to serve "/explain". If empty, "/explain" is disabled.
+	// It is served apart from ListenAddr, as it reads pods and nodes from the API server without authentication.
+	ExplainBindAddress string `json:"explain-bind-address"`
 }
 
 var config = &Config{
@@ -63,6 +86,16 @@ It scores nodes with this formula:
     min(10, max(0, log2(capacity >> 30 / divisor)))
 
 The default divisor is 1.  It can be changed with a command-line option.
+The scoring strategy can be changed to "binpack" or "linear" for each
+device-class in the config file.
+
+If "reservation-ttl" is configured, the capacity requested by the pods
+admitted by the filter verb is reserved until their volumes are provisioned
+or the TTL expires, and it is subtracted from the capacity of the nodes.
+
+If "explain-bind-address" is configured, the decisions for a pod are
+explained at "/explain" via HTTP on that address.  The metrics are served
+at "/metrics".
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
@@ -85,7 +118,41 @@ func subMain(parentCtx context.Context) error {
 		}
 	}
 
-	h, err := scheduler.NewHandler(config.DefaultDivisor, config.Divisors)
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(topolvmv1.AddToScheme(scheme))
+	utilruntime.Must(topolvmlegacyv1.AddToScheme(scheme))
+
+	var ledger *scheduler.Ledger
+	var mgr ctrl.Manager
+	var reader client.Reader
+	if config.ReservationTTL.Duration > 0 {
+		ledger = scheduler.NewLedger(config.ReservationTTL.Duration)
+		var err error
+		mgr, err = newReservationManager(scheme, ledger)
+		if err != nil {
+			return err
+		}
+		reader = mgr.GetAPIReader()
+	} else if config.ExplainBindAddress != "" {
+		if cfg, err := ctrl.GetConfig(); err == nil {
+			reader, err = client.New(cfg, client.Options{Scheme: scheme})
+			if err != nil {
+				return err
+			}
+		} else {
+			logger.Info("pods cannot be explained by name without the kubeconfig", "error", err.Error())
+		}
+	}
+
+	scoring := scheduler.ScoringOptions{
+		DefaultDivisor:  config.DefaultDivisor,
+		Divisors:        config.Divisors,
+		DefaultStrategy: config.DefaultStrategy,
+		Strategies:      config.Strategies,
+		Weights:         config.Weights,
+	}
+	h, err := scheduler.NewHandler(scoring, config.ThinPool, ledger)
 	if err != nil {
 		return err
 	}
@@ -112,6 +179,34 @@ func subMain(parentCtx context.Context) error {
 		}()
 	}
 
+	var explainServer *http.Server
+	if config.ExplainBindAddress != "" {
+		eh, err := scheduler.NewExplainHandler(scoring, config.ThinPool, ledger, reader)
+		if err != nil {
+			return err
+		}
+		explainServer = &http.Server{
+			Addr:        config.ExplainBindAddress,
+			Handler:     accessLogHandler(parentCtx, eh),
+			ReadTimeout: 30 * time.Second,
+		}
+		go func() {
+			if err := explainServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				logger.Error(err, "explain server error")
+			}
+		}()
+	}
+
+	if mgr != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := mgr.Start(ctx); err != nil {
+				logger.Error(err, "reservation manager error")
+			}
+		}()
+	}
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -121,6 +216,11 @@ func subMain(parentCtx context.Context) error {
 				logger.Error(err, "failed to shutdown pprof server")
 			}
 		}
+		if explainServer != nil {
+			if err := explainServer.Shutdown(parentCtx); err != nil {
+				logger.Error(err, "failed to shutdown explain server")
+			}
+		}
 		if err := serv.Shutdown(parentCtx); err != nil {
 			logger.Error(err, "failed to shutdown gracefully")
 		}
@@ -133,6 +233,30 @@ func subMain(parentCtx context.Context) error {
 	return nil
 }
 
+// newReservationManager returns a manager which updates the reservations of ledger
+// from the PersistentVolumeClaims and LogicalVolumes.
+func newReservationManager(scheme *runtime.Scheme, ledger *scheduler.Ledger) (ctrl.Manager, error) {
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme: scheme,
+		Metrics: metricsserver.Options{
+			BindAddress: "0",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedClient := clientwrapper.NewWrappedClient(mgr.GetClient())
+	if err := scheduler.NewReservationReconciler(wrappedClient, ledger).SetupWithManager(mgr); err != nil {
+		return nil, err
+	}
+	return mgr, nil
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
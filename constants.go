@@ -30,6 +30,24 @@ func GetCapacityKeyPrefix() string {
 	return fmt.Sprintf("capacity.%s/", GetPluginName())
 }
 
+// GetThinPoolDataPercentKeyPrefix returns the key prefix of Node annotation that represents
+// the data usage of the thin pool of a device-class in percent.
+func GetThinPoolDataPercentKeyPrefix() string {
+	return fmt.Sprintf("thinpool-data-percent.%s/", GetPluginName())
+}
+
+// GetThinPoolMetadataPercentKeyPrefix returns the key prefix of Node annotation that represents
+// the metadata usage of the thin pool of a device-class in percent.
+func GetThinPoolMetadataPercentKeyPrefix() string {
+	return fmt.Sprintf("thinpool-metadata-percent.%s/", GetPluginName())
+}
+
+// GetLvcreateOptionClassesKey returns the key of Node annotation that represents
+// the comma-separated names of the lvcreate-option-classes available on the node.
+func GetLvcreateOptionClassesKey() string {
+	return fmt.Sprintf("%s/lvcreate-option-classes", GetPluginName())
+}
+
 // GetCapacityResource returns the resource name of topolvm capacity.
 func GetCapacityResource() corev1.ResourceName {
 	return corev1.ResourceName(fmt.Sprintf("%s/capacity", GetPluginName()))
@@ -50,6 +68,102 @@ func GetLvcreateOptionClassKey() string {
 	return fmt.Sprintf("%s/lvcreate-option-class", GetPluginName())
 }
 
+// GetCacheModeKey returns the key used in CSI volume modify requests to specify the cache mode of a volume.
+func GetCacheModeKey() string {
+	return fmt.Sprintf("%s/cache-mode", GetPluginName())
+}
+
+// GetReadIOPSKey returns the key used in CSI volume requests to limit the read operations per second of a volume.
+func GetReadIOPSKey() string {
+	return fmt.Sprintf("%s/read-iops", GetPluginName())
+}
+
+// GetWriteIOPSKey returns the key used in CSI volume requests to limit the write operations per second of a volume.
+func GetWriteIOPSKey() string {
+	return fmt.Sprintf("%s/write-iops", GetPluginName())
+}
+
+// GetReadBytesPerSecondKey returns the key used in CSI volume requests to limit the read bandwidth of a volume.
+func GetReadBytesPerSecondKey() string {
+	return fmt.Sprintf("%s/read-bytes-per-second", GetPluginName())
+}
+
+// GetWriteBytesPerSecondKey returns the key used in CSI volume requests to limit the write bandwidth of a volume.
+func GetWriteBytesPerSecondKey() string {
+	return fmt.Sprintf("%s/write-bytes-per-second", GetPluginName())
+}
+
+// GetRestoreModeKey returns the key used in CSI volume create requests to specify how a volume is restored or cloned.
+func GetRestoreModeKey() string {
+	return fmt.Sprintf("%s/restore-mode", GetPluginName())
+}
+
+// GetEncryptionKey returns the key used in CSI volume create requests to specify how a volume is encrypted.
+func GetEncryptionKey() string {
+	return fmt.Sprintf("%s/encryption", GetPluginName())
+}
+
+// GetGroupSnapshotKey returns the key of LogicalVolume label that represents the group snapshot which it belongs to.
+func GetGroupSnapshotKey() string {
+	return fmt.Sprintf("%s/group-snapshot", GetPluginName())
+}
+
+// GetGroupSnapshotSizeKey returns the key of LogicalVolume annotation that represents the number of snapshots in its group snapshot.
+func GetGroupSnapshotSizeKey() string {
+	return fmt.Sprintf("%s/group-snapshot-size", GetPluginName())
+}
+
+// GetTransferAddressKey returns the key of Node annotation that represents the address of the volume transfer server.
+func GetTransferAddressKey() string {
+	return fmt.Sprintf("transfer.%s/address", GetPluginName())
+}
+
+// GetMigrationClaimKey returns the key of LogicalVolumeMigration annotation that holds the PersistentVolumeClaim
+// being recreated to be bound to the migrated volume.
+func GetMigrationClaimKey() string {
+	return fmt.Sprintf("%s/migration-claim", GetPluginName())
+}
+
+// GetBackupKey returns the key of the PVC annotation which specifies the LogicalVolumeBackup to restore from.
+func GetBackupKey() string {
+	return fmt.Sprintf("%s/backup", GetPluginName())
+}
+
+// GetImportKey returns the key of LogicalVolume annotation that represents the existing logical volume to import,
+// in the form of "<volume group>/<logical volume>" or "<logical volume>".
+func GetImportKey() string {
+	return fmt.Sprintf("%s/import", GetPluginName())
+}
+
+// GetImportStorageClassKey returns the key of LogicalVolume annotation that represents the StorageClass name
+// of the PersistentVolume created for the imported logical volume.
+func GetImportStorageClassKey() string {
+	return fmt.Sprintf("%s/import-storage-class", GetPluginName())
+}
+
+// GetImportClaimKey returns the key of LogicalVolume annotation that represents the PVC, in the form of
+// "<namespace>/<name>", which the PersistentVolume created for the imported logical volume is reserved for.
+func GetImportClaimKey() string {
+	return fmt.Sprintf("%s/import-claim", GetPluginName())
+}
+
+// GetImportVolumeModeKey returns the key of LogicalVolume annotation that represents the volume mode
+// of the PersistentVolume created for the imported logical volume.
+func GetImportVolumeModeKey() string {
+	return fmt.Sprintf("%s/import-volume-mode", GetPluginName())
+}
+
+// GetImportFsTypeKey returns the key of LogicalVolume annotation that represents the filesystem type
+// of the imported logical volume.
+func GetImportFsTypeKey() string {
+	return fmt.Sprintf("%s/import-fs-type", GetPluginName())
+}
+
+// GetImportedTag returns the LVM tag added to the logical volume imported by the LogicalVolume of the name.
+func GetImportedTag(name string) string {
+	return fmt.Sprintf("%s/logicalvolume=%s", GetPluginName(), name)
+}
+
 // GetResizeRequestedAtKey returns the key of LogicalVolume that represents the timestamp of the resize request.
 func GetResizeRequestedAtKey() string {
 	return fmt.Sprintf("%s/resize-requested-at", GetPluginName())
@@ -65,6 +179,11 @@ func GetLogicalVolumeFinalizer() string {
 	return fmt.Sprintf("%s/logicalvolume", GetPluginName())
 }
 
+// GetLogicalVolumeBackupFinalizer returns the name of LogicalVolumeBackup finalizer
+func GetLogicalVolumeBackupFinalizer() string {
+	return fmt.Sprintf("%s/logicalvolumebackup", GetPluginName())
+}
+
 // GetNodeFinalizer returns the name of Node finalizer of TopoLVM
 func GetNodeFinalizer() string {
 	return fmt.Sprintf("%s/node", GetPluginName())
@@ -87,6 +206,18 @@ const DefaultCSISocket = "/run/topolvm/csi-topolvm.sock"
 // DefaultLVMdSocket is the default path of the lvmd socket file.
 const DefaultLVMdSocket = "/run/topolvm/lvmd.sock"
 
+// RestoreModeSameNode is the restore mode which creates a restored or cloned volume
+// as a snapshot of the source on the node of the source volume. This is the default.
+const RestoreModeSameNode = "same-node"
+
+// RestoreModeCrossNode is the restore mode which creates a restored or cloned volume on the node
+// chosen by the scheduler and copies the data of the source volume from its node if they differ.
+const RestoreModeCrossNode = "cross-node"
+
+// EncryptionLUKS is the encryption which formats a volume with LUKS and opens it with dm-crypt
+// using the passphrase in the node-publish secret.
+const EncryptionLUKS = "luks"
+
 // DefaultDeviceClassAnnotationName is the part of annotation name for the default device-class.
 const DefaultDeviceClassAnnotationName = "00default"
 
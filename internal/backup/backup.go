@@ -0,0 +1,217 @@
+package backup
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+)
+
+// writeMessageSize is the maximum size of the data in a single WriteLV message.
+const writeMessageSize = 1 << 20
+
+// Progress represents the progress of a backup.
+type Progress struct {
+	SizeBytes     uint64
+	TotalBytes    uint64
+	ReadBytes     uint64
+	UploadedBytes int64
+}
+
+// Backup reads the logical volume specified by src with lvService and uploads its data as chunks to the repository.
+// If base is given, src must have BaseName set to the snapshot of the base backup, and only the changed chunks are read.
+// progress is called every time a chunk is processed. It returns the manifest of the backup, which is not uploaded yet.
+func Backup(
+	ctx context.Context,
+	repo *Repository,
+	lvService proto.LVServiceClient,
+	src *proto.ReadLVRequest,
+	base *Manifest,
+	progress func(Progress),
+) (*Manifest, error) {
+	if base != nil && base.ChunkSize != ChunkSize {
+		return nil, fmt.Errorf("chunk size of the base backup %d differs from %d", base.ChunkSize, ChunkSize)
+	}
+	if (base != nil) != (src.GetBaseName() != "") {
+		return nil, errors.New("base snapshot must be given with the base backup")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req := &proto.ReadLVRequest{
+		Name:        src.GetName(),
+		DeviceClass: src.GetDeviceClass(),
+		BaseName:    src.GetBaseName(),
+		Alignment:   ChunkSize,
+	}
+	reader, err := lvService.ReadLV(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	header, err := reader.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive the size of the volume: %w", err)
+	}
+	size := header.GetSizeBytes()
+	if base != nil && base.SizeBytes != size {
+		return nil, fmt.Errorf("size of the base backup %d differs from the size of the volume %d", base.SizeBytes, size)
+	}
+
+	chunks := map[uint64]Chunk{}
+	if base != nil {
+		for _, c := range base.Chunks {
+			chunks[c.Offset] = c
+		}
+	}
+
+	p := Progress{SizeBytes: size, TotalBytes: header.GetTotalBytes()}
+	progress(p)
+
+	known := map[string]struct{}{}
+	var buf []byte
+	var bufOffset, filled uint64
+	flush := func() error {
+		if buf == nil {
+			return nil
+		}
+		if filled != uint64(len(buf)) {
+			return fmt.Errorf("data of the chunk at offset %d is incomplete", bufOffset)
+		}
+		if isZero(buf) {
+			delete(chunks, bufOffset)
+		} else {
+			hash, uploaded, err := repo.putChunk(ctx, buf, known)
+			if err != nil {
+				return err
+			}
+			chunks[bufOffset] = Chunk{Offset: bufOffset, Length: uint64(len(buf)), Hash: hash}
+			p.UploadedBytes += uploaded
+		}
+		buf = nil
+		progress(p)
+		return nil
+	}
+
+	for {
+		msg, err := reader.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the volume: %w", err)
+		}
+
+		data, offset := msg.GetData(), msg.GetOffset()
+		if offset+uint64(len(data)) > size {
+			return nil, fmt.Errorf("data at offset %d exceeds the size of the volume %d", offset, size)
+		}
+		for len(data) > 0 {
+			chunkOffset := offset / ChunkSize * ChunkSize
+			if buf == nil || chunkOffset != bufOffset {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+				buf = make([]byte, min(ChunkSize, size-chunkOffset))
+				bufOffset, filled = chunkOffset, 0
+			}
+			n := copy(buf[offset-chunkOffset:], data)
+			filled += uint64(n)
+			p.ReadBytes += uint64(n)
+			data = data[n:]
+			offset += uint64(n)
+			if filled == uint64(len(buf)) {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Version:   manifestVersion,
+		SizeBytes: size,
+		ChunkSize: ChunkSize,
+		Chunks:    make([]Chunk, 0, len(chunks)),
+	}
+	for _, c := range chunks {
+		m.Chunks = append(m.Chunks, c)
+	}
+	slices.SortFunc(m.Chunks, func(a, b Chunk) int {
+		return cmp.Compare(a.Offset, b.Offset)
+	})
+	return m, nil
+}
+
+// Restore downloads the chunks of the manifest and writes them to the logical volume named dstName in dstDeviceClass.
+// progress is called with the number of bytes to be restored and restored so far every time a chunk is written.
+// It returns the number of bytes written.
+func Restore(
+	ctx context.Context,
+	repo *Repository,
+	m *Manifest,
+	lvService proto.LVServiceClient,
+	dstName, dstDeviceClass string,
+	progress func(total, transferred uint64),
+) (uint64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var total uint64
+	for _, c := range m.Chunks {
+		total += c.Length
+	}
+	progress(total, 0)
+
+	writer, err := lvService.WriteLV(ctx)
+	if err != nil {
+		return 0, err
+	}
+	err = writer.Send(&proto.WriteLVRequest{
+		Name:        dstName,
+		DeviceClass: dstDeviceClass,
+		SizeBytes:   m.SizeBytes,
+	})
+
+	var transferred uint64
+	for _, c := range m.Chunks {
+		if err != nil {
+			break
+		}
+		var data []byte
+		data, err = repo.getChunk(ctx, c)
+		if err != nil {
+			return transferred, err
+		}
+		for i := 0; i < len(data) && err == nil; i += writeMessageSize {
+			piece := data[i:min(i+writeMessageSize, len(data))]
+			err = writer.Send(&proto.WriteLVRequest{Offset: c.Offset + uint64(i), Data: piece})
+		}
+		if err == nil {
+			transferred += c.Length
+			progress(total, transferred)
+		}
+	}
+	// io.EOF means that the destination has aborted, the cause is returned by CloseAndRecv.
+	if err != nil && !errors.Is(err, io.EOF) {
+		return transferred, err
+	}
+
+	res, err := writer.CloseAndRecv()
+	if err != nil {
+		return transferred, err
+	}
+	return res.GetWrittenBytes(), nil
+}
+
+func isZero(data []byte) bool {
+	return len(bytes.Trim(data, "\x00")) == 0
+}
@@ -0,0 +1,165 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+)
+
+type readClient struct {
+	grpc.ClientStream
+	msgs []*proto.ReadLVResponse
+}
+
+func (c *readClient) Recv() (*proto.ReadLVResponse, error) {
+	if len(c.msgs) == 0 {
+		return nil, io.EOF
+	}
+	msg := c.msgs[0]
+	c.msgs = c.msgs[1:]
+	return msg, nil
+}
+
+type writeClient struct {
+	grpc.ClientStream
+	volume  []byte
+	written uint64
+}
+
+func (c *writeClient) Send(req *proto.WriteLVRequest) error {
+	copy(c.volume[req.Offset:], req.Data)
+	c.written += uint64(len(req.Data))
+	return nil
+}
+
+func (c *writeClient) CloseAndRecv() (*proto.WriteLVResponse, error) {
+	return &proto.WriteLVResponse{WrittenBytes: c.written}, nil
+}
+
+// fakeLVService serves the given regions of the volume in 1MiB messages like lvmd does.
+type fakeLVService struct {
+	proto.LVServiceClient
+	volume  []byte
+	regions [][2]uint64
+	request *proto.ReadLVRequest
+	writer  *writeClient
+}
+
+func (s *fakeLVService) ReadLV(_ context.Context, in *proto.ReadLVRequest, _ ...grpc.CallOption) (proto.LVService_ReadLVClient, error) {
+	s.request = in
+	var total uint64
+	var msgs []*proto.ReadLVResponse
+	for _, r := range s.regions {
+		total += r[1] - r[0]
+		for offset := r[0]; offset < r[1]; offset += 1 << 20 {
+			end := min(offset+1<<20, r[1])
+			msgs = append(msgs, &proto.ReadLVResponse{Offset: offset, Data: s.volume[offset:end]})
+		}
+	}
+	header := &proto.ReadLVResponse{SizeBytes: uint64(len(s.volume)), TotalBytes: total}
+	return &readClient{msgs: append([]*proto.ReadLVResponse{header}, msgs...)}, nil
+}
+
+func (s *fakeLVService) WriteLV(_ context.Context, _ ...grpc.CallOption) (proto.LVService_WriteLVClient, error) {
+	return s.writer, nil
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	store, srv := newFakeS3(t)
+	repo, err := NewRepository(srv.URL, "bucket", "", "", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// 4 chunks and a half: random, zero, random, a copy of the first, and random.
+	size := uint64(ChunkSize*4 + ChunkSize/2)
+	volume := make([]byte, size)
+	if _, err := rand.Read(volume); err != nil {
+		t.Fatal(err)
+	}
+	clear(volume[ChunkSize : ChunkSize*2])
+	copy(volume[ChunkSize*3:ChunkSize*4], volume[:ChunkSize])
+
+	src := &fakeLVService{volume: volume, regions: [][2]uint64{{0, size}}}
+	var last Progress
+	full, err := Backup(ctx, repo, src, &proto.ReadLVRequest{Name: "snap1", DeviceClass: "thin"}, nil,
+		func(p Progress) { last = p })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.request.Alignment != ChunkSize {
+		t.Errorf("chunk size should be requested as the alignment: %d", src.request.Alignment)
+	}
+	if len(full.Chunks) != 4 {
+		t.Fatalf("zero chunk should be skipped: %v", full.Chunks)
+	}
+	if full.Chunks[0].Hash != full.Chunks[2].Hash {
+		t.Error("chunks having the same data should have the same hash")
+	}
+	if full.Chunks[3].Length != ChunkSize/2 {
+		t.Errorf("the last chunk should be partial: %d", full.Chunks[3].Length)
+	}
+	if store.puts != 3 {
+		t.Errorf("duplicated chunks should be uploaded once: %d", store.puts)
+	}
+	if last.ReadBytes != size || last.TotalBytes != size || last.UploadedBytes == 0 {
+		t.Errorf("unexpected progress: %+v", last)
+	}
+
+	// Modify the second chunk and zero the third chunk, then take an incremental backup.
+	modified := bytes.Clone(volume)
+	modified[ChunkSize+10] = 1
+	clear(modified[ChunkSize*2 : ChunkSize*3])
+	src = &fakeLVService{volume: modified, regions: [][2]uint64{{ChunkSize, ChunkSize * 3}}}
+	_, err = Backup(ctx, repo, src, &proto.ReadLVRequest{Name: "snap2", DeviceClass: "thin"}, full, func(Progress) {})
+	if err == nil {
+		t.Error("incremental backup without the base snapshot should fail")
+	}
+	incremental, err := Backup(ctx, repo, src, &proto.ReadLVRequest{Name: "snap2", DeviceClass: "thin", BaseName: "snap1"}, full, func(Progress) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.request.BaseName != "snap1" {
+		t.Errorf("base snapshot should be requested: %s", src.request.BaseName)
+	}
+	if len(incremental.Chunks) != 4 || incremental.Chunks[1].Offset != ChunkSize || incremental.Chunks[2].Offset != ChunkSize*3 {
+		t.Fatalf("unexpected chunks of incremental backup: %v", incremental.Chunks)
+	}
+
+	if err := repo.PutManifest(ctx, ManifestKey("uid"), incremental); err != nil {
+		t.Fatal(err)
+	}
+	m, err := repo.GetManifest(ctx, ManifestKey("uid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := &fakeLVService{writer: &writeClient{volume: make([]byte, size)}}
+	written, err := Restore(ctx, repo, m, dst, "restored", "thin", func(_, _ uint64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != ChunkSize*3+ChunkSize/2 {
+		t.Errorf("unexpected written bytes: %d", written)
+	}
+	if !bytes.Equal(dst.writer.volume, modified) {
+		t.Error("restored data does not match")
+	}
+
+	// Corrupt a chunk.
+	for k := range store.objects {
+		if bytes.HasPrefix([]byte(k), []byte("/bucket/chunks/")) {
+			store.objects[k] = []byte("broken")
+		}
+	}
+	dst = &fakeLVService{writer: &writeClient{volume: make([]byte, size)}}
+	if _, err := Restore(ctx, repo, m, dst, "restored", "thin", func(_, _ uint64) {}); err == nil {
+		t.Error("restore should fail with corrupted chunks")
+	}
+}
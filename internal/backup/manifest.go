@@ -0,0 +1,120 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+)
+
+const (
+	// ChunkSize is the size of the regions of a logical volume stored as objects.
+	ChunkSize = 4 << 20
+
+	manifestVersion = 1
+)
+
+// Manifest describes the chunks composing a backup. Regions without chunks are zeros.
+// A manifest of an incremental backup lists all chunks including the ones of its base,
+// so any backup can be restored from its own manifest.
+type Manifest struct {
+	Version   int     `json:"version"`
+	SizeBytes uint64  `json:"sizeBytes"`
+	ChunkSize uint64  `json:"chunkSize"`
+	Chunks    []Chunk `json:"chunks"`
+}
+
+// Chunk is a region of a logical volume stored as an object named by the SHA-256 hash of its data.
+type Chunk struct {
+	Offset uint64 `json:"offset"`
+	Length uint64 `json:"length"`
+	Hash   string `json:"hash"`
+}
+
+// ManifestKey returns the key of the manifest for the backup.
+func ManifestKey(backupUID string) string {
+	return path.Join("backups", backupUID+".json")
+}
+
+func chunkKey(hash string) string {
+	return path.Join("chunks", hash[:2], hash)
+}
+
+// PutManifest uploads the manifest as the object of the key.
+func (r *Repository) PutManifest(ctx context.Context, key string, m *Manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return r.Put(ctx, key, data)
+}
+
+// GetManifest downloads the manifest of the key.
+func (r *Repository) GetManifest(ctx context.Context, key string) (*Manifest, error) {
+	data, err := r.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	m := new(Manifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", key, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d: %s", m.Version, key)
+	}
+	return m, nil
+}
+
+// putChunk compresses and uploads the data unless a chunk having the same data is already stored.
+// It returns the hash of the data and the number of bytes uploaded.
+func (r *Repository) putChunk(ctx context.Context, data []byte, known map[string]struct{}) (string, int64, error) {
+	hash := sha256Hex(data)
+	if _, ok := known[hash]; ok {
+		return hash, 0, nil
+	}
+	exists, err := r.Exists(ctx, chunkKey(hash))
+	if err != nil {
+		return "", 0, err
+	}
+	if exists {
+		known[hash] = struct{}{}
+		return hash, 0, nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return "", 0, err
+	}
+	if err := w.Close(); err != nil {
+		return "", 0, err
+	}
+	if err := r.Put(ctx, chunkKey(hash), buf.Bytes()); err != nil {
+		return "", 0, err
+	}
+	known[hash] = struct{}{}
+	return hash, int64(buf.Len()), nil
+}
+
+// getChunk downloads and decompresses the chunk, and verifies its data.
+func (r *Repository) getChunk(ctx context.Context, chunk Chunk) ([]byte, error) {
+	compressed, err := r.Get(ctx, chunkKey(chunk.Hash))
+	if err != nil {
+		return nil, err
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress chunk %s: %w", chunk.Hash, err)
+	}
+	data, err := io.ReadAll(gr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress chunk %s: %w", chunk.Hash, err)
+	}
+	if uint64(len(data)) != chunk.Length || sha256Hex(data) != chunk.Hash {
+		return nil, fmt.Errorf("chunk %s at offset %d is corrupted", chunk.Hash, chunk.Offset)
+	}
+	return data, nil
+}
@@ -0,0 +1,40 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AccessKeyIDKey is the key of the access key ID in the credentials Secret.
+	AccessKeyIDKey = "accessKeyID"
+	// SecretAccessKeyKey is the key of the secret access key in the credentials Secret.
+	SecretAccessKeyKey = "secretAccessKey"
+)
+
+// NewRepositoryFromSpec returns a Repository for the BackupRepository with the credentials read from its Secret.
+// The Secret must be in namespace, so that the credentials of other namespaces cannot be used for backups.
+func NewRepositoryFromSpec(ctx context.Context, reader client.Reader, namespace string, spec topolvmv1.BackupRepository) (*Repository, error) {
+	ref := spec.CredentialsSecretRef
+	if ref.Namespace != namespace {
+		return nil, fmt.Errorf("credentials secret %s/%s is not in namespace %s", ref.Namespace, ref.Name, namespace)
+	}
+	secret := new(corev1.Secret)
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get credentials secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+	accessKeyID, ok := secret.Data[AccessKeyIDKey]
+	if !ok {
+		return nil, fmt.Errorf("credentials secret %s/%s does not have %s", ref.Namespace, ref.Name, AccessKeyIDKey)
+	}
+	secretAccessKey, ok := secret.Data[SecretAccessKeyKey]
+	if !ok {
+		return nil, fmt.Errorf("credentials secret %s/%s does not have %s", ref.Namespace, ref.Name, SecretAccessKeyKey)
+	}
+	return NewRepository(spec.Endpoint, spec.Bucket, spec.Prefix, spec.Region, string(accessKeyID), string(secretAccessKey))
+}
@@ -0,0 +1,38 @@
+package backup
+
+import (
+	"context"
+	"testing"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNewRepositoryFromSpec(t *testing.T) {
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "topolvm-system", Name: "credentials"},
+			Data:       map[string][]byte{AccessKeyIDKey: []byte("id"), SecretAccessKeyKey: []byte("secret")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "credentials"},
+			Data:       map[string][]byte{AccessKeyIDKey: []byte("id"), SecretAccessKeyKey: []byte("secret")},
+		},
+	).Build()
+	spec := func(namespace string) topolvmv1.BackupRepository {
+		return topolvmv1.BackupRepository{
+			Endpoint:             "http://127.0.0.1:9000",
+			Bucket:               "backup",
+			CredentialsSecretRef: corev1.SecretReference{Namespace: namespace, Name: "credentials"},
+		}
+	}
+
+	if _, err := NewRepositoryFromSpec(context.Background(), c, "topolvm-system", spec("topolvm-system")); err != nil {
+		t.Errorf("the secret in the namespace should be used: %v", err)
+	}
+	if _, err := NewRepositoryFromSpec(context.Background(), c, "topolvm-system", spec("other")); err == nil {
+		t.Error("the secret in another namespace should be rejected")
+	}
+}
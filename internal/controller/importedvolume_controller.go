@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/topolvm/topolvm"
+	topolvmlegacyv1 "github.com/topolvm/topolvm/api/legacy/v1"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// annProvisionedBy is the annotation of PersistentVolumes which represents the provisioner of the volume.
+const annProvisionedBy = "pv.kubernetes.io/provisioned-by"
+
+// ImportedVolumeReconciler creates PersistentVolumes for the LogicalVolumes which have imported existing LVs.
+type ImportedVolumeReconciler struct {
+	client client.Client
+}
+
+// NewImportedVolumeReconciler returns ImportedVolumeReconciler.
+func NewImportedVolumeReconciler(client client.Client) *ImportedVolumeReconciler {
+	return &ImportedVolumeReconciler{
+		client: client,
+	}
+}
+
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch
+
+// Reconcile creates the PersistentVolume of the LogicalVolume once it has imported an existing LV.
+func (r *ImportedVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	lv := new(topolvmv1.LogicalVolume)
+	err := r.client.Get(ctx, req.NamespacedName, lv)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+
+	if _, ok := lv.Annotations[topolvm.GetImportKey()]; !ok {
+		return ctrl.Result{}, nil
+	}
+	if lv.DeletionTimestamp != nil || lv.Status.VolumeID == "" || lv.Status.CurrentSize == nil {
+		return ctrl.Result{}, nil
+	}
+
+	var pv corev1.PersistentVolume
+	err = r.client.Get(ctx, types.NamespacedName{Name: lv.Name}, &pv)
+	switch {
+	case err == nil:
+		return ctrl.Result{}, nil
+	case apierrors.IsNotFound(err):
+	default:
+		return ctrl.Result{}, err
+	}
+
+	newPV, err := importedVolumePV(lv)
+	if err != nil {
+		// The annotations need to be fixed by the user, so it is not retried.
+		log.Error(err, "invalid annotations to import LV", "name", lv.Name)
+		return ctrl.Result{}, nil
+	}
+	if err := r.client.Create(ctx, newPV); err != nil && !apierrors.IsAlreadyExists(err) {
+		log.Error(err, "failed to create PersistentVolume", "name", lv.Name)
+		return ctrl.Result{}, err
+	}
+	log.Info("created PersistentVolume for imported LV", "name", lv.Name, "volume_id", lv.Status.VolumeID)
+	return ctrl.Result{}, nil
+}
+
+// importedVolumePV returns the PersistentVolume of the LogicalVolume which has imported an existing LV.
+// The reclaim policy is Retain so that the data is not lost by deleting the PVC by mistake.
+func importedVolumePV(lv *topolvmv1.LogicalVolume) (*corev1.PersistentVolume, error) {
+	volumeMode := corev1.PersistentVolumeFilesystem
+	if mode, ok := lv.Annotations[topolvm.GetImportVolumeModeKey()]; ok {
+		switch m := corev1.PersistentVolumeMode(mode); m {
+		case corev1.PersistentVolumeFilesystem, corev1.PersistentVolumeBlock:
+			volumeMode = m
+		default:
+			return nil, fmt.Errorf("invalid volume mode: %s", mode)
+		}
+	}
+
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: lv.Name,
+			Annotations: map[string]string{
+				annProvisionedBy: topolvm.GetPluginName(),
+			},
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceStorage: *lv.Status.CurrentSize,
+			},
+			AccessModes:                   []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+			StorageClassName:              lv.Annotations[topolvm.GetImportStorageClassKey()],
+			VolumeMode:                    &volumeMode,
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:       topolvm.GetPluginName(),
+					VolumeHandle: lv.Status.VolumeID,
+				},
+			},
+			NodeAffinity: nodeAffinity(lv.Spec.NodeName),
+		},
+	}
+	if volumeMode == corev1.PersistentVolumeFilesystem {
+		pv.Spec.CSI.FSType = lv.Annotations[topolvm.GetImportFsTypeKey()]
+	}
+	if claim, ok := lv.Annotations[topolvm.GetImportClaimKey()]; ok {
+		namespace, name, found := strings.Cut(claim, "/")
+		if !found || namespace == "" || name == "" {
+			return nil, fmt.Errorf("invalid claim: %s", claim)
+		}
+		pv.Spec.ClaimRef = &corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+			Namespace:  namespace,
+			Name:       name,
+		}
+	}
+	return pv, nil
+}
+
+// nodeAffinity returns the node affinity of PersistentVolumes on the node.
+func nodeAffinity(nodeName string) *corev1.VolumeNodeAffinity {
+	return &corev1.VolumeNodeAffinity{
+		Required: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key:      topolvm.GetTopologyNodeKey(),
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{nodeName},
+				}},
+			}},
+		},
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ImportedVolumeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ok := obj.GetAnnotations()[topolvm.GetImportKey()]
+		return ok
+	})
+	builder := ctrl.NewControllerManagedBy(mgr).Named("importedvolume")
+	if topolvm.UseLegacy() {
+		builder = builder.For(&topolvmlegacyv1.LogicalVolume{})
+	} else {
+		builder = builder.For(&topolvmv1.LogicalVolume{})
+	}
+	return builder.WithEventFilter(pred).Complete(r)
+}
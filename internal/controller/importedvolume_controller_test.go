@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestImportedVolumePV(t *testing.T) {
+	lv := &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "imported",
+			Annotations: map[string]string{
+				topolvm.GetImportKey():             "myvg/data",
+				topolvm.GetImportStorageClassKey(): "topolvm-provisioner",
+				topolvm.GetImportClaimKey():        "ns/data",
+				topolvm.GetImportFsTypeKey():       "xfs",
+			},
+		},
+		Spec: topolvmv1.LogicalVolumeSpec{NodeName: "node1"},
+		Status: topolvmv1.LogicalVolumeStatus{
+			VolumeID:    "data",
+			CurrentSize: resource.NewQuantity(1<<30, resource.BinarySI),
+		},
+	}
+
+	pv, err := importedVolumePV(lv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pv.Name != "imported" || pv.Spec.CSI.VolumeHandle != "data" || pv.Spec.CSI.FSType != "xfs" {
+		t.Errorf("unexpected PersistentVolume: %+v", pv)
+	}
+	if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain {
+		t.Errorf("unexpected reclaim policy: %s", pv.Spec.PersistentVolumeReclaimPolicy)
+	}
+	if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.Namespace != "ns" || pv.Spec.ClaimRef.Name != "data" {
+		t.Errorf("unexpected claimRef: %+v", pv.Spec.ClaimRef)
+	}
+	if pv.Spec.StorageClassName != "topolvm-provisioner" {
+		t.Errorf("unexpected storage class: %s", pv.Spec.StorageClassName)
+	}
+	values := pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions[0].Values
+	if len(values) != 1 || values[0] != "node1" {
+		t.Errorf("unexpected node affinity: %v", values)
+	}
+	if q := pv.Spec.Capacity[corev1.ResourceStorage]; q.Value() != 1<<30 {
+		t.Errorf("unexpected capacity: %s", q.String())
+	}
+
+	lv.Annotations[topolvm.GetImportVolumeModeKey()] = "Block"
+	pv, err = importedVolumePV(lv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *pv.Spec.VolumeMode != corev1.PersistentVolumeBlock || pv.Spec.CSI.FSType != "" {
+		t.Errorf("unexpected block PersistentVolume: %+v", pv.Spec)
+	}
+
+	for _, ann := range []struct{ key, value string }{
+		{topolvm.GetImportVolumeModeKey(), "Raw"},
+		{topolvm.GetImportClaimKey(), "data"},
+	} {
+		lv2 := lv.DeepCopy()
+		lv2.Annotations[ann.key] = ann.value
+		if _, err := importedVolumePV(lv2); err == nil {
+			t.Errorf("%s=%s should be invalid", ann.key, ann.value)
+		}
+	}
+}
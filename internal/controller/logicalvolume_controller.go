@@ -2,18 +2,21 @@ package controller
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 
 	"github.com/go-logr/logr"
 	"github.com/topolvm/topolvm"
 	topolvmlegacyv1 "github.com/topolvm/topolvm/api/legacy/v1"
 	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/transfer"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -23,21 +26,37 @@ import (
 
 // LogicalVolumeReconciler reconciles a LogicalVolume object
 type LogicalVolumeReconciler struct {
-	client    client.Client
-	nodeName  string
-	vgService proto.VGServiceClient
-	lvService proto.LVServiceClient
+	client          client.Client
+	apiReader       client.Reader
+	nodeName        string
+	backupNamespace string
+	freezeStateFile string
+	vgService       proto.VGServiceClient
+	lvService       proto.LVServiceClient
+	dialer          transfer.Dialer
+	transfers       *transferTracker
 }
 
 //+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes/status,verbs=get;update;patch
-
-func NewLogicalVolumeReconcilerWithServices(client client.Client, nodeName string, vgService proto.VGServiceClient, lvService proto.LVServiceClient) *LogicalVolumeReconciler {
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumebackups,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumebackups/status,verbs=get;update;patch
+
+// NewLogicalVolumeReconcilerWithServices returns LogicalVolumeReconciler. transferTLSConfig is used to read
+// the sources on other nodes; volumes cannot be restored or cloned across nodes if it is nil.
+// Volumes are restored from the backups whose credentials Secrets are in backupNamespace.
+// The filesystems frozen for group snapshots are recorded in freezeStateFile unless it is empty.
+func NewLogicalVolumeReconcilerWithServices(client client.Client, nodeName string, vgService proto.VGServiceClient, lvService proto.LVServiceClient,
+	transferTLSConfig *tls.Config, backupNamespace, freezeStateFile string) *LogicalVolumeReconciler {
 	return &LogicalVolumeReconciler{
-		client:    client,
-		nodeName:  nodeName,
-		vgService: vgService,
-		lvService: lvService,
+		client:          client,
+		nodeName:        nodeName,
+		backupNamespace: backupNamespace,
+		freezeStateFile: freezeStateFile,
+		vgService:       vgService,
+		lvService:       lvService,
+		dialer:          transfer.NewDialer(client, transferTLSConfig),
+		transfers:       newTransferTracker(),
 	}
 }
 
@@ -96,6 +115,14 @@ func (r *LogicalVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
 		}
 
+		if _, ok := lv.Labels[topolvm.GetGroupSnapshotKey()]; ok && lv.Status.VolumeID == "" {
+			result, err := r.createGroupSnapshot(ctx, log, lv)
+			if err != nil {
+				log.Error(err, "failed to create group snapshot", "name", lv.Name)
+			}
+			return result, err
+		}
+
 		if lv.Status.VolumeID == "" {
 			err := r.createLV(ctx, log, lv)
 			if err != nil {
@@ -107,6 +134,12 @@ func (r *LogicalVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		err := r.expandLV(ctx, log, lv)
 		if err != nil {
 			log.Error(err, "failed to expand LV", "name", lv.Name)
+			return ctrl.Result{}, err
+		}
+
+		err = r.modifyLV(ctx, log, lv)
+		if err != nil {
+			log.Error(err, "failed to modify LV", "name", lv.Name)
 		}
 		return ctrl.Result{}, err
 	}
@@ -118,6 +151,10 @@ func (r *LogicalVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	log.Info("start finalizing LogicalVolume", "name", lv.Name)
+	r.transfers.stop(lv.UID)
+	if err := r.releaseBackupSnapshots(ctx, log, lv); err != nil {
+		return ctrl.Result{}, err
+	}
 	err := r.removeLVIfExists(ctx, log, lv)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -135,6 +172,7 @@ func (r *LogicalVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *LogicalVolumeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.apiReader = mgr.GetAPIReader()
 	builder := ctrl.NewControllerManagedBy(mgr)
 	if topolvm.UseLegacy() {
 		builder = builder.For(&topolvmlegacyv1.LogicalVolume{})
@@ -144,10 +182,19 @@ func (r *LogicalVolumeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return builder.WithEventFilter(&logicalVolumeFilter{r.nodeName}).Complete(r)
 }
 
+// volumeName returns the name of the LV of the LogicalVolume.
+// It is the UID of the LogicalVolume unless the LogicalVolume has imported an existing LV.
+func volumeName(lv *topolvmv1.LogicalVolume) string {
+	if lv.Status.VolumeID != "" {
+		return lv.Status.VolumeID
+	}
+	return string(lv.UID)
+}
+
 func (r *LogicalVolumeReconciler) removeLVIfExists(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume) error {
 	// Finalizer's process ( RemoveLV then removeString ) is not atomic,
 	// so checking existence of LV to ensure its idempotence
-	_, err := r.lvService.RemoveLV(ctx, &proto.RemoveLVRequest{Name: string(lv.UID), DeviceClass: lv.Spec.DeviceClass})
+	_, err := r.lvService.RemoveLV(ctx, &proto.RemoveLVRequest{Name: volumeName(lv), DeviceClass: lv.Spec.DeviceClass})
 	if status.Code(err) == codes.NotFound {
 		log.Info("LV already removed", "name", lv.Name, "uid", lv.UID)
 		return nil
@@ -160,6 +207,45 @@ func (r *LogicalVolumeReconciler) removeLVIfExists(ctx context.Context, log logr
 	return nil
 }
 
+// releaseBackupSnapshots removes the snapshots kept by the LogicalVolumeBackups of the LogicalVolume being deleted,
+// because a thick origin cannot be removed while it has snapshots. The snapshots of a deleted volume cannot be
+// the base of incremental backups anyway. The backups in the object stores are kept.
+func (r *LogicalVolumeReconciler) releaseBackupSnapshots(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume) error {
+	if topolvm.UseLegacy() {
+		// LogicalVolumeBackup is not available with the legacy API group.
+		return nil
+	}
+	lvbs := new(topolvmv1.LogicalVolumeBackupList)
+	if err := r.client.List(ctx, lvbs); err != nil {
+		log.Error(err, "failed to list LogicalVolumeBackups", "name", lv.Name)
+		return err
+	}
+	for i := range lvbs.Items {
+		lvb := &lvbs.Items[i]
+		if lvb.Spec.LogicalVolume != lv.Name || lvb.Status.NodeName != r.nodeName || lvb.Status.SnapshotID == "" {
+			continue
+		}
+		_, err := r.lvService.RemoveLV(ctx, &proto.RemoveLVRequest{Name: lvb.Status.SnapshotID, DeviceClass: lvb.Status.DeviceClass})
+		if err != nil && status.Code(err) != codes.NotFound {
+			log.Error(err, "failed to remove snapshot of backup", "name", lv.Name, "backup", lvb.Name, "snapshot", lvb.Status.SnapshotID)
+			return err
+		}
+
+		lvb2 := lvb.DeepCopy()
+		lvb2.Status.SnapshotID = ""
+		if !lvb2.Status.Completed && lvb2.Status.Code == codes.OK {
+			lvb2.Status.Code = codes.Aborted
+			lvb2.Status.Message = fmt.Sprintf("LogicalVolume %s is deleted during the backup", lv.Name)
+		}
+		if err := r.client.Status().Patch(ctx, lvb2, client.MergeFrom(lvb)); err != nil {
+			log.Error(err, "failed to update status of backup", "name", lv.Name, "backup", lvb.Name)
+			return err
+		}
+		log.Info("released snapshot of backup", "name", lv.Name, "backup", lvb.Name, "snapshot", lvb.Status.SnapshotID)
+	}
+	return nil
+}
+
 func (r *LogicalVolumeReconciler) volumeExists(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume) (bool, error) {
 	respList, err := r.vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: lv.Spec.DeviceClass})
 	if err != nil {
@@ -183,6 +269,28 @@ func (r *LogicalVolumeReconciler) createLV(ctx context.Context, log logr.Logger,
 		return nil
 	}
 
+	// Adopt an existing LV instead of creating a new one.
+	if source, ok := lv.Annotations[topolvm.GetImportKey()]; ok {
+		return r.importLV(ctx, log, lv, source)
+	}
+
+	// Restore from a backup in the object store.
+	if lv.Spec.SourceKind == topolvmv1.SourceKindLogicalVolumeBackup {
+		return r.createLVFromBackup(ctx, log, lv)
+	}
+
+	// Restore or clone across nodes by transferring the data of the source LV.
+	if lv.Spec.Source != "" && lv.Spec.AccessType == "rw" {
+		sourcelv := new(topolvmv1.LogicalVolume)
+		if err := r.client.Get(ctx, types.NamespacedName{Namespace: lv.Namespace, Name: lv.Spec.Source}, sourcelv); err != nil {
+			log.Error(err, "unable to fetch source LogicalVolume", "name", lv.Name)
+			return err
+		}
+		if sourcelv.Spec.NodeName != r.nodeName {
+			return r.createLVFromRemoteSource(ctx, log, lv, sourcelv)
+		}
+	}
+
 	reqBytes := lv.Spec.Size.Value()
 
 	err := func() error {
@@ -257,6 +365,10 @@ func (r *LogicalVolumeReconciler) createLV(ctx context.Context, log logr.Logger,
 
 		lv.Status.VolumeID = volume.Name
 		lv.Status.CurrentSize = resource.NewQuantity(volume.SizeBytes, resource.BinarySI)
+		// The cache mode of the device-class is applied when the LV is created.
+		// If spec.cacheMode is specified, it is applied later by modifyLV.
+		lv.Status.CurrentLvcreateOptionClass = ptr.To(lv.Spec.LvcreateOptionClass)
+		lv.Status.CurrentCacheMode = ptr.To("")
 		lv.Status.Code = codes.OK
 		lv.Status.Message = ""
 		return nil
@@ -297,7 +409,7 @@ func (r *LogicalVolumeReconciler) expandLV(ctx context.Context, log logr.Logger,
 
 	err := func() error {
 		resp, err := r.lvService.ResizeLV(ctx, &proto.ResizeLVRequest{
-			Name:        string(lv.UID),
+			Name:        volumeName(lv),
 			SizeBytes:   reqBytes,
 			DeviceClass: lv.Spec.DeviceClass,
 		})
@@ -333,6 +445,65 @@ func (r *LogicalVolumeReconciler) expandLV(ctx context.Context, log logr.Logger,
 	return nil
 }
 
+// modifyLV applies the changes of spec.lvcreateOptionClass and spec.cacheMode to the LV.
+// The status fields which are not populated are regarded as being the same as the spec.
+func (r *LogicalVolumeReconciler) modifyLV(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume) error {
+	classChanged := lv.Status.CurrentLvcreateOptionClass != nil && *lv.Status.CurrentLvcreateOptionClass != lv.Spec.LvcreateOptionClass
+	cacheModeChanged := lv.Status.CurrentCacheMode != nil && *lv.Status.CurrentCacheMode != lv.Spec.CacheMode
+	if !classChanged && !cacheModeChanged {
+		return nil
+	}
+
+	// Empty fields are left unchanged by lvmd.
+	req := &proto.ModifyLVRequest{
+		Name:        volumeName(lv),
+		DeviceClass: lv.Spec.DeviceClass,
+	}
+	if classChanged {
+		req.LvcreateOptionClass = lv.Spec.LvcreateOptionClass
+	}
+	if cacheModeChanged {
+		req.CacheMode = lv.Spec.CacheMode
+	}
+
+	err := func() error {
+		var err error
+		if req.LvcreateOptionClass != "" || req.CacheMode != "" {
+			_, err = r.lvService.ModifyLV(ctx, req)
+		}
+		if err != nil {
+			code, message := extractFromError(err)
+			log.Error(err, message)
+			lv.Status.Code = code
+			lv.Status.Message = message
+			return err
+		}
+
+		lv.Status.CurrentLvcreateOptionClass = ptr.To(lv.Spec.LvcreateOptionClass)
+		lv.Status.CurrentCacheMode = ptr.To(lv.Spec.CacheMode)
+		lv.Status.Code = codes.OK
+		lv.Status.Message = ""
+		return nil
+	}()
+
+	if err != nil {
+		if err2 := r.client.Status().Update(ctx, lv); err2 != nil {
+			// err2 is logged but not returned because err is more important
+			log.Error(err2, "failed to update status", "name", lv.Name, "uid", lv.UID)
+		}
+		return err
+	}
+
+	if err := r.client.Status().Update(ctx, lv); err != nil {
+		log.Error(err, "failed to update status", "name", lv.Name, "uid", lv.UID)
+		return err
+	}
+
+	log.Info("modified LV", "name", lv.Name, "uid", lv.UID, "status.volumeID", lv.Status.VolumeID,
+		"spec.lvcreateOptionClass", lv.Spec.LvcreateOptionClass, "spec.cacheMode", lv.Spec.CacheMode)
+	return nil
+}
+
 type logicalVolumeFilter struct {
 	nodeName string
 }
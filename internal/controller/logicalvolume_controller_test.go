@@ -10,10 +10,13 @@ import (
 	topolvmv1 "github.com/topolvm/topolvm/api/v1"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
 	storegev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/config"
@@ -74,6 +77,36 @@ func (MockLVServiceClient) ResizeLV(ctx context.Context, in *proto.ResizeLVReque
 	panic("unimplemented")
 }
 
+// ModifyLV implements proto.LVServiceClient.
+// The lvcreate-option-class named "unknown" is rejected.
+func (MockLVServiceClient) ModifyLV(ctx context.Context, in *proto.ModifyLVRequest, opts ...grpc.CallOption) (*proto.Empty, error) {
+	if in.LvcreateOptionClass == "unknown" {
+		return nil, status.Errorf(codes.InvalidArgument, "lvcreate-option-class %s is not found", in.LvcreateOptionClass)
+	}
+	return &proto.Empty{}, nil
+}
+
+// ImportLV implements proto.LVServiceClient.
+func (MockLVServiceClient) ImportLV(ctx context.Context, in *proto.ImportLVRequest, opts ...grpc.CallOption) (*proto.ImportLVResponse, error) {
+	for _, lv := range *volumes {
+		if lv.Name == in.Name {
+			lv.Tags = append(lv.Tags, in.Tags...)
+			return &proto.ImportLVResponse{Volume: lv}, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "logical volume %s is not found", in.Name)
+}
+
+// ReadLV implements proto.LVServiceClient.
+func (MockLVServiceClient) ReadLV(ctx context.Context, in *proto.ReadLVRequest, opts ...grpc.CallOption) (proto.LVService_ReadLVClient, error) {
+	panic("unimplemented")
+}
+
+// WriteLV implements proto.LVServiceClient.
+func (MockLVServiceClient) WriteLV(ctx context.Context, opts ...grpc.CallOption) (proto.LVService_WriteLVClient, error) {
+	panic("unimplemented")
+}
+
 var _ = Describe("LogicalVolume controller", func() {
 	ctx := context.Background()
 	var stopFunc func()
@@ -97,7 +130,7 @@ var _ = Describe("LogicalVolume controller", func() {
 		vgService = MockVGServiceClient{}
 		lvService = MockLVServiceClient{}
 
-		reconciler := NewLogicalVolumeReconcilerWithServices(mgr.GetClient(), nodeNameBase+suffix, vgService, lvService)
+		reconciler := NewLogicalVolumeReconcilerWithServices(mgr.GetClient(), nodeNameBase+suffix, vgService, lvService, nil, "", "")
 		err = reconciler.SetupWithManager(mgr)
 		Expect(err).NotTo(HaveOccurred())
 
@@ -229,4 +262,81 @@ var _ = Describe("LogicalVolume controller", func() {
 			return !controllerutil.ContainsFinalizer(&lv, topolvm.GetLogicalVolumeFinalizer())
 		}, "2s").Should(BeTrue())
 	})
+
+	It("should import an existing LV", func() {
+		startReconciler("-import")
+
+		ctx := context.Background()
+		*volumes = append(*volumes, &proto.LogicalVolume{Name: "existing-data", SizeBytes: 2 << 30})
+
+		lv := setupResources(ctx, "-import")
+		lv2 := lv.DeepCopy()
+		lv2.Annotations = map[string]string{
+			topolvm.GetImportKey(): "myvg/existing-data",
+		}
+		err := k8sClient.Patch(ctx, lv2, client.MergeFrom(&lv))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func(g Gomega) {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(lv.Status.VolumeID).To(Equal("existing-data"))
+			g.Expect(lv.Status.CurrentSize).NotTo(BeNil())
+			g.Expect(lv.Status.CurrentSize.Value()).To(Equal(int64(2 << 30)))
+		}).Should(Succeed())
+		Expect((*volumes)[len(*volumes)-1].Tags).To(ContainElement(topolvm.GetImportedTag(lv.Name)))
+	})
+
+	It("should record a failed modification until it is reverted", func() {
+		startReconciler("-modify")
+
+		ctx := context.Background()
+		lv := setupResources(ctx, "-modify")
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)).To(Succeed())
+			g.Expect(lv.Status.VolumeID).NotTo(BeEmpty())
+		}).Should(Succeed())
+
+		// request an lvcreate-option-class which lvmd rejects, in the same way as ControllerModifyVolume
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)).To(Succeed())
+			lv.Status.CurrentLvcreateOptionClass = ptr.To("")
+			lv.Status.CurrentCacheMode = ptr.To("")
+			g.Expect(k8sClient.Status().Update(ctx, &lv)).To(Succeed())
+			lv.Spec.LvcreateOptionClass = "unknown"
+			g.Expect(k8sClient.Update(ctx, &lv)).To(Succeed())
+		}).Should(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)).To(Succeed())
+			g.Expect(lv.Status.Code).To(Equal(codes.InvalidArgument))
+			g.Expect(*lv.Status.CurrentLvcreateOptionClass).To(BeEmpty())
+		}).Should(Succeed())
+
+		// the CSI controller reverts the spec and clears the status, so the LV is left healthy
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)).To(Succeed())
+			lv.Spec.LvcreateOptionClass = ""
+			g.Expect(k8sClient.Update(ctx, &lv)).To(Succeed())
+			lv.Status.Code = codes.OK
+			lv.Status.Message = ""
+			g.Expect(k8sClient.Status().Update(ctx, &lv)).To(Succeed())
+		}).Should(Succeed())
+		Consistently(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)).To(Succeed())
+			g.Expect(lv.Status.Code).To(Equal(codes.OK))
+		}, "2s").Should(Succeed())
+
+		// the next modification is applied
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)).To(Succeed())
+			lv.Spec.LvcreateOptionClass = "fast"
+			g.Expect(k8sClient.Update(ctx, &lv)).To(Succeed())
+		}).Should(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)).To(Succeed())
+			g.Expect(lv.Status.Code).To(Equal(codes.OK))
+			g.Expect(*lv.Status.CurrentLvcreateOptionClass).To(Equal("fast"))
+		}).Should(Succeed())
+	})
 })
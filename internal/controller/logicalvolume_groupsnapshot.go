@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/filesystem"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// freezeTimeout is the maximum time for which the filesystems are frozen to take a group snapshot.
+var freezeTimeout = 30 * time.Second
+
+// createGroupSnapshot creates the snapshot LVs of all the LogicalVolumes in the group snapshot of lv at once.
+// The filesystems of the source volumes are frozen while the snapshots are taken, so that the snapshots are
+// consistent with each other. The LVs are not created until all the LogicalVolumes of the group are found.
+func (r *LogicalVolumeReconciler) createGroupSnapshot(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume) (ctrl.Result, error) {
+	// When lv.Status.Code is not codes.OK (== 0), the group snapshot has already failed.
+	if lv.Status.Code != codes.OK {
+		return ctrl.Result{}, nil
+	}
+
+	groupID := lv.Labels[topolvm.GetGroupSnapshotKey()]
+	size, err := strconv.Atoi(lv.Annotations[topolvm.GetGroupSnapshotSizeKey()])
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("invalid size of group snapshot %s: %w", groupID, err)
+	}
+
+	lvList := new(topolvmv1.LogicalVolumeList)
+	if err := r.client.List(ctx, lvList, client.MatchingLabels{topolvm.GetGroupSnapshotKey(): groupID}); err != nil {
+		return ctrl.Result{}, err
+	}
+	var members []*topolvmv1.LogicalVolume
+	for i := range lvList.Items {
+		member := &lvList.Items[i]
+		if member.Spec.NodeName != r.nodeName || member.DeletionTimestamp != nil {
+			continue
+		}
+		// The finalizer must be added before the LV is created, so that it is removed with the LogicalVolume.
+		if !controllerutil.ContainsFinalizer(member, topolvm.GetLogicalVolumeFinalizer()) {
+			log.Info("waiting for the finalizer of group snapshot member", "group", groupID, "name", member.Name)
+			return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+		}
+		members = append(members, member)
+	}
+	if len(members) < size {
+		log.Info("waiting for group snapshot members", "group", groupID, "found", len(members), "size", size)
+		return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+	}
+
+	existing := make(map[string]*proto.LogicalVolume)
+	var pending []*topolvmv1.LogicalVolume
+	var sources []*topolvmv1.LogicalVolume
+	for _, member := range members {
+		if member.Status.VolumeID != "" {
+			continue
+		}
+		// In case topolvm-node crashed just after LVM LV creation, LV may already exist.
+		found, err := r.findLV(ctx, member)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if found != nil {
+			existing[member.Name] = found
+			continue
+		}
+
+		source := new(topolvmv1.LogicalVolume)
+		if err := r.client.Get(ctx, types.NamespacedName{Name: member.Spec.Source}, source); err != nil {
+			log.Error(err, "unable to fetch source LogicalVolume", "name", member.Name)
+			return ctrl.Result{}, err
+		}
+		if source.Spec.NodeName != r.nodeName || source.Status.VolumeID == "" {
+			return ctrl.Result{}, r.failGroupSnapshot(ctx, log, members, codes.FailedPrecondition,
+				fmt.Sprintf("source volume %s is not available on node %s", source.Name, r.nodeName))
+		}
+		pending = append(pending, member)
+		sources = append(sources, source)
+	}
+
+	if len(pending) > 0 {
+		created, err := r.snapshotFrozen(ctx, log, pending, sources)
+		if err != nil {
+			code, message := extractFromError(err)
+			return ctrl.Result{}, errors.Join(err, r.failGroupSnapshot(ctx, log, members, code, message))
+		}
+		for name, volume := range created {
+			existing[name] = volume
+		}
+	}
+
+	for _, member := range members {
+		volume, ok := existing[member.Name]
+		if !ok {
+			continue
+		}
+		member.Status.VolumeID = volume.Name
+		if slices.Contains(pending, member) {
+			// Don't set CurrentSize of existing LVs because the Spec.Size field may be updated after they are created.
+			member.Status.CurrentSize = resource.NewQuantity(volume.SizeBytes, resource.BinarySI)
+		}
+		member.Status.Code = codes.OK
+		member.Status.Message = ""
+		if err := r.client.Status().Update(ctx, member); err != nil {
+			log.Error(err, "failed to update status", "name", member.Name, "uid", member.UID)
+			return ctrl.Result{}, err
+		}
+		log.Info("created new LV", "name", member.Name, "uid", member.UID, "status.volumeID", member.Status.VolumeID, "group", groupID)
+	}
+	return ctrl.Result{}, nil
+}
+
+// snapshotFrozen freezes the filesystems of the sources, creates the snapshots of them, and thaws the filesystems.
+// It returns the created LVs by the names of the LogicalVolumes. The filesystems are thawed in freezeTimeout
+// even if the snapshots are not created. They are recorded in the freeze state file while they are frozen,
+// so that topolvm-node thaws them on startup if it dies before thawing them.
+func (r *LogicalVolumeReconciler) snapshotFrozen(ctx context.Context, log logr.Logger, lvs, sources []*topolvmv1.LogicalVolume) (map[string]*proto.LogicalVolume, error) {
+	var mountPoints []string
+	for _, source := range sources {
+		volume, err := r.findLV(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		if volume == nil {
+			return nil, fmt.Errorf("LV of source volume %s is not found", source.Name)
+		}
+		mps, err := filesystem.MountPointsOfDevice(volume.DevMajor, volume.DevMinor)
+		if err != nil {
+			return nil, err
+		}
+		mountPoints = append(mountPoints, mps...)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, freezeTimeout)
+	defer cancel()
+
+	var frozen []string
+	defer func() {
+		var thawed []string
+		for _, mp := range frozen {
+			if err := filesystem.Thaw(mp); err != nil {
+				log.Error(err, "failed to thaw filesystem", "mountpoint", mp)
+				continue
+			}
+			thawed = append(thawed, mp)
+		}
+		if err := filesystem.ForgetFrozen(r.freezeStateFile, thawed); err != nil {
+			log.Error(err, "failed to update freeze state file", "mountpoints", thawed)
+		}
+	}()
+	for _, mp := range mountPoints {
+		// The mount point is recorded and thawed even if fsfreeze fails, as it may be frozen after a timeout.
+		if err := filesystem.RecordFrozen(r.freezeStateFile, mp); err != nil {
+			return nil, err
+		}
+		frozen = append(frozen, mp)
+		if err := filesystem.Freeze(ctx, mp); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("froze filesystems for group snapshot", "mountpoints", frozen)
+
+	created := make(map[string]*proto.LogicalVolume, len(lvs))
+	for i, lv := range lvs {
+		reqBytes := lv.Spec.Size.Value()
+		if currentSize := sources[i].Status.CurrentSize.Value(); reqBytes < currentSize {
+			return nil, fmt.Errorf("cannot create new LV, requested size %d is smaller than source LV size %d", reqBytes, currentSize)
+		}
+		resp, err := r.lvService.CreateLVSnapshot(ctx, &proto.CreateLVSnapshotRequest{
+			Name:         string(lv.UID),
+			DeviceClass:  lv.Spec.DeviceClass,
+			SourceVolume: sources[i].Status.VolumeID,
+			SizeBytes:    reqBytes,
+			AccessType:   lv.Spec.AccessType,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, status.Errorf(codes.DeadlineExceeded, "snapshots are not taken while filesystems are frozen for %s: %v", freezeTimeout, err)
+			}
+			return nil, err
+		}
+		created[lv.Name] = resp.Snapshot
+	}
+	return created, nil
+}
+
+// failGroupSnapshot records the error in the status of all the LogicalVolumes of the group snapshot,
+// so that the controller deletes them.
+func (r *LogicalVolumeReconciler) failGroupSnapshot(ctx context.Context, log logr.Logger, members []*topolvmv1.LogicalVolume, code codes.Code, message string) error {
+	var errs []error
+	for _, member := range members {
+		if member.Status.VolumeID != "" {
+			continue
+		}
+		member.Status.Code = code
+		member.Status.Message = message
+		if err := r.client.Status().Update(ctx, member); err != nil {
+			log.Error(err, "failed to update status", "name", member.Name, "uid", member.UID)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// findLV returns the LV of the LogicalVolume, or nil if it does not exist.
+func (r *LogicalVolumeReconciler) findLV(ctx context.Context, lv *topolvmv1.LogicalVolume) (*proto.LogicalVolume, error) {
+	name := volumeName(lv)
+	respList, err := r.vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: lv.Spec.DeviceClass})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range respList.Volumes {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
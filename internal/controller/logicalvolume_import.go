@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/codes"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// importLV brings the existing LV given by the import annotation under the management of the LogicalVolume
+// instead of creating a new LV. The data of the LV is left untouched, and the LV keeps its name as Status.VolumeID.
+func (r *LogicalVolumeReconciler) importLV(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume, source string) error {
+	vgName, lvName, found := strings.Cut(source, "/")
+	if !found {
+		vgName, lvName = "", source
+	}
+
+	err := func() error {
+		if lvName == "" || strings.Contains(lvName, "/") {
+			lv.Status.Code = codes.InvalidArgument
+			lv.Status.Message = fmt.Sprintf("invalid %s annotation: %q", topolvm.GetImportKey(), source)
+			return errors.New(lv.Status.Message)
+		}
+
+		lvList := new(topolvmv1.LogicalVolumeList)
+		if err := r.client.List(ctx, lvList); err != nil {
+			return err
+		}
+		for _, other := range lvList.Items {
+			if other.UID != lv.UID && other.Spec.NodeName == lv.Spec.NodeName && other.Status.VolumeID == lvName {
+				lv.Status.Code = codes.AlreadyExists
+				lv.Status.Message = fmt.Sprintf("LV %s is already managed by LogicalVolume %s", lvName, other.Name)
+				return errors.New(lv.Status.Message)
+			}
+		}
+
+		resp, err := r.lvService.ImportLV(ctx, &proto.ImportLVRequest{
+			Name:        lvName,
+			DeviceClass: lv.Spec.DeviceClass,
+			VolumeGroup: vgName,
+			Tags:        []string{topolvm.GetImportedTag(lv.Name)},
+		})
+		if err != nil {
+			code, message := extractFromError(err)
+			log.Error(err, message)
+			lv.Status.Code = code
+			lv.Status.Message = message
+			return err
+		}
+
+		lv.Status.VolumeID = resp.Volume.Name
+		lv.Status.CurrentSize = resource.NewQuantity(resp.Volume.SizeBytes, resource.BinarySI)
+		lv.Status.Code = codes.OK
+		lv.Status.Message = ""
+		return nil
+	}()
+
+	if err != nil {
+		if err2 := r.client.Status().Update(ctx, lv); err2 != nil {
+			// err2 is logged but not returned because err is more important
+			log.Error(err2, "failed to update status", "name", lv.Name, "uid", lv.UID)
+		}
+		return err
+	}
+
+	if err := r.client.Status().Update(ctx, lv); err != nil {
+		log.Error(err, "failed to update status", "name", lv.Name, "uid", lv.UID)
+		return err
+	}
+
+	log.Info("imported existing LV", "name", lv.Name, "uid", lv.UID, "source", source,
+		"status.volumeID", lv.Status.VolumeID, "status.currentSize", lv.Status.CurrentSize)
+	return nil
+}
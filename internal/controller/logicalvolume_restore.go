@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/backup"
+	"google.golang.org/grpc/codes"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// createLVFromBackup creates an empty LV and starts restoring the data of the LogicalVolumeBackup into it.
+// As with the transfer from another node, Status.VolumeID is set only after the restore completes.
+func (r *LogicalVolumeReconciler) createLVFromBackup(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume) error {
+	if r.transfers.isRunning(lv.UID) {
+		return nil
+	}
+
+	lvb := new(topolvmv1.LogicalVolumeBackup)
+	err := r.prepareTransfer(ctx, log, lv, "", func(reqBytes int64) error {
+		if err := r.client.Get(ctx, types.NamespacedName{Name: lv.Spec.Source}, lvb); err != nil {
+			log.Error(err, "unable to fetch source LogicalVolumeBackup", "name", lv.Name)
+			return err
+		}
+		if lvb.Status.Code != codes.OK {
+			lv.Status.Code = codes.FailedPrecondition
+			lv.Status.Message = fmt.Sprintf("backup %s has failed", lvb.Name)
+			return fmt.Errorf("cannot create new LV, backup %s has failed: %s", lvb.Name, lvb.Status.Message)
+		}
+		if !lvb.Status.Completed {
+			return fmt.Errorf("backup %s is not completed yet", lvb.Name)
+		}
+		if reqBytes < lvb.Status.SizeBytes {
+			lv.Status.Code = codes.OutOfRange
+			lv.Status.Message = fmt.Sprintf("requested size %d is smaller than backup size %d", reqBytes, lvb.Status.SizeBytes)
+			return fmt.Errorf("cannot create new LV, requested size %d is smaller than backup size %d", reqBytes, lvb.Status.SizeBytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	repository := lvb.Spec.Repository
+	manifestKey := lvb.Status.Manifest
+	key := client.ObjectKeyFromObject(lv)
+	r.transfers.start(ctx, lv.UID, func(ctx context.Context) {
+		r.transferLV(ctx, log, key, "backup "+lvb.Name,
+			func(ctx context.Context, lv *topolvmv1.LogicalVolume, progress func(total, transferred uint64)) (uint64, error) {
+				repo, err := backup.NewRepositoryFromSpec(ctx, r.apiReader, r.backupNamespace, repository)
+				if err != nil {
+					return 0, err
+				}
+				m, err := repo.GetManifest(ctx, manifestKey)
+				if err != nil {
+					return 0, err
+				}
+				return backup.Restore(ctx, repo, m, r.lvService, string(lv.UID), lv.Spec.DeviceClass, progress)
+			})
+	})
+	log.Info("started restoring LV from backup", "name", lv.Name, "uid", lv.UID, "backup", lvb.Name)
+	return nil
+}
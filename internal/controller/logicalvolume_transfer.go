@@ -0,0 +1,291 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/transfer"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/codes"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// transferProgressInterval is the minimum interval between updates of the transfer progress in the status.
+const transferProgressInterval = 5 * time.Second
+
+// transferTracker tracks the data transfers running in background.
+type transferTracker struct {
+	mu      sync.Mutex
+	running map[types.UID]*runningTransfer
+}
+
+type runningTransfer struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newTransferTracker() *transferTracker {
+	return &transferTracker{running: map[types.UID]*runningTransfer{}}
+}
+
+// start runs f in background unless a transfer for uid is already running. It returns false if it is running.
+func (t *transferTracker) start(ctx context.Context, uid types.UID, f func(ctx context.Context)) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.running[uid]; ok {
+		return false
+	}
+
+	// The transfer outlives the reconciliation, and is canceled only when the LogicalVolume is deleted.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	tr := &runningTransfer{cancel: cancel, done: make(chan struct{})}
+	t.running[uid] = tr
+	go func() {
+		defer func() {
+			t.mu.Lock()
+			delete(t.running, uid)
+			t.mu.Unlock()
+			cancel()
+			close(tr.done)
+		}()
+		f(ctx)
+	}()
+	return true
+}
+
+// isRunning returns true if a transfer for uid is running.
+func (t *transferTracker) isRunning(uid types.UID) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.running[uid]
+	return ok
+}
+
+// stop cancels the transfer for uid if it is running, and waits for it to finish.
+func (t *transferTracker) stop(uid types.UID) {
+	t.mu.Lock()
+	tr, ok := t.running[uid]
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
+	tr.cancel()
+	<-tr.done
+}
+
+// createLVFromRemoteSource creates an empty LV and starts copying the data of the source LV on another node into it.
+// Status.VolumeID is set only after the transfer completes, so the volume is not used before it is fully populated.
+// An interrupted transfer, e.g. by a restart of topolvm-node, is started over from the beginning.
+func (r *LogicalVolumeReconciler) createLVFromRemoteSource(ctx context.Context, log logr.Logger, lv, sourcelv *topolvmv1.LogicalVolume) error {
+	if r.transfers.isRunning(lv.UID) {
+		return nil
+	}
+
+	err := r.prepareTransfer(ctx, log, lv, sourcelv.Spec.NodeName, func(reqBytes int64) error {
+		if sourcelv.Status.VolumeID == "" || sourcelv.Status.CurrentSize == nil {
+			return fmt.Errorf("source LV %s is not provisioned yet", sourcelv.Name)
+		}
+		if currentSize := sourcelv.Status.CurrentSize.Value(); reqBytes < currentSize {
+			lv.Status.Code = codes.OutOfRange
+			lv.Status.Message = fmt.Sprintf("requested size %d is smaller than source LV size %d", reqBytes, currentSize)
+			return fmt.Errorf("cannot create new LV, requested size %d is smaller than source LV size %d", reqBytes, currentSize)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	source := &proto.ReadLVRequest{Name: sourcelv.Status.VolumeID, DeviceClass: sourcelv.Spec.DeviceClass}
+	sourceNode := sourcelv.Spec.NodeName
+	key := client.ObjectKeyFromObject(lv)
+	r.transfers.start(ctx, lv.UID, func(ctx context.Context) {
+		r.transferLV(ctx, log, key, "node "+sourceNode,
+			func(ctx context.Context, lv *topolvmv1.LogicalVolume, progress func(total, transferred uint64)) (uint64, error) {
+				// the source node authorizes the transfer with the hash of a new token recorded in the status.
+				token, err := transfer.NewToken()
+				if err != nil {
+					return 0, err
+				}
+				err = r.updateTransferStatus(ctx, key, func(lv *topolvmv1.LogicalVolume) {
+					lv.Status.Transfer.TokenHash = transfer.HashToken(token)
+				})
+				if err != nil {
+					return 0, err
+				}
+				ctx = transfer.WithCredentials(ctx, lv.Name, token)
+				sourceService, conn, err := r.dialer(ctx, sourceNode)
+				if err != nil {
+					return 0, err
+				}
+				defer func() { _ = conn.Close() }()
+				return transfer.Copy(ctx, sourceService, source, r.lvService, string(lv.UID), lv.Spec.DeviceClass, progress)
+			})
+	})
+	log.Info("started transferring LV", "name", lv.Name, "uid", lv.UID, "source", sourcelv.Name, "source_node", sourceNode)
+	return nil
+}
+
+// prepareTransfer creates an empty LV to be populated by a transfer, and records the transfer in the status.
+// An LV left by an interrupted attempt is removed and created again, so that every attempt starts from a clean LV.
+// validate is called with the requested size before creating the LV, and may set the status code
+// to fail the provisioning.
+func (r *LogicalVolumeReconciler) prepareTransfer(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume, sourceNode string, validate func(reqBytes int64) error) error {
+	reqBytes := lv.Spec.Size.Value()
+	err := func() error {
+		if err := validate(reqBytes); err != nil {
+			return err
+		}
+
+		found, err := r.volumeExists(ctx, log, lv)
+		if err != nil {
+			lv.Status.Code = codes.Internal
+			lv.Status.Message = "failed to check volume existence"
+			return err
+		}
+		if found {
+			_, err = r.lvService.RemoveLV(ctx, &proto.RemoveLVRequest{Name: string(lv.UID), DeviceClass: lv.Spec.DeviceClass})
+			if err != nil {
+				code, message := extractFromError(err)
+				log.Error(err, message)
+				lv.Status.Code = code
+				lv.Status.Message = message
+				return err
+			}
+			log.Info("removed LV left by an interrupted transfer", "name", lv.Name, "uid", lv.UID)
+		}
+		_, err = r.lvService.CreateLV(ctx, &proto.CreateLVRequest{
+			Name:                string(lv.UID),
+			DeviceClass:         lv.Spec.DeviceClass,
+			LvcreateOptionClass: lv.Spec.LvcreateOptionClass,
+			SizeBytes:           reqBytes,
+		})
+		if err != nil {
+			code, message := extractFromError(err)
+			log.Error(err, message)
+			lv.Status.Code = code
+			lv.Status.Message = message
+			return err
+		}
+
+		lv.Status.Transfer = &topolvmv1.TransferStatus{SourceNodeName: sourceNode}
+		lv.Status.Code = codes.OK
+		lv.Status.Message = ""
+		return nil
+	}()
+
+	if err2 := r.client.Status().Update(ctx, lv); err2 != nil {
+		log.Error(err2, "failed to update status", "name", lv.Name, "uid", lv.UID)
+		if err == nil {
+			return err2
+		}
+	}
+	return err
+}
+
+// copyFunc writes the data of the source into the LV for the LogicalVolume,
+// calling progress with the number of bytes to be written and written so far.
+type copyFunc func(ctx context.Context, lv *topolvmv1.LogicalVolume, progress func(total, transferred uint64)) (uint64, error)
+
+// transferLV populates the LV with copyData and records the result in the status.
+// from describes the source of the data in logs and error messages.
+func (r *LogicalVolumeReconciler) transferLV(ctx context.Context, log logr.Logger, key client.ObjectKey, from string, copyData copyFunc) {
+	var lastUpdate time.Time
+	progress := func(total, transferred uint64) {
+		if time.Since(lastUpdate) < transferProgressInterval {
+			return
+		}
+		lastUpdate = time.Now()
+		err := r.updateTransferStatus(ctx, key, func(lv *topolvmv1.LogicalVolume) {
+			lv.Status.Transfer.TotalBytes = int64(total)
+			lv.Status.Transfer.TransferredBytes = int64(transferred)
+		})
+		if err != nil {
+			log.Error(err, "failed to update transfer progress", "name", key.Name)
+		}
+	}
+
+	written, err := func() (uint64, error) {
+		lv := new(topolvmv1.LogicalVolume)
+		if err := r.client.Get(ctx, key, lv); err != nil {
+			return 0, err
+		}
+		return copyData(ctx, lv, progress)
+	}()
+	if ctx.Err() != nil {
+		// the LogicalVolume is being deleted.
+		log.Info("transfer is canceled", "name", key.Name)
+		return
+	}
+
+	var size int64
+	if err == nil {
+		size, err = r.volumeSize(ctx, log, key)
+	}
+
+	err2 := r.updateTransferStatus(ctx, key, func(lv *topolvmv1.LogicalVolume) {
+		lv.Status.Transfer.TokenHash = ""
+		if err != nil {
+			// The controller deletes the LogicalVolume on failure, and the provisioning is retried from the beginning.
+			code, message := extractFromError(err)
+			lv.Status.Code = code
+			lv.Status.Message = fmt.Sprintf("failed to transfer data from %s: %s", from, message)
+			return
+		}
+		lv.Status.Transfer.TransferredBytes = int64(written)
+		lv.Status.Transfer.Completed = true
+		lv.Status.VolumeID = string(lv.UID)
+		lv.Status.CurrentSize = resource.NewQuantity(size, resource.BinarySI)
+		lv.Status.Code = codes.OK
+		lv.Status.Message = ""
+	})
+	if err != nil {
+		log.Error(err, "failed to transfer LV", "name", key.Name, "from", from)
+	}
+	if err2 != nil {
+		log.Error(err2, "failed to update status", "name", key.Name)
+		return
+	}
+	if err == nil {
+		log.Info("created new LV by transfer", "name", key.Name, "from", from, "written", written)
+	}
+}
+
+// volumeSize returns the actual size of the LV for the LogicalVolume.
+func (r *LogicalVolumeReconciler) volumeSize(ctx context.Context, log logr.Logger, key client.ObjectKey) (int64, error) {
+	lv := new(topolvmv1.LogicalVolume)
+	if err := r.client.Get(ctx, key, lv); err != nil {
+		return 0, err
+	}
+	respList, err := r.vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: lv.Spec.DeviceClass})
+	if err != nil {
+		log.Error(err, "failed to get list of LV")
+		return 0, err
+	}
+	for _, v := range respList.Volumes {
+		if v.Name == string(lv.UID) {
+			return v.SizeBytes, nil
+		}
+	}
+	return 0, fmt.Errorf("LV %s is not found", lv.UID)
+}
+
+// updateTransferStatus applies mutate to the latest LogicalVolume having the transfer status and patches its status.
+func (r *LogicalVolumeReconciler) updateTransferStatus(ctx context.Context, key client.ObjectKey, mutate func(lv *topolvmv1.LogicalVolume)) error {
+	lv := new(topolvmv1.LogicalVolume)
+	if err := r.client.Get(ctx, key, lv); err != nil {
+		return err
+	}
+	if lv.Status.Transfer == nil {
+		return fmt.Errorf("transfer status of %s is missing", key.Name)
+	}
+	lv2 := lv.DeepCopy()
+	mutate(lv2)
+	return r.client.Status().Patch(ctx, lv2, client.MergeFrom(lv))
+}
@@ -0,0 +1,428 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/backup"
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// LogicalVolumeBackupReconciler takes a snapshot of a LogicalVolume on this node and uploads it to the object store.
+type LogicalVolumeBackupReconciler struct {
+	client          client.Client
+	apiReader       client.Reader
+	nodeName        string
+	backupNamespace string
+	vgService       proto.VGServiceClient
+	lvService       proto.LVServiceClient
+	uploads         *transferTracker
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumebackups,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumebackups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",namespace=topolvm-system,resources=secrets,verbs=get
+
+// NewLogicalVolumeBackupReconciler returns LogicalVolumeBackupReconciler.
+// Only the credentials Secrets in backupNamespace can be used by the LogicalVolumeBackups.
+func NewLogicalVolumeBackupReconciler(client client.Client, apiReader client.Reader, nodeName, backupNamespace string,
+	vgService proto.VGServiceClient, lvService proto.LVServiceClient) *LogicalVolumeBackupReconciler {
+	return &LogicalVolumeBackupReconciler{
+		client:          client,
+		apiReader:       apiReader,
+		nodeName:        nodeName,
+		backupNamespace: backupNamespace,
+		vgService:       vgService,
+		lvService:       lvService,
+		uploads:         newTransferTracker(),
+	}
+}
+
+// Reconcile takes a snapshot for a LogicalVolumeBackup and uploads it, or removes the snapshot on deletion.
+func (r *LogicalVolumeBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	lvb := new(topolvmv1.LogicalVolumeBackup)
+	if err := r.client.Get(ctx, req.NamespacedName, lvb); err != nil {
+		if !apierrs.IsNotFound(err) {
+			log.Error(err, "unable to fetch LogicalVolumeBackup")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if lvb.Status.NodeName == "" {
+		// The snapshot is not taken yet. The node of the LogicalVolume is in charge of the backup.
+		lv := new(topolvmv1.LogicalVolume)
+		err := r.client.Get(ctx, types.NamespacedName{Name: lvb.Spec.LogicalVolume}, lv)
+		switch {
+		case apierrs.IsNotFound(err):
+			if lvb.DeletionTimestamp != nil {
+				return ctrl.Result{}, r.removeFinalizer(ctx, log, lvb)
+			}
+			if lvb.Status.Code != codes.OK {
+				return ctrl.Result{}, nil
+			}
+			return ctrl.Result{}, r.updateStatus(ctx, client.ObjectKeyFromObject(lvb), func(lvb *topolvmv1.LogicalVolumeBackup) {
+				lvb.Status.Code = codes.NotFound
+				lvb.Status.Message = fmt.Sprintf("LogicalVolume %s is not found", lvb.Spec.LogicalVolume)
+			})
+		case err != nil:
+			log.Error(err, "unable to fetch LogicalVolume", "name", lvb.Spec.LogicalVolume)
+			return ctrl.Result{}, err
+		}
+		if lv.Spec.NodeName != r.nodeName {
+			return ctrl.Result{}, nil
+		}
+		if lvb.DeletionTimestamp == nil && lvb.Status.Code == codes.OK {
+			if !controllerutil.ContainsFinalizer(lvb, topolvm.GetLogicalVolumeBackupFinalizer()) {
+				lvb2 := lvb.DeepCopy()
+				controllerutil.AddFinalizer(lvb2, topolvm.GetLogicalVolumeBackupFinalizer())
+				if err := r.client.Patch(ctx, lvb2, client.MergeFrom(lvb)); err != nil {
+					log.Error(err, "failed to add finalizer", "name", lvb.Name)
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+			}
+			err := r.takeSnapshot(ctx, log, lvb, lv)
+			if err != nil {
+				log.Error(err, "failed to take snapshot", "name", lvb.Name)
+			}
+			return ctrl.Result{}, err
+		}
+	} else if lvb.Status.NodeName != r.nodeName {
+		return ctrl.Result{}, nil
+	}
+
+	if lvb.DeletionTimestamp != nil {
+		if !controllerutil.ContainsFinalizer(lvb, topolvm.GetLogicalVolumeBackupFinalizer()) {
+			return ctrl.Result{}, nil
+		}
+		log.Info("start finalizing LogicalVolumeBackup", "name", lvb.Name)
+		r.uploads.stop(lvb.UID)
+		if err := r.removeSnapshotIfExists(ctx, log, lvb); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.removeFinalizer(ctx, log, lvb)
+	}
+
+	if lvb.Status.Completed || lvb.Status.Code != codes.OK {
+		err := r.releaseThickSnapshot(ctx, log, lvb)
+		if err != nil {
+			log.Error(err, "failed to release thick snapshot", "name", lvb.Name)
+		}
+		return ctrl.Result{}, err
+	}
+	if lvb.Status.SnapshotID == "" {
+		return ctrl.Result{}, nil
+	}
+	key := client.ObjectKeyFromObject(lvb)
+	if r.uploads.start(ctx, lvb.UID, func(ctx context.Context) { r.upload(ctx, log, key) }) {
+		log.Info("started uploading backup", "name", lvb.Name, "snapshot", lvb.Status.SnapshotID)
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LogicalVolumeBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&topolvmv1.LogicalVolumeBackup{}).
+		WithEventFilter(&logicalVolumeBackupFilter{r.nodeName}).
+		Complete(r)
+}
+
+// takeSnapshot takes a read-only snapshot of the LogicalVolume and records it in the status.
+// The snapshot is named after the UID of the LogicalVolumeBackup.
+func (r *LogicalVolumeBackupReconciler) takeSnapshot(ctx context.Context, log logr.Logger, lvb *topolvmv1.LogicalVolumeBackup, lv *topolvmv1.LogicalVolume) error {
+	if lv.Status.VolumeID == "" || lv.Status.CurrentSize == nil {
+		return fmt.Errorf("LV %s is not provisioned yet", lv.Name)
+	}
+
+	err := func() error {
+		if lv.DeletionTimestamp != nil {
+			lvb.Status.Code = codes.FailedPrecondition
+			lvb.Status.Message = fmt.Sprintf("LV %s is being deleted", lv.Name)
+			return fmt.Errorf("LV %s is being deleted", lv.Name)
+		}
+
+		// In case topolvm-node crashed just after taking the snapshot, it may already exist.
+		respList, err := r.vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: lv.Spec.DeviceClass})
+		if err != nil {
+			lvb.Status.Code = codes.Internal
+			lvb.Status.Message = "failed to check snapshot existence"
+			return err
+		}
+		var snapshot *proto.LogicalVolume
+		for _, v := range respList.Volumes {
+			if v.Name == string(lvb.UID) {
+				snapshot = v
+				break
+			}
+		}
+		if snapshot == nil {
+			resp, err := r.lvService.CreateLVSnapshot(ctx, &proto.CreateLVSnapshotRequest{
+				Name:         string(lvb.UID),
+				DeviceClass:  lv.Spec.DeviceClass,
+				SourceVolume: lv.Status.VolumeID,
+				SizeBytes:    lv.Status.CurrentSize.Value(),
+				AccessType:   "ro",
+			})
+			if err != nil {
+				code, message := extractFromError(err)
+				lvb.Status.Code = code
+				lvb.Status.Message = message
+				return err
+			}
+			snapshot = resp.Snapshot
+		}
+
+		lvb.Status.NodeName = r.nodeName
+		lvb.Status.DeviceClass = lv.Spec.DeviceClass
+		lvb.Status.SnapshotID = snapshot.Name
+		lvb.Status.SizeBytes = snapshot.SizeBytes
+		return nil
+	}()
+
+	if err2 := r.client.Status().Update(ctx, lvb); err2 != nil {
+		log.Error(err2, "failed to update status", "name", lvb.Name)
+		if err == nil {
+			return err2
+		}
+	}
+	if err == nil {
+		log.Info("took snapshot for backup", "name", lvb.Name, "snapshot", lvb.Status.SnapshotID, "lv", lv.Name)
+	}
+	return err
+}
+
+// upload uploads the snapshot of the LogicalVolumeBackup and records the result in the status.
+// An interrupted upload is started over, and the chunks uploaded already are skipped.
+func (r *LogicalVolumeBackupReconciler) upload(ctx context.Context, log logr.Logger, key client.ObjectKey) {
+	var lastUpdate time.Time
+	progress := func(p backup.Progress) {
+		if time.Since(lastUpdate) < transferProgressInterval {
+			return
+		}
+		lastUpdate = time.Now()
+		err := r.updateStatus(ctx, key, func(lvb *topolvmv1.LogicalVolumeBackup) {
+			setBackupProgress(lvb, p)
+		})
+		if err != nil {
+			log.Error(err, "failed to update backup progress", "name", key.Name)
+		}
+	}
+
+	var last backup.Progress
+	manifestKey, err := func() (string, error) {
+		lvb := new(topolvmv1.LogicalVolumeBackup)
+		if err := r.client.Get(ctx, key, lvb); err != nil {
+			return "", err
+		}
+		repo, err := backup.NewRepositoryFromSpec(ctx, r.apiReader, r.backupNamespace, lvb.Spec.Repository)
+		if err != nil {
+			return "", err
+		}
+
+		src := &proto.ReadLVRequest{Name: lvb.Status.SnapshotID, DeviceClass: lvb.Status.DeviceClass}
+		var base *backup.Manifest
+		if lvb.Spec.Base != "" {
+			baseSnapshot, err := r.getBase(ctx, lvb)
+			if err != nil {
+				return "", err
+			}
+			base, err = repo.GetManifest(ctx, baseSnapshot.Status.Manifest)
+			if err != nil {
+				return "", err
+			}
+			src.BaseName = baseSnapshot.Status.SnapshotID
+		}
+
+		m, err := backup.Backup(ctx, repo, r.lvService, src, base, func(p backup.Progress) {
+			last = p
+			progress(p)
+		})
+		if err != nil {
+			return "", err
+		}
+		manifestKey := backup.ManifestKey(string(lvb.UID))
+		return manifestKey, repo.PutManifest(ctx, manifestKey, m)
+	}()
+	if ctx.Err() != nil {
+		// the LogicalVolumeBackup is being deleted.
+		log.Info("upload is canceled", "name", key.Name)
+		return
+	}
+
+	err2 := r.updateStatus(ctx, key, func(lvb *topolvmv1.LogicalVolumeBackup) {
+		setBackupProgress(lvb, last)
+		if err != nil {
+			code, message := extractFromError(err)
+			lvb.Status.Code = code
+			lvb.Status.Message = fmt.Sprintf("failed to upload backup: %s", message)
+			return
+		}
+		lvb.Status.Manifest = manifestKey
+		lvb.Status.Completed = true
+	})
+	if err != nil {
+		log.Error(err, "failed to upload backup", "name", key.Name)
+	}
+	if err2 != nil {
+		log.Error(err2, "failed to update status", "name", key.Name)
+		return
+	}
+	if err == nil {
+		log.Info("uploaded backup", "name", key.Name, "manifest", manifestKey, "uploaded", last.UploadedBytes)
+	}
+}
+
+// getBase returns the base LogicalVolumeBackup after checking that it can be the base of the incremental backup.
+func (r *LogicalVolumeBackupReconciler) getBase(ctx context.Context, lvb *topolvmv1.LogicalVolumeBackup) (*topolvmv1.LogicalVolumeBackup, error) {
+	base := new(topolvmv1.LogicalVolumeBackup)
+	if err := r.client.Get(ctx, types.NamespacedName{Name: lvb.Spec.Base}, base); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, status.Errorf(codes.NotFound, "base backup %s is not found", lvb.Spec.Base)
+		}
+		return nil, err
+	}
+	switch {
+	case !base.Status.Completed:
+		return nil, status.Errorf(codes.FailedPrecondition, "base backup %s is not completed", base.Name)
+	case base.Status.SnapshotID == "":
+		return nil, status.Errorf(codes.FailedPrecondition, "snapshot of base backup %s is already removed", base.Name)
+	case base.Spec.LogicalVolume != lvb.Spec.LogicalVolume:
+		return nil, status.Errorf(codes.InvalidArgument, "base backup %s is not a backup of %s", base.Name, lvb.Spec.LogicalVolume)
+	case !reflect.DeepEqual(base.Spec.Repository, lvb.Spec.Repository):
+		return nil, status.Errorf(codes.InvalidArgument, "base backup %s is stored in another repository", base.Name)
+	case base.Status.NodeName != lvb.Status.NodeName || base.Status.DeviceClass != lvb.Status.DeviceClass:
+		return nil, status.Errorf(codes.FailedPrecondition, "snapshot of base backup %s is not in the same device class", base.Name)
+	case base.Status.SizeBytes != lvb.Status.SizeBytes:
+		return nil, status.Errorf(codes.FailedPrecondition, "LV %s is resized after base backup %s", lvb.Spec.LogicalVolume, base.Name)
+	}
+	return base, nil
+}
+
+// releaseThickSnapshot removes the snapshot of the finished LogicalVolumeBackup if it is a thick snapshot.
+// A thick snapshot cannot be the base of an incremental backup, and its origin cannot be removed while it exists.
+func (r *LogicalVolumeBackupReconciler) releaseThickSnapshot(ctx context.Context, log logr.Logger, lvb *topolvmv1.LogicalVolumeBackup) error {
+	if lvb.Status.SnapshotID == "" {
+		return nil
+	}
+	resp, err := r.vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: lvb.Status.DeviceClass})
+	if err != nil {
+		return err
+	}
+	thick := false
+	for _, v := range resp.Volumes {
+		if v.Name == lvb.Status.SnapshotID {
+			thick = isThickSnapshot(v)
+			break
+		}
+	}
+	if !thick {
+		return nil
+	}
+
+	if err := r.removeSnapshotIfExists(ctx, log, lvb); err != nil {
+		return err
+	}
+	return r.updateStatus(ctx, client.ObjectKeyFromObject(lvb), func(lvb *topolvmv1.LogicalVolumeBackup) {
+		lvb.Status.SnapshotID = ""
+	})
+}
+
+// isThickSnapshot returns true if the attributes of the logical volume show that it is a thick snapshot.
+func isThickSnapshot(lv *proto.LogicalVolume) bool {
+	return len(lv.Attr) > 0 && command.VolumeType(lv.Attr[0]) == command.VolumeTypeSnapshot
+}
+
+func (r *LogicalVolumeBackupReconciler) removeSnapshotIfExists(ctx context.Context, log logr.Logger, lvb *topolvmv1.LogicalVolumeBackup) error {
+	if lvb.Status.SnapshotID == "" {
+		return nil
+	}
+	_, err := r.lvService.RemoveLV(ctx, &proto.RemoveLVRequest{Name: lvb.Status.SnapshotID, DeviceClass: lvb.Status.DeviceClass})
+	if status.Code(err) == codes.NotFound {
+		log.Info("snapshot already removed", "name", lvb.Name, "snapshot", lvb.Status.SnapshotID)
+		return nil
+	}
+	if err != nil {
+		log.Error(err, "failed to remove snapshot", "name", lvb.Name, "snapshot", lvb.Status.SnapshotID)
+		return err
+	}
+	log.Info("removed snapshot", "name", lvb.Name, "snapshot", lvb.Status.SnapshotID)
+	return nil
+}
+
+func (r *LogicalVolumeBackupReconciler) removeFinalizer(ctx context.Context, log logr.Logger, lvb *topolvmv1.LogicalVolumeBackup) error {
+	if !controllerutil.ContainsFinalizer(lvb, topolvm.GetLogicalVolumeBackupFinalizer()) {
+		return nil
+	}
+	lvb2 := lvb.DeepCopy()
+	controllerutil.RemoveFinalizer(lvb2, topolvm.GetLogicalVolumeBackupFinalizer())
+	if err := r.client.Patch(ctx, lvb2, client.MergeFrom(lvb)); err != nil {
+		log.Error(err, "failed to remove finalizer", "name", lvb.Name)
+		return err
+	}
+	return nil
+}
+
+// updateStatus applies mutate to the latest LogicalVolumeBackup and patches its status.
+func (r *LogicalVolumeBackupReconciler) updateStatus(ctx context.Context, key client.ObjectKey, mutate func(lvb *topolvmv1.LogicalVolumeBackup)) error {
+	lvb := new(topolvmv1.LogicalVolumeBackup)
+	if err := r.client.Get(ctx, key, lvb); err != nil {
+		return err
+	}
+	lvb2 := lvb.DeepCopy()
+	mutate(lvb2)
+	return r.client.Status().Patch(ctx, lvb2, client.MergeFrom(lvb))
+}
+
+func setBackupProgress(lvb *topolvmv1.LogicalVolumeBackup, p backup.Progress) {
+	lvb.Status.TotalBytes = int64(p.TotalBytes)
+	lvb.Status.ReadBytes = int64(p.ReadBytes)
+	lvb.Status.UploadedBytes = p.UploadedBytes
+}
+
+// logicalVolumeBackupFilter passes the LogicalVolumeBackups whose snapshot is taken on this node or not taken yet.
+type logicalVolumeBackupFilter struct {
+	nodeName string
+}
+
+func (f logicalVolumeBackupFilter) filter(obj client.Object) bool {
+	lvb, ok := obj.(*topolvmv1.LogicalVolumeBackup)
+	if !ok {
+		return false
+	}
+	return lvb.Status.NodeName == "" || lvb.Status.NodeName == f.nodeName
+}
+
+func (f logicalVolumeBackupFilter) Create(e event.CreateEvent) bool {
+	return f.filter(e.Object)
+}
+
+func (f logicalVolumeBackupFilter) Delete(e event.DeleteEvent) bool {
+	return f.filter(e.Object)
+}
+
+func (f logicalVolumeBackupFilter) Update(e event.UpdateEvent) bool {
+	return f.filter(e.ObjectNew)
+}
+
+func (f logicalVolumeBackupFilter) Generic(e event.GenericEvent) bool {
+	return f.filter(e.Object)
+}
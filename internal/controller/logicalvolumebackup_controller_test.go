@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+type MockBackupLVServiceClient struct {
+	MockLVServiceClient
+}
+
+// CreateLVSnapshot implements proto.LVServiceClient.
+func (MockBackupLVServiceClient) CreateLVSnapshot(ctx context.Context, in *proto.CreateLVSnapshotRequest, opts ...grpc.CallOption) (*proto.CreateLVSnapshotResponse, error) {
+	lv := proto.LogicalVolume{
+		Name:      in.Name,
+		SizeBytes: in.SizeBytes,
+	}
+	*volumes = append(*volumes, &lv)
+	return &proto.CreateLVSnapshotResponse{Snapshot: &lv}, nil
+}
+
+// RemoveLV implements proto.LVServiceClient.
+func (MockBackupLVServiceClient) RemoveLV(ctx context.Context, in *proto.RemoveLVRequest, opts ...grpc.CallOption) (*proto.Empty, error) {
+	return &proto.Empty{}, nil
+}
+
+var _ = Describe("LogicalVolumeBackup controller", func() {
+	ctx := context.Background()
+	var stopFunc func()
+	errCh := make(chan error)
+
+	startReconciler := func(suffix string) {
+		skipNameValidation := true
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+			Scheme: scheme,
+			Controller: config.Controller{
+				SkipNameValidation: &skipNameValidation,
+			},
+			Metrics: server.Options{
+				BindAddress: "0", // disable metrics
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		reconciler := NewLogicalVolumeBackupReconciler(mgr.GetClient(), mgr.GetAPIReader(), nodeNameBase+suffix, "default",
+			MockVGServiceClient{}, MockBackupLVServiceClient{})
+		err = reconciler.SetupWithManager(mgr)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(ctx)
+		stopFunc = cancel
+		go func() {
+			errCh <- mgr.Start(ctx)
+		}()
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	AfterEach(func() {
+		stopFunc()
+		Expect(<-errCh).NotTo(HaveOccurred())
+	})
+
+	newBackup := func(name, lvName string) *topolvmv1.LogicalVolumeBackup {
+		return &topolvmv1.LogicalVolumeBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: topolvmv1.LogicalVolumeBackupSpec{
+				LogicalVolume: lvName,
+				Repository: topolvmv1.BackupRepository{
+					Endpoint: "http://127.0.0.1:9000",
+					Bucket:   "backup",
+					CredentialsSecretRef: corev1.SecretReference{
+						Namespace: "default",
+						Name:      "missing",
+					},
+				},
+			},
+		}
+	}
+
+	It("should take a snapshot of LogicalVolume and remove it on deletion", func() {
+		suffix := "-backup-snapshot"
+		startReconciler(suffix)
+
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: logicalVolumeNameBase + suffix,
+			},
+			Spec: topolvmv1.LogicalVolumeSpec{
+				Name:        logicalVolumeNameBase + suffix,
+				NodeName:    nodeNameBase + suffix,
+				DeviceClass: "thin",
+				Size:        *resource.NewQuantity(1<<30, resource.BinarySI),
+			},
+		}
+		Expect(k8sClient.Create(ctx, lv)).To(Succeed())
+		lv.Status.VolumeID = "volume" + suffix
+		lv.Status.CurrentSize = resource.NewQuantity(1<<30, resource.BinarySI)
+		Expect(k8sClient.Status().Update(ctx, lv)).To(Succeed())
+
+		lvb := newBackup("backup"+suffix, lv.Name)
+		Expect(k8sClient.Create(ctx, lvb)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(lvb), lvb)).To(Succeed())
+			g.Expect(controllerutil.ContainsFinalizer(lvb, topolvm.GetLogicalVolumeBackupFinalizer())).To(BeTrue())
+			g.Expect(lvb.Status.NodeName).To(Equal(nodeNameBase + suffix))
+			g.Expect(lvb.Status.DeviceClass).To(Equal("thin"))
+			g.Expect(lvb.Status.SnapshotID).To(Equal(string(lvb.UID)))
+			g.Expect(lvb.Status.SizeBytes).To(Equal(int64(1 << 30)))
+		}).Should(Succeed())
+
+		// The upload fails because the credentials secret does not exist.
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(lvb), lvb)).To(Succeed())
+			g.Expect(lvb.Status.Code).NotTo(Equal(codes.OK))
+			g.Expect(lvb.Status.Completed).To(BeFalse())
+		}).Should(Succeed())
+
+		Expect(k8sClient.Delete(ctx, lvb)).To(Succeed())
+		Eventually(func(g Gomega) {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(lvb), lvb)
+			g.Expect(err).To(HaveOccurred())
+		}).Should(Succeed())
+	})
+
+	It("should fail when LogicalVolume does not exist", func() {
+		suffix := "-backup-missing"
+		startReconciler(suffix)
+
+		lvb := newBackup("backup"+suffix, logicalVolumeNameBase+suffix)
+		Expect(k8sClient.Create(ctx, lvb)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(lvb), lvb)).To(Succeed())
+			g.Expect(lvb.Status.Code).To(Equal(codes.NotFound))
+			g.Expect(lvb.Status.NodeName).To(BeEmpty())
+		}).Should(Succeed())
+	})
+})
@@ -0,0 +1,594 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// requeueIntervalForDetach is the interval to check if the pods using the volume have stopped.
+const requeueIntervalForDetach = 10 * time.Second
+
+// The annotations of PersistentVolumeClaims not inherited by the recreated PersistentVolumeClaim.
+var migrationDroppedClaimAnnotations = []string{
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/storage-provisioner",
+	AnnSelectedNode,
+}
+
+// LogicalVolumeMigrationReconciler moves a volume to another node by copying its data into a new LogicalVolume,
+// and rebinds its PersistentVolumeClaim to the new PersistentVolume.
+type LogicalVolumeMigrationReconciler struct {
+	client    client.Client
+	apiReader client.Reader
+}
+
+// NewLogicalVolumeMigrationReconciler returns LogicalVolumeMigrationReconciler.
+func NewLogicalVolumeMigrationReconciler(client client.Client, apiReader client.Reader) *LogicalVolumeMigrationReconciler {
+	return &LogicalVolumeMigrationReconciler{
+		client:    client,
+		apiReader: apiReader,
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumemigrations,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumemigrations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch;create;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
+
+// Reconcile advances the migration by one phase at a time.
+func (r *LogicalVolumeMigrationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	m := new(topolvmv1.LogicalVolumeMigration)
+	err := r.client.Get(ctx, req.NamespacedName, m)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+
+	// The target LogicalVolume is deleted by the garbage collector.
+	if m.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	switch m.Status.Phase {
+	case "":
+		return ctrl.Result{}, r.start(ctx, log, m)
+	case topolvmv1.MigrationPhaseWaitingForDetach:
+		return r.waitForDetach(ctx, log, m)
+	case topolvmv1.MigrationPhaseTransferring:
+		return ctrl.Result{}, r.checkTransfer(ctx, log, m)
+	case topolvmv1.MigrationPhaseRebinding:
+		return r.rebind(ctx, log, m)
+	}
+	return ctrl.Result{}, nil
+}
+
+// start validates the migration and records the volume to be migrated.
+func (r *LogicalVolumeMigrationReconciler) start(ctx context.Context, log logr.Logger, m *topolvmv1.LogicalVolumeMigration) error {
+	source := new(topolvmv1.LogicalVolume)
+	err := r.client.Get(ctx, types.NamespacedName{Name: m.Spec.LogicalVolume}, source)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return r.fail(ctx, log, m, codes.NotFound, fmt.Sprintf("LogicalVolume %s is not found", m.Spec.LogicalVolume))
+	default:
+		return err
+	}
+	if source.Status.VolumeID == "" || source.Status.CurrentSize == nil || source.DeletionTimestamp != nil {
+		return r.fail(ctx, log, m, codes.FailedPrecondition, fmt.Sprintf("LogicalVolume %s is not provisioned", source.Name))
+	}
+	if source.Spec.AccessType != "" && source.Spec.AccessType != "rw" {
+		return r.fail(ctx, log, m, codes.InvalidArgument, fmt.Sprintf("LogicalVolume %s is a snapshot", source.Name))
+	}
+	if source.Spec.NodeName == m.Spec.TargetNodeName {
+		return r.fail(ctx, log, m, codes.InvalidArgument, fmt.Sprintf("LogicalVolume %s is already on node %s", source.Name, m.Spec.TargetNodeName))
+	}
+
+	var node metav1.PartialObjectMetadata
+	node.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Node"))
+	err = r.client.Get(ctx, types.NamespacedName{Name: m.Spec.TargetNodeName}, &node)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return r.fail(ctx, log, m, codes.NotFound, fmt.Sprintf("node %s is not found", m.Spec.TargetNodeName))
+	default:
+		return err
+	}
+	if _, ok := node.Annotations[topolvm.GetTransferAddressKey()]; !ok {
+		return r.fail(ctx, log, m, codes.FailedPrecondition, fmt.Sprintf("transfer server is not enabled on node %s", m.Spec.TargetNodeName))
+	}
+
+	var pv corev1.PersistentVolume
+	err = r.client.Get(ctx, types.NamespacedName{Name: source.Name}, &pv)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return r.fail(ctx, log, m, codes.NotFound, fmt.Sprintf("PersistentVolume %s is not found", source.Name))
+	default:
+		return err
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != topolvm.GetPluginName() || pv.Spec.CSI.VolumeHandle != source.Status.VolumeID {
+		return r.fail(ctx, log, m, codes.FailedPrecondition, fmt.Sprintf("PersistentVolume %s is not of LogicalVolume %s", pv.Name, source.Name))
+	}
+	if pv.Status.Phase != corev1.VolumeBound || pv.Spec.ClaimRef == nil {
+		return r.fail(ctx, log, m, codes.FailedPrecondition, fmt.Sprintf("PersistentVolume %s is not bound", pv.Name))
+	}
+
+	var lvs topolvmv1.LogicalVolumeList
+	if err := r.client.List(ctx, &lvs); err != nil {
+		return err
+	}
+	for _, lv := range lvs.Items {
+		if lv.Spec.Source == source.Name && lv.Spec.SourceKind != topolvmv1.SourceKindLogicalVolumeBackup && lv.DeletionTimestamp == nil {
+			return r.fail(ctx, log, m, codes.FailedPrecondition, fmt.Sprintf("LogicalVolume %s has a snapshot or a clone %s", source.Name, lv.Name))
+		}
+	}
+
+	var migrations topolvmv1.LogicalVolumeMigrationList
+	if err := r.client.List(ctx, &migrations); err != nil {
+		return err
+	}
+	for _, other := range migrations.Items {
+		if other.Name != m.Name && other.Spec.LogicalVolume == source.Name && isMigrationActive(&other) {
+			return r.fail(ctx, log, m, codes.AlreadyExists, fmt.Sprintf("LogicalVolume %s is being migrated by %s", source.Name, other.Name))
+		}
+	}
+
+	m.Status.Phase = topolvmv1.MigrationPhaseWaitingForDetach
+	m.Status.SourceNodeName = source.Spec.NodeName
+	m.Status.ClaimNamespace = pv.Spec.ClaimRef.Namespace
+	m.Status.ClaimName = pv.Spec.ClaimRef.Name
+	m.Status.TargetLogicalVolume = "pvc-" + string(m.UID)
+	m.Status.ReclaimPolicy = pv.Spec.PersistentVolumeReclaimPolicy
+	m.Status.Code = codes.OK
+	m.Status.Message = ""
+	if err := r.client.Status().Update(ctx, m); err != nil {
+		log.Error(err, "failed to update status", "name", m.Name)
+		return err
+	}
+	log.Info("started migration", "name", m.Name, "logical_volume", source.Name,
+		"source_node", source.Spec.NodeName, "target_node", m.Spec.TargetNodeName)
+	return nil
+}
+
+// waitForDetach waits for the pods using the volume to stop, and creates the LogicalVolume on the target node.
+func (r *LogicalVolumeMigrationReconciler) waitForDetach(ctx context.Context, log logr.Logger, m *topolvmv1.LogicalVolumeMigration) (ctrl.Result, error) {
+	pods, err := r.podsUsingClaim(ctx, m.Status.ClaimNamespace, m.Status.ClaimName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(pods) != 0 {
+		log.Info("waiting for pods to stop", "name", m.Name, "pod", pods[0].Name, "namespace", pods[0].Namespace)
+		return ctrl.Result{RequeueAfter: requeueIntervalForDetach}, nil
+	}
+
+	source := new(topolvmv1.LogicalVolume)
+	if err := r.client.Get(ctx, types.NamespacedName{Name: m.Spec.LogicalVolume}, source); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, r.fail(ctx, log, m, codes.NotFound, fmt.Sprintf("LogicalVolume %s is not found", m.Spec.LogicalVolume))
+		}
+		return ctrl.Result{}, err
+	}
+
+	target := migrationTargetLV(m, source)
+	if err := controllerutil.SetControllerReference(m, target, r.client.Scheme()); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.client.Create(ctx, target); err != nil && !apierrors.IsAlreadyExists(err) {
+		log.Error(err, "failed to create LogicalVolume", "name", target.Name)
+		return ctrl.Result{}, err
+	}
+
+	m.Status.Phase = topolvmv1.MigrationPhaseTransferring
+	if err := r.client.Status().Update(ctx, m); err != nil {
+		log.Error(err, "failed to update status", "name", m.Name)
+		return ctrl.Result{}, err
+	}
+	log.Info("started transferring volume", "name", m.Name, "target", target.Name)
+	return ctrl.Result{}, nil
+}
+
+// checkTransfer mirrors the progress of the transfer into the LogicalVolume on the target node.
+// The migration is aborted if a pod starts using the volume, which is possible while the pod mutating webhook is disabled.
+func (r *LogicalVolumeMigrationReconciler) checkTransfer(ctx context.Context, log logr.Logger, m *topolvmv1.LogicalVolumeMigration) error {
+	pods, err := r.podsUsingClaim(ctx, m.Status.ClaimNamespace, m.Status.ClaimName)
+	if err != nil {
+		return err
+	}
+	if len(pods) != 0 {
+		if err := r.cleanupTarget(ctx, log, m); err != nil {
+			return err
+		}
+		return r.fail(ctx, log, m, codes.Aborted,
+			fmt.Sprintf("pod %s/%s started using the volume during the migration", pods[0].Namespace, pods[0].Name))
+	}
+
+	target := new(topolvmv1.LogicalVolume)
+	if err := r.client.Get(ctx, types.NamespacedName{Name: m.Status.TargetLogicalVolume}, target); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.fail(ctx, log, m, codes.Aborted, fmt.Sprintf("LogicalVolume %s is deleted", m.Status.TargetLogicalVolume))
+		}
+		return err
+	}
+
+	if target.Status.Code != codes.OK {
+		if err := r.cleanupTarget(ctx, log, m); err != nil {
+			return err
+		}
+		return r.fail(ctx, log, m, target.Status.Code, target.Status.Message)
+	}
+
+	m2 := m.DeepCopy()
+	if target.Status.Transfer != nil {
+		m2.Status.TotalBytes = target.Status.Transfer.TotalBytes
+		m2.Status.TransferredBytes = target.Status.Transfer.TransferredBytes
+	}
+	if target.Status.VolumeID != "" {
+		m2.Status.Phase = topolvmv1.MigrationPhaseRebinding
+	}
+	if err := r.client.Status().Patch(ctx, m2, client.MergeFrom(m)); err != nil {
+		log.Error(err, "failed to update status", "name", m.Name)
+		return err
+	}
+	if m2.Status.Phase == topolvmv1.MigrationPhaseRebinding {
+		log.Info("finished transferring volume", "name", m.Name, "target", target.Name)
+	}
+	return nil
+}
+
+// rebind replaces the PersistentVolume of the PersistentVolumeClaim with the one of the target LogicalVolume.
+// The PersistentVolumeClaim is recreated, as its volume cannot be changed once bound.
+// Each step is idempotent, so rebind is retried from the beginning on errors.
+func (r *LogicalVolumeMigrationReconciler) rebind(ctx context.Context, log logr.Logger, m *topolvmv1.LogicalVolumeMigration) (ctrl.Result, error) {
+	target := new(topolvmv1.LogicalVolume)
+	if err := r.client.Get(ctx, types.NamespacedName{Name: m.Status.TargetLogicalVolume}, target); err != nil {
+		return ctrl.Result{}, err
+	}
+	// The target LogicalVolume outlives the migration from now on.
+	if len(target.OwnerReferences) != 0 {
+		target2 := target.DeepCopy()
+		target2.OwnerReferences = nil
+		if err := r.client.Patch(ctx, target2, client.MergeFrom(target)); err != nil {
+			log.Error(err, "failed to remove owner reference", "name", target.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	oldPV, err := r.getPV(ctx, m.Spec.LogicalVolume)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	newPV, err := r.getPV(ctx, target.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if newPV == nil {
+		if oldPV == nil {
+			return ctrl.Result{}, r.fail(ctx, log, m, codes.NotFound, fmt.Sprintf("PersistentVolume %s is not found", m.Spec.LogicalVolume))
+		}
+		newPV = migratedPV(oldPV, target, m)
+		if err := r.client.Create(ctx, newPV); err != nil {
+			log.Error(err, "failed to create PersistentVolume", "name", newPV.Name)
+			return ctrl.Result{}, err
+		}
+		log.Info("created PersistentVolume", "name", newPV.Name)
+	}
+	// The old volume must not be deleted along with the old PersistentVolumeClaim until the new one is bound.
+	if oldPV != nil && oldPV.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain {
+		if err := r.patchReclaimPolicy(ctx, oldPV, corev1.PersistentVolumeReclaimRetain); err != nil {
+			log.Error(err, "failed to patch PersistentVolume", "name", oldPV.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	pvc := new(corev1.PersistentVolumeClaim)
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: m.Status.ClaimNamespace, Name: m.Status.ClaimName}, pvc)
+	switch {
+	case apierrors.IsNotFound(err):
+		return r.recreateClaim(ctx, log, m, newPV)
+	case err != nil:
+		return ctrl.Result{}, err
+	case pvc.DeletionTimestamp != nil:
+		return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+	case oldPV != nil && pvc.Spec.VolumeName == oldPV.Name:
+		return r.deleteClaim(ctx, log, m, pvc)
+	case pvc.Spec.VolumeName != newPV.Name:
+		return ctrl.Result{}, r.fail(ctx, log, m, codes.Aborted,
+			fmt.Sprintf("PersistentVolumeClaim %s/%s is bound to another volume %s", pvc.Namespace, pvc.Name, pvc.Spec.VolumeName))
+	case pvc.Status.Phase != corev1.ClaimBound:
+		return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+	}
+
+	return ctrl.Result{}, r.complete(ctx, log, m, oldPV, newPV)
+}
+
+// deleteClaim deletes the PersistentVolumeClaim bound to the old volume after saving it in the annotation.
+func (r *LogicalVolumeMigrationReconciler) deleteClaim(ctx context.Context, log logr.Logger, m *topolvmv1.LogicalVolumeMigration, pvc *corev1.PersistentVolumeClaim) (ctrl.Result, error) {
+	pods, err := r.podsUsingClaim(ctx, pvc.Namespace, pvc.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(pods) != 0 {
+		// The data may have been changed after the transfer.
+		if err := r.rollback(ctx, log, m); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.fail(ctx, log, m, codes.Aborted,
+			fmt.Sprintf("pod %s/%s started using the volume during the migration", pods[0].Namespace, pods[0].Name))
+	}
+
+	if _, ok := m.Annotations[topolvm.GetMigrationClaimKey()]; !ok {
+		data, err := json.Marshal(pvc)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		m2 := m.DeepCopy()
+		if m2.Annotations == nil {
+			m2.Annotations = map[string]string{}
+		}
+		m2.Annotations[topolvm.GetMigrationClaimKey()] = string(data)
+		if err := r.client.Patch(ctx, m2, client.MergeFrom(m)); err != nil {
+			log.Error(err, "failed to save PersistentVolumeClaim", "name", m.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	err = r.client.Delete(ctx, pvc, client.Preconditions{UID: &pvc.UID})
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Error(err, "failed to delete PersistentVolumeClaim", "name", pvc.Name, "namespace", pvc.Namespace)
+		return ctrl.Result{}, err
+	}
+	log.Info("deleted PersistentVolumeClaim to rebind", "name", pvc.Name, "namespace", pvc.Namespace)
+	return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+}
+
+// recreateClaim creates the PersistentVolumeClaim saved in the annotation to be bound to the new PersistentVolume.
+func (r *LogicalVolumeMigrationReconciler) recreateClaim(ctx context.Context, log logr.Logger, m *topolvmv1.LogicalVolumeMigration, pv *corev1.PersistentVolume) (ctrl.Result, error) {
+	data, ok := m.Annotations[topolvm.GetMigrationClaimKey()]
+	if !ok {
+		return ctrl.Result{}, r.fail(ctx, log, m, codes.NotFound,
+			fmt.Sprintf("PersistentVolumeClaim %s/%s is deleted", m.Status.ClaimNamespace, m.Status.ClaimName))
+	}
+	old := new(corev1.PersistentVolumeClaim)
+	if err := json.Unmarshal([]byte(data), old); err != nil {
+		return ctrl.Result{}, r.fail(ctx, log, m, codes.Internal, fmt.Sprintf("failed to restore PersistentVolumeClaim: %v", err))
+	}
+
+	pvc := migratedClaim(old, pv, m.Spec.TargetNodeName)
+	if err := r.client.Create(ctx, pvc); err != nil && !apierrors.IsAlreadyExists(err) {
+		log.Error(err, "failed to create PersistentVolumeClaim", "name", pvc.Name, "namespace", pvc.Namespace)
+		return ctrl.Result{}, err
+	}
+	log.Info("recreated PersistentVolumeClaim", "name", pvc.Name, "namespace", pvc.Namespace, "volume", pv.Name)
+	return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+}
+
+// complete restores the reclaim policy of the new PersistentVolume, and deletes the old volume.
+func (r *LogicalVolumeMigrationReconciler) complete(ctx context.Context, log logr.Logger, m *topolvmv1.LogicalVolumeMigration, oldPV, newPV *corev1.PersistentVolume) error {
+	if policy := m.Status.ReclaimPolicy; policy != "" && newPV.Spec.PersistentVolumeReclaimPolicy != policy {
+		if err := r.patchReclaimPolicy(ctx, newPV, policy); err != nil {
+			log.Error(err, "failed to patch PersistentVolume", "name", newPV.Name)
+			return err
+		}
+	}
+
+	if oldPV != nil {
+		if err := r.client.Delete(ctx, oldPV); err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "failed to delete PersistentVolume", "name", oldPV.Name)
+			return err
+		}
+	}
+	source := &topolvmv1.LogicalVolume{ObjectMeta: metav1.ObjectMeta{Name: m.Spec.LogicalVolume}}
+	if err := r.client.Delete(ctx, source); err != nil && !apierrors.IsNotFound(err) {
+		log.Error(err, "failed to delete LogicalVolume", "name", source.Name)
+		return err
+	}
+
+	m.Status.Phase = topolvmv1.MigrationPhaseCompleted
+	if err := r.client.Status().Update(ctx, m); err != nil {
+		log.Error(err, "failed to update status", "name", m.Name)
+		return err
+	}
+	log.Info("completed migration", "name", m.Name, "logical_volume", m.Spec.LogicalVolume, "target", newPV.Name)
+	return nil
+}
+
+// rollback deletes the new PersistentVolume and the target LogicalVolume, and restores the reclaim policy of the old PersistentVolume.
+func (r *LogicalVolumeMigrationReconciler) rollback(ctx context.Context, log logr.Logger, m *topolvmv1.LogicalVolumeMigration) error {
+	newPV, err := r.getPV(ctx, m.Status.TargetLogicalVolume)
+	if err != nil {
+		return err
+	}
+	if newPV != nil {
+		if err := r.client.Delete(ctx, newPV); err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "failed to delete PersistentVolume", "name", newPV.Name)
+			return err
+		}
+	}
+	oldPV, err := r.getPV(ctx, m.Spec.LogicalVolume)
+	if err != nil {
+		return err
+	}
+	if policy := m.Status.ReclaimPolicy; oldPV != nil && policy != "" && oldPV.Spec.PersistentVolumeReclaimPolicy != policy {
+		if err := r.patchReclaimPolicy(ctx, oldPV, policy); err != nil {
+			log.Error(err, "failed to patch PersistentVolume", "name", oldPV.Name)
+			return err
+		}
+	}
+	return r.cleanupTarget(ctx, log, m)
+}
+
+// cleanupTarget deletes the target LogicalVolume.
+func (r *LogicalVolumeMigrationReconciler) cleanupTarget(ctx context.Context, log logr.Logger, m *topolvmv1.LogicalVolumeMigration) error {
+	target := &topolvmv1.LogicalVolume{ObjectMeta: metav1.ObjectMeta{Name: m.Status.TargetLogicalVolume}}
+	if err := r.client.Delete(ctx, target); err != nil && !apierrors.IsNotFound(err) {
+		log.Error(err, "failed to delete LogicalVolume", "name", target.Name)
+		return err
+	}
+	return nil
+}
+
+// fail records the failure in the status. The migration is not retried.
+func (r *LogicalVolumeMigrationReconciler) fail(ctx context.Context, log logr.Logger, m *topolvmv1.LogicalVolumeMigration, code codes.Code, message string) error {
+	m.Status.Phase = topolvmv1.MigrationPhaseFailed
+	m.Status.Code = code
+	m.Status.Message = message
+	if err := r.client.Status().Update(ctx, m); err != nil {
+		log.Error(err, "failed to update status", "name", m.Name)
+		return err
+	}
+	log.Info("migration failed", "name", m.Name, "code", code, "message", message)
+	return nil
+}
+
+func (r *LogicalVolumeMigrationReconciler) getPV(ctx context.Context, name string) (*corev1.PersistentVolume, error) {
+	pv := new(corev1.PersistentVolume)
+	err := r.client.Get(ctx, types.NamespacedName{Name: name}, pv)
+	switch {
+	case err == nil:
+		return pv, nil
+	case apierrors.IsNotFound(err):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (r *LogicalVolumeMigrationReconciler) patchReclaimPolicy(ctx context.Context, pv *corev1.PersistentVolume, policy corev1.PersistentVolumeReclaimPolicy) error {
+	pv2 := pv.DeepCopy()
+	pv2.Spec.PersistentVolumeReclaimPolicy = policy
+	return r.client.Patch(ctx, pv2, client.MergeFrom(pv))
+}
+
+// podsUsingClaim returns the pods which are not terminated and use the PersistentVolumeClaim.
+func (r *LogicalVolumeMigrationReconciler) podsUsingClaim(ctx context.Context, namespace, name string) ([]corev1.Pod, error) {
+	var pods corev1.PodList
+	// query directly to API server not to miss the pods just created
+	if err := r.apiReader.List(ctx, &pods, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	var result []corev1.Pod
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == name {
+				result = append(result, pod)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func isMigrationActive(m *topolvmv1.LogicalVolumeMigration) bool {
+	return m.DeletionTimestamp == nil && m.Status.Phase != topolvmv1.MigrationPhaseCompleted && m.Status.Phase != topolvmv1.MigrationPhaseFailed
+}
+
+// migrationTargetLV returns the LogicalVolume on the target node to be populated with the data of the source.
+func migrationTargetLV(m *topolvmv1.LogicalVolumeMigration, source *topolvmv1.LogicalVolume) *topolvmv1.LogicalVolume {
+	size := source.Spec.Size
+	if source.Status.CurrentSize != nil && source.Status.CurrentSize.Cmp(size) > 0 {
+		size = *source.Status.CurrentSize
+	}
+	return &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: m.Status.TargetLogicalVolume,
+		},
+		Spec: topolvmv1.LogicalVolumeSpec{
+			Name:                m.Status.TargetLogicalVolume,
+			NodeName:            m.Spec.TargetNodeName,
+			Size:                size,
+			DeviceClass:         source.Spec.DeviceClass,
+			LvcreateOptionClass: source.Spec.LvcreateOptionClass,
+			Source:              source.Name,
+			AccessType:          "rw",
+			CacheMode:           source.Spec.CacheMode,
+			IOLimits:            source.Spec.IOLimits.DeepCopy(),
+		},
+	}
+}
+
+// migratedPV returns the PersistentVolume of the target LogicalVolume reserved for the PersistentVolumeClaim.
+// Its reclaim policy is Retain until the PersistentVolumeClaim is bound to it.
+func migratedPV(old *corev1.PersistentVolume, target *topolvmv1.LogicalVolume, m *topolvmv1.LogicalVolumeMigration) *corev1.PersistentVolume {
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   target.Name,
+			Labels: old.Labels,
+		},
+		Spec: *old.Spec.DeepCopy(),
+	}
+	if provisioner, ok := old.Annotations[annProvisionedBy]; ok {
+		pv.Annotations = map[string]string{annProvisionedBy: provisioner}
+	}
+	pv.Spec.CSI.VolumeHandle = target.Status.VolumeID
+	pv.Spec.NodeAffinity = nodeAffinity(target.Spec.NodeName)
+	pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+	pv.Spec.ClaimRef = &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "PersistentVolumeClaim",
+		Namespace:  m.Status.ClaimNamespace,
+		Name:       m.Status.ClaimName,
+	}
+	return pv
+}
+
+// migratedClaim returns the PersistentVolumeClaim to replace the old one, which is bound to the PersistentVolume.
+func migratedClaim(old *corev1.PersistentVolumeClaim, pv *corev1.PersistentVolume, nodeName string) *corev1.PersistentVolumeClaim {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            old.Name,
+			Namespace:       old.Namespace,
+			Labels:          old.Labels,
+			Annotations:     map[string]string{},
+			OwnerReferences: old.OwnerReferences,
+		},
+		Spec: *old.Spec.DeepCopy(),
+	}
+	for k, v := range old.Annotations {
+		pvc.Annotations[k] = v
+	}
+	for _, k := range migrationDroppedClaimAnnotations {
+		delete(pvc.Annotations, k)
+	}
+	// The PVC is looked up by this annotation when the node is deleted.
+	pvc.Annotations[AnnSelectedNode] = nodeName
+	pvc.Spec.VolumeName = pv.Name
+	pvc.Spec.DataSource = nil
+	pvc.Spec.DataSourceRef = nil
+	return pvc
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LogicalVolumeMigrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&topolvmv1.LogicalVolumeMigration{}).
+		Owns(&topolvmv1.LogicalVolume{}).
+		Complete(r)
+}
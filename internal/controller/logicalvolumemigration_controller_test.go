@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testMigration() *topolvmv1.LogicalVolumeMigration {
+	return &topolvmv1.LogicalVolumeMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", UID: "migration-uid"},
+		Spec: topolvmv1.LogicalVolumeMigrationSpec{
+			LogicalVolume:  "pvc-source",
+			TargetNodeName: "node2",
+		},
+		Status: topolvmv1.LogicalVolumeMigrationStatus{
+			Phase:               topolvmv1.MigrationPhaseTransferring,
+			SourceNodeName:      "node1",
+			ClaimNamespace:      "ns",
+			ClaimName:           "data",
+			TargetLogicalVolume: "pvc-migration-uid",
+			ReclaimPolicy:       corev1.PersistentVolumeReclaimDelete,
+		},
+	}
+}
+
+func TestMigrationTargetLV(t *testing.T) {
+	m := testMigration()
+	source := &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-source"},
+		Spec: topolvmv1.LogicalVolumeSpec{
+			Name:        "pvc-source",
+			NodeName:    "node1",
+			Size:        resource.MustParse("1Gi"),
+			DeviceClass: "ssd",
+			IOLimits:    &topolvmv1.IOLimits{ReadIOPS: 100},
+		},
+		Status: topolvmv1.LogicalVolumeStatus{
+			VolumeID:    "source-id",
+			CurrentSize: resource.NewQuantity(2<<30, resource.BinarySI),
+		},
+	}
+	lv := migrationTargetLV(m, source)
+	if lv.Name != "pvc-migration-uid" || lv.Spec.NodeName != "node2" || lv.Spec.Source != "pvc-source" || lv.Spec.AccessType != "rw" {
+		t.Errorf("unexpected LogicalVolume: %+v", lv)
+	}
+	if lv.Spec.Size.Value() != 2<<30 {
+		t.Errorf("the size should be the current size of the source: %s", lv.Spec.Size.String())
+	}
+	if lv.Spec.DeviceClass != "ssd" || lv.Spec.IOLimits == nil || lv.Spec.IOLimits.ReadIOPS != 100 {
+		t.Errorf("the spec of the source is not inherited: %+v", lv.Spec)
+	}
+	if len(lv.Annotations) != 0 {
+		t.Errorf("unexpected annotations: %v", lv.Annotations)
+	}
+}
+
+func TestCheckTransferAbortsOnPods(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := topolvmv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	m := testMigration()
+	target := &topolvmv1.LogicalVolume{ObjectMeta: metav1.ObjectMeta{Name: m.Status.TargetLogicalVolume}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"},
+				},
+			}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(m, target, pod).WithStatusSubresource(m).Build()
+	r := NewLogicalVolumeMigrationReconciler(c, c)
+
+	if err := r.checkTransfer(ctx, logr.Discard(), m); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(m), m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Status.Phase != topolvmv1.MigrationPhaseFailed || m.Status.Code != codes.Aborted {
+		t.Errorf("the migration should be aborted: %+v", m.Status)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(target), target); !apierrors.IsNotFound(err) {
+		t.Errorf("the target LogicalVolume should be deleted: %v", err)
+	}
+}
+
+func TestMigratedPV(t *testing.T) {
+	m := testMigration()
+	old := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pvc-source",
+			Annotations: map[string]string{
+				annProvisionedBy:                       topolvm.GetPluginName(),
+				"pv.kubernetes.io/bound-by-controller": "yes",
+			},
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:                      corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+			StorageClassName:              "topolvm-provisioner",
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:       topolvm.GetPluginName(),
+					VolumeHandle: "source-id",
+					FSType:       "xfs",
+				},
+			},
+			NodeAffinity: nodeAffinity("node1"),
+			ClaimRef:     &corev1.ObjectReference{Namespace: "ns", Name: "data", UID: "old-claim-uid"},
+		},
+	}
+	target := &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-migration-uid"},
+		Spec:       topolvmv1.LogicalVolumeSpec{NodeName: "node2"},
+		Status:     topolvmv1.LogicalVolumeStatus{VolumeID: "target-id"},
+	}
+
+	pv := migratedPV(old, target, m)
+	if pv.Name != "pvc-migration-uid" || pv.Spec.CSI.VolumeHandle != "target-id" || pv.Spec.CSI.FSType != "xfs" {
+		t.Errorf("unexpected PersistentVolume: %+v", pv)
+	}
+	if old.Spec.CSI.VolumeHandle != "source-id" {
+		t.Error("the old PersistentVolume should not be modified")
+	}
+	if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain {
+		t.Errorf("unexpected reclaim policy: %s", pv.Spec.PersistentVolumeReclaimPolicy)
+	}
+	if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.Namespace != "ns" || pv.Spec.ClaimRef.Name != "data" || pv.Spec.ClaimRef.UID != "" {
+		t.Errorf("unexpected claimRef: %+v", pv.Spec.ClaimRef)
+	}
+	values := pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions[0].Values
+	if len(values) != 1 || values[0] != "node2" {
+		t.Errorf("unexpected node affinity: %v", values)
+	}
+	if len(pv.Annotations) != 1 || pv.Annotations[annProvisionedBy] != topolvm.GetPluginName() {
+		t.Errorf("unexpected annotations: %v", pv.Annotations)
+	}
+}
+
+func TestMigratedClaim(t *testing.T) {
+	old := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "data",
+			Namespace: "ns",
+			UID:       "old-claim-uid",
+			Labels:    map[string]string{"app": "db"},
+			Annotations: map[string]string{
+				"pv.kubernetes.io/bind-completed":          "yes",
+				"volume.kubernetes.io/storage-provisioner": topolvm.GetPluginName(),
+				AnnSelectedNode:    "node1",
+				"example.com/keep": "true",
+			},
+			Finalizers:      []string{"kubernetes.io/pvc-protection"},
+			ResourceVersion: "100",
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: ptr.To("topolvm-provisioner"),
+			VolumeName:       "pvc-source",
+			DataSource:       &corev1.TypedLocalObjectReference{Kind: "PersistentVolumeClaim", Name: "origin"},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+	}
+	pv := &corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pvc-migration-uid"}}
+
+	pvc := migratedClaim(old, pv, "node2")
+	if pvc.Name != "data" || pvc.Namespace != "ns" || pvc.UID != "" || pvc.ResourceVersion != "" || len(pvc.Finalizers) != 0 {
+		t.Errorf("unexpected metadata: %+v", pvc.ObjectMeta)
+	}
+	if pvc.Spec.VolumeName != "pvc-migration-uid" || pvc.Spec.DataSource != nil || *pvc.Spec.StorageClassName != "topolvm-provisioner" {
+		t.Errorf("unexpected spec: %+v", pvc.Spec)
+	}
+	if pvc.Status.Phase != "" {
+		t.Errorf("status should be empty: %+v", pvc.Status)
+	}
+	expected := map[string]string{AnnSelectedNode: "node2", "example.com/keep": "true"}
+	if len(pvc.Annotations) != len(expected) {
+		t.Errorf("unexpected annotations: %v", pvc.Annotations)
+	}
+	for k, v := range expected {
+		if pvc.Annotations[k] != v {
+			t.Errorf("unexpected annotation %s: %s", k, pvc.Annotations[k])
+		}
+	}
+	if old.Annotations[AnnSelectedNode] != "node1" {
+		t.Error("the old PersistentVolumeClaim should not be modified")
+	}
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-logr/logr"
 	"github.com/topolvm/topolvm"
@@ -37,6 +38,7 @@ func NewNodeReconciler(client client.Client, skipNodeFinalize bool) *NodeReconci
 //+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;delete
 //+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumemigrations,verbs=get;list;watch
 
 // Reconcile finalize Node
 func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -98,6 +100,22 @@ func (r *NodeReconciler) doFinalize(ctx context.Context, log logr.Logger, node c
 		return nil
 	}
 
+	// The PVCs being migrated are deleted after the migration so that the data is kept.
+	if !topolvm.UseLegacy() {
+		var migrations topolvmv1.LogicalVolumeMigrationList
+		if err := r.client.List(ctx, &migrations); err != nil {
+			log.Error(err, "unable to fetch LogicalVolumeMigrationList")
+			return err
+		}
+		for _, m := range migrations.Items {
+			if m.Status.SourceNodeName == node.GetName() && isMigrationActive(&m) {
+				err := fmt.Errorf("LogicalVolumeMigration %s is in progress", m.Name)
+				log.Error(err, "waiting for migration to finish", "node", node.GetName())
+				return err
+			}
+		}
+	}
+
 	scs, err := r.targetStorageClasses(ctx)
 	if err != nil {
 		log.Error(err, "unable to fetch StorageClass")
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,12 +15,19 @@ import (
 	"github.com/topolvm/topolvm/internal/driver/internal/k8s"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/resource"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 var ctrlLogger = ctrl.Log.WithName("driver").WithName("controller")
 
+// Parameters added by external-provisioner with --extra-create-metadata.
+const (
+	pvcNameKey      = "csi.storage.k8s.io/pvc/name"
+	pvcNamespaceKey = "csi.storage.k8s.io/pvc/namespace"
+)
+
 var (
 	ErrNoNegativeRequestBytes = errors.New("required capacity must not be negative")
 	ErrNoNegativeLimitBytes   = errors.New("capacity limit must not be negative")
@@ -32,8 +40,14 @@ type ControllerServerSettings struct {
 	MinimumAllocationSettings `json:"allocation" ,yaml:"allocation"`
 }
 
+// ControllerServer serves the CSI controller service and the CSI group controller service.
+type ControllerServer interface {
+	csi.ControllerServer
+	csi.GroupControllerServer
+}
+
 // NewControllerServer returns a new ControllerServer.
-func NewControllerServer(mgr manager.Manager, settings ControllerServerSettings) (csi.ControllerServer, error) {
+func NewControllerServer(mgr manager.Manager, settings ControllerServerSettings) (ControllerServer, error) {
 	lvService, err := k8s.NewLogicalVolumeService(mgr)
 	if err != nil {
 		return nil, err
@@ -53,6 +67,7 @@ func NewControllerServer(mgr manager.Manager, settings ControllerServerSettings)
 // This is a wrapper for controllerServerNoLocked to protect concurrent method call.
 type controllerServer struct {
 	csi.UnimplementedControllerServer
+	csi.UnimplementedGroupControllerServer
 
 	// This protects server methods using a volume name.
 	lockByName *LockByID
@@ -88,6 +103,21 @@ func (s *controllerServer) GetCapacity(ctx context.Context, req *csi.GetCapacity
 	return s.server.GetCapacity(ctx, req)
 }
 
+func (s *controllerServer) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest) (*csi.ListVolumesResponse, error) {
+	// This reads kube-apiserver only, so it is unnecessary to take lock.
+	return s.server.ListVolumes(ctx, req)
+}
+
+func (s *controllerServer) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+	// This reads kube-apiserver only, so it is unnecessary to take lock.
+	return s.server.ControllerGetVolume(ctx, req)
+}
+
+func (s *controllerServer) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRequest) (*csi.ListSnapshotsResponse, error) {
+	// This reads kube-apiserver only, so it is unnecessary to take lock.
+	return s.server.ListSnapshots(ctx, req)
+}
+
 func (s *controllerServer) ControllerGetCapabilities(ctx context.Context, req *csi.ControllerGetCapabilitiesRequest) (*csi.ControllerGetCapabilitiesResponse, error) {
 	// This returns constants only, it is unnecessary to take lock.
 	return s.server.ControllerGetCapabilities(ctx, req)
@@ -114,6 +144,13 @@ func (s *controllerServer) ControllerExpandVolume(ctx context.Context, req *csi.
 	return s.server.ControllerExpandVolume(ctx, req)
 }
 
+func (s *controllerServer) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (*csi.ControllerModifyVolumeResponse, error) {
+	s.lockByVolumeID.LockByID(req.GetVolumeId())
+	defer s.lockByVolumeID.UnlockByID(req.GetVolumeId())
+
+	return s.server.ControllerModifyVolume(ctx, req)
+}
+
 func isRequirementsContaining(requirements *csi.TopologyRequirement, node string) bool {
 	for _, topo := range append(requirements.Preferred, requirements.Requisite...) {
 		if v, ok := topo.GetSegments()[topolvm.GetTopologyNodeKey()]; ok {
@@ -141,6 +178,7 @@ func findNodeHavingTopologyNodeKey(requirements *csi.TopologyRequirement) string
 // Therefore, must not use it directly.
 type controllerServerNoLocked struct {
 	csi.UnimplementedControllerServer
+	csi.UnimplementedGroupControllerServer
 
 	lvService   *k8s.LogicalVolumeService
 	nodeService *k8s.NodeService
@@ -153,6 +191,8 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 	source := req.GetVolumeContentSource()
 	deviceClass := req.GetParameters()[topolvm.GetDeviceClassKey()]
 	lvcreateOptionClass := req.GetParameters()[topolvm.GetLvcreateOptionClassKey()]
+	restoreMode := req.GetParameters()[topolvm.GetRestoreModeKey()]
+	encryption := req.GetParameters()[topolvm.GetEncryptionKey()]
 
 	ctrlLogger.Info("CreateVolume called",
 		"name", req.GetName(),
@@ -168,6 +208,7 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 	var (
 		// sourceID   string
 		sourceName string
+		sourceKind string
 		sourceVol  *v1.LogicalVolume
 		err        error
 	)
@@ -176,6 +217,22 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 		return nil, status.Error(codes.InvalidArgument, "no volume capabilities are provided")
 	}
 
+	ioLimits, err := parseIOLimits(req.GetParameters())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	// the mutable parameters of VolumeAttributesClass take precedence over the parameters of StorageClass
+	mutableOptionClass, cacheMode, mutableIOLimits, err := parseMutableParameters(req.GetMutableParameters())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if mutableOptionClass != "" {
+		lvcreateOptionClass = mutableOptionClass
+	}
+	if mutableIOLimits != nil {
+		ioLimits = mutableIOLimits
+	}
+
 	required, limit := s.settings.MinMaxAllocationsFromSettings(
 		req.GetCapacityRange().GetRequiredBytes(),
 		req.GetCapacityRange().GetLimitBytes(),
@@ -212,6 +269,23 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
+	switch restoreMode {
+	case "":
+		restoreMode = topolvm.RestoreModeSameNode
+	case topolvm.RestoreModeSameNode, topolvm.RestoreModeCrossNode:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown restore mode: %s", restoreMode)
+	}
+
+	var volumeContext map[string]string
+	switch encryption {
+	case "":
+	case topolvm.EncryptionLUKS:
+		volumeContext = map[string]string{topolvm.GetEncryptionKey(): encryption}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown encryption: %s", encryption)
+	}
+
 	// check if the create volume request has a data source
 	if source != nil {
 		// get the source volumeID/snapshotID if exists
@@ -225,20 +299,58 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 		if requestCapacityBytes < sourceSizeBytes {
 			return nil, status.Error(codes.OutOfRange, "requested size is smaller than the size of the source")
 		}
-		// If a volume has a source, it has to provisioned on the same node and device class as the source volume.
+		// If a volume has a source, it has to provisioned with the same device class as the source volume.
 
 		if deviceClass != sourceVol.Spec.DeviceClass {
 			return nil, status.Error(codes.InvalidArgument, "device class mismatch. Snapshots should be created with the same device class as the source.")
 		}
 		deviceClass = sourceVol.Spec.DeviceClass
 		sourceName = sourceVol.Spec.Name
+		if err := s.validateSourceEncryption(ctx, sourceVol.Name, encryption); err != nil {
+			return nil, err
+		}
+	}
+
+	// check if the PVC requests to restore a backup
+	pvcName, pvcNamespace := req.GetParameters()[pvcNameKey], req.GetParameters()[pvcNamespaceKey]
+	if !topolvm.UseLegacy() && pvcName != "" && pvcNamespace != "" {
+		backup, err := s.lvService.GetBackupOfClaim(ctx, pvcNamespace, pvcName)
+		if err != nil {
+			return nil, err
+		}
+		if backup != nil {
+			if source != nil {
+				return nil, status.Error(codes.InvalidArgument, "cannot restore a backup into a volume having a data source")
+			}
+			if !backup.Status.Completed {
+				return nil, status.Errorf(codes.Unavailable, "backup %s is not completed yet", backup.Name)
+			}
+			if requestCapacityBytes < backup.Status.SizeBytes {
+				return nil, status.Error(codes.OutOfRange, "requested size is smaller than the size of the backup")
+			}
+			if err := s.validateSourceEncryption(ctx, backup.Spec.LogicalVolume, encryption); err != nil {
+				return nil, err
+			}
+			// The backup is restored on the node chosen by the scheduler.
+			sourceName = backup.Name
+			sourceKind = v1.SourceKindLogicalVolumeBackup
+		}
 	}
 
 	// process topology
 	var node string
 	requirements := req.GetAccessibilityRequirements()
 
-	if source != nil {
+	if source != nil && restoreMode == topolvm.RestoreModeCrossNode {
+		// the volume is created on the node chosen by the scheduler, and the data of the source is copied from its node if they differ.
+		node = sourceVol.Spec.NodeName
+		if requirements != nil {
+			node = findNodeHavingTopologyNodeKey(requirements)
+			if node == "" {
+				return nil, status.Errorf(codes.InvalidArgument, "cannot find key '%s' in accessibility_requirements", topolvm.GetTopologyNodeKey())
+			}
+		}
+	} else if source != nil {
 		// the snapshot must be created on the same node as the source
 		node = sourceVol.Spec.NodeName
 		if requirements != nil && !isRequirementsContaining(requirements, node) {
@@ -277,7 +389,7 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 	}
 	name = strings.ToLower(name)
 
-	volume, err := s.lvService.CreateVolume(ctx, node, deviceClass, lvcreateOptionClass, name, sourceName, requestCapacityBytes)
+	volume, err := s.lvService.CreateVolume(ctx, node, deviceClass, lvcreateOptionClass, cacheMode, ioLimits, name, sourceName, sourceKind, requestCapacityBytes)
 	if err != nil {
 		_, ok := status.FromError(err)
 		if !ok {
@@ -290,6 +402,7 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 		Volume: &csi.Volume{
 			CapacityBytes: volume.Status.CurrentSize.Value(),
 			VolumeId:      volume.Status.VolumeID,
+			VolumeContext: volumeContext,
 			ContentSource: source,
 			AccessibleTopology: []*csi.Topology{
 				{
@@ -301,6 +414,19 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 }
 
 // validateContentSource checks if the request has a data source and returns source volume information.
+// validateSourceEncryption returns InvalidArgument if the encryption differs from that of the source volume,
+// as the data is copied as is. The LogicalVolume of the source is specified by name.
+func (s controllerServerNoLocked) validateSourceEncryption(ctx context.Context, name, encryption string) error {
+	sourceEncryption, ok, err := s.lvService.GetEncryptionOfSource(ctx, name)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if ok && sourceEncryption != encryption {
+		return status.Errorf(codes.InvalidArgument, "encryption %q differs from %q of the source volume", encryption, sourceEncryption)
+	}
+	return nil
+}
+
 func (s controllerServerNoLocked) validateContentSource(ctx context.Context, req *csi.CreateVolumeRequest) (*v1.LogicalVolume, string, error) {
 	volumeSource := req.VolumeContentSource
 
@@ -417,6 +543,89 @@ func (s controllerServerNoLocked) DeleteSnapshot(ctx context.Context, req *csi.D
 	return &csi.DeleteSnapshotResponse{}, nil
 }
 
+func (s controllerServerNoLocked) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRequest) (*csi.ListSnapshotsResponse, error) {
+	ctrlLogger.Info("ListSnapshots called",
+		"snapshot_id", req.GetSnapshotId(),
+		"source_volume_id", req.GetSourceVolumeId(),
+		"max_entries", req.GetMaxEntries(),
+		"starting_token", req.GetStartingToken())
+
+	if req.GetMaxEntries() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "max_entries must not be negative")
+	}
+
+	snapshots, err := s.lvService.ListSnapshots(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	sourceVolumeIDs, err := s.sourceVolumeIDs(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	snapshots = filterSnapshots(snapshots, sourceVolumeIDs, req.GetSnapshotId(), req.GetSourceVolumeId())
+	page, nextToken, err := pageOfVolumes(snapshots, req.GetStartingToken(), int(req.GetMaxEntries()))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*csi.ListSnapshotsResponse_Entry, 0, len(page))
+	for i := range page {
+		entries = append(entries, &csi.ListSnapshotsResponse_Entry{
+			Snapshot: snapshotOfLogicalVolume(&page[i], sourceVolumeIDs[page[i].Spec.Source]),
+		})
+	}
+	return &csi.ListSnapshotsResponse{Entries: entries, NextToken: nextToken}, nil
+}
+
+// sourceVolumeIDs returns the volume IDs of the LogicalVolumes which can be the sources of snapshots by their names.
+func (s controllerServerNoLocked) sourceVolumeIDs(ctx context.Context) (map[string]string, error) {
+	volumes, err := s.lvService.ListVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sourceVolumeIDs := make(map[string]string, len(volumes))
+	for _, lv := range volumes {
+		sourceVolumeIDs[lv.Spec.Name] = lv.Status.VolumeID
+	}
+	return sourceVolumeIDs, nil
+}
+
+// filterSnapshots returns the snapshots matching the snapshot ID and the source volume ID, if they are given.
+// sourceVolumeIDs maps the names of the source LogicalVolumes to their volume IDs.
+func filterSnapshots(snapshots []v1.LogicalVolume, sourceVolumeIDs map[string]string, snapshotID, sourceVolumeID string) []v1.LogicalVolume {
+	if snapshotID == "" && sourceVolumeID == "" {
+		return snapshots
+	}
+	filtered := make([]v1.LogicalVolume, 0, len(snapshots))
+	for _, lv := range snapshots {
+		if snapshotID != "" && lv.Status.VolumeID != snapshotID {
+			continue
+		}
+		if sourceVolumeID != "" && sourceVolumeIDs[lv.Spec.Source] != sourceVolumeID {
+			continue
+		}
+		filtered = append(filtered, lv)
+	}
+	return filtered
+}
+
+// snapshotOfLogicalVolume returns the CSI snapshot provisioned as a LogicalVolume.
+func snapshotOfLogicalVolume(lv *v1.LogicalVolume, sourceVolumeID string) *csi.Snapshot {
+	currentSize := lv.Status.CurrentSize
+	if currentSize == nil {
+		currentSize = &lv.Spec.Size
+	}
+	return &csi.Snapshot{
+		SizeBytes:       currentSize.Value(),
+		SnapshotId:      lv.Status.VolumeID,
+		SourceVolumeId:  sourceVolumeID,
+		CreationTime:    &timestamp.Timestamp{Seconds: lv.CreationTimestamp.Unix()},
+		ReadyToUse:      lv.Status.VolumeID != "" && lv.Status.Code == codes.OK,
+		GroupSnapshotId: lv.Labels[topolvm.GetGroupSnapshotKey()],
+	}
+}
+
 // convertRequestCapacityBytes converts requestBytes and limitBytes to a valid capacity.
 func convertRequestCapacityBytes(requestBytes, limitBytes int64) (int64, error) {
 	if requestBytes < 0 {
@@ -585,6 +794,11 @@ func (s controllerServerNoLocked) ControllerGetCapabilities(context.Context, *cs
 		csi.ControllerServiceCapability_RPC_GET_CAPACITY,
 		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
 		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
+		csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
+		csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
+		csi.ControllerServiceCapability_RPC_GET_VOLUME,
+		csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
+		csi.ControllerServiceCapability_RPC_MODIFY_VOLUME,
 	}
 
 	csiCaps := make([]*csi.ControllerServiceCapability, len(capabilities))
@@ -674,3 +888,217 @@ func (s controllerServerNoLocked) ControllerExpandVolume(ctx context.Context, re
 		NodeExpansionRequired: nodeExpansionRequired,
 	}, nil
 }
+
+// ControllerModifyVolume changes the lvcreate-option-class and the cache mode of a volume.
+// topolvm-node applies the changes to the LV online.
+func (s controllerServerNoLocked) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (*csi.ControllerModifyVolumeResponse, error) {
+	volumeID := req.GetVolumeId()
+	logger := ctrlLogger.WithValues("volumeID", volumeID,
+		"mutable_parameters", req.GetMutableParameters(),
+		"num_secrets", len(req.GetSecrets()))
+
+	logger.Info("ControllerModifyVolume called")
+
+	if len(volumeID) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "volume id is nil")
+	}
+	lvcreateOptionClass, cacheMode, ioLimits, err := parseMutableParameters(req.GetMutableParameters())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	lv, err := s.lvService.GetVolume(ctx, volumeID)
+	if err != nil {
+		if errors.Is(err, k8s.ErrVolumeNotFound) {
+			return nil, status.Errorf(codes.NotFound, "LogicalVolume for volume id %s is not found", volumeID)
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if lv.Spec.Source != "" && lv.Spec.AccessType == "ro" {
+		return nil, status.Errorf(codes.InvalidArgument, "volume %s is a snapshot and cannot be modified", volumeID)
+	}
+
+	_, err = s.lvService.ModifyVolume(ctx, volumeID, lvcreateOptionClass, cacheMode, ioLimits)
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return nil, err
+	}
+
+	logger.Info("ControllerModifyVolume has succeeded")
+
+	return &csi.ControllerModifyVolumeResponse{}, nil
+}
+
+// parseMutableParameters returns the lvcreate-option-class, the cache mode and the IO limits specified by the mutable parameters.
+// Empty strings and nil are returned for the parameters which are not specified.
+func parseMutableParameters(params map[string]string) (lvcreateOptionClass, cacheMode string, ioLimits *v1.IOLimits, err error) {
+	for k, v := range params {
+		switch k {
+		case topolvm.GetLvcreateOptionClassKey():
+			if v == "" {
+				return "", "", nil, fmt.Errorf("empty value of mutable parameter %s", k)
+			}
+			lvcreateOptionClass = v
+		case topolvm.GetCacheModeKey():
+			if v != "writethrough" && v != "writeback" {
+				return "", "", nil, fmt.Errorf("invalid value of mutable parameter %s: %s", k, v)
+			}
+			cacheMode = v
+		case topolvm.GetReadIOPSKey(), topolvm.GetWriteIOPSKey(), topolvm.GetReadBytesPerSecondKey(), topolvm.GetWriteBytesPerSecondKey():
+		default:
+			return "", "", nil, fmt.Errorf("unknown mutable parameter: %s", k)
+		}
+	}
+	ioLimits, err = parseIOLimits(params)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return lvcreateOptionClass, cacheMode, ioLimits, nil
+}
+
+// parseIOLimits returns the IO limits specified by the parameters, or nil if none of them is specified.
+// The values are quantities such as "1000" or "100Mi", and zero means unlimited.
+// The limits which are not specified are unlimited, so that the limits are replaced as a whole.
+func parseIOLimits(params map[string]string) (*v1.IOLimits, error) {
+	var ioLimits *v1.IOLimits
+	fields := []struct {
+		key   string
+		field func(*v1.IOLimits) *int64
+	}{
+		{topolvm.GetReadIOPSKey(), func(l *v1.IOLimits) *int64 { return &l.ReadIOPS }},
+		{topolvm.GetWriteIOPSKey(), func(l *v1.IOLimits) *int64 { return &l.WriteIOPS }},
+		{topolvm.GetReadBytesPerSecondKey(), func(l *v1.IOLimits) *int64 { return &l.ReadBytesPerSecond }},
+		{topolvm.GetWriteBytesPerSecondKey(), func(l *v1.IOLimits) *int64 { return &l.WriteBytesPerSecond }},
+	}
+	for _, f := range fields {
+		v, ok := params[f.key]
+		if !ok {
+			continue
+		}
+		q, err := resource.ParseQuantity(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of parameter %s: %w", f.key, err)
+		}
+		if q.Sign() < 0 {
+			return nil, fmt.Errorf("negative value of parameter %s: %s", f.key, v)
+		}
+		if ioLimits == nil {
+			ioLimits = &v1.IOLimits{}
+		}
+		*f.field(ioLimits) = q.Value()
+	}
+	return ioLimits, nil
+}
+
+func (s controllerServerNoLocked) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest) (*csi.ListVolumesResponse, error) {
+	ctrlLogger.Info("ListVolumes called",
+		"max_entries", req.GetMaxEntries(),
+		"starting_token", req.GetStartingToken())
+
+	if req.GetMaxEntries() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "max_entries must not be negative")
+	}
+
+	lvs, err := s.lvService.ListVolumes(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	page, nextToken, err := pageOfVolumes(lvs, req.GetStartingToken(), int(req.GetMaxEntries()))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*csi.ListVolumesResponse_Entry, 0, len(page))
+	for i := range page {
+		entries = append(entries, &csi.ListVolumesResponse_Entry{
+			Volume: volumeOfLogicalVolume(&page[i]),
+			Status: &csi.ListVolumesResponse_VolumeStatus{
+				VolumeCondition: volumeConditionOfLogicalVolume(&page[i]),
+			},
+		})
+	}
+	return &csi.ListVolumesResponse{Entries: entries, NextToken: nextToken}, nil
+}
+
+// pageOfVolumes returns the page of the LogicalVolumes sorted by volume ID from the starting token, and the token of the next page.
+// The token is the volume ID of the first entry of the page, so that a page is not shifted by the volumes deleted meanwhile.
+func pageOfVolumes(lvs []v1.LogicalVolume, startingToken string, maxEntries int) ([]v1.LogicalVolume, string, error) {
+	start := 0
+	if startingToken != "" {
+		start = slices.IndexFunc(lvs, func(lv v1.LogicalVolume) bool {
+			return lv.Status.VolumeID >= startingToken
+		})
+		if start < 0 {
+			return nil, "", status.Errorf(codes.Aborted, "invalid starting_token: %s", startingToken)
+		}
+	}
+	end := len(lvs)
+	if maxEntries > 0 {
+		end = min(end, start+maxEntries)
+	}
+
+	var nextToken string
+	if end < len(lvs) {
+		nextToken = lvs[end].Status.VolumeID
+	}
+	return lvs[start:end], nextToken, nil
+}
+
+func (s controllerServerNoLocked) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+	volumeID := req.GetVolumeId()
+	ctrlLogger.Info("ControllerGetVolume called", "volume_id", volumeID)
+
+	if len(volumeID) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "volume_id is not provided")
+	}
+
+	lv, err := s.lvService.GetVolume(ctx, volumeID)
+	if err != nil {
+		if errors.Is(err, k8s.ErrVolumeNotFound) {
+			return nil, status.Errorf(codes.NotFound, "LogicalVolume for volume id %s is not found", volumeID)
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &csi.ControllerGetVolumeResponse{
+		Volume: volumeOfLogicalVolume(lv),
+		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+			VolumeCondition: volumeConditionOfLogicalVolume(lv),
+		},
+	}, nil
+}
+
+// volumeOfLogicalVolume returns the CSI volume provisioned as a LogicalVolume.
+func volumeOfLogicalVolume(lv *v1.LogicalVolume) *csi.Volume {
+	currentSize := lv.Status.CurrentSize
+	if currentSize == nil {
+		currentSize = &lv.Spec.Size
+	}
+	return &csi.Volume{
+		CapacityBytes: currentSize.Value(),
+		VolumeId:      lv.Status.VolumeID,
+		AccessibleTopology: []*csi.Topology{
+			{
+				Segments: map[string]string{topolvm.GetTopologyNodeKey(): lv.Spec.NodeName},
+			},
+		},
+	}
+}
+
+// volumeConditionOfLogicalVolume returns the condition of the volume from the health reported by topolvm-node.
+func volumeConditionOfLogicalVolume(lv *v1.LogicalVolume) *csi.VolumeCondition {
+	if lv.Status.Health == nil {
+		return &csi.VolumeCondition{
+			Abnormal: false,
+			Message:  "volume health has not been checked yet",
+		}
+	}
+	return &csi.VolumeCondition{
+		Abnormal: lv.Status.Health.Abnormal,
+		Message:  lv.Status.Health.Message,
+	}
+}
@@ -3,9 +3,13 @@ package driver
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/topolvm/topolvm"
+	v1 "github.com/topolvm/topolvm/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func Test_convertRequestCapacityBytes(t *testing.T) {
@@ -114,3 +118,182 @@ func Test_roundUp(t *testing.T) {
 		})
 	}
 }
+
+func Test_pageOfVolumes(t *testing.T) {
+	var lvs []v1.LogicalVolume
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		lvs = append(lvs, v1.LogicalVolume{Status: v1.LogicalVolumeStatus{VolumeID: id}})
+	}
+
+	testCases := []struct {
+		token      string
+		maxEntries int
+		expected   []string
+		nextToken  string
+		aborted    bool
+	}{
+		{"", 0, []string{"a", "b", "c", "d", "e"}, "", false},
+		{"", 2, []string{"a", "b"}, "c", false},
+		{"c", 2, []string{"c", "d"}, "e", false},
+		{"e", 2, []string{"e"}, "", false},
+		// the volume of the token has been deleted
+		{"bb", 2, []string{"c", "d"}, "e", false},
+		{"f", 2, nil, "", true},
+	}
+
+	for _, tc := range testCases {
+		name := fmt.Sprintf("token %q with max entries %d", tc.token, tc.maxEntries)
+		t.Run(name, func(t *testing.T) {
+			page, nextToken, err := pageOfVolumes(lvs, tc.token, tc.maxEntries)
+			if tc.aborted {
+				if status.Code(err) != codes.Aborted {
+					t.Fatalf("expected Aborted, but was %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, lv := range page {
+				ids = append(ids, lv.Status.VolumeID)
+			}
+			if !slices.Equal(ids, tc.expected) || nextToken != tc.nextToken {
+				t.Errorf("expected %v and %q, but was %v and %q", tc.expected, tc.nextToken, ids, nextToken)
+			}
+		})
+	}
+}
+
+func Test_filterSnapshots(t *testing.T) {
+	snapshot := func(id, source string) v1.LogicalVolume {
+		return v1.LogicalVolume{
+			Spec:   v1.LogicalVolumeSpec{Source: source, AccessType: "ro"},
+			Status: v1.LogicalVolumeStatus{VolumeID: id},
+		}
+	}
+	snapshots := []v1.LogicalVolume{snapshot("a", "vol1"), snapshot("b", "vol2"), snapshot("c", "vol1"), snapshot("d", "deleted")}
+	sourceVolumeIDs := map[string]string{"vol1": "id1", "vol2": "id2"}
+
+	testCases := []struct {
+		snapshotID     string
+		sourceVolumeID string
+		expected       []string
+	}{
+		{"", "", []string{"a", "b", "c", "d"}},
+		{"b", "", []string{"b"}},
+		{"x", "", nil},
+		{"", "id1", []string{"a", "c"}},
+		{"", "id3", nil},
+		{"a", "id1", []string{"a"}},
+		{"a", "id2", nil},
+	}
+
+	for _, tc := range testCases {
+		name := fmt.Sprintf("snapshot %q of source %q", tc.snapshotID, tc.sourceVolumeID)
+		t.Run(name, func(t *testing.T) {
+			var ids []string
+			for _, lv := range filterSnapshots(snapshots, sourceVolumeIDs, tc.snapshotID, tc.sourceVolumeID) {
+				ids = append(ids, lv.Status.VolumeID)
+			}
+			if !slices.Equal(ids, tc.expected) {
+				t.Errorf("expected %v, but was %v", tc.expected, ids)
+			}
+		})
+	}
+}
+
+func Test_snapshotOfLogicalVolume(t *testing.T) {
+	lv := &v1.LogicalVolume{
+		Spec:   v1.LogicalVolumeSpec{Source: "vol1", AccessType: "ro"},
+		Status: v1.LogicalVolumeStatus{VolumeID: "a"},
+	}
+	if !snapshotOfLogicalVolume(lv, "id1").GetReadyToUse() {
+		t.Error("snapshot with volume ID should be ready to use")
+	}
+
+	lv.Status.Code = codes.Internal
+	if snapshotOfLogicalVolume(lv, "id1").GetReadyToUse() {
+		t.Error("snapshot with error code should not be ready to use")
+	}
+}
+
+func Test_parseMutableParameters(t *testing.T) {
+	testCases := []struct {
+		name                string
+		params              map[string]string
+		lvcreateOptionClass string
+		cacheMode           string
+		err                 bool
+	}{
+		{"no parameters", nil, "", "", false},
+		{"lvcreate-option-class", map[string]string{topolvm.GetLvcreateOptionClassKey(): "raid1"}, "raid1", "", false},
+		{"cache mode", map[string]string{topolvm.GetCacheModeKey(): "writeback"}, "", "writeback", false},
+		{"both", map[string]string{topolvm.GetLvcreateOptionClassKey(): "raid1", topolvm.GetCacheModeKey(): "writethrough"}, "raid1", "writethrough", false},
+		{"empty lvcreate-option-class", map[string]string{topolvm.GetLvcreateOptionClassKey(): ""}, "", "", true},
+		{"invalid cache mode", map[string]string{topolvm.GetCacheModeKey(): "writecache"}, "", "", true},
+		{"IO limits", map[string]string{topolvm.GetReadIOPSKey(): "1000"}, "", "", false},
+		{"unknown parameter", map[string]string{topolvm.GetDeviceClassKey(): "ssd"}, "", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lvcreateOptionClass, cacheMode, _, err := parseMutableParameters(tc.params)
+			if tc.err {
+				if err == nil {
+					t.Error("error should be returned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if lvcreateOptionClass != tc.lvcreateOptionClass || cacheMode != tc.cacheMode {
+				t.Errorf("expected (%q, %q), but was (%q, %q)", tc.lvcreateOptionClass, tc.cacheMode, lvcreateOptionClass, cacheMode)
+			}
+		})
+	}
+}
+
+func Test_parseIOLimits(t *testing.T) {
+	testCases := []struct {
+		name     string
+		params   map[string]string
+		expected *v1.IOLimits
+		err      bool
+	}{
+		{"no limits", map[string]string{topolvm.GetDeviceClassKey(): "ssd"}, nil, false},
+		{
+			"all limits",
+			map[string]string{
+				topolvm.GetReadIOPSKey():            "1000",
+				topolvm.GetWriteIOPSKey():           "2k",
+				topolvm.GetReadBytesPerSecondKey():  "100Mi",
+				topolvm.GetWriteBytesPerSecondKey(): "0",
+				topolvm.GetLvcreateOptionClassKey(): "raid1",
+			},
+			&v1.IOLimits{ReadIOPS: 1000, WriteIOPS: 2000, ReadBytesPerSecond: 100 << 20},
+			false,
+		},
+		{"invalid value", map[string]string{topolvm.GetReadIOPSKey(): "fast"}, nil, true},
+		{"negative value", map[string]string{topolvm.GetWriteBytesPerSecondKey(): "-1"}, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ioLimits, err := parseIOLimits(tc.params)
+			if tc.err {
+				if err == nil {
+					t.Error("error should be returned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (ioLimits == nil) != (tc.expected == nil) || (ioLimits != nil && *ioLimits != *tc.expected) {
+				t.Errorf("expected %v, but was %v", tc.expected, ioLimits)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	v1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/driver/internal/k8s"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *controllerServer) GroupControllerGetCapabilities(ctx context.Context, req *csi.GroupControllerGetCapabilitiesRequest) (*csi.GroupControllerGetCapabilitiesResponse, error) {
+	// This returns constants only, it is unnecessary to take lock.
+	return s.server.GroupControllerGetCapabilities(ctx, req)
+}
+
+func (s *controllerServer) CreateVolumeGroupSnapshot(ctx context.Context, req *csi.CreateVolumeGroupSnapshotRequest) (*csi.CreateVolumeGroupSnapshotResponse, error) {
+	s.lockByName.LockByID(req.GetName())
+	defer s.lockByName.UnlockByID(req.GetName())
+
+	return s.server.CreateVolumeGroupSnapshot(ctx, req)
+}
+
+func (s *controllerServer) DeleteVolumeGroupSnapshot(ctx context.Context, req *csi.DeleteVolumeGroupSnapshotRequest) (*csi.DeleteVolumeGroupSnapshotResponse, error) {
+	s.lockByVolumeID.LockByID(req.GetGroupSnapshotId())
+	defer s.lockByVolumeID.UnlockByID(req.GetGroupSnapshotId())
+
+	return s.server.DeleteVolumeGroupSnapshot(ctx, req)
+}
+
+func (s *controllerServer) GetVolumeGroupSnapshot(ctx context.Context, req *csi.GetVolumeGroupSnapshotRequest) (*csi.GetVolumeGroupSnapshotResponse, error) {
+	// This reads kube-apiserver only, so it is unnecessary to take lock.
+	return s.server.GetVolumeGroupSnapshot(ctx, req)
+}
+
+func (s controllerServerNoLocked) GroupControllerGetCapabilities(context.Context, *csi.GroupControllerGetCapabilitiesRequest) (*csi.GroupControllerGetCapabilitiesResponse, error) {
+	return &csi.GroupControllerGetCapabilitiesResponse{
+		Capabilities: []*csi.GroupControllerServiceCapability{
+			{
+				Type: &csi.GroupControllerServiceCapability_Rpc{
+					Rpc: &csi.GroupControllerServiceCapability_RPC{
+						Type: csi.GroupControllerServiceCapability_RPC_CREATE_DELETE_GET_VOLUME_GROUP_SNAPSHOT,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// CreateVolumeGroupSnapshot creates the snapshots of the volumes on a node at once.
+// topolvm-node freezes the filesystems of the volumes while taking the snapshots,
+// so that the snapshots are consistent with each other.
+func (s controllerServerNoLocked) CreateVolumeGroupSnapshot(ctx context.Context, req *csi.CreateVolumeGroupSnapshotRequest) (*csi.CreateVolumeGroupSnapshotResponse, error) {
+	// Since the kubernetes snapshots are Read-Only, we set accessType as 'ro' to activate thin-snapshots as read-only volumes
+	accessType := "ro"
+
+	ctrlLogger.Info("CreateVolumeGroupSnapshot called",
+		"name", req.GetName(),
+		"source_volume_ids", req.GetSourceVolumeIds(),
+		"parameters", req.GetParameters(),
+		"num_secrets", len(req.GetSecrets()))
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing name")
+	}
+	if len(req.GetSourceVolumeIds()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "missing source volume ids")
+	}
+
+	groupID := strings.ToLower(req.GetName())
+	sourceVolIDs := slices.Clone(req.GetSourceVolumeIds())
+	slices.Sort(sourceVolIDs)
+	sourceVolIDs = slices.Compact(sourceVolIDs)
+
+	sources := make([]*v1.LogicalVolume, 0, len(sourceVolIDs))
+	sourceVolumeIDs := make(map[string]string, len(sourceVolIDs))
+	for _, sourceVolID := range sourceVolIDs {
+		sourceVol, err := s.lvService.GetVolume(ctx, sourceVolID)
+		if err != nil {
+			if errors.Is(err, k8s.ErrVolumeNotFound) {
+				return nil, status.Errorf(codes.NotFound, "failed to find source volume %s", sourceVolID)
+			}
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		// the snapshots are taken at once only on a node.
+		if len(sources) > 0 && sourceVol.Spec.NodeName != sources[0].Spec.NodeName {
+			return nil, status.Errorf(codes.InvalidArgument, "source volumes must be on the same node: %s is on %s, %s is on %s",
+				sources[0].Status.VolumeID, sources[0].Spec.NodeName, sourceVolID, sourceVol.Spec.NodeName)
+		}
+		if sourceVol.Status.CurrentSize == nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "source volume %s is not provisioned yet", sourceVolID)
+		}
+		sources = append(sources, sourceVol)
+		sourceVolumeIDs[sourceVol.Spec.Name] = sourceVolID
+	}
+
+	snapshots, err := s.lvService.CreateGroupSnapshot(ctx, sources[0].Spec.NodeName, groupID, accessType, sources)
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return nil, err
+	}
+
+	lvs := make([]v1.LogicalVolume, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		lvs = append(lvs, *snapshot)
+	}
+	return &csi.CreateVolumeGroupSnapshotResponse{
+		GroupSnapshot: volumeGroupSnapshotOf(groupID, lvs, sourceVolumeIDs),
+	}, nil
+}
+
+// DeleteVolumeGroupSnapshot deletes the snapshots of a group snapshot.
+func (s controllerServerNoLocked) DeleteVolumeGroupSnapshot(ctx context.Context, req *csi.DeleteVolumeGroupSnapshotRequest) (*csi.DeleteVolumeGroupSnapshotResponse, error) {
+	ctrlLogger.Info("DeleteVolumeGroupSnapshot called",
+		"group_snapshot_id", req.GetGroupSnapshotId(),
+		"snapshot_ids", req.GetSnapshotIds(),
+		"num_secrets", len(req.GetSecrets()))
+
+	groupID := req.GetGroupSnapshotId()
+	if groupID == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing group snapshot id")
+	}
+
+	snapshots, err := s.lvService.ListGroupSnapshot(ctx, groupID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	for _, snapshotID := range req.GetSnapshotIds() {
+		if slices.ContainsFunc(snapshots, func(lv v1.LogicalVolume) bool { return lv.Status.VolumeID == snapshotID }) {
+			continue
+		}
+		// the snapshot may have been deleted already
+		_, err := s.lvService.GetVolume(ctx, snapshotID)
+		if errors.Is(err, k8s.ErrVolumeNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "snapshot %s is not a member of group snapshot %s", snapshotID, groupID)
+	}
+
+	for _, snapshot := range snapshots {
+		if err := s.lvService.DeleteVolume(ctx, snapshot.Status.VolumeID); err != nil {
+			ctrlLogger.Error(err, "DeleteVolumeGroupSnapshot failed", "group_snapshot_id", groupID, "snapshot_id", snapshot.Status.VolumeID)
+			_, ok := status.FromError(err)
+			if !ok {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			return nil, err
+		}
+	}
+	return &csi.DeleteVolumeGroupSnapshotResponse{}, nil
+}
+
+// GetVolumeGroupSnapshot returns the snapshots of a group snapshot.
+func (s controllerServerNoLocked) GetVolumeGroupSnapshot(ctx context.Context, req *csi.GetVolumeGroupSnapshotRequest) (*csi.GetVolumeGroupSnapshotResponse, error) {
+	ctrlLogger.Info("GetVolumeGroupSnapshot called",
+		"group_snapshot_id", req.GetGroupSnapshotId(),
+		"snapshot_ids", req.GetSnapshotIds())
+
+	groupID := req.GetGroupSnapshotId()
+	if groupID == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing group snapshot id")
+	}
+
+	snapshots, err := s.lvService.ListGroupSnapshot(ctx, groupID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if len(snapshots) == 0 {
+		return nil, status.Errorf(codes.NotFound, "group snapshot %s is not found", groupID)
+	}
+	if ids := req.GetSnapshotIds(); len(ids) > 0 {
+		members := make([]string, 0, len(snapshots))
+		for _, lv := range snapshots {
+			members = append(members, lv.Status.VolumeID)
+		}
+		ids = slices.Clone(ids)
+		slices.Sort(ids)
+		if !slices.Equal(slices.Compact(ids), members) {
+			return nil, status.Errorf(codes.InvalidArgument, "snapshot ids do not match the members of group snapshot %s", groupID)
+		}
+	}
+
+	sourceVolumeIDs, err := s.sourceVolumeIDs(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &csi.GetVolumeGroupSnapshotResponse{
+		GroupSnapshot: volumeGroupSnapshotOf(groupID, snapshots, sourceVolumeIDs),
+	}, nil
+}
+
+// volumeGroupSnapshotOf returns the CSI group snapshot of the snapshot LogicalVolumes.
+// sourceVolumeIDs maps the names of the source LogicalVolumes to their volume IDs.
+func volumeGroupSnapshotOf(groupID string, snapshots []v1.LogicalVolume, sourceVolumeIDs map[string]string) *csi.VolumeGroupSnapshot {
+	groupSnapshot := &csi.VolumeGroupSnapshot{
+		GroupSnapshotId: groupID,
+		ReadyToUse:      true,
+	}
+	for i := range snapshots {
+		snapshot := snapshotOfLogicalVolume(&snapshots[i], sourceVolumeIDs[snapshots[i].Spec.Source])
+		groupSnapshot.Snapshots = append(groupSnapshot.Snapshots, snapshot)
+		groupSnapshot.ReadyToUse = groupSnapshot.ReadyToUse && snapshot.ReadyToUse
+		// the snapshots are taken after all of them are created
+		if groupSnapshot.CreationTime == nil || groupSnapshot.CreationTime.Seconds < snapshot.CreationTime.Seconds {
+			groupSnapshot.CreationTime = snapshot.CreationTime
+		}
+	}
+	return groupSnapshot
+}
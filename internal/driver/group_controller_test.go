@@ -0,0 +1,52 @@
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/topolvm/topolvm"
+	v1 "github.com/topolvm/topolvm/api/v1"
+	"google.golang.org/grpc/codes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_volumeGroupSnapshotOf(t *testing.T) {
+	now := time.Now()
+	snapshot := func(id, source string, created time.Time) v1.LogicalVolume {
+		return v1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:            map[string]string{topolvm.GetGroupSnapshotKey(): "group1"},
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec:   v1.LogicalVolumeSpec{Source: source, AccessType: "ro"},
+			Status: v1.LogicalVolumeStatus{VolumeID: id},
+		}
+	}
+	snapshots := []v1.LogicalVolume{
+		snapshot("a", "vol1", now.Add(-time.Second)),
+		snapshot("b", "vol2", now),
+	}
+	sourceVolumeIDs := map[string]string{"vol1": "id1", "vol2": "id2"}
+
+	groupSnapshot := volumeGroupSnapshotOf("group1", snapshots, sourceVolumeIDs)
+	if groupSnapshot.GetGroupSnapshotId() != "group1" || len(groupSnapshot.GetSnapshots()) != 2 {
+		t.Fatalf("unexpected group snapshot: %v", groupSnapshot)
+	}
+	for i, expected := range []string{"id1", "id2"} {
+		s := groupSnapshot.GetSnapshots()[i]
+		if s.GetSourceVolumeId() != expected || s.GetGroupSnapshotId() != "group1" {
+			t.Errorf("unexpected snapshot: %v", s)
+		}
+	}
+	if groupSnapshot.GetCreationTime().GetSeconds() != now.Unix() {
+		t.Errorf("creation time should be of the latest snapshot: %v", groupSnapshot.GetCreationTime())
+	}
+	if !groupSnapshot.GetReadyToUse() {
+		t.Error("group snapshot should be ready to use")
+	}
+
+	snapshots[1].Status.Code = codes.Internal
+	if volumeGroupSnapshotOf("group1", snapshots, sourceVolumeIDs).GetReadyToUse() {
+		t.Error("group snapshot with a failed snapshot should not be ready to use")
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/topolvm/topolvm"
@@ -14,11 +17,13 @@ import (
 	"github.com/topolvm/topolvm/internal/getter"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -35,6 +40,7 @@ type LogicalVolumeService struct {
 		client.StatusClient
 	}
 	getter       getter.Interface
+	apiReader    client.Reader
 	volumeGetter *volumeGetter
 }
 
@@ -122,8 +128,50 @@ func (v *volumeGetter) Get(ctx context.Context, volumeID string) (*topolvmv1.Log
 	return foundLv, nil
 }
 
+// List returns LogicalVolumes which have volume IDs and are not snapshots, sorted by volume ID.
+func (v *volumeGetter) List(ctx context.Context) ([]topolvmv1.LogicalVolume, error) {
+	return v.list(ctx, func(lv *topolvmv1.LogicalVolume) bool {
+		return lv.Spec.AccessType != "ro"
+	})
+}
+
+// ListSnapshots returns LogicalVolumes which have volume IDs and are snapshots of other volumes, sorted by volume ID.
+func (v *volumeGetter) ListSnapshots(ctx context.Context) ([]topolvmv1.LogicalVolume, error) {
+	return v.list(ctx, func(lv *topolvmv1.LogicalVolume) bool {
+		return lv.Spec.AccessType == "ro" && lv.Spec.Source != ""
+	})
+}
+
+// ListGroupSnapshot returns LogicalVolumes which have volume IDs and belong to the group snapshot, sorted by volume ID.
+func (v *volumeGetter) ListGroupSnapshot(ctx context.Context, groupID string) ([]topolvmv1.LogicalVolume, error) {
+	return v.list(ctx, func(lv *topolvmv1.LogicalVolume) bool {
+		return lv.Spec.AccessType == "ro" && lv.Spec.Source != ""
+	}, client.MatchingLabels{topolvm.GetGroupSnapshotKey(): groupID})
+}
+
+func (v *volumeGetter) list(ctx context.Context, filter func(*topolvmv1.LogicalVolume) bool, opts ...client.ListOption) ([]topolvmv1.LogicalVolume, error) {
+	lvList := new(topolvmv1.LogicalVolumeList)
+	if err := v.cacheReader.List(ctx, lvList, opts...); err != nil {
+		return nil, err
+	}
+
+	lvs := make([]topolvmv1.LogicalVolume, 0, len(lvList.Items))
+	for _, lv := range lvList.Items {
+		if lv.Status.VolumeID == "" || !filter(&lv) {
+			continue
+		}
+		lvs = append(lvs, lv)
+	}
+	slices.SortFunc(lvs, func(a, b topolvmv1.LogicalVolume) int {
+		return strings.Compare(a.Status.VolumeID, b.Status.VolumeID)
+	})
+	return lvs, nil
+}
+
 //+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;create;delete
 //+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumebackups,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get
 
 // NewLogicalVolumeService returns LogicalVolumeService.
 func NewLogicalVolumeService(mgr manager.Manager) (*LogicalVolumeService, error) {
@@ -149,13 +197,15 @@ func NewLogicalVolumeService(mgr manager.Manager) (*LogicalVolumeService, error)
 	return &LogicalVolumeService{
 		writer:       client,
 		getter:       newRetryMissingGetter(client, apiReader),
+		apiReader:    apiReader,
 		volumeGetter: &volumeGetter{cacheReader: client, apiReader: apiReader},
 	}, nil
 }
 
 // CreateVolume creates volume
-func (s *LogicalVolumeService) CreateVolume(ctx context.Context, node, dc, oc, name, sourceName string, requestBytes int64) (*topolvmv1.LogicalVolume, error) {
-	logger.Info("k8s.CreateVolume called", "name", name, "node", node, "size", requestBytes, "sourceName", sourceName)
+func (s *LogicalVolumeService) CreateVolume(ctx context.Context, node, dc, oc, cacheMode string, ioLimits *topolvmv1.IOLimits,
+	name, sourceName, sourceKind string, requestBytes int64) (*topolvmv1.LogicalVolume, error) {
+	logger.Info("k8s.CreateVolume called", "name", name, "node", node, "size", requestBytes, "sourceName", sourceName, "sourceKind", sourceKind)
 	var lv *topolvmv1.LogicalVolume
 	// if the create volume request has no source, proceed with regular lv creation.
 	if sourceName == "" {
@@ -168,6 +218,8 @@ func (s *LogicalVolumeService) CreateVolume(ctx context.Context, node, dc, oc, n
 				NodeName:            node,
 				DeviceClass:         dc,
 				LvcreateOptionClass: oc,
+				CacheMode:           cacheMode,
+				IOLimits:            ioLimits,
 				Size:                *resource.NewQuantity(requestBytes, resource.BinarySI),
 			},
 		}
@@ -183,8 +235,11 @@ func (s *LogicalVolumeService) CreateVolume(ctx context.Context, node, dc, oc, n
 				NodeName:            node,
 				DeviceClass:         dc,
 				LvcreateOptionClass: oc,
+				CacheMode:           cacheMode,
+				IOLimits:            ioLimits,
 				Size:                *resource.NewQuantity(requestBytes, resource.BinarySI),
 				Source:              sourceName,
+				SourceKind:          sourceKind,
 				AccessType:          "rw",
 			},
 		}
@@ -193,6 +248,59 @@ func (s *LogicalVolumeService) CreateVolume(ctx context.Context, node, dc, oc, n
 	return s.createAndWait(ctx, lv)
 }
 
+// GetEncryptionOfSource returns the encryption of the PersistentVolume which the data of the LogicalVolume comes from.
+// A snapshot or a clone is traced back to its source until a PersistentVolume of TopoLVM is found.
+// It returns false if no PersistentVolume is found, e.g. when it has been deleted.
+func (s *LogicalVolumeService) GetEncryptionOfSource(ctx context.Context, name string) (string, bool, error) {
+	for name != "" {
+		lv := new(topolvmv1.LogicalVolume)
+		if err := s.getter.Get(ctx, client.ObjectKey{Name: name}, lv); err != nil {
+			if apierrors.IsNotFound(err) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+
+		// The PersistentVolume is named after the volume by the external-provisioner.
+		pv := new(corev1.PersistentVolume)
+		err := s.apiReader.Get(ctx, client.ObjectKey{Name: lv.Spec.Name}, pv)
+		if err == nil && pv.Spec.CSI != nil && pv.Spec.CSI.Driver == topolvm.GetPluginName() && pv.Spec.CSI.VolumeHandle == lv.Status.VolumeID {
+			return pv.Spec.CSI.VolumeAttributes[topolvm.GetEncryptionKey()], true, nil
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			return "", false, err
+		}
+
+		if lv.Spec.SourceKind == topolvmv1.SourceKindLogicalVolumeBackup {
+			return "", false, nil
+		}
+		name = lv.Spec.Source
+	}
+	return "", false, nil
+}
+
+// GetBackupOfClaim returns the LogicalVolumeBackup specified by the annotation of the PVC.
+// It returns nil if the PVC does not have the annotation.
+func (s *LogicalVolumeService) GetBackupOfClaim(ctx context.Context, namespace, name string) (*topolvmv1.LogicalVolumeBackup, error) {
+	pvc := new(corev1.PersistentVolumeClaim)
+	if err := s.getter.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, pvc); err != nil {
+		return nil, err
+	}
+	backupName, ok := pvc.Annotations[topolvm.GetBackupKey()]
+	if !ok {
+		return nil, nil
+	}
+
+	lvb := new(topolvmv1.LogicalVolumeBackup)
+	if err := s.getter.Get(ctx, client.ObjectKey{Name: backupName}, lvb); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, status.Errorf(codes.NotFound, "backup %s is not found", backupName)
+		}
+		return nil, err
+	}
+	return lvb, nil
+}
+
 // DeleteVolume deletes volume
 func (s *LogicalVolumeService) DeleteVolume(ctx context.Context, volumeID string) error {
 	logger.Info("k8s.DeleteVolume called", "volumeID", volumeID)
@@ -254,6 +362,51 @@ func (s *LogicalVolumeService) CreateSnapshot(ctx context.Context, node, dc, sou
 	return s.createAndWait(ctx, snapshotLV)
 }
 
+// CreateGroupSnapshot creates the snapshots of the source volumes on a node as a group snapshot.
+// topolvm-node takes all the snapshots at once after all the LogicalVolumes of the group are created.
+// If any of them fails, all the LogicalVolumes of the group are deleted.
+func (s *LogicalVolumeService) CreateGroupSnapshot(ctx context.Context, node, groupID, accessType string, sources []*topolvmv1.LogicalVolume) ([]*topolvmv1.LogicalVolume, error) {
+	logger.Info("CreateGroupSnapshot called", "group", groupID, "sources", len(sources))
+	snapshotLVs := make([]*topolvmv1.LogicalVolume, 0, len(sources))
+	for i, source := range sources {
+		name := fmt.Sprintf("%s-%d", groupID, i)
+		snapshotLV := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      map[string]string{topolvm.GetGroupSnapshotKey(): groupID},
+				Annotations: map[string]string{topolvm.GetGroupSnapshotSizeKey(): strconv.Itoa(len(sources))},
+			},
+			Spec: topolvmv1.LogicalVolumeSpec{
+				Name:        name,
+				NodeName:    node,
+				DeviceClass: source.Spec.DeviceClass,
+				Size:        *source.Status.CurrentSize,
+				Source:      source.Spec.Name,
+				AccessType:  accessType,
+			},
+		}
+		if err := s.create(ctx, snapshotLV); err != nil {
+			return nil, err
+		}
+		snapshotLVs = append(snapshotLVs, snapshotLV)
+	}
+
+	provisioned := make([]*topolvmv1.LogicalVolume, 0, len(snapshotLVs))
+	for _, snapshotLV := range snapshotLVs {
+		lv, err := s.waitForVolumeProvisioning(ctx, snapshotLV.Name)
+		if err != nil {
+			for _, other := range snapshotLVs {
+				if err := s.writer.Delete(ctx, other); err != nil && !apierrors.IsNotFound(err) {
+					logger.Error(err, "failed to delete LogicalVolume of failed group snapshot", "name", other.Name)
+				}
+			}
+			return nil, err
+		}
+		provisioned = append(provisioned, lv)
+	}
+	return provisioned, nil
+}
+
 // ExpandVolume expands volume
 func (s *LogicalVolumeService) ExpandVolume(ctx context.Context, volumeID string, requestBytes int64) (*topolvmv1.LogicalVolume, error) {
 	logger := logger.WithValues("volume_id", volumeID, "size", requestBytes)
@@ -264,6 +417,7 @@ func (s *LogicalVolumeService) ExpandVolume(ctx context.Context, volumeID string
 	if err != nil {
 		return nil, err
 	}
+	origSize := lv.Spec.Size
 
 	err = s.updateSpecSize(ctx, volumeID, request)
 	if err != nil {
@@ -283,6 +437,14 @@ func (s *LogicalVolumeService) ExpandVolume(ctx context.Context, volumeID string
 			return false, err
 		}
 
+		if changedLV.Status.Code == codes.FailedPrecondition {
+			// topolvm-node cannot expand the LV in its current state, e.g. the origin of thick snapshots in use.
+			// Revert the request so that the LV is left healthy and the expansion can be requested again later.
+			if err := s.revertSpecSize(ctx, lv.Name, request, &origSize); err != nil {
+				return false, err
+			}
+			return false, status.Errorf(codes.FailedPrecondition, "volume %s cannot be expanded now: %s", volumeID, changedLV.Status.Message)
+		}
 		if changedLV.Status.Code != codes.OK {
 			return false, status.Error(changedLV.Status.Code, changedLV.Status.Message)
 		}
@@ -310,11 +472,85 @@ func (s *LogicalVolumeService) ExpandVolume(ctx context.Context, volumeID string
 	})
 }
 
+// ModifyVolume changes the lvcreate-option-class, the cache mode and the IO limits of the volume,
+// and waits until the lvcreate-option-class and the cache mode are applied. Empty arguments are left unchanged.
+// The IO limits are applied by topolvm-node asynchronously.
+func (s *LogicalVolumeService) ModifyVolume(ctx context.Context, volumeID, lvcreateOptionClass, cacheMode string, ioLimits *topolvmv1.IOLimits) (*topolvmv1.LogicalVolume, error) {
+	logger := logger.WithValues("volume_id", volumeID, "lvcreate_option_class", lvcreateOptionClass, "cache_mode", cacheMode)
+	logger.Info("k8s.ModifyVolume called")
+
+	lv, err := s.GetVolume(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+	origClass, origCacheMode := lv.Spec.LvcreateOptionClass, lv.Spec.CacheMode
+
+	err = s.updateSpecModification(ctx, volumeID, lvcreateOptionClass, cacheMode, ioLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	var changedLV topolvmv1.LogicalVolume
+	return &changedLV, wait.Backoff{
+		Duration: 1 * time.Second, // initial backoff
+		Factor:   2,               // factor for duration increase
+		Jitter:   0.1,
+		Steps:    math.MaxInt, // run for infinity; we assume context gets canceled
+		Cap:      10 * time.Second,
+	}.DelayFunc().Until(ctx, true, false, func(ctx context.Context) (bool, error) {
+		if err := s.getter.Get(ctx, client.ObjectKey{Name: lv.Name}, &changedLV); err != nil {
+			logger.Error(err, "failed to get LogicalVolume", "name", lv.Name)
+			return false, err
+		}
+
+		if changedLV.Status.Code == codes.InvalidArgument || changedLV.Status.Code == codes.FailedPrecondition {
+			// topolvm-node cannot apply the modification, e.g. the lvcreate-option-class does not exist.
+			// Revert the request so that topolvm-node stops retrying it and the LV is left healthy.
+			if err := s.revertSpecModification(ctx, lv.Name, lvcreateOptionClass, cacheMode, origClass, origCacheMode, changedLV.Status.Code); err != nil {
+				return false, err
+			}
+			return false, status.Errorf(changedLV.Status.Code, "volume %s cannot be modified: %s", volumeID, changedLV.Status.Message)
+		}
+		if changedLV.Status.Code != codes.OK {
+			return false, status.Error(changedLV.Status.Code, changedLV.Status.Message)
+		}
+
+		current := changedLV.Status
+		if ptr.Deref(current.CurrentLvcreateOptionClass, "") != changedLV.Spec.LvcreateOptionClass ||
+			ptr.Deref(current.CurrentCacheMode, "") != changedLV.Spec.CacheMode {
+			logger.Info("waiting for update of 'status.currentLvcreateOptionClass' and 'status.currentCacheMode'",
+				"name", lv.Name,
+				"status.currentLvcreateOptionClass", current.CurrentLvcreateOptionClass,
+				"status.currentCacheMode", current.CurrentCacheMode,
+			)
+			return false, nil
+		}
+
+		logger.Info("LogicalVolume successfully modified")
+		return true, nil
+	})
+}
+
 // GetVolume returns LogicalVolume by volume ID.
 func (s *LogicalVolumeService) GetVolume(ctx context.Context, volumeID string) (*topolvmv1.LogicalVolume, error) {
 	return s.volumeGetter.Get(ctx, volumeID)
 }
 
+// ListVolumes returns LogicalVolumes which are provisioned as volumes, excluding snapshots, sorted by volume ID.
+func (s *LogicalVolumeService) ListVolumes(ctx context.Context) ([]topolvmv1.LogicalVolume, error) {
+	return s.volumeGetter.List(ctx)
+}
+
+// ListSnapshots returns LogicalVolumes which are provisioned as snapshots, sorted by volume ID.
+func (s *LogicalVolumeService) ListSnapshots(ctx context.Context) ([]topolvmv1.LogicalVolume, error) {
+	return s.volumeGetter.ListSnapshots(ctx)
+}
+
+// ListGroupSnapshot returns LogicalVolumes which are provisioned as the snapshots of the group snapshot, sorted by volume ID.
+func (s *LogicalVolumeService) ListGroupSnapshot(ctx context.Context, groupID string) ([]topolvmv1.LogicalVolume, error) {
+	return s.volumeGetter.ListGroupSnapshot(ctx, groupID)
+}
+
 // updateSpecSize updates .Spec.Size of LogicalVolume.
 func (s *LogicalVolumeService) updateSpecSize(ctx context.Context, volumeID string, size *resource.Quantity) error {
 	return wait.ExponentialBackoffWithContext(ctx,
@@ -343,6 +579,142 @@ func (s *LogicalVolumeService) updateSpecSize(ctx context.Context, volumeID stri
 		})
 }
 
+// revertSpecSize restores .Spec.Size of LogicalVolume to the size before the rejected expansion request,
+// and then clears the error which topolvm-node recorded for the request.
+func (s *LogicalVolumeService) revertSpecSize(ctx context.Context, name string, request, orig *resource.Quantity) error {
+	return wait.ExponentialBackoffWithContext(ctx,
+		retry.DefaultBackoff,
+		func(ctx context.Context) (bool, error) {
+			lv := new(topolvmv1.LogicalVolume)
+			if err := s.getter.Get(ctx, client.ObjectKey{Name: name}, lv); err != nil {
+				return false, err
+			}
+			if lv.Spec.Size.Cmp(*request) == 0 {
+				lv.Spec.Size = *orig
+				if err := s.writer.Update(ctx, lv); err != nil {
+					if apierrors.IsConflict(err) {
+						logger.Info("detected conflict when trying to revert LogicalVolume spec", "name", lv.Name)
+						return false, nil
+					}
+					logger.Error(err, "failed to revert LogicalVolume spec", "name", lv.Name)
+					return false, err
+				}
+			}
+			if lv.Status.Code != codes.FailedPrecondition {
+				return true, nil
+			}
+			lv.Status.Code = codes.OK
+			lv.Status.Message = ""
+			if err := s.writer.Status().Update(ctx, lv); err != nil {
+				if apierrors.IsConflict(err) {
+					logger.Info("detected conflict when trying to update LogicalVolume status", "name", lv.Name)
+					return false, nil
+				}
+				logger.Error(err, "failed to update LogicalVolume status", "name", lv.Name)
+				return false, err
+			}
+			return true, nil
+		})
+}
+
+// revertSpecModification restores .Spec.LvcreateOptionClass and .Spec.CacheMode of LogicalVolume to the values
+// before the rejected modification request, and then clears the error which topolvm-node recorded for the request.
+// Empty requested values were not changed by the request, so they are not restored.
+func (s *LogicalVolumeService) revertSpecModification(ctx context.Context, name, lvcreateOptionClass, cacheMode, origClass, origCacheMode string, code codes.Code) error {
+	return wait.ExponentialBackoffWithContext(ctx,
+		retry.DefaultBackoff,
+		func(ctx context.Context) (bool, error) {
+			lv := new(topolvmv1.LogicalVolume)
+			if err := s.getter.Get(ctx, client.ObjectKey{Name: name}, lv); err != nil {
+				return false, err
+			}
+			reverted := false
+			if lvcreateOptionClass != "" && lv.Spec.LvcreateOptionClass == lvcreateOptionClass {
+				lv.Spec.LvcreateOptionClass = origClass
+				reverted = true
+			}
+			if cacheMode != "" && lv.Spec.CacheMode == cacheMode {
+				lv.Spec.CacheMode = origCacheMode
+				reverted = true
+			}
+			if reverted {
+				if err := s.writer.Update(ctx, lv); err != nil {
+					if apierrors.IsConflict(err) {
+						logger.Info("detected conflict when trying to revert LogicalVolume spec", "name", lv.Name)
+						return false, nil
+					}
+					logger.Error(err, "failed to revert LogicalVolume spec", "name", lv.Name)
+					return false, err
+				}
+			}
+			if lv.Status.Code != code {
+				return true, nil
+			}
+			lv.Status.Code = codes.OK
+			lv.Status.Message = ""
+			if err := s.writer.Status().Update(ctx, lv); err != nil {
+				if apierrors.IsConflict(err) {
+					logger.Info("detected conflict when trying to update LogicalVolume status", "name", lv.Name)
+					return false, nil
+				}
+				logger.Error(err, "failed to update LogicalVolume status", "name", lv.Name)
+				return false, err
+			}
+			return true, nil
+		})
+}
+
+// updateSpecModification updates .Spec.LvcreateOptionClass, .Spec.CacheMode and .Spec.IOLimits of LogicalVolume.
+// The status fields of them are populated with the current spec if missing,
+// so that topolvm-node can tell whether the LV has to be modified or not.
+func (s *LogicalVolumeService) updateSpecModification(ctx context.Context, volumeID, lvcreateOptionClass, cacheMode string, ioLimits *topolvmv1.IOLimits) error {
+	return wait.ExponentialBackoffWithContext(ctx,
+		retry.DefaultBackoff,
+		func(ctx context.Context) (bool, error) {
+			lv, err := s.GetVolume(ctx, volumeID)
+			if err != nil {
+				return false, err
+			}
+
+			if lv.Status.CurrentLvcreateOptionClass == nil || lv.Status.CurrentCacheMode == nil {
+				if lv.Status.CurrentLvcreateOptionClass == nil {
+					lv.Status.CurrentLvcreateOptionClass = ptr.To(lv.Spec.LvcreateOptionClass)
+				}
+				if lv.Status.CurrentCacheMode == nil {
+					lv.Status.CurrentCacheMode = ptr.To(lv.Spec.CacheMode)
+				}
+				if err := s.writer.Status().Update(ctx, lv); err != nil {
+					if apierrors.IsConflict(err) {
+						logger.Info("detected conflict when trying to update LogicalVolume status", "name", lv.Name)
+						return false, nil
+					}
+					logger.Error(err, "failed to update LogicalVolume status", "name", lv.Name)
+					return false, err
+				}
+			}
+
+			if lvcreateOptionClass != "" {
+				lv.Spec.LvcreateOptionClass = lvcreateOptionClass
+			}
+			if cacheMode != "" {
+				lv.Spec.CacheMode = cacheMode
+			}
+			if ioLimits != nil {
+				lv.Spec.IOLimits = ioLimits
+			}
+			if err := s.writer.Update(ctx, lv); err != nil {
+				if apierrors.IsConflict(err) {
+					logger.Info("detected conflict when trying to update LogicalVolume spec", "name", lv.Name)
+					return false, nil
+				} else {
+					logger.Error(err, "failed to update LogicalVolume spec", "name", lv.Name)
+					return false, err
+				}
+			}
+			return true, nil
+		})
+}
+
 // createAndWait creates a new LogicalVolume resource and wait until it is fully provisioned
 func (s *LogicalVolumeService) createAndWait(ctx context.Context, lv *topolvmv1.LogicalVolume) (*topolvmv1.LogicalVolume, error) {
 	if err := s.create(ctx, lv); err != nil {
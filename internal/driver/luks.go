@@ -0,0 +1,266 @@
+package driver
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/topolvm/topolvm/internal/filesystem"
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	mountutil "k8s.io/mount-utils"
+	utilexec "k8s.io/utils/exec"
+)
+
+const (
+	cryptsetupCmd = "cryptsetup"
+
+	// luksPassphraseKey is the key of the passphrase in the node-publish and node-expand secrets.
+	luksPassphraseKey = "passphrase"
+	// luksPreviousPassphraseKey is the key of the passphrase to be replaced with the passphrase
+	// in the node-publish secrets, used for rotating the key of a volume.
+	luksPreviousPassphraseKey = "previousPassphrase"
+
+	// cryptsetup exits with this code when no key slot can be unlocked with the passphrase.
+	cryptsetupWrongPassphrase = 2
+)
+
+// luksMappingDir is the directory of the device-mapper devices. It is a variable for tests.
+var luksMappingDir = "/dev/mapper"
+
+// luksMappingName returns the device-mapper name of the opened LUKS device of a volume.
+func luksMappingName(volumeID string) string {
+	return "luks-" + volumeID
+}
+
+// luksMappingPath returns the path of the opened LUKS device of a volume.
+func luksMappingPath(volumeID string) string {
+	return filepath.Join(luksMappingDir, luksMappingName(volumeID))
+}
+
+// openLUKSVolume opens the LUKS device on a logical volume with the passphrase in the secrets
+// and returns the path of the opened device. A volume without any signature is formatted first,
+// unless it has a source whose data would be destroyed.
+// If the volume cannot be unlocked with the passphrase but can be with the previous passphrase,
+// the key is changed to the passphrase.
+func (s *nodeServerNoLocked) openLUKSVolume(volumeID, lvPath string, hasSource bool, secrets map[string]string) (string, error) {
+	passphrase := secrets[luksPassphraseKey]
+	if passphrase == "" {
+		return "", status.Errorf(codes.InvalidArgument, "no %s is provided in the node-publish secrets", luksPassphraseKey)
+	}
+
+	mappingPath := luksMappingPath(volumeID)
+	if _, err := os.Stat(mappingPath); err == nil {
+		// The opened device is usable without the passphrase, so the passphrase is tested against the volume.
+		testPassphrase := func(passphrase string) error {
+			return s.cryptsetup(passphrase, "open", "--test-passphrase", "--key-file=-", lvPath)
+		}
+		if err := s.unlockLUKSVolume(volumeID, lvPath, secrets, testPassphrase, func() {}); err != nil {
+			return "", err
+		}
+		// the volume may have been expanded while it was not published
+		if err := s.resizeLUKSVolume(volumeID, passphrase); err != nil {
+			return "", err
+		}
+		return mappingPath, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", status.Errorf(codes.Internal, "stat failed: target=%s, error=%v", mappingPath, err)
+	}
+
+	err := s.cryptsetup("", "isLuks", lvPath)
+	if exitCode(err) == 1 {
+		if hasSource {
+			return "", status.Errorf(codes.FailedPrecondition, "volume is not encrypted but has the data of its source: volume=%s", volumeID)
+		}
+		if err := s.checkNoSignature(volumeID, lvPath); err != nil {
+			return "", err
+		}
+		if out, err := s.mounter.Exec.Command("wipefs", "-a", lvPath).CombinedOutput(); err != nil {
+			return "", status.Errorf(codes.Internal, "wipefs failed: volume=%s, output=%s, error=%v", volumeID, string(out), err)
+		}
+		if err := s.cryptsetup(passphrase, "luksFormat", "--type", "luks2", "--batch-mode", "--key-file=-", lvPath); err != nil {
+			return "", status.Errorf(codes.Internal, "luksFormat failed: volume=%s, error=%v", volumeID, err)
+		}
+		nodeLogger.Info("luksFormat succeeded", "volume", volumeID)
+	} else if err != nil {
+		return "", status.Errorf(codes.Internal, "LUKS check failed: volume=%s, error=%v", volumeID, err)
+	}
+
+	open := func(passphrase string) error {
+		return s.cryptsetup(passphrase, "open", "--type", "luks", "--key-file=-", lvPath, luksMappingName(volumeID))
+	}
+	closeMapping := func() {
+		_ = s.cryptsetup("", "close", luksMappingName(volumeID))
+	}
+	if err := s.unlockLUKSVolume(volumeID, lvPath, secrets, open, closeMapping); err != nil {
+		return "", err
+	}
+
+	nodeLogger.Info("LUKS device is opened", "volume", volumeID, "device", mappingPath)
+	return mappingPath, nil
+}
+
+// unlockLUKSVolume runs unlock with the passphrase in the secrets. If the passphrase is wrong, it runs unlock
+// with the previous passphrase and changes the key to the passphrase. undo is called if the key cannot be changed.
+func (s *nodeServerNoLocked) unlockLUKSVolume(volumeID, lvPath string, secrets map[string]string, unlock func(passphrase string) error, undo func()) error {
+	passphrase := secrets[luksPassphraseKey]
+	err := unlock(passphrase)
+	previous := secrets[luksPreviousPassphraseKey]
+	if exitCode(err) == cryptsetupWrongPassphrase && previous != "" {
+		if err := unlock(previous); err != nil {
+			return status.Errorf(codes.PermissionDenied, "failed to open LUKS device with the passphrase or the previous passphrase: volume=%s, error=%v", volumeID, err)
+		}
+		if err := s.changeLUKSKey(lvPath, previous, passphrase); err != nil {
+			undo()
+			return status.Errorf(codes.Internal, "failed to change the key of LUKS device: volume=%s, error=%v", volumeID, err)
+		}
+		nodeLogger.Info("LUKS key rotation succeeded", "volume", volumeID)
+	} else if exitCode(err) == cryptsetupWrongPassphrase {
+		return status.Errorf(codes.PermissionDenied, "failed to open LUKS device with the passphrase: volume=%s, error=%v", volumeID, err)
+	} else if err != nil {
+		return status.Errorf(codes.Internal, "failed to open LUKS device: volume=%s, error=%v", volumeID, err)
+	}
+	return nil
+}
+
+// checkNoSignature returns FailedPrecondition if blkid finds any signature on the volume,
+// such as a filesystem or a partition table, so that the data is not destroyed by luksFormat.
+func (s *nodeServerNoLocked) checkNoSignature(volumeID, lvPath string) error {
+	out, err := s.mounter.Exec.Command("blkid", "-p", "-o", "export", lvPath).CombinedOutput()
+	switch exitCode(err) {
+	case 2:
+		// blkid exits with status 2 when no signature is found
+		return nil
+	case 0, 8:
+		// blkid exits with status 8 when the signatures are ambivalent
+		return status.Errorf(codes.FailedPrecondition, "volume is not encrypted but has signatures: volume=%s, signatures=%s",
+			volumeID, strings.Join(strings.Fields(string(out)), ","))
+	default:
+		return status.Errorf(codes.Internal, "blkid failed: volume=%s, output=%s, error=%v", volumeID, string(out), err)
+	}
+}
+
+// changeLUKSKey replaces the key slot unlocked by the old passphrase with the new passphrase.
+// The new passphrase is passed in a temporary file, as cryptsetup reads only one key from the standard input.
+func (s *nodeServerNoLocked) changeLUKSKey(lvPath, oldPassphrase, newPassphrase string) error {
+	f, err := os.CreateTemp("", "topolvm-luks-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	if _, err := f.WriteString(newPassphrase); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return s.cryptsetup(oldPassphrase, "luksChangeKey", "--key-file=-", lvPath, f.Name())
+}
+
+// resizeLUKSVolume resizes the opened LUKS device of a volume to the size of the logical volume.
+// Without the passphrase, LUKS2 devices are resized with the volume key in the kernel keyring.
+func (s *nodeServerNoLocked) resizeLUKSVolume(volumeID, passphrase string) error {
+	args := []string{"resize", luksMappingName(volumeID)}
+	if passphrase != "" {
+		args = append(args, "--key-file=-")
+	}
+	if err := s.cryptsetup(passphrase, args...); err != nil {
+		return status.Errorf(codes.Internal, "failed to resize LUKS device: volume=%s, error=%v", volumeID, err)
+	}
+	return nil
+}
+
+// isLUKSVolumeOpened returns true if the LUKS device of a volume is opened.
+func isLUKSVolumeOpened(volumeID string) (bool, error) {
+	_, err := os.Stat(luksMappingPath(volumeID))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// closeLUKSVolume closes the opened LUKS device of a volume unless it is still mounted on another target.
+func (s *nodeServerNoLocked) closeLUKSVolume(volumeID string) error {
+	mappingPath := luksMappingPath(volumeID)
+	inUse, err := isDeviceMounted(mappingPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "mount check failed: device=%s, error=%v", mappingPath, err)
+	}
+	if inUse {
+		nodeLogger.Info("LUKS device is still in use", "volume_id", volumeID)
+		return nil
+	}
+	if err := s.cryptsetup("", "close", luksMappingName(volumeID)); err != nil {
+		return status.Errorf(codes.Internal, "failed to close LUKS device: volume=%s, error=%v", volumeID, err)
+	}
+	nodeLogger.Info("LUKS device is closed", "volume_id", volumeID)
+	return nil
+}
+
+// isDeviceMounted returns true if a filesystem on the device is mounted, or the device file is bind-mounted.
+func isDeviceMounted(device string) (bool, error) {
+	resolved, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return false, err
+	}
+	var st unix.Stat_t
+	if err := filesystem.Stat(resolved, &st); err != nil {
+		return false, err
+	}
+	mountInfos, err := mountutil.ParseMountInfo("/proc/self/mountinfo")
+	if err != nil {
+		return false, err
+	}
+	for _, mi := range mountInfos {
+		if mi.Major == int(unix.Major(st.Rdev)) && mi.Minor == int(unix.Minor(st.Rdev)) {
+			return true, nil
+		}
+		// bind mounts of device files have the path in devtmpfs as their root
+		if mi.Root == strings.TrimPrefix(resolved, "/dev") || mi.Root == strings.TrimPrefix(device, "/dev") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// cryptsetup runs cryptsetup with the passphrase in the standard input.
+func (s *nodeServerNoLocked) cryptsetup(passphrase string, args ...string) error {
+	cmd := s.mounter.Exec.Command(cryptsetupCmd, args...)
+	cmd.SetStdin(strings.NewReader(passphrase))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return &cryptsetupError{err: err, output: strings.TrimSpace(string(out))}
+	}
+	return nil
+}
+
+type cryptsetupError struct {
+	err    error
+	output string
+}
+
+func (e *cryptsetupError) Error() string {
+	return e.err.Error() + ": " + e.output
+}
+
+func (e *cryptsetupError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code of a failed command, 0 for nil, or -1 if the command was not run.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus()
+	}
+	return -1
+}
@@ -0,0 +1,186 @@
+package driver
+
+import (
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	mountutil "k8s.io/mount-utils"
+	"k8s.io/utils/exec"
+	testingexec "k8s.io/utils/exec/testing"
+)
+
+type cryptsetupCall struct {
+	args  []string
+	stdin string
+}
+
+// fakeCryptsetup returns a fake exec which runs the commands with the exit codes in order and records their calls.
+func fakeCryptsetup(t *testing.T, calls *[]cryptsetupCall, exitCodes ...int) *testingexec.FakeExec {
+	fake := &testingexec.FakeExec{}
+	for _, code := range exitCodes {
+		fake.CommandScript = append(fake.CommandScript, func(cmd string, args ...string) exec.Cmd {
+			fakeCmd := &testingexec.FakeCmd{}
+			fakeCmd.CombinedOutputScript = []testingexec.FakeAction{func() ([]byte, []byte, error) {
+				var stdin []byte
+				if fakeCmd.Stdin != nil {
+					var err error
+					stdin, err = io.ReadAll(fakeCmd.Stdin)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				*calls = append(*calls, cryptsetupCall{args: append([]string{cmd}, args...), stdin: string(stdin)})
+				if code != 0 {
+					return nil, nil, testingexec.FakeExitError{Status: code}
+				}
+				return nil, nil, nil
+			}}
+			return testingexec.InitFakeCmd(fakeCmd, cmd, args...)
+		})
+	}
+	return fake
+}
+
+func TestOpenLUKSVolume(t *testing.T) {
+	const volumeID = "3f4a1b7c-5d6e-4f80-9a1b-2c3d4e5f6a7b"
+	const lvPath = "/dev/myvg1/3f4a1b7c-5d6e-4f80-9a1b-2c3d4e5f6a7b"
+
+	t.Run("no passphrase", func(t *testing.T) {
+		s := &nodeServerNoLocked{}
+		_, err := s.openLUKSVolume(volumeID, lvPath, false, map[string]string{})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument, actual %v", err)
+		}
+	})
+
+	t.Run("rotate key", func(t *testing.T) {
+		var calls []cryptsetupCall
+		// isLuks, open with the passphrase, open with the previous passphrase, luksChangeKey
+		s := &nodeServerNoLocked{mounter: mountutil.SafeFormatAndMount{Exec: fakeCryptsetup(t, &calls, 0, 2, 0, 0)}}
+		device, err := s.openLUKSVolume(volumeID, lvPath, false, map[string]string{
+			luksPassphraseKey:         "new",
+			luksPreviousPassphraseKey: "old",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if device != "/dev/mapper/luks-"+volumeID {
+			t.Errorf("unexpected device: %s", device)
+		}
+		if len(calls) != 4 {
+			t.Fatalf("unexpected calls: %v", calls)
+		}
+		if calls[1].args[1] != "open" || calls[1].stdin != "new" {
+			t.Errorf("should open with the passphrase first: %v", calls[1])
+		}
+		if calls[2].args[1] != "open" || calls[2].stdin != "old" {
+			t.Errorf("should open with the previous passphrase: %v", calls[2])
+		}
+		if calls[3].args[1] != "luksChangeKey" || calls[3].stdin != "old" || !slices.Contains(calls[3].args, lvPath) {
+			t.Errorf("should change the key unlocked by the previous passphrase: %v", calls[3])
+		}
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		var calls []cryptsetupCall
+		s := &nodeServerNoLocked{mounter: mountutil.SafeFormatAndMount{Exec: fakeCryptsetup(t, &calls, 0, 2)}}
+		_, err := s.openLUKSVolume(volumeID, lvPath, false, map[string]string{luksPassphraseKey: "wrong"})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("expected PermissionDenied, actual %v", err)
+		}
+	})
+
+	t.Run("format", func(t *testing.T) {
+		var calls []cryptsetupCall
+		// isLuks, blkid, wipefs, luksFormat, open
+		s := &nodeServerNoLocked{mounter: mountutil.SafeFormatAndMount{Exec: fakeCryptsetup(t, &calls, 1, 2, 0, 0, 0)}}
+		_, err := s.openLUKSVolume(volumeID, lvPath, false, map[string]string{luksPassphraseKey: "new"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(calls) != 5 || calls[1].args[0] != "blkid" || calls[2].args[0] != "wipefs" || calls[3].args[1] != "luksFormat" {
+			t.Errorf("unexpected calls: %v", calls)
+		}
+	})
+
+	t.Run("volume having a source", func(t *testing.T) {
+		var calls []cryptsetupCall
+		s := &nodeServerNoLocked{mounter: mountutil.SafeFormatAndMount{Exec: fakeCryptsetup(t, &calls, 1)}}
+		_, err := s.openLUKSVolume(volumeID, lvPath, true, map[string]string{luksPassphraseKey: "new"})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("expected FailedPrecondition, actual %v", err)
+		}
+		if len(calls) != 1 {
+			t.Errorf("volume having a source should not be formatted: %v", calls)
+		}
+	})
+
+	t.Run("partitioned device", func(t *testing.T) {
+		var calls []cryptsetupCall
+		// isLuks, blkid finding a partition table
+		s := &nodeServerNoLocked{mounter: mountutil.SafeFormatAndMount{Exec: fakeCryptsetup(t, &calls, 1, 0)}}
+		_, err := s.openLUKSVolume(volumeID, lvPath, false, map[string]string{luksPassphraseKey: "new"})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("expected FailedPrecondition, actual %v", err)
+		}
+		if len(calls) != 2 || !slices.Equal(calls[1].args, []string{"blkid", "-p", "-o", "export", lvPath}) {
+			t.Errorf("device having any signature should not be formatted: %v", calls)
+		}
+	})
+}
+
+func TestOpenLUKSVolume_Opened(t *testing.T) {
+	const volumeID = "3f4a1b7c-5d6e-4f80-9a1b-2c3d4e5f6a7b"
+	const lvPath = "/dev/myvg1/3f4a1b7c-5d6e-4f80-9a1b-2c3d4e5f6a7b"
+
+	orig := luksMappingDir
+	luksMappingDir = t.TempDir()
+	t.Cleanup(func() { luksMappingDir = orig })
+	if err := os.WriteFile(luksMappingPath(volumeID), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		var calls []cryptsetupCall
+		s := &nodeServerNoLocked{mounter: mountutil.SafeFormatAndMount{Exec: fakeCryptsetup(t, &calls, 2)}}
+		_, err := s.openLUKSVolume(volumeID, lvPath, false, map[string]string{luksPassphraseKey: "wrong"})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("expected PermissionDenied, actual %v", err)
+		}
+		if len(calls) != 1 || !slices.Contains(calls[0].args, "--test-passphrase") || calls[0].stdin != "wrong" {
+			t.Errorf("passphrase should be tested: %v", calls)
+		}
+	})
+
+	t.Run("rotate key", func(t *testing.T) {
+		var calls []cryptsetupCall
+		// test the passphrase, test the previous passphrase, luksChangeKey, resize
+		s := &nodeServerNoLocked{mounter: mountutil.SafeFormatAndMount{Exec: fakeCryptsetup(t, &calls, 2, 0, 0, 0)}}
+		device, err := s.openLUKSVolume(volumeID, lvPath, false, map[string]string{
+			luksPassphraseKey:         "new",
+			luksPreviousPassphraseKey: "old",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if device != luksMappingPath(volumeID) {
+			t.Errorf("unexpected device: %s", device)
+		}
+		if len(calls) != 4 {
+			t.Fatalf("unexpected calls: %v", calls)
+		}
+		if !slices.Contains(calls[1].args, "--test-passphrase") || calls[1].stdin != "old" {
+			t.Errorf("should test the previous passphrase: %v", calls[1])
+		}
+		if calls[2].args[1] != "luksChangeKey" || calls[2].stdin != "old" {
+			t.Errorf("should change the key unlocked by the previous passphrase: %v", calls[2])
+		}
+		if calls[3].args[1] != "resize" || calls[3].stdin != "new" {
+			t.Errorf("should resize with the passphrase: %v", calls[3])
+		}
+	})
+}
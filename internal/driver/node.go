@@ -12,8 +12,10 @@ import (
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/topolvm/topolvm"
+	v1 "github.com/topolvm/topolvm/api/v1"
 	"github.com/topolvm/topolvm/internal/driver/internal/k8s"
 	"github.com/topolvm/topolvm/internal/filesystem"
+	"github.com/topolvm/topolvm/internal/iothrottle"
 	"github.com/topolvm/topolvm/internal/lvmd/command"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	"golang.org/x/sys/unix"
@@ -27,6 +29,9 @@ import (
 
 const (
 	findmntCmd = "/bin/findmnt"
+
+	// podUIDKey is the volume context added by kubelet as podInfoOnMount of the CSIDriver is true.
+	podUIDKey = "csi.storage.k8s.io/pod.uid"
 )
 
 var nodeLogger = ctrl.Log.WithName("driver").WithName("node")
@@ -165,17 +170,59 @@ func (s *nodeServerNoLocked) NodePublishVolume(ctx context.Context, req *csi.Nod
 		return nil, status.Errorf(codes.NotFound, "failed to find LV: %s", volumeID)
 	}
 
+	device := lv.GetPath()
+	if volumeContext[topolvm.GetEncryptionKey()] == topolvm.EncryptionLUKS {
+		device, err = s.openLUKSVolume(volumeID, lv.GetPath(), lvr.Spec.Source != "", req.GetSecrets())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if isBlockVol {
-		err = s.nodePublishBlockVolume(req, lv)
+		err = s.nodePublishBlockVolume(req, device)
 	} else if isFsVol {
-		err = s.nodePublishFilesystemVolume(req, lv)
+		err = s.nodePublishFilesystemVolume(req, device)
 	}
 	if err != nil {
 		return nil, err
 	}
+
+	if lvr.Spec.IOLimits != nil {
+		major, minor := lv.GetDevMajor(), lv.GetDevMinor()
+		if device != lv.GetPath() {
+			// IOs of the pod are submitted to the dm-crypt device of an encrypted volume
+			major, minor, err = iothrottle.DeviceOf(device)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+		}
+		if err := s.applyIOLimits(req.GetTargetPath(), volumeContext[podUIDKey], major, minor, lvr.Spec.IOLimits); err != nil {
+			return nil, err
+		}
+	}
 	return &csi.NodePublishVolumeResponse{}, nil
 }
 
+// applyIOLimits sets the IO limits of the device in the cgroup of the pod which the volume is published to.
+// podUID may be empty, then it is taken from the target path.
+func (s *nodeServerNoLocked) applyIOLimits(targetPath, podUID string, major, minor uint32, limits *v1.IOLimits) error {
+	if podUID == "" {
+		var ok bool
+		_, podUID, ok = iothrottle.ParseTargetPath(targetPath)
+		if !ok {
+			return status.Errorf(codes.InvalidArgument, "cannot find the pod of target_path %s to limit IO", targetPath)
+		}
+	}
+	if err := iothrottle.Apply(podUID, major, minor, limits); err != nil {
+		return status.Errorf(codes.Internal, "failed to limit IO of pod %s: %v", podUID, err)
+	}
+	nodeLogger.Info("applied IO limits",
+		"target_path", targetPath,
+		"pod_uid", podUID,
+		"limits", limits)
+	return nil
+}
+
 func makeMountOptions(readOnly bool, mountOption *csi.VolumeCapability_MountVolume) ([]string, error) {
 	mountOptions := make([]string, 0, len(mountOption.MountFlags)+2)
 	mountOptions = append(mountOptions, toReadOnlyMountOption(readOnly)...)
@@ -199,7 +246,7 @@ func toReadOnlyMountOption(readOnly bool) []string {
 	return map[bool][]string{true: {"ro"}, false: nil}[readOnly]
 }
 
-func (s *nodeServerNoLocked) nodePublishFilesystemVolume(req *csi.NodePublishVolumeRequest, lv *proto.LogicalVolume) error {
+func (s *nodeServerNoLocked) nodePublishFilesystemVolume(req *csi.NodePublishVolumeRequest, device string) error {
 	// Check request
 	mountOption := req.GetVolumeCapability().GetMount()
 	if mountOption.FsType == "" {
@@ -215,7 +262,7 @@ func (s *nodeServerNoLocked) nodePublishFilesystemVolume(req *csi.NodePublishVol
 		return status.Errorf(codes.Internal, "mkdir failed: target=%s, error=%v", req.GetTargetPath(), err)
 	}
 
-	fsType, err := filesystem.DetectFilesystem(lv.GetPath())
+	fsType, err := filesystem.DetectFilesystem(device)
 	if err != nil {
 		return status.Errorf(codes.Internal, "filesystem check failed: volume=%s, error=%v", req.GetVolumeId(), err)
 	}
@@ -235,7 +282,7 @@ func (s *nodeServerNoLocked) nodePublishFilesystemVolume(req *csi.NodePublishVol
 			// wipefs to clear stray signatures (e.g., JMicron marker in the
 			// last sector per #1126). Behavior was verified in PR 1127. If you
 			// need the verification code, see that PR.
-			out, err := s.mounter.Exec.Command("wipefs", "-a", lv.GetPath()).CombinedOutput()
+			out, err := s.mounter.Exec.Command("wipefs", "-a", device).CombinedOutput()
 			if err != nil {
 				return status.Errorf(codes.Internal, "wipefs failed: volume=%s, output=%s, error=%v", req.GetVolumeId(), string(out), err)
 			}
@@ -244,7 +291,7 @@ func (s *nodeServerNoLocked) nodePublishFilesystemVolume(req *csi.NodePublishVol
 				"output", string(out))
 			mountFunc = s.mounter.FormatAndMount
 		}
-		if err := mountFunc(lv.GetPath(), req.GetTargetPath(), mountOption.FsType, mountOptions); err != nil {
+		if err := mountFunc(device, req.GetTargetPath(), mountOption.FsType, mountOptions); err != nil {
 			return status.Errorf(codes.Internal, "mount failed: volume=%s, error=%v", req.GetVolumeId(), err)
 		}
 		if err := os.Chmod(req.GetTargetPath(), 0777|os.ModeSetgid); err != nil {
@@ -253,8 +300,8 @@ func (s *nodeServerNoLocked) nodePublishFilesystemVolume(req *csi.NodePublishVol
 	}
 
 	r := mountutil.NewResizeFs(s.mounter.Exec)
-	if resize, err := r.NeedResize(lv.GetPath(), req.GetTargetPath()); resize {
-		if _, err := r.Resize(lv.GetPath(), req.GetTargetPath()); err != nil {
+	if resize, err := r.NeedResize(device, req.GetTargetPath()); resize {
+		if _, err := r.Resize(device, req.GetTargetPath()); err != nil {
 			return status.Errorf(codes.Internal, "failed to resize filesystem %s (mounted at: %s): %v", req.VolumeId, req.GetTargetPath(), err)
 		}
 	} else if err != nil {
@@ -269,7 +316,7 @@ func (s *nodeServerNoLocked) nodePublishFilesystemVolume(req *csi.NodePublishVol
 	return nil
 }
 
-func (s *nodeServerNoLocked) nodePublishBlockVolume(req *csi.NodePublishVolumeRequest, lv *proto.LogicalVolume) error {
+func (s *nodeServerNoLocked) nodePublishBlockVolume(req *csi.NodePublishVolumeRequest, device string) error {
 	// Find lv and create a block device with it
 	// We mount via bind mount so that we can also respect the readonly flag
 	mountOptions := append(toReadOnlyMountOption(req.GetReadonly()), "bind")
@@ -284,7 +331,7 @@ func (s *nodeServerNoLocked) nodePublishBlockVolume(req *csi.NodePublishVolumeRe
 		return status.Errorf(codes.Internal, "chmod failed: target=%s, error=%v", req.GetTargetPath(), err)
 	}
 
-	if err := s.mounter.Mount(lv.GetPath(), req.GetTargetPath(), "", mountOptions); err != nil {
+	if err := s.mounter.Mount(device, req.GetTargetPath(), "", mountOptions); err != nil {
 		return status.Errorf(codes.Internal, "(bind)mount failed: volume=%s, error=%v", req.GetVolumeId(), err)
 	}
 
@@ -329,11 +376,26 @@ func (s *nodeServerNoLocked) NodeUnpublishVolume(ctx context.Context, req *csi.N
 	if os.IsNotExist(err) {
 		// target_path does not exist, but legacy device for mount-type PV may still exist.
 		_ = os.Remove(filepath.Join(topolvm.LegacyDeviceDirectory, volumeID))
+		if err := s.closeLUKSVolume(volumeID); err != nil {
+			return nil, err
+		}
 		return &csi.NodeUnpublishVolumeResponse{}, nil
 	} else if err != nil {
 		return nil, status.Errorf(codes.Internal, "stat failed for %s: %v", targetPath, err)
 	}
 
+	// remove the IO limits before the device is unmounted, as the pod cgroup may remain until the pod is deleted
+	if _, podUID, ok := iothrottle.ParseTargetPath(targetPath); ok {
+		major, minor, err := iothrottle.DeviceOf(targetPath)
+		if err == nil {
+			err = iothrottle.Apply(podUID, major, minor, nil)
+		}
+		if err != nil && !errors.Is(err, iothrottle.ErrPodCgroupNotFound) {
+			// the limits are removed with the pod cgroup anyway
+			nodeLogger.Error(err, "failed to remove IO limits", "volume_id", volumeID, "target_path", targetPath)
+		}
+	}
+
 	// remove device file if target_path is device, unmount target_path otherwise
 	if info.IsDir() {
 		err = s.nodeUnpublishFilesystemVolume(req)
@@ -343,6 +405,10 @@ func (s *nodeServerNoLocked) NodeUnpublishVolume(ctx context.Context, req *csi.N
 	if err != nil {
 		return nil, err
 	}
+	// the LUKS device of an encrypted volume is closed when it is unpublished from all targets
+	if err := s.closeLUKSVolume(volumeID); err != nil {
+		return nil, err
+	}
 	return &csi.NodeUnpublishVolumeResponse{}, nil
 }
 
@@ -483,6 +549,18 @@ func (s *nodeServerNoLocked) NodeExpandVolume(ctx context.Context, req *csi.Node
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
+	// the LUKS device of an encrypted volume is resized before the filesystem
+	encrypted, err := isLUKSVolumeOpened(volumeID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "stat failed: target=%s, error=%v", luksMappingPath(volumeID), err)
+	}
+	if encrypted {
+		if err := s.resizeLUKSVolume(volumeID, req.GetSecrets()[luksPassphraseKey]); err != nil {
+			return nil, err
+		}
+		logger.Info("LUKS device is resized")
+	}
+
 	if isBlock := req.GetVolumeCapability().GetBlock() != nil; isBlock {
 		logger.Info("NodeExpandVolume(block) is skipped")
 		return &csi.NodeExpandVolumeResponse{}, nil
@@ -515,9 +593,14 @@ func (s *nodeServerNoLocked) NodeExpandVolume(ctx context.Context, req *csi.Node
 	}
 	logger = logger.WithValues("device", devicePath)
 
+	device := lv.GetPath()
+	if encrypted {
+		device = luksMappingPath(volumeID)
+	}
+
 	logger.Info("triggering filesystem resize")
 	r := mountutil.NewResizeFs(s.mounter.Exec)
-	if _, err := r.Resize(lv.GetPath(), volumePath); err != nil {
+	if _, err := r.Resize(device, volumePath); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to resize filesystem %s (mounted at: %s): %v", volumeID, volumePath, err)
 	}
 
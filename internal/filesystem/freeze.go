@@ -0,0 +1,169 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	mountutil "k8s.io/mount-utils"
+)
+
+const (
+	fsfreezeCmd = "/sbin/fsfreeze"
+	sysDevBlock = "/sys/dev/block"
+)
+
+// MountPointsOfDevice returns a mount point of the filesystem on the block device, and those on the devices
+// holding it such as dm-crypt devices. Only one mount point is returned for each filesystem,
+// because the filesystem is frozen and thawed as a whole.
+func MountPointsOfDevice(major, minor uint32) ([]string, error) {
+	devices := []string{fmt.Sprintf("%d:%d", major, minor)}
+	holders, err := filepath.Glob(filepath.Join(sysDevBlock, devices[0], "holders", "*", "dev"))
+	if err != nil {
+		return nil, err
+	}
+	for _, holder := range holders {
+		data, err := os.ReadFile(holder)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, strings.TrimSpace(string(data)))
+	}
+
+	mountInfos, err := mountutil.ParseMountInfo("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	return mountPointsOf(mountInfos, devices), nil
+}
+
+// mountPointsOf returns the first mount point found for each of the devices given as "major:minor".
+func mountPointsOf(mountInfos []mountutil.MountInfo, devices []string) []string {
+	var mountPoints []string
+	for _, device := range devices {
+		for _, mi := range mountInfos {
+			if fmt.Sprintf("%d:%d", mi.Major, mi.Minor) == device {
+				mountPoints = append(mountPoints, mi.MountPoint)
+				break
+			}
+		}
+	}
+	return mountPoints
+}
+
+// stateMu serializes the updates of the state files of frozen filesystems.
+var stateMu sync.Mutex
+
+// Freeze suspends the access to the filesystem mounted on the mount point,
+// and flushes its data so that the block device is consistent on disk.
+// fsfreeze is killed when ctx is done.
+func Freeze(ctx context.Context, mountPoint string) error {
+	out, err := exec.CommandContext(ctx, fsfreezeCmd, "--freeze", mountPoint).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("fsfreeze failed: output=%s, mountpoint=%s, error=%v", string(out), mountPoint, err)
+	}
+	return nil
+}
+
+// Thaw resumes the access to the filesystem frozen by Freeze.
+func Thaw(mountPoint string) error {
+	out, err := exec.Command(fsfreezeCmd, "--unfreeze", mountPoint).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("fsfreeze failed: output=%s, mountpoint=%s, error=%v", string(out), mountPoint, err)
+	}
+	return nil
+}
+
+// RecordFrozen adds the mount point to the state file before it is frozen, so that ThawRecorded thaws it
+// even if the process dies while the filesystem is frozen. Nothing is recorded if stateFile is empty.
+func RecordFrozen(stateFile, mountPoint string) error {
+	if stateFile == "" {
+		return nil
+	}
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	mountPoints, err := readState(stateFile)
+	if err != nil {
+		return err
+	}
+	return writeState(stateFile, append(mountPoints, mountPoint))
+}
+
+// ForgetFrozen removes the thawed mount points from the state file.
+func ForgetFrozen(stateFile string, thawed []string) error {
+	if stateFile == "" {
+		return nil
+	}
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	mountPoints, err := readState(stateFile)
+	if err != nil {
+		return err
+	}
+	mountPoints = slices.DeleteFunc(mountPoints, func(mp string) bool {
+		return slices.Contains(thawed, mp)
+	})
+	return writeState(stateFile, mountPoints)
+}
+
+// ThawRecorded thaws the filesystems recorded in the state file, which were left frozen by a dead process,
+// and removes the state file. It returns the thawed mount points.
+// The mount points which are not frozen or not mounted anymore are ignored.
+func ThawRecorded(stateFile string) ([]string, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	mountPoints, err := readState(stateFile)
+	if err != nil {
+		return nil, err
+	}
+	var thawed []string
+	for _, mp := range mountPoints {
+		if err := Thaw(mp); err == nil {
+			thawed = append(thawed, mp)
+		}
+	}
+	return thawed, writeState(stateFile, nil)
+}
+
+// readState returns the mount points in the state file, or nil if it does not exist.
+func readState(stateFile string) ([]string, error) {
+	data, err := os.ReadFile(stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var mountPoints []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			mountPoints = append(mountPoints, line)
+		}
+	}
+	return mountPoints, nil
+}
+
+// writeState replaces the state file with the mount points atomically, or removes it if there is none.
+func writeState(stateFile string, mountPoints []string) error {
+	if len(mountPoints) == 0 {
+		err := os.Remove(stateFile)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	tmp := stateFile + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(mountPoints, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, stateFile)
+}
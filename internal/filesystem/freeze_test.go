@@ -0,0 +1,71 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	mountutil "k8s.io/mount-utils"
+)
+
+func TestMountPointsOf(t *testing.T) {
+	mountInfos := []mountutil.MountInfo{
+		{Major: 253, Minor: 1, MountPoint: "/var/lib/kubelet/pods/a/volumes/kubernetes.io~csi/pvc-1/mount"},
+		{Major: 253, Minor: 1, MountPoint: "/var/lib/kubelet/pods/b/volumes/kubernetes.io~csi/pvc-1/mount"},
+		{Major: 253, Minor: 3, MountPoint: "/var/lib/kubelet/pods/c/volumes/kubernetes.io~csi/pvc-2/mount"},
+	}
+
+	testCases := []struct {
+		devices  []string
+		expected []string
+	}{
+		{[]string{"253:1"}, []string{"/var/lib/kubelet/pods/a/volumes/kubernetes.io~csi/pvc-1/mount"}},
+		// the filesystem is on the dm-crypt device holding the LV
+		{[]string{"253:2", "253:3"}, []string{"/var/lib/kubelet/pods/c/volumes/kubernetes.io~csi/pvc-2/mount"}},
+		// block volume
+		{[]string{"253:4"}, nil},
+	}
+
+	for _, tc := range testCases {
+		actual := mountPointsOf(mountInfos, tc.devices)
+		if !slices.Equal(actual, tc.expected) {
+			t.Errorf("devices %v: expected %v, but was %v", tc.devices, tc.expected, actual)
+		}
+	}
+}
+
+func TestFreezeState(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "frozen-filesystems")
+
+	for _, mp := range []string{"/nonexistent/a", "/nonexistent/b"} {
+		if err := RecordFrozen(stateFile, mp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ForgetFrozen(stateFile, []string{"/nonexistent/a"}); err != nil {
+		t.Fatal(err)
+	}
+	mountPoints, err := readState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(mountPoints, []string{"/nonexistent/b"}) {
+		t.Errorf("unexpected mount points: %v", mountPoints)
+	}
+
+	// the mount point which cannot be thawed is ignored.
+	thawed, err := ThawRecorded(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thawed) != 0 {
+		t.Errorf("unexpected thawed mount points: %v", thawed)
+	}
+	if _, err := os.Stat(stateFile); !os.IsNotExist(err) {
+		t.Errorf("state file should be removed: %v", err)
+	}
+	if err := ForgetFrozen(stateFile, []string{"/nonexistent/b"}); err != nil {
+		t.Errorf("missing state file should be ignored: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package hook
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/topolvm/topolvm"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// advertisement is the device-classes and the lvcreate-option-classes advertised by the annotations of nodes.
+type advertisement struct {
+	// freeCapacities is the largest free capacity of each device-class among the nodes.
+	// It is not the total capacity, as the nodes advertise only the free capacity.
+	freeCapacities map[string]uint64
+	// optionClasses is the names of the lvcreate-option-classes available on any node.
+	optionClasses map[string]struct{}
+	// optionClassesPublished is true if any node publishes its lvcreate-option-classes.
+	// topolvm-node of older versions does not publish them.
+	optionClassesPublished bool
+}
+
+func readAdvertisement(ctx context.Context, r client.Reader) (*advertisement, error) {
+	var nodes corev1.NodeList
+	if err := r.List(ctx, &nodes); err != nil {
+		return nil, err
+	}
+
+	adv := &advertisement{
+		freeCapacities: make(map[string]uint64),
+		optionClasses:  make(map[string]struct{}),
+	}
+	for _, node := range nodes.Items {
+		for key, val := range node.Annotations {
+			if dc, ok := strings.CutPrefix(key, topolvm.GetCapacityKeyPrefix()); ok {
+				capacity, err := strconv.ParseUint(val, 10, 64)
+				if err != nil {
+					continue
+				}
+				if current, ok := adv.freeCapacities[dc]; !ok || capacity > current {
+					adv.freeCapacities[dc] = capacity
+				}
+			}
+		}
+		if val, ok := node.Annotations[topolvm.GetLvcreateOptionClassesKey()]; ok {
+			adv.optionClassesPublished = true
+			for _, name := range strings.Split(val, ",") {
+				if name != "" {
+					adv.optionClasses[name] = struct{}{}
+				}
+			}
+		}
+	}
+	return adv, nil
+}
+
+// deviceClassOf returns the device-class specified by the parameters of a StorageClass.
+func deviceClassOf(parameters map[string]string) string {
+	dc, ok := parameters[topolvm.GetDeviceClassKey()]
+	if !ok || dc == "" {
+		return topolvm.DefaultDeviceClassAnnotationName
+	}
+	return dc
+}
@@ -3,15 +3,21 @@ package hook
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
 	"github.com/topolvm/topolvm/internal/getter"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,19 +30,54 @@ var pmLogger = ctrl.Log.WithName("pod-mutator")
 //+kubebuilder:webhook:failurePolicy=fail,matchPolicy=equivalent,groups=core,resources=pods,verbs=create,versions=v1,name=pod-hook.topolvm.io,path=/pod/mutate,mutating=true,sideEffects=none,admissionReviewVersions={v1,v1beta1}
 //+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch
 //+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumemigrations,verbs=get;list;watch
+
+// annDefaultStorageClass is the annotation of the default StorageClass.
+const annDefaultStorageClass = "storageclass.kubernetes.io/is-default-class"
+
+// MissingPVCPolicy is the policy for the PVCs of pods which do not exist yet
+// and cannot be resolved from the volumeClaimTemplates of the owning StatefulSets.
+type MissingPVCPolicy string
+
+const (
+	// MissingPVCIgnore allows the pods without counting the capacity of the missing PVCs.
+	MissingPVCIgnore MissingPVCPolicy = "ignore"
+	// MissingPVCDeny denies the pods.
+	MissingPVCDeny MissingPVCPolicy = "deny"
+	// MissingPVCDefaultClass counts the default capacity of the default StorageClass for the missing PVCs.
+	MissingPVCDefaultClass MissingPVCPolicy = "default-class"
+)
+
+// ParseMissingPVCPolicy parses the name of MissingPVCPolicy.
+func ParseMissingPVCPolicy(name string) (MissingPVCPolicy, error) {
+	switch p := MissingPVCPolicy(name); p {
+	case MissingPVCIgnore, MissingPVCDeny, MissingPVCDefaultClass:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid missing PVC policy: %s", name)
+}
+
+var errMissingPVC = errors.New("PVC does not exist")
 
 // podMutator mutates pods using PVC for TopoLVM.
 type podMutator struct {
-	getter  *getter.RetryMissingGetter
-	decoder admission.Decoder
+	reader        client.Reader
+	getter        *getter.RetryMissingGetter
+	decoder       admission.Decoder
+	missingPolicy MissingPVCPolicy
 }
 
 // PodMutator creates a mutating webhook for Pods.
-func PodMutator(r client.Reader, apiReader client.Reader, dec admission.Decoder) http.Handler {
+// The PVCs of a pod which do not exist yet are resolved from the volumeClaimTemplates of the owning StatefulSet,
+// and missingPolicy is applied if they cannot be resolved.
+func PodMutator(r client.Reader, apiReader client.Reader, dec admission.Decoder, missingPolicy MissingPVCPolicy) http.Handler {
 	return &webhook.Admission{
 		Handler: &podMutator{
-			getter:  getter.NewRetryMissingGetter(r, apiReader),
-			decoder: dec,
+			reader:        r,
+			getter:        getter.NewRetryMissingGetter(r, apiReader),
+			decoder:       dec,
+			missingPolicy: missingPolicy,
 		},
 	}
 }
@@ -64,7 +105,20 @@ func (m *podMutator) Handle(ctx context.Context, req admission.Request) admissio
 		pod.Namespace = req.Namespace
 	}
 
+	// The data of a volume being migrated must not be changed until its PVC is rebound to the migrated volume.
+	migration, err := m.claimUnderMigration(ctx, pod)
+	if err != nil {
+		pmLogger.Error(err, "claimUnderMigration failed")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if migration != "" {
+		return admission.Denied(migration)
+	}
+
 	capacities, err := m.volumesCapacity(ctx, pod)
+	if errors.Is(err, errMissingPVC) {
+		return admission.Denied(err.Error())
+	}
 	if err != nil {
 		pmLogger.Error(err, "volumesCapacity failed")
 		return admission.Errored(http.StatusInternalServerError, err)
@@ -100,8 +154,36 @@ func (m *podMutator) Handle(ctx context.Context, req admission.Request) admissio
 	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod)
 }
 
+// claimUnderMigration returns the reason to deny the pod if any of its PVCs is being migrated by LogicalVolumeMigration.
+// LogicalVolumeMigration is not served in the legacy API group, nor without its CRD, where no claims are under migration.
+func (m *podMutator) claimUnderMigration(ctx context.Context, pod *corev1.Pod) (string, error) {
+	if topolvm.UseLegacy() {
+		return "", nil
+	}
+	var migrations topolvmv1.LogicalVolumeMigrationList
+	if err := m.reader.List(ctx, &migrations); err != nil {
+		if meta.IsNoMatchError(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	for _, mig := range migrations.Items {
+		if mig.DeletionTimestamp != nil || mig.Status.ClaimNamespace != pod.Namespace ||
+			mig.Status.Phase == topolvmv1.MigrationPhaseCompleted || mig.Status.Phase == topolvmv1.MigrationPhaseFailed {
+			continue
+		}
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName == mig.Status.ClaimName {
+				return fmt.Sprintf("PVC %s is being migrated by LogicalVolumeMigration %s", mig.Status.ClaimName, mig.Name), nil
+			}
+		}
+	}
+	return "", nil
+}
+
 type targetSC struct {
 	getter *getter.RetryMissingGetter
+	reader client.Reader
 	cache  map[string]*storagev1.StorageClass
 }
 
@@ -127,8 +209,31 @@ func (t *targetSC) Get(ctx context.Context, name string) (*storagev1.StorageClas
 	return &sc, nil
 }
 
+// Default returns the default StorageClass if it is for TopoLVM.
+// If there are multiple default StorageClasses, the newest one is used as well as the DefaultStorageClass admission plugin.
+func (t *targetSC) Default(ctx context.Context) (*storagev1.StorageClass, error) {
+	var scs storagev1.StorageClassList
+	if err := t.reader.List(ctx, &scs); err != nil {
+		return nil, err
+	}
+	var def *storagev1.StorageClass
+	for i := range scs.Items {
+		sc := &scs.Items[i]
+		if sc.Annotations[annDefaultStorageClass] != "true" {
+			continue
+		}
+		if def == nil || def.CreationTimestamp.Before(&sc.CreationTimestamp) {
+			def = sc
+		}
+	}
+	if def == nil || def.Provisioner != topolvm.GetPluginName() {
+		return nil, nil
+	}
+	return def, nil
+}
+
 func (m *podMutator) volumesCapacity(ctx context.Context, pod *corev1.Pod) (map[string]int64, error) {
-	targetSC := targetSC{m.getter, map[string]*storagev1.StorageClass{}}
+	targetSC := targetSC{m.getter, m.reader, map[string]*storagev1.StorageClass{}}
 	capacities := make(map[string]int64)
 	for _, vol := range pod.Spec.Volumes {
 		switch {
@@ -184,8 +289,24 @@ func (m *podMutator) pvcCapacity(
 			return "", 0, false, err
 		}
 		// Pods should be created even if their PVCs do not exist yet.
-		// TopoLVM does not care about such pods after they are created, though.
-		return "", 0, false, nil
+		// The PVCs of StatefulSets are created from their volumeClaimTemplates.
+		spec, err := m.claimTemplate(ctx, pod, pvcName)
+		if err != nil {
+			return "", 0, false, err
+		}
+		if spec == nil {
+			switch m.missingPolicy {
+			case MissingPVCDeny:
+				return "", 0, false, fmt.Errorf("%w: %s", errMissingPVC, pvcName)
+			case MissingPVCDefaultClass:
+				spec = &corev1.PersistentVolumeClaimSpec{}
+			default:
+				// TopoLVM does not care about such pods after they are created.
+				return "", 0, false, nil
+			}
+		}
+		dc, requested, err := m.templateCapacity(ctx, spec, targetSC)
+		return dc, requested, false, err
 	}
 
 	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
@@ -222,6 +343,68 @@ func (m *podMutator) pvcCapacity(
 	return dc, requested, false, nil
 }
 
+// claimTemplate returns the spec of the volumeClaimTemplate of the StatefulSet owning the pod
+// from which the PVC is created, or nil if the PVC is not created from a volumeClaimTemplate.
+func (m *podMutator) claimTemplate(ctx context.Context, pod *corev1.Pod, pvcName string) (*corev1.PersistentVolumeClaimSpec, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "StatefulSet" || owner.APIVersion != appsv1.SchemeGroupVersion.String() {
+		return nil, nil
+	}
+
+	var sts appsv1.StatefulSet
+	if err := m.getter.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name}, &sts); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if sts.UID != owner.UID {
+		return nil, nil
+	}
+
+	// The name of the PVC created from a volumeClaimTemplate is "<template name>-<pod name>".
+	for i := range sts.Spec.VolumeClaimTemplates {
+		template := &sts.Spec.VolumeClaimTemplates[i]
+		if template.Name+"-"+pod.Name == pvcName {
+			return &template.Spec, nil
+		}
+	}
+	return nil, nil
+}
+
+// templateCapacity returns the device-class and the capacity of the PVC which will be created from spec.
+// If the storage class is not specified, the PVC will have the default StorageClass.
+func (m *podMutator) templateCapacity(ctx context.Context, spec *corev1.PersistentVolumeClaimSpec, targetSC targetSC) (string, int64, error) {
+	var sc *storagev1.StorageClass
+	var err error
+	switch {
+	case spec.StorageClassName == nil:
+		sc, err = targetSC.Default(ctx)
+	case *spec.StorageClassName == "":
+		return "", 0, nil
+	default:
+		sc, err = targetSC.Get(ctx, *spec.StorageClassName)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if sc == nil {
+		return "", 0, nil
+	}
+
+	var requested = topolvm.DefaultSize
+	if req, ok := spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		if req.Value() != 0 {
+			requested = req.Value()
+		}
+	}
+	dc, ok := sc.Parameters[topolvm.GetDeviceClassKey()]
+	if !ok {
+		dc = topolvm.DefaultDeviceClassAnnotationName
+	}
+	return dc, requested, nil
+}
+
 func (m *podMutator) ephemeralCapacity(
 	ctx context.Context,
 	_ *corev1.Pod,
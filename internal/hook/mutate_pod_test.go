@@ -1,16 +1,29 @@
 package hook
 
 import (
+	"context"
+	"errors"
 	"strconv"
+	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/getter"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 const (
@@ -424,4 +437,213 @@ var _ = Describe("pod mutation webhook", func() {
 		Expect(limit.Value()).Should(Equal(int64(1)))
 		Expect(capacity).Should(Equal(strconv.Itoa(500 * mebibyte)))
 	})
+
+	It("should mutate pod of StatefulSet before its PVC", func() {
+		sts := &appsv1.StatefulSet{}
+		sts.Namespace = mutatePodNamespace
+		sts.Name = "sts"
+		sts.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "sts"}}
+		sts.Spec.Template.Labels = map[string]string{"app": "sts"}
+		sts.Spec.Template.Spec.Containers = []corev1.Container{{Name: "container1", Image: "ubuntu"}}
+		sts.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "data"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					StorageClassName: ptr.To(topolvmProvisioner2StorageClassName),
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{"storage": *resource.NewQuantity(3<<30, resource.BinarySI)},
+					},
+				},
+			},
+		}
+		err := k8sClient.Create(testCtx, sts)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		pod := testPod()
+		pod.OwnerReferences = []metav1.OwnerReference{
+			*metav1.NewControllerRef(sts, appsv1.SchemeGroupVersion.WithKind("StatefulSet")),
+		}
+		pod.Spec.Volumes = []corev1.Volume{
+			{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: pvcSource("data-" + defaultPodName),
+				},
+			},
+		}
+		err = k8sClient.Create(testCtx, pod)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		pod = getPod()
+		request := pod.Spec.Containers[0].Resources.Requests[topolvm.GetCapacityResource()]
+		capacity := pod.Annotations[topolvm.GetCapacityKeyPrefix()+deviceClass2]
+		Expect(request.Value()).Should(Equal(int64(1)))
+		Expect(capacity).Should(Equal(strconv.Itoa(3 << 30)))
+	})
 })
+
+func TestPodMutator_missingPVC(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	defaultSC := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "default",
+			Annotations: map[string]string{annDefaultStorageClass: "true"},
+		},
+		Provisioner: topolvm.GetPluginName(),
+		Parameters:  map[string]string{topolvm.GetDeviceClassKey(): deviceClass3},
+	}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "sts", Namespace: "ns", UID: "sts-uid"},
+		Spec: appsv1.StatefulSetSpec{
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "data"},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{"storage": *resource.NewQuantity(2<<30, resource.BinarySI)},
+						},
+					},
+				},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(defaultSC, sts).Build()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "sts-0", Namespace: "ns"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: pvcSource("data-sts-0")}},
+			},
+		},
+	}
+	newMutator := func(policy MissingPVCPolicy) *podMutator {
+		return &podMutator{reader: c, getter: getter.NewRetryMissingGetter(c, c), missingPolicy: policy}
+	}
+
+	// the PVC is resolved from the volumeClaimTemplate of the StatefulSet with the default StorageClass
+	pod.OwnerReferences = []metav1.OwnerReference{
+		*metav1.NewControllerRef(sts, appsv1.SchemeGroupVersion.WithKind("StatefulSet")),
+	}
+	capacities, err := newMutator(MissingPVCDeny).volumesCapacity(ctx, pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capacities[deviceClass3] != 2<<30 {
+		t.Errorf("unexpected capacities: %v", capacities)
+	}
+
+	// the policy is applied to the PVC which is not resolved
+	pod.OwnerReferences = nil
+	capacities, err = newMutator(MissingPVCIgnore).volumesCapacity(ctx, pod)
+	if err != nil || len(capacities) != 0 {
+		t.Errorf("the missing PVC should be ignored: %v, %v", capacities, err)
+	}
+	if _, err := newMutator(MissingPVCDeny).volumesCapacity(ctx, pod); !errors.Is(err, errMissingPVC) {
+		t.Errorf("the missing PVC should be denied: %v", err)
+	}
+	capacities, err = newMutator(MissingPVCDefaultClass).volumesCapacity(ctx, pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capacities[deviceClass3] != topolvm.DefaultSize {
+		t.Errorf("the missing PVC should be counted with the default StorageClass: %v", capacities)
+	}
+}
+
+func TestPodMutator_claimUnderMigration(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := topolvmv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	migration := func(name, claim string, phase topolvmv1.MigrationPhase) *topolvmv1.LogicalVolumeMigration {
+		return &topolvmv1.LogicalVolumeMigration{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: topolvmv1.LogicalVolumeMigrationStatus{
+				Phase:          phase,
+				ClaimNamespace: "ns",
+				ClaimName:      claim,
+			},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		migration("transferring", "migrating", topolvmv1.MigrationPhaseTransferring),
+		migration("completed", "migrated", topolvmv1.MigrationPhaseCompleted),
+	).Build()
+	m := &podMutator{reader: c}
+
+	testCases := []struct {
+		claim  string
+		denied bool
+	}{
+		{"migrating", true},
+		{"migrated", false},
+		{"other", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.claim, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns"},
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: pvcSource(tc.claim)}},
+					},
+				},
+			}
+			reason, err := m.claimUnderMigration(ctx, pod)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (reason != "") != tc.denied {
+				t.Errorf("unexpected reason: %q", reason)
+			}
+		})
+	}
+}
+
+func TestPodMutator_claimUnderMigrationWithoutCRD(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := topolvmv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(context.Context, client.WithWatch, client.ObjectList, ...client.ListOption) error {
+			return &meta.NoKindMatchError{GroupKind: topolvmv1.GroupVersion.WithKind("LogicalVolumeMigration").GroupKind()}
+		},
+	}).Build()
+	m := &podMutator{reader: c}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: pvcSource("data")}},
+			},
+		},
+	}
+
+	reason, err := m.claimUnderMigration(ctx, pod)
+	if err != nil || reason != "" {
+		t.Errorf("pod should be allowed without the CRD: %q, %v", reason, err)
+	}
+
+	// LogicalVolumeMigration is not listed in the legacy API group.
+	t.Setenv("USE_LEGACY", "true")
+	m = &podMutator{reader: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()}
+	reason, err = m.claimUnderMigration(ctx, pod)
+	if err != nil || reason != "" {
+		t.Errorf("pod should be allowed in the legacy mode: %q, %v", reason, err)
+	}
+}
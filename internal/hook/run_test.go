@@ -37,7 +37,7 @@ func run(ctx context.Context, cfg *rest.Config, scheme *runtime.Scheme, opts *en
 
 	dec := admission.NewDecoder(scheme)
 	wh := mgr.GetWebhookServer()
-	wh.Register(podMutatingWebhookPath, PodMutator(mgr.GetClient(), mgr.GetAPIReader(), dec))
+	wh.Register(podMutatingWebhookPath, PodMutator(mgr.GetClient(), mgr.GetAPIReader(), dec, MissingPVCIgnore))
 
 	if err := mgr.Start(ctx); err != nil {
 		return err
@@ -0,0 +1,174 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var pvcvLogger = ctrl.Log.WithName("pvc-validator")
+
+//+kubebuilder:webhook:failurePolicy=fail,matchPolicy=equivalent,groups=core,resources=persistentvolumeclaims,verbs=create,versions=v1,name=pvc-hook.topolvm.io,path=/pvc/validate,mutating=false,sideEffects=none,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots;volumesnapshotcontents,verbs=get
+
+// pvcValidator validates PVCs for TopoLVM.
+type pvcValidator struct {
+	reader    client.Reader
+	apiReader client.Reader
+	decoder   admission.Decoder
+}
+
+// PVCValidator creates a validating webhook for PVCs.
+// VolumeSnapshots are read with apiReader not to watch them, as their CRDs may not be installed.
+func PVCValidator(r client.Reader, apiReader client.Reader, dec admission.Decoder) http.Handler {
+	return &webhook.Admission{
+		Handler: &pvcValidator{
+			reader:    r,
+			apiReader: apiReader,
+			decoder:   dec,
+		},
+	}
+}
+
+// Handle implements admission.Handler interface.
+func (v *pvcValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := v.decoder.Decode(req, pvc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if pvc.Namespace == "" {
+		pvc.Namespace = req.Namespace
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return admission.Allowed("no storage class")
+	}
+
+	var sc storagev1.StorageClass
+	if err := v.reader.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, &sc); err != nil {
+		if apierrs.IsNotFound(err) {
+			return admission.Allowed("storage class not found")
+		}
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if sc.Provisioner != topolvm.GetPluginName() {
+		return admission.Allowed("not a PVC for TopoLVM")
+	}
+
+	adv, err := readAdvertisement(ctx, v.reader)
+	if err != nil {
+		pvcvLogger.Error(err, "readAdvertisement failed")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	capacityResp := validateCapacity(pvc, &sc, adv)
+	if !capacityResp.Allowed {
+		return capacityResp
+	}
+
+	sourceDC, ok, err := v.snapshotDeviceClass(ctx, pvc)
+	if err != nil {
+		pvcvLogger.Error(err, "snapshotDeviceClass failed", "name", pvc.Name, "namespace", pvc.Namespace)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if ok && sourceDC != sc.Parameters[topolvm.GetDeviceClassKey()] {
+		return admission.Denied(fmt.Sprintf("device-class %q differs from %q of the source snapshot",
+			sc.Parameters[topolvm.GetDeviceClassKey()], sourceDC))
+	}
+	return admission.Allowed("").WithWarnings(capacityResp.Warnings...)
+}
+
+func validateCapacity(pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass, adv *advertisement) admission.Response {
+	// Nodes may not have started yet when TopoLVM is installed.
+	if len(adv.freeCapacities) == 0 {
+		return admission.Allowed("no node advertises device-classes")
+	}
+
+	var requested = topolvm.DefaultSize
+	if req, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		if req.Value() != 0 {
+			requested = req.Value()
+		}
+	}
+	dc := deviceClassOf(sc.Parameters)
+	capacity, ok := adv.freeCapacities[dc]
+	if !ok {
+		return admission.Denied(fmt.Sprintf("no node has device-class %q", dc))
+	}
+	// The free capacity may increase later, e.g. when volumes are deleted, so the PVC is only warned.
+	if uint64(requested) > capacity {
+		return admission.Allowed("").WithWarnings(fmt.Sprintf(
+			"requested size %d exceeds the largest free capacity %d of device-class %q among nodes; the PVC stays Pending until a node has enough free capacity",
+			requested, capacity, dc))
+	}
+	return admission.Allowed("")
+}
+
+// snapshotDeviceClass returns the device-class of the LogicalVolume of the VolumeSnapshot which the PVC is restored from.
+// It returns false if the PVC is not restored from a snapshot of TopoLVM, or the snapshot is not ready yet.
+func (v *pvcValidator) snapshotDeviceClass(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (string, bool, error) {
+	namespace := pvc.Namespace
+	var name string
+	switch {
+	case pvc.Spec.DataSourceRef != nil:
+		ref := pvc.Spec.DataSourceRef
+		if ref.APIGroup == nil || *ref.APIGroup != snapapi.GroupName || ref.Kind != "VolumeSnapshot" {
+			return "", false, nil
+		}
+		if ref.Namespace != nil && *ref.Namespace != "" {
+			namespace = *ref.Namespace
+		}
+		name = ref.Name
+	case pvc.Spec.DataSource != nil:
+		ref := pvc.Spec.DataSource
+		if ref.APIGroup == nil || *ref.APIGroup != snapapi.GroupName || ref.Kind != "VolumeSnapshot" {
+			return "", false, nil
+		}
+		name = ref.Name
+	default:
+		return "", false, nil
+	}
+
+	var vs snapapi.VolumeSnapshot
+	if err := v.apiReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &vs); err != nil {
+		if apierrs.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if vs.Status == nil || vs.Status.BoundVolumeSnapshotContentName == nil {
+		return "", false, nil
+	}
+	var content snapapi.VolumeSnapshotContent
+	if err := v.apiReader.Get(ctx, types.NamespacedName{Name: *vs.Status.BoundVolumeSnapshotContentName}, &content); err != nil {
+		if apierrs.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if content.Spec.Driver != topolvm.GetPluginName() || content.Status == nil || content.Status.SnapshotHandle == nil {
+		return "", false, nil
+	}
+
+	var lvs topolvmv1.LogicalVolumeList
+	if err := v.reader.List(ctx, &lvs); err != nil {
+		return "", false, err
+	}
+	for _, lv := range lvs.Items {
+		if lv.Status.VolumeID == *content.Status.SnapshotHandle {
+			return lv.Spec.DeviceClass, true, nil
+		}
+	}
+	return "", false, nil
+}
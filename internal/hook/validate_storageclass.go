@@ -0,0 +1,76 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/topolvm/topolvm"
+	storagev1 "k8s.io/api/storage/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var scvLogger = ctrl.Log.WithName("storageclass-validator")
+
+//+kubebuilder:webhook:failurePolicy=fail,matchPolicy=equivalent,groups=storage.k8s.io,resources=storageclasses,verbs=create,versions=v1,name=storageclass-hook.topolvm.io,path=/storageclass/validate,mutating=false,sideEffects=none,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+
+// storageClassValidator validates StorageClasses for TopoLVM.
+type storageClassValidator struct {
+	reader  client.Reader
+	decoder admission.Decoder
+}
+
+// StorageClassValidator creates a validating webhook for StorageClasses.
+func StorageClassValidator(r client.Reader, dec admission.Decoder) http.Handler {
+	return &webhook.Admission{
+		Handler: &storageClassValidator{
+			reader:  r,
+			decoder: dec,
+		},
+	}
+}
+
+// Handle implements admission.Handler interface.
+func (v *storageClassValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	sc := &storagev1.StorageClass{}
+	if err := v.decoder.Decode(req, sc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if sc.Provisioner != topolvm.GetPluginName() {
+		return admission.Allowed("not a StorageClass for TopoLVM")
+	}
+
+	adv, err := readAdvertisement(ctx, v.reader)
+	if err != nil {
+		scvLogger.Error(err, "readAdvertisement failed")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return validateStorageClass(sc, adv)
+}
+
+func validateStorageClass(sc *storagev1.StorageClass, adv *advertisement) admission.Response {
+	// Nodes may not have started yet when TopoLVM and its StorageClasses are installed at once.
+	if len(adv.freeCapacities) == 0 {
+		return admission.Allowed("no node advertises device-classes")
+	}
+
+	dc := deviceClassOf(sc.Parameters)
+	if _, ok := adv.freeCapacities[dc]; !ok {
+		if dc == topolvm.DefaultDeviceClassAnnotationName {
+			return admission.Denied("no node has the default device-class")
+		}
+		return admission.Denied(fmt.Sprintf("no node has device-class %q", dc))
+	}
+
+	oc, ok := sc.Parameters[topolvm.GetLvcreateOptionClassKey()]
+	if ok && oc != "" && adv.optionClassesPublished {
+		if _, ok := adv.optionClasses[oc]; !ok {
+			return admission.Denied(fmt.Sprintf("no node has lvcreate-option-class %q", oc))
+		}
+	}
+	return admission.Allowed("")
+}
@@ -0,0 +1,189 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testValidationNode(name string, capacities map[string]uint64, optionClasses *string) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{},
+		},
+	}
+	for dc, capacity := range capacities {
+		node.Annotations[topolvm.GetCapacityKeyPrefix()+dc] = fmt.Sprintf("%d", capacity)
+	}
+	if optionClasses != nil {
+		node.Annotations[topolvm.GetLvcreateOptionClassesKey()] = *optionClasses
+	}
+	return node
+}
+
+func testValidationClient(t *testing.T, objects ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, topolvmv1.AddToScheme, snapapi.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).WithStatusSubresource(&topolvmv1.LogicalVolume{}).Build()
+}
+
+func TestReadAdvertisement(t *testing.T) {
+	c := testValidationClient(t,
+		testValidationNode("node1", map[string]uint64{"ssd": 5 << 30, topolvm.DefaultDeviceClassAnnotationName: 5 << 30}, ptr.To("raid1,striped")),
+		testValidationNode("node2", map[string]uint64{"ssd": 10 << 30, "hdd": 20 << 30}, ptr.To("")),
+	)
+	adv, err := readAdvertisement(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]uint64{"ssd": 10 << 30, "hdd": 20 << 30, topolvm.DefaultDeviceClassAnnotationName: 5 << 30}
+	if fmt.Sprint(adv.freeCapacities) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, but was %v", expected, adv.freeCapacities)
+	}
+	if !adv.optionClassesPublished || len(adv.optionClasses) != 2 {
+		t.Errorf("unexpected option classes: %v", adv.optionClasses)
+	}
+}
+
+func TestValidateStorageClass(t *testing.T) {
+	adv := &advertisement{
+		freeCapacities:         map[string]uint64{"ssd": 10 << 30, topolvm.DefaultDeviceClassAnnotationName: 5 << 30},
+		optionClasses:          map[string]struct{}{"raid1": {}},
+		optionClassesPublished: true,
+	}
+	testCases := []struct {
+		parameters map[string]string
+		adv        *advertisement
+		allowed    bool
+	}{
+		{map[string]string{}, adv, true},
+		{map[string]string{topolvm.GetDeviceClassKey(): "ssd"}, adv, true},
+		{map[string]string{topolvm.GetDeviceClassKey(): "sdd"}, adv, false},
+		{map[string]string{topolvm.GetDeviceClassKey(): "ssd", topolvm.GetLvcreateOptionClassKey(): "raid1"}, adv, true},
+		{map[string]string{topolvm.GetDeviceClassKey(): "ssd", topolvm.GetLvcreateOptionClassKey(): "raid2"}, adv, false},
+		// older nodes do not publish lvcreate-option-classes
+		{map[string]string{topolvm.GetLvcreateOptionClassKey(): "raid2"}, &advertisement{freeCapacities: adv.freeCapacities}, true},
+		// no node has started yet
+		{map[string]string{topolvm.GetDeviceClassKey(): "sdd"}, &advertisement{freeCapacities: map[string]uint64{}}, true},
+	}
+	for _, tc := range testCases {
+		sc := &storagev1.StorageClass{Provisioner: topolvm.GetPluginName(), Parameters: tc.parameters}
+		resp := validateStorageClass(sc, tc.adv)
+		if resp.Allowed != tc.allowed {
+			t.Errorf("parameters %v: expected allowed=%v, but was %v: %v", tc.parameters, tc.allowed, resp.Allowed, resp.Result)
+		}
+	}
+}
+
+func TestValidateCapacity(t *testing.T) {
+	adv := &advertisement{
+		freeCapacities: map[string]uint64{"ssd": 10 << 30},
+	}
+	sc := &storagev1.StorageClass{
+		Provisioner: topolvm.GetPluginName(),
+		Parameters:  map[string]string{topolvm.GetDeviceClassKey(): "ssd"},
+	}
+	for _, tc := range []struct {
+		requested string
+		warned    bool
+	}{
+		{"10Gi", false},
+		// the free capacity may increase later, so the PVC is allowed with a warning
+		{"11Gi", true},
+		{"", false},
+	} {
+		pvc := &corev1.PersistentVolumeClaim{}
+		if tc.requested != "" {
+			pvc.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(tc.requested)}
+		}
+		resp := validateCapacity(pvc, sc, adv)
+		if !resp.Allowed {
+			t.Errorf("requested %q: should be allowed: %v", tc.requested, resp.Result)
+		}
+		if warned := len(resp.Warnings) != 0; warned != tc.warned {
+			t.Errorf("requested %q: expected warned=%v, but was %v", tc.requested, tc.warned, resp.Warnings)
+		}
+	}
+
+	// the default device-class is not advertised
+	resp := validateCapacity(&corev1.PersistentVolumeClaim{}, &storagev1.StorageClass{Provisioner: topolvm.GetPluginName()}, adv)
+	if resp.Allowed {
+		t.Error("PVC of the device-class which no node has should be denied")
+	}
+}
+
+func TestSnapshotDeviceClass(t *testing.T) {
+	ctx := context.Background()
+	lv := &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "snapshot-1"},
+		Spec:       topolvmv1.LogicalVolumeSpec{Name: "snapshot-1", DeviceClass: "ssd"},
+	}
+	vs := &snapapi.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "snap", Namespace: "ns"},
+	}
+	content := &snapapi.VolumeSnapshotContent{
+		ObjectMeta: metav1.ObjectMeta{Name: "content"},
+		Spec:       snapapi.VolumeSnapshotContentSpec{Driver: topolvm.GetPluginName()},
+	}
+	c := testValidationClient(t, lv, vs, content)
+	lv.Status.VolumeID = "snapshot-id"
+	if err := c.Status().Update(ctx, lv); err != nil {
+		t.Fatal(err)
+	}
+	vs.Status = &snapapi.VolumeSnapshotStatus{BoundVolumeSnapshotContentName: ptr.To("content")}
+	if err := c.Update(ctx, vs); err != nil {
+		t.Fatal(err)
+	}
+	content.Status = &snapapi.VolumeSnapshotContentStatus{SnapshotHandle: ptr.To("snapshot-id")}
+	if err := c.Update(ctx, content); err != nil {
+		t.Fatal(err)
+	}
+	v := &pvcValidator{reader: c, apiReader: c}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			DataSource: &corev1.TypedLocalObjectReference{
+				APIGroup: ptr.To(snapapi.GroupName),
+				Kind:     "VolumeSnapshot",
+				Name:     "snap",
+			},
+		},
+	}
+	dc, ok, err := v.snapshotDeviceClass(ctx, pvc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || dc != "ssd" {
+		t.Errorf("unexpected device-class: %q, %v", dc, ok)
+	}
+
+	pvc.Spec.DataSource.Name = "missing"
+	if _, ok, err := v.snapshotDeviceClass(ctx, pvc); err != nil || ok {
+		t.Errorf("missing snapshot should be ignored: %v, %v", ok, err)
+	}
+
+	pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{Kind: "PersistentVolumeClaim", Name: "source"}
+	if _, ok, err := v.snapshotDeviceClass(ctx, pvc); err != nil || ok {
+		t.Errorf("PVC not restored from a snapshot should be ignored: %v, %v", ok, err)
+	}
+}
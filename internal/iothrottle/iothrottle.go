@@ -0,0 +1,195 @@
+package iothrottle
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"golang.org/x/sys/unix"
+	mountutil "k8s.io/mount-utils"
+)
+
+const (
+	cgroupRoot = "/sys/fs/cgroup"
+	ioMaxFile  = "io.max"
+
+	// kubelet nests the pod cgroups under a few levels of the QoS classes and its own cgroup root.
+	maxPodCgroupDepth = 4
+)
+
+// ErrPodCgroupNotFound represents the cgroup v2 of the pod is not found.
+var ErrPodCgroupNotFound = errors.New("cgroup of pod is not found")
+
+var (
+	podUIDPattern = regexp.MustCompile(`^[0-9a-f-]+$`)
+
+	// The target paths of kubelet are "<kubelet dir>/pods/<pod UID>/volumes/kubernetes.io~csi/<PV name>/mount"
+	// for filesystem volumes, and "<kubelet dir>/plugins/kubernetes.io/csi/volumeDevices/publish/<PV name>/<pod UID>"
+	// for block volumes.
+	fsTargetPathPattern    = regexp.MustCompile(`/pods/([^/]+)/volumes/kubernetes\.io~csi/([^/]+)/mount$`)
+	blockTargetPathPattern = regexp.MustCompile(`/volumeDevices/publish/([^/]+)/([^/]+)$`)
+)
+
+// Publication is a volume published to a pod.
+type Publication struct {
+	VolumeName string
+	PodUID     string
+	TargetPath string
+}
+
+// ParseTargetPath returns the name of the PersistentVolume and the UID of the pod from a target path of kubelet.
+func ParseTargetPath(targetPath string) (volumeName, podUID string, ok bool) {
+	if m := fsTargetPathPattern.FindStringSubmatch(targetPath); m != nil {
+		return m[2], m[1], true
+	}
+	if m := blockTargetPathPattern.FindStringSubmatch(targetPath); m != nil {
+		return m[1], m[2], true
+	}
+	return "", "", false
+}
+
+// Publications returns the volumes published to the pods on this node, found in /proc/self/mountinfo.
+func Publications() ([]Publication, error) {
+	mountInfos, err := mountutil.ParseMountInfo("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	return publicationsOf(mountInfos), nil
+}
+
+func publicationsOf(mountInfos []mountutil.MountInfo) []Publication {
+	var publications []Publication
+	for _, mi := range mountInfos {
+		volumeName, podUID, ok := ParseTargetPath(mi.MountPoint)
+		if !ok {
+			continue
+		}
+		publications = append(publications, Publication{
+			VolumeName: volumeName,
+			PodUID:     podUID,
+			TargetPath: mi.MountPoint,
+		})
+	}
+	return publications
+}
+
+// DeviceOf returns the device number of the block device published on the target path.
+// It is the device of the filesystem for a filesystem volume, or the device file itself for a block volume.
+func DeviceOf(targetPath string) (major, minor uint32, err error) {
+	var st unix.Stat_t
+	if err := unix.Stat(targetPath, &st); err != nil {
+		return 0, 0, fmt.Errorf("failed to stat %s: %w", targetPath, err)
+	}
+	dev := st.Dev
+	if st.Mode&unix.S_IFMT == unix.S_IFBLK {
+		dev = st.Rdev
+	}
+	return unix.Major(dev), unix.Minor(dev), nil
+}
+
+// Apply sets the IO limits of the device in io.max of the cgroup of the pod.
+// The limits of the device are removed if limits is nil or has no limits.
+// It does nothing if io.max already has the limits.
+func Apply(podUID string, major, minor uint32, limits *topolvmv1.IOLimits) error {
+	cgroup, err := findPodCgroup(cgroupRoot, podUID)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(cgroup, ioMaxFile)
+	current, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		if !hasLimits(limits) {
+			return nil
+		}
+		return fmt.Errorf("io controller is not enabled for cgroup %s", cgroup)
+	}
+	if err != nil {
+		return err
+	}
+
+	line := ioMaxLine(major, minor, limits)
+	existing := ioMaxLineOf(string(current), major, minor)
+	if existing == line || (existing == "" && !hasLimits(limits)) {
+		return nil
+	}
+	if err := os.WriteFile(file, []byte(line), 0644); err != nil {
+		return fmt.Errorf("failed to write %q to %s: %w", line, file, err)
+	}
+	return nil
+}
+
+// findPodCgroup returns the directory of the cgroup of the pod.
+// The cgroup is named "pod<UID>" by the cgroupfs driver of kubelet,
+// or "kubepods-<QoS class>-pod<UID with underscores>.slice" by the systemd driver.
+func findPodCgroup(root, podUID string) (string, error) {
+	if !podUIDPattern.MatchString(podUID) {
+		return "", fmt.Errorf("invalid pod UID: %q", podUID)
+	}
+	cgroupfsName := "pod" + podUID
+	systemdSuffix := "-pod" + strings.ReplaceAll(podUID, "-", "_") + ".slice"
+
+	var found string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if name := d.Name(); name == cgroupfsName || strings.HasSuffix(name, systemdSuffix) {
+			found = path
+			return fs.SkipAll
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." && strings.Count(rel, string(filepath.Separator)) >= maxPodCgroupDepth-1 {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", fmt.Errorf("%w: %s", ErrPodCgroupNotFound, podUID)
+	}
+	return found, nil
+}
+
+func hasLimits(limits *topolvmv1.IOLimits) bool {
+	return limits != nil && *limits != topolvmv1.IOLimits{}
+}
+
+// ioMaxLine returns the line of io.max for the limits of the device, in the same format as the kernel prints.
+func ioMaxLine(major, minor uint32, limits *topolvmv1.IOLimits) string {
+	if limits == nil {
+		limits = &topolvmv1.IOLimits{}
+	}
+	value := func(v int64) string {
+		if v <= 0 {
+			return "max"
+		}
+		return strconv.FormatInt(v, 10)
+	}
+	return fmt.Sprintf("%d:%d rbps=%s wbps=%s riops=%s wiops=%s", major, minor,
+		value(limits.ReadBytesPerSecond), value(limits.WriteBytesPerSecond), value(limits.ReadIOPS), value(limits.WriteIOPS))
+}
+
+// ioMaxLineOf returns the line of the device in the content of io.max, or an empty string if the device has no limits.
+func ioMaxLineOf(content string, major, minor uint32) string {
+	prefix := fmt.Sprintf("%d:%d ", major, minor)
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSpace(line)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package iothrottle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	mountutil "k8s.io/mount-utils"
+)
+
+func TestParseTargetPath(t *testing.T) {
+	testCases := []struct {
+		path       string
+		volumeName string
+		podUID     string
+		ok         bool
+	}{
+		{"/var/lib/kubelet/pods/0a1b2c3d-0000-1111-2222-333344445555/volumes/kubernetes.io~csi/pvc-1/mount", "pvc-1", "0a1b2c3d-0000-1111-2222-333344445555", true},
+		{"/var/lib/kubelet/plugins/kubernetes.io/csi/volumeDevices/publish/pvc-2/0a1b2c3d-0000-1111-2222-333344445555", "pvc-2", "0a1b2c3d-0000-1111-2222-333344445555", true},
+		{"/var/lib/kubelet/plugins/kubernetes.io/csi/topolvm.io/abc/globalmount", "", "", false},
+		{"/", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		volumeName, podUID, ok := ParseTargetPath(tc.path)
+		if volumeName != tc.volumeName || podUID != tc.podUID || ok != tc.ok {
+			t.Errorf("%s: expected (%q, %q, %v), but was (%q, %q, %v)", tc.path, tc.volumeName, tc.podUID, tc.ok, volumeName, podUID, ok)
+		}
+	}
+}
+
+func TestPublicationsOf(t *testing.T) {
+	mountInfos := []mountutil.MountInfo{
+		{MountPoint: "/"},
+		{MountPoint: "/var/lib/kubelet/pods/a/volumes/kubernetes.io~csi/pvc-1/mount"},
+		{MountPoint: "/var/lib/kubelet/pods/b/volumes/kubernetes.io~empty-dir/cache"},
+		{MountPoint: "/var/lib/kubelet/plugins/kubernetes.io/csi/volumeDevices/publish/pvc-2/c"},
+	}
+
+	expected := []Publication{
+		{VolumeName: "pvc-1", PodUID: "a", TargetPath: "/var/lib/kubelet/pods/a/volumes/kubernetes.io~csi/pvc-1/mount"},
+		{VolumeName: "pvc-2", PodUID: "c", TargetPath: "/var/lib/kubelet/plugins/kubernetes.io/csi/volumeDevices/publish/pvc-2/c"},
+	}
+	if actual := publicationsOf(mountInfos); !slices.Equal(actual, expected) {
+		t.Errorf("expected %v, but was %v", expected, actual)
+	}
+}
+
+func TestFindPodCgroup(t *testing.T) {
+	root := t.TempDir()
+	dirs := []string{
+		// systemd driver
+		"kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod0a1b2c3d_0000_1111_2222_333344445555.slice/cri-containerd-abc.scope",
+		// cgroupfs driver nested in the cgroup root of kubelet
+		"kubelet/kubepods/besteffort/pod9f8e7d6c-0000-1111-2222-333344445555",
+		"system.slice/containerd.service",
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		podUID   string
+		expected string
+		err      error
+	}{
+		{"0a1b2c3d-0000-1111-2222-333344445555", "kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod0a1b2c3d_0000_1111_2222_333344445555.slice", nil},
+		{"9f8e7d6c-0000-1111-2222-333344445555", "kubelet/kubepods/besteffort/pod9f8e7d6c-0000-1111-2222-333344445555", nil},
+		{"ffffffff-0000-1111-2222-333344445555", "", ErrPodCgroupNotFound},
+	}
+
+	for _, tc := range testCases {
+		actual, err := findPodCgroup(root, tc.podUID)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected error %v, but was %v", tc.podUID, tc.err, err)
+			continue
+		}
+		if tc.err == nil && actual != filepath.Join(root, tc.expected) {
+			t.Errorf("%s: expected %s, but was %s", tc.podUID, tc.expected, actual)
+		}
+	}
+
+	if _, err := findPodCgroup(root, "../../etc"); err == nil {
+		t.Error("invalid pod UID should be rejected")
+	}
+}
+
+func TestIOMaxLine(t *testing.T) {
+	if line := ioMaxLine(253, 3, &topolvmv1.IOLimits{ReadIOPS: 1000, WriteBytesPerSecond: 1048576}); line != "253:3 rbps=max wbps=1048576 riops=1000 wiops=max" {
+		t.Errorf("unexpected line: %s", line)
+	}
+	if line := ioMaxLine(253, 3, nil); line != "253:3 rbps=max wbps=max riops=max wiops=max" {
+		t.Errorf("unexpected line: %s", line)
+	}
+
+	content := "8:0 rbps=max wbps=max riops=100 wiops=max\n253:3 rbps=max wbps=1048576 riops=1000 wiops=max\n"
+	if line := ioMaxLineOf(content, 253, 3); line != "253:3 rbps=max wbps=1048576 riops=1000 wiops=max" {
+		t.Errorf("unexpected line: %s", line)
+	}
+	if line := ioMaxLineOf(content, 253, 30); line != "" {
+		t.Errorf("unexpected line: %s", line)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math"
 	"path"
+	"strconv"
+	"strings"
 
 	"github.com/topolvm/topolvm"
 )
@@ -173,14 +175,23 @@ func (vg *VolumeGroup) convertLV(lv lv) *LogicalVolume {
 		origin = &lv.origin
 	}
 
-	var pool *string
+	var pool, cache *string
 	if len(lv.poolLV) > 0 {
-		pool = &lv.poolLV
+		if strings.HasPrefix(lv.poolLV, "[") {
+			// an attached cache volume is hidden, so its name is reported in brackets.
+			name := strings.Trim(lv.poolLV, "[]")
+			cache = &name
+		} else {
+			pool = &lv.poolLV
+		}
 	}
 
+	var cowSize uint64
 	if origin != nil && pool == nil {
 		// this volume is a snapshot, but not a thin volume.
+		// lv_size reports the size of its copy-on-write area in this case.
 		size = lv.originSize
+		cowSize = lv.size
 	}
 
 	return &LogicalVolume{
@@ -189,8 +200,10 @@ func (vg *VolumeGroup) convertLV(lv lv) *LogicalVolume {
 		lv.path,
 		vg,
 		size,
+		cowSize,
 		origin,
 		pool,
+		cache,
 		uint32(lv.major),
 		uint32(lv.minor),
 		lv.tags,
@@ -204,6 +217,13 @@ func (vg *VolumeGroup) convertLV(lv lv) *LogicalVolume {
 // lvcreateOptions are additional arguments to pass to lvcreate.
 func (vg *VolumeGroup) CreateVolume(ctx context.Context, name string, size uint64, tags []string, stripe uint, stripeSize string,
 	lvcreateOptions []string) error {
+	return vg.createVolume(ctx, name, size, tags, stripe, stripeSize, lvcreateOptions, nil)
+}
+
+// createVolume creates logical volume in this volume group.
+// If pvs is not empty, the volume is allocated only from the given physical volumes.
+func (vg *VolumeGroup) createVolume(ctx context.Context, name string, size uint64, tags []string, stripe uint, stripeSize string,
+	lvcreateOptions []string, pvs []string) error {
 
 	if size%uint64(topolvm.MinimumSectorSize) != 0 {
 		return ErrNoMultipleOfSectorSize
@@ -223,6 +243,7 @@ func (vg *VolumeGroup) CreateVolume(ctx context.Context, name string, size uint6
 	}
 	lvcreateArgs = append(lvcreateArgs, lvcreateOptions...)
 	lvcreateArgs = append(lvcreateArgs, vg.Name())
+	lvcreateArgs = append(lvcreateArgs, pvs...)
 
 	return callLVM(ctx, lvcreateArgs...)
 }
@@ -354,6 +375,36 @@ func (t *ThinPool) Resize(ctx context.Context, newSize uint64) error {
 	return nil
 }
 
+// MetadataSize returns the size of the metadata space of the thin pool.
+func (t *ThinPool) MetadataSize(ctx context.Context) (uint64, error) {
+	type metadataReport struct {
+		Report []struct {
+			LV []struct {
+				MetadataSize string `json:"lv_metadata_size"`
+			} `json:"lv"`
+		} `json:"report"`
+	}
+
+	res := new(metadataReport)
+	err := callLVMInto(ctx, res, verbosityLVMStateNoUpdate,
+		"lvs", t.state.fullName, "-o", "lv_metadata_size", "--units", "b", "--nosuffix", "--reportformat", "json")
+	if err != nil {
+		return 0, err
+	}
+	if len(res.Report) == 0 || len(res.Report[0].LV) == 0 {
+		return 0, ErrNotFound
+	}
+	return strconv.ParseUint(res.Report[0].LV[0].MetadataSize, 10, 64)
+}
+
+// ResizeMetadata extends the metadata space of the thin pool.
+func (t *ThinPool) ResizeMetadata(ctx context.Context, newSize uint64) error {
+	if newSize%uint64(topolvm.MinimumSectorSize) != 0 {
+		return ErrNoMultipleOfSectorSize
+	}
+	return callLVM(ctx, "lvextend", "--poolmetadatasize", fmt.Sprintf("%vb", newSize), t.state.fullName)
+}
+
 // ListVolumes lists all volumes in this thin pool.
 func (t *ThinPool) ListVolumes(ctx context.Context) (map[string]*LogicalVolume, error) {
 	volumes, err := t.vg.ListVolumes(ctx)
@@ -437,8 +488,10 @@ type LogicalVolume struct {
 	path     string
 	vg       *VolumeGroup
 	size     uint64
+	cowSize  uint64
 	origin   *string
 	pool     *string
+	cache    *string
 	devMajor uint32
 	devMinor uint32
 	tags     []string
@@ -483,6 +536,31 @@ func (l *LogicalVolume) Origin(ctx context.Context) (*LogicalVolume, error) {
 	return l.vg.FindVolume(ctx, *l.origin)
 }
 
+// IsThickSnapshot checks if the volume is a thick (copy-on-write) snapshot or not.
+func (l *LogicalVolume) IsThickSnapshot() bool {
+	return l.origin != nil && l.pool == nil
+}
+
+// COWSize returns the size of the copy-on-write area if this is a thick snapshot, or 0 if not.
+func (l *LogicalVolume) COWSize() uint64 {
+	return l.cowSize
+}
+
+// ThickSnapshots returns the thick snapshots whose origin is this volume.
+func (l *LogicalVolume) ThickSnapshots(ctx context.Context) ([]*LogicalVolume, error) {
+	volumes, err := l.vg.ListVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []*LogicalVolume
+	for _, v := range volumes {
+		if v.IsThickSnapshot() && *v.origin == l.name {
+			snapshots = append(snapshots, v)
+		}
+	}
+	return snapshots, nil
+}
+
 // IsThin checks if the volume is thin volume or not.
 func (l *LogicalVolume) IsThin() bool {
 	return l.attr[0] == byte(VolumeTypeThinVolume)
@@ -534,6 +612,31 @@ func (l *LogicalVolume) ThinSnapshot(ctx context.Context, name string, tags []st
 	return callLVM(ctx, lvcreateArgs...)
 }
 
+// Snapshot takes a thick (copy-on-write) snapshot of a volume.
+// The volume must be thickly-provisioned and must not be a snapshot itself.
+// cowSize is the size of the copy-on-write area in bytes. LVM invalidates
+// the snapshot once the changes to the origin exceed this area.
+// If pvs is given, the copy-on-write area is allocated only from the given physical volumes.
+func (l *LogicalVolume) Snapshot(ctx context.Context, name string, cowSize uint64, tags []string, pvs ...string) error {
+	if l.IsThin() {
+		return fmt.Errorf("cannot take thick snapshot of thin volume: %s", l.fullname)
+	}
+	if l.IsSnapshot() {
+		return fmt.Errorf("cannot take thick snapshot of snapshot volume: %s", l.fullname)
+	}
+
+	lvcreateArgs := []string{"lvcreate", "-s", "-n", name, "-L", fmt.Sprintf("%vb", cowSize)}
+
+	for _, tag := range tags {
+		lvcreateArgs = append(lvcreateArgs, "--addtag")
+		lvcreateArgs = append(lvcreateArgs, tag)
+	}
+	lvcreateArgs = append(lvcreateArgs, l.fullname)
+	lvcreateArgs = append(lvcreateArgs, pvs...)
+
+	return callLVM(ctx, lvcreateArgs...)
+}
+
 // Activate activates the logical volume for desired access.
 func (l *LogicalVolume) Activate(ctx context.Context, access string) error {
 	var lvchangeArgs []string
@@ -549,16 +652,50 @@ func (l *LogicalVolume) Activate(ctx context.Context, access string) error {
 	return callLVM(ctx, lvchangeArgs...)
 }
 
+// Change changes the properties of the logical volume with the extra arguments to pass to lvchange.
+func (l *LogicalVolume) Change(ctx context.Context, lvchangeOptions []string) error {
+	args := append([]string{"lvchange"}, lvchangeOptions...)
+	return callLVM(ctx, append(args, l.fullname)...)
+}
+
+// AddTags adds the tags to the logical volume.
+func (l *LogicalVolume) AddTags(ctx context.Context, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	args := []string{"lvchange"}
+	for _, tag := range tags {
+		args = append(args, "--addtag", tag)
+	}
+	if err := callLVM(ctx, append(args, l.fullname)...); err != nil {
+		return err
+	}
+	l.tags = append(l.tags, tags...)
+	return nil
+}
+
+// Deactivate deactivates the logical volume.
+// Deactivating the origin of thick snapshots deactivates the snapshots as well.
+func (l *LogicalVolume) Deactivate(ctx context.Context) error {
+	return callLVM(ctx, "lvchange", "-a", "n", l.fullname)
+}
+
 // Resize this volume.
 // newSize is a new size of this volume in bytes.
-func (l *LogicalVolume) Resize(ctx context.Context, newSize uint64) error {
+// Thick snapshots cannot be resized with this method, use ResizeCOW instead.
+// If pvs is given, the volume is extended only onto the given physical volumes.
+func (l *LogicalVolume) Resize(ctx context.Context, newSize uint64, pvs ...string) error {
+	if l.IsThickSnapshot() {
+		return fmt.Errorf("cannot resize thick snapshot volume: %s", l.fullname)
+	}
 	if l.size > newSize {
 		return fmt.Errorf("volume cannot be shrunk")
 	}
 	if l.size == newSize {
 		return nil
 	}
-	if err := callLVM(ctx, "lvresize", "-L", fmt.Sprintf("%vb", newSize), l.fullname); err != nil {
+	args := append([]string{"lvresize", "-L", fmt.Sprintf("%vb", newSize), l.fullname}, pvs...)
+	if err := callLVM(ctx, args...); err != nil {
 		return err
 	}
 
@@ -572,6 +709,31 @@ func (l *LogicalVolume) Resize(ctx context.Context, newSize uint64) error {
 	return nil
 }
 
+// ResizeCOW resizes the copy-on-write area of this thick snapshot.
+// newSize is a new size of the copy-on-write area in bytes.
+func (l *LogicalVolume) ResizeCOW(ctx context.Context, newSize uint64) error {
+	if !l.IsThickSnapshot() {
+		return fmt.Errorf("volume is not a thick snapshot: %s", l.fullname)
+	}
+	if l.cowSize > newSize {
+		return fmt.Errorf("copy-on-write area cannot be shrunk")
+	}
+	if l.cowSize == newSize {
+		return nil
+	}
+	if err := callLVM(ctx, "lvresize", "-L", fmt.Sprintf("%vb", newSize), l.fullname); err != nil {
+		return err
+	}
+
+	vol, err := l.vg.FindVolume(ctx, l.name)
+	if err != nil {
+		return err
+	}
+	l.cowSize = vol.cowSize
+
+	return nil
+}
+
 // RemoveVolume removes the given volume from the volume group.
 func (vg *VolumeGroup) RemoveVolume(ctx context.Context, name string) error {
 	err := callLVM(ctx, "lvremove", "-f", fullName(name, vg))
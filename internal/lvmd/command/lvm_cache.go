@@ -0,0 +1,188 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// CacheModeWritecache is the cache mode which attaches a dm-writecache instead of a dm-cache.
+const CacheModeWritecache = "writecache"
+
+// CacheStats holds the statistics of the cache of a logical volume.
+// dm-writecache reports the usage of the cache blocks only, so the hits and misses are zero for it.
+type CacheStats struct {
+	ReadHits    uint64
+	ReadMisses  uint64
+	WriteHits   uint64
+	WriteMisses uint64
+	DirtyBlocks uint64
+	UsedBlocks  uint64
+	TotalBlocks uint64
+}
+
+// CreateCachedVolume creates a logical volume on originPVs and attaches a cache volume of cacheSize bytes
+// created on cachePVs. mode is "writeback" or "writethrough" for dm-cache, or "writecache" for dm-writecache.
+func (vg *VolumeGroup) CreateCachedVolume(ctx context.Context, name string, size uint64, tags []string, stripe uint, stripeSize string,
+	lvcreateOptions []string, originPVs []string, cacheSize uint64, cachePVs []string, mode string) error {
+	if err := vg.createVolume(ctx, name, size, tags, stripe, stripeSize, lvcreateOptions, originPVs); err != nil {
+		return err
+	}
+	if err := vg.attachCache(ctx, name, cacheSize, cachePVs, mode); err != nil {
+		return errors.Join(err, vg.RemoveVolume(ctx, name))
+	}
+	return nil
+}
+
+// attachCache creates a cache volume on cachePVs and attaches it to the named volume.
+// LVM hides the cache volume once it is attached, and removes it together with the volume.
+func (vg *VolumeGroup) attachCache(ctx context.Context, name string, cacheSize uint64, cachePVs []string, mode string) error {
+	cacheName := name + "-cache"
+	if err := vg.createVolume(ctx, cacheName, cacheSize, nil, 0, "", nil, cachePVs); err != nil {
+		return fmt.Errorf("failed to create cache volume: %w", err)
+	}
+
+	args := []string{"lvconvert", "-y"}
+	if mode == CacheModeWritecache {
+		args = append(args, "--type", "writecache")
+	} else {
+		args = append(args, "--type", "cache", "--cachemode", mode)
+	}
+	args = append(args, "--cachevol", fullName(cacheName, vg), fullName(name, vg))
+	if err := callLVM(ctx, args...); err != nil {
+		return errors.Join(fmt.Errorf("failed to attach cache volume: %w", err), vg.RemoveVolume(ctx, cacheName))
+	}
+	return nil
+}
+
+// IsCached checks if the volume has an attached cache volume or not.
+func (l *LogicalVolume) IsCached() bool {
+	return l.cache != nil
+}
+
+// ResizeCached resizes this volume of a cached device class.
+// LVM cannot resize every kind of cached volume, so the cache is detached first, which flushes its dirty blocks.
+// Then the volume is extended onto originPVs and a new cache volume of cacheSize bytes is attached.
+func (l *LogicalVolume) ResizeCached(ctx context.Context, newSize uint64, originPVs []string, cacheSize uint64, cachePVs []string, mode string) error {
+	if l.size > newSize {
+		return fmt.Errorf("volume cannot be shrunk")
+	}
+	if l.size == newSize && l.cache != nil {
+		return nil
+	}
+
+	if l.cache != nil {
+		if err := callLVM(ctx, "lvconvert", "-y", "--uncache", l.fullname); err != nil {
+			return fmt.Errorf("failed to detach cache volume: %w", err)
+		}
+		l.cache = nil
+	}
+	if err := l.Resize(ctx, newSize, originPVs...); err != nil {
+		return err
+	}
+	if err := l.vg.attachCache(ctx, l.name, cacheSize, cachePVs, mode); err != nil {
+		return err
+	}
+	cache := l.name + "-cache"
+	l.cache = &cache
+	return nil
+}
+
+// CacheMode returns the cache mode of this cached volume, "writeback" or "writethrough" for dm-cache.
+// An empty string is returned for dm-writecache, which does not have a cache mode.
+func (l *LogicalVolume) CacheMode(ctx context.Context) (string, error) {
+	type cacheModeReport struct {
+		Report []struct {
+			LV []struct {
+				CacheMode string `json:"cache_mode"`
+			} `json:"lv"`
+		} `json:"report"`
+	}
+
+	res := new(cacheModeReport)
+	err := callLVMInto(ctx, res, verbosityLVMStateNoUpdate,
+		"lvs", l.fullname, "-o", "cache_mode", "--reportformat", "json")
+	if err != nil {
+		return "", err
+	}
+	if len(res.Report) == 0 || len(res.Report[0].LV) == 0 {
+		return "", ErrNotFound
+	}
+	return res.Report[0].LV[0].CacheMode, nil
+}
+
+// ChangeCacheMode changes the cache mode of this volume cached by dm-cache.
+// Changing the mode to "writethrough" flushes the dirty blocks of the cache.
+func (l *LogicalVolume) ChangeCacheMode(ctx context.Context, mode string) error {
+	if l.cache == nil {
+		return fmt.Errorf("volume is not cached: %s", l.fullname)
+	}
+	return callLVM(ctx, "lvchange", "-y", "--cachemode", mode, l.fullname)
+}
+
+// CacheStats returns the statistics of the caches of the cached volumes in this volume group, keyed by volume name.
+// writecache must be true if the caches are dm-writecache, as LVM reports the statistics of the two in different fields.
+func (vg *VolumeGroup) CacheStats(ctx context.Context, writecache bool) (map[string]CacheStats, error) {
+	segtype := "cache"
+	fields := "lv_name,cache_total_blocks,cache_used_blocks,cache_dirty_blocks," +
+		"cache_read_hits,cache_read_misses,cache_write_hits,cache_write_misses"
+	if writecache {
+		segtype = "writecache"
+		fields = "lv_name,writecache_total_blocks,writecache_free_blocks,writecache_writeback_blocks"
+	}
+
+	res := new(cacheStatsReport)
+	err := callLVMInto(ctx, res, verbosityLVMStateNoUpdate,
+		"lvs", vg.Name(), "--select", "segtype="+segtype, "-o", fields, "--reportformat", "json")
+	if err != nil {
+		return nil, err
+	}
+	return res.stats()
+}
+
+type cacheStatsReport struct {
+	Report []struct {
+		LV []map[string]string `json:"lv"`
+	} `json:"report"`
+}
+
+// stats converts the report into CacheStats. Volumes whose cache is not active, which have empty fields, are skipped.
+func (r *cacheStatsReport) stats() (map[string]CacheStats, error) {
+	ret := map[string]CacheStats{}
+	for _, report := range r.Report {
+		for _, fields := range report.LV {
+			values := map[string]uint64{}
+			for k, v := range fields {
+				if k == "lv_name" || v == "" {
+					continue
+				}
+				n, err := strconv.ParseUint(v, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse %s of %s: %w", k, fields["lv_name"], err)
+				}
+				values[k] = n
+			}
+			if len(values) == 0 {
+				continue
+			}
+
+			var stats CacheStats
+			if total, ok := values["writecache_total_blocks"]; ok {
+				stats.TotalBlocks = total
+				stats.UsedBlocks = total - min(total, values["writecache_free_blocks"])
+				stats.DirtyBlocks = values["writecache_writeback_blocks"]
+			} else {
+				stats.TotalBlocks = values["cache_total_blocks"]
+				stats.UsedBlocks = values["cache_used_blocks"]
+				stats.DirtyBlocks = values["cache_dirty_blocks"]
+				stats.ReadHits = values["cache_read_hits"]
+				stats.ReadMisses = values["cache_read_misses"]
+				stats.WriteHits = values["cache_write_hits"]
+				stats.WriteMisses = values["cache_write_misses"]
+			}
+			ret[fields["lv_name"]] = stats
+		}
+	}
+	return ret, nil
+}
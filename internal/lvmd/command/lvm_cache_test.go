@@ -0,0 +1,72 @@
+package command
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCacheStatsReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		report string
+		want   map[string]CacheStats
+	}{
+		{
+			name: "dm-cache",
+			report: `{"report": [{"lv": [
+				{"lv_name":"vol1", "cache_total_blocks":"1000", "cache_used_blocks":"300", "cache_dirty_blocks":"20",
+				 "cache_read_hits":"50", "cache_read_misses":"10", "cache_write_hits":"40", "cache_write_misses":"5"},
+				{"lv_name":"vol2", "cache_total_blocks":"", "cache_used_blocks":"", "cache_dirty_blocks":"",
+				 "cache_read_hits":"", "cache_read_misses":"", "cache_write_hits":"", "cache_write_misses":""}
+			]}]}`,
+			want: map[string]CacheStats{
+				"vol1": {ReadHits: 50, ReadMisses: 10, WriteHits: 40, WriteMisses: 5, DirtyBlocks: 20, UsedBlocks: 300, TotalBlocks: 1000},
+			},
+		},
+		{
+			name: "dm-writecache",
+			report: `{"report": [{"lv": [
+				{"lv_name":"vol1", "writecache_total_blocks":"1000", "writecache_free_blocks":"600", "writecache_writeback_blocks":"30"}
+			]}]}`,
+			want: map[string]CacheStats{
+				"vol1": {DirtyBlocks: 30, UsedBlocks: 400, TotalBlocks: 1000},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := new(cacheStatsReport)
+			if err := json.Unmarshal([]byte(tt.report), res); err != nil {
+				t.Fatal(err)
+			}
+			got, err := res.stats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, but got %v", tt.want, got)
+			}
+		})
+	}
+
+	res := new(cacheStatsReport)
+	if err := json.Unmarshal([]byte(`{"report": [{"lv": [{"lv_name":"vol1", "cache_used_blocks":"x"}]}]}`), res); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := res.stats(); err == nil {
+		t.Error("should fail to parse invalid numbers")
+	}
+}
+
+func TestConvertCachedLV(t *testing.T) {
+	group := &VolumeGroup{state: vg{name: "vg0"}}
+	cached := group.convertLV(lv{name: "vol1", fullName: "vg0/vol1", poolLV: "[vol1-cache_cvol]", attr: "Cwi-a-C---"})
+	if !cached.IsCached() || cached.IsThin() {
+		t.Error("volume with an attached cache volume should be cached and not thin")
+	}
+	thin := group.convertLV(lv{name: "vol2", fullName: "vg0/vol2", poolLV: "pool0", attr: "Vwi-a-tz--"})
+	if thin.IsCached() || !thin.IsThin() {
+		t.Error("volume in a thin pool should be thin and not cached")
+	}
+}
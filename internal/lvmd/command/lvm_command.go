@@ -72,7 +72,32 @@ func callLVMInto(ctx context.Context, into any, logVerbosity int, args ...string
 // Not calling close on this method will result in a resource leak.
 func callLVMStreamed(ctx context.Context, logVerbosity int, args ...string) (io.ReadCloser, error) {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithCallDepth(1))
-	wholeCommand := slices.Concat(lvmCommandPrefix, args)
+	return callStreamed(ctx, logVerbosity, slices.Concat(lvmCommandPrefix, args))
+}
+
+// callHostCommandStreamed calls a device-mapper related command on the host, e.g. dmsetup or thin_dump,
+// and returns the output as a ReadCloser. The command is run the same way as lvm, i.e. with
+// lvmCommandPrefix where the lvm binary is replaced by the given command name.
+// The caller is responsible for closing the ReadCloser.
+func callHostCommandStreamed(ctx context.Context, logVerbosity int, name string, args ...string) (io.ReadCloser, error) {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithCallDepth(1))
+	return callStreamed(ctx, logVerbosity, slices.Concat(lvmCommandPrefix[:len(lvmCommandPrefix)-1], []string{name}, args))
+}
+
+// callHostCommand calls a device-mapper related command on the host and prints the output to the log.
+func callHostCommand(ctx context.Context, name string, args ...string) error {
+	output, err := callHostCommandStreamed(ctx, verbosityLVMStateUpdate, name, args...)
+	if err != nil {
+		return fmt.Errorf("failed to execute command: %v", err)
+	}
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		log.FromContext(ctx).Info(strings.TrimSpace(scanner.Text()))
+	}
+	return errors.Join(output.Close(), scanner.Err())
+}
+
+func callStreamed(ctx context.Context, logVerbosity int, wholeCommand []string) (io.ReadCloser, error) {
 	// Use CommandContext so kubelet timing out on an RPC (default 2 min
 	// csiTimeout) cancels the underlying vgs/lvs/... subprocess instead
 	// of leaving it running on the node. exec.Command ignores ctx and
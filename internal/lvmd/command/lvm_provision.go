@@ -0,0 +1,63 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CreateVolumeGroup creates a volume group from the given devices.
+// The devices are initialized as physical volumes if they are not.
+func CreateVolumeGroup(ctx context.Context, name string, devices []string) error {
+	return callLVM(ctx, append([]string{"vgcreate", name}, devices...)...)
+}
+
+// Extend adds the given devices to this volume group.
+// The devices are initialized as physical volumes if they are not.
+func (vg *VolumeGroup) Extend(ctx context.Context, devices []string) error {
+	return callLVM(ctx, append([]string{"vgextend", vg.Name()}, devices...)...)
+}
+
+// ExtentSize returns the size of the physical extents of this volume group in bytes.
+func (vg *VolumeGroup) ExtentSize(ctx context.Context) (uint64, error) {
+	type extentReport struct {
+		Report []struct {
+			VG []struct {
+				ExtentSize string `json:"vg_extent_size"`
+			} `json:"vg"`
+		} `json:"report"`
+	}
+
+	res := new(extentReport)
+	err := callLVMInto(ctx, res, verbosityLVMStateNoUpdate,
+		"vgs", vg.Name(), "-o", "vg_extent_size", "--units", "b", "--nosuffix", "--reportformat", "json")
+	if err != nil {
+		return 0, err
+	}
+	if len(res.Report) == 0 || len(res.Report[0].VG) == 0 {
+		return 0, ErrNotFound
+	}
+	return strconv.ParseUint(res.Report[0].VG[0].ExtentSize, 10, 64)
+}
+
+// ListSignatures returns the types of the signatures found on the device,
+// e.g. filesystems, partition tables or LVM physical volumes.
+func ListSignatures(ctx context.Context, device string) ([]string, error) {
+	output, err := callHostCommandStreamed(ctx, verbosityLVMStateNoUpdate,
+		"wipefs", "--noheadings", "--output", "TYPE", device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute command: %v", err)
+	}
+
+	var signatures []string
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		if signature := strings.TrimSpace(scanner.Text()); signature != "" {
+			signatures = append(signatures, signature)
+		}
+	}
+	return signatures, errors.Join(output.Close(), scanner.Err())
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"context"
+	"strconv"
+)
+
+// PhysicalVolume represents a physical volume in a volume group.
+type PhysicalVolume struct {
+	Name string
+	Size uint64
+	Free uint64
+	// Missing is true if the device of the physical volume cannot be found
+	Missing bool
+}
+
+// ListPhysicalVolumes lists the physical volumes in this volume group.
+func (vg *VolumeGroup) ListPhysicalVolumes(ctx context.Context) ([]PhysicalVolume, error) {
+	type pvReport struct {
+		Report []struct {
+			PV []struct {
+				Name    string `json:"pv_name"`
+				Size    string `json:"pv_size"`
+				Free    string `json:"pv_free"`
+				Missing string `json:"pv_missing"`
+			} `json:"pv"`
+		} `json:"report"`
+	}
+
+	res := new(pvReport)
+	err := callLVMInto(ctx, res, verbosityLVMStateNoUpdate,
+		"pvs", "--select", "vg_name="+vg.Name(), "-o", "pv_name,pv_size,pv_free,pv_missing",
+		"--units", "b", "--nosuffix", "--reportformat", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var pvs []PhysicalVolume
+	for _, report := range res.Report {
+		for _, pv := range report.PV {
+			size, err := strconv.ParseUint(pv.Size, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			free, err := strconv.ParseUint(pv.Free, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			pvs = append(pvs, PhysicalVolume{Name: pv.Name, Size: size, Free: free, Missing: pv.Missing != ""})
+		}
+	}
+	return pvs, nil
+}
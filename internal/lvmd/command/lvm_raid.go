@@ -0,0 +1,59 @@
+package command
+
+import (
+	"context"
+	"strings"
+)
+
+// RepairRAID replaces the failed images of this RAID volume with new images
+// allocated from the other physical volumes in the volume group.
+func (l *LogicalVolume) RepairRAID(ctx context.Context) error {
+	return callLVM(ctx, "lvconvert", "--repair", "-y", l.fullname)
+}
+
+// Refresh reloads the device-mapper tables of this volume, which recovers
+// RAID images from transient write errors.
+func (l *LogicalVolume) Refresh(ctx context.Context) error {
+	return callLVM(ctx, "lvchange", "--refresh", l.fullname)
+}
+
+// ImageDevices returns the physical volumes which hold the images and the metadata of this RAID volume.
+// A missing physical volume is reported as "[unknown]".
+func (l *LogicalVolume) ImageDevices(ctx context.Context) ([]string, error) {
+	type devicesReport struct {
+		Report []struct {
+			LV []struct {
+				Devices string `json:"devices"`
+			} `json:"lv"`
+		} `json:"report"`
+	}
+
+	res := new(devicesReport)
+	err := callLVMInto(ctx, res, verbosityLVMStateNoUpdate,
+		"lvs", "-a", "--select", "lv_parent="+l.name, "-o", "devices", "--reportformat", "json", l.vg.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []string
+	for _, report := range res.Report {
+		for _, lv := range report.LV {
+			devices = append(devices, parseDevices(lv.Devices)...)
+		}
+	}
+	return devices, nil
+}
+
+// parseDevices parses the devices field of lvs, e.g. "/dev/sda(0),/dev/sdb(10)", into the paths of the devices.
+func parseDevices(field string) []string {
+	var devices []string
+	for _, device := range strings.Split(field, ",") {
+		if i := strings.LastIndex(device, "("); i >= 0 {
+			device = device[:i]
+		}
+		if device = strings.TrimSpace(device); device != "" {
+			devices = append(devices, device)
+		}
+	}
+	return devices
+}
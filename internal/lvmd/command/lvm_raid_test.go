@@ -0,0 +1,24 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDevices(t *testing.T) {
+	cases := []struct {
+		field    string
+		expected []string
+	}{
+		{"/dev/sda(0)", []string{"/dev/sda"}},
+		{"/dev/sda(0),/dev/sdb(10)", []string{"/dev/sda", "/dev/sdb"}},
+		{"[unknown](1)", []string{"[unknown]"}},
+		{"", nil},
+	}
+
+	for _, c := range cases {
+		if actual := parseDevices(c.field); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%q: expected %v, but got %v", c.field, c.expected, actual)
+		}
+	}
+}
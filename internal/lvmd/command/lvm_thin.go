@@ -0,0 +1,253 @@
+package command
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// sectorSize is the unit of the data block size reported by thin_dump.
+const sectorSize = 512
+
+// Range is a contiguous region of a logical volume in bytes.
+type Range struct {
+	Offset uint64
+	Length uint64
+}
+
+// metadataSnapLock serializes the use of thin pool metadata snapshots, as a
+// pool can hold only a single metadata snapshot at a time.
+var metadataSnapLock sync.Mutex
+
+// AllocatedRanges returns the regions of this thin volume that are provisioned
+// in its thin pool, sorted by offset. Unprovisioned regions read as zeros.
+// The mappings are read from a metadata snapshot of the pool with thin_dump,
+// so the thin-provisioning-tools and dmsetup are required on the host.
+func (l *LogicalVolume) AllocatedRanges(ctx context.Context) ([]Range, error) {
+	if !l.IsThin() || l.pool == nil {
+		return nil, fmt.Errorf("cannot get allocated ranges of non-thin volume: %s", l.fullname)
+	}
+
+	thinID, err := l.thinID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []Range
+	err = l.withMetadataSnap(ctx, func(metadataDevice string) error {
+		output, err := callHostCommandStreamed(ctx, verbosityLVMStateNoUpdate,
+			"thin_dump", "--metadata-snap", "--dev-id", strconv.FormatUint(thinID, 10), metadataDevice)
+		if err != nil {
+			return fmt.Errorf("failed to execute command: %v", err)
+		}
+		ranges, err = parseThinDump(output, thinID)
+		return errors.Join(err, output.Close())
+	})
+	return ranges, err
+}
+
+// ChangedRanges returns the regions of this thin volume that differ from the base
+// thin volume in the same thin pool, sorted by offset. The regions include the ones
+// unprovisioned in this volume but provisioned in the base, which read as zeros.
+// The differences are read from a metadata snapshot of the pool with thin_delta.
+func (l *LogicalVolume) ChangedRanges(ctx context.Context, base *LogicalVolume) ([]Range, error) {
+	if !l.IsThin() || l.pool == nil || !base.IsThin() || base.pool == nil {
+		return nil, fmt.Errorf("cannot get changed ranges of non-thin volumes: %s, %s", l.fullname, base.fullname)
+	}
+	if l.vg.Name() != base.vg.Name() || *l.pool != *base.pool {
+		return nil, fmt.Errorf("cannot get changed ranges of volumes in different thin pools: %s, %s", l.fullname, base.fullname)
+	}
+
+	thinID, err := l.thinID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	baseID, err := base.thinID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []Range
+	err = l.withMetadataSnap(ctx, func(metadataDevice string) error {
+		output, err := callHostCommandStreamed(ctx, verbosityLVMStateNoUpdate,
+			"thin_delta", "--metadata-snap",
+			"--snap1", strconv.FormatUint(baseID, 10), "--snap2", strconv.FormatUint(thinID, 10), metadataDevice)
+		if err != nil {
+			return fmt.Errorf("failed to execute command: %v", err)
+		}
+		ranges, err = parseThinDelta(output)
+		return errors.Join(err, output.Close())
+	})
+	return ranges, err
+}
+
+// withMetadataSnap reserves a metadata snapshot of the thin pool of this volume,
+// calls f with the path of the metadata device, and releases the snapshot.
+func (l *LogicalVolume) withMetadataSnap(ctx context.Context, f func(metadataDevice string) error) error {
+	metadataSnapLock.Lock()
+	defer metadataSnapLock.Unlock()
+
+	poolDevice := dmName(l.vg.Name(), *l.pool) + "-tpool"
+	if err := callHostCommand(ctx, "dmsetup", "message", poolDevice, "0", "reserve_metadata_snap"); err != nil {
+		return fmt.Errorf("failed to reserve metadata snapshot of %s: %w", poolDevice, err)
+	}
+	defer func() {
+		if err := callHostCommand(ctx, "dmsetup", "message", poolDevice, "0", "release_metadata_snap"); err != nil {
+			log.FromContext(ctx).Error(err, "failed to release metadata snapshot", "pool", poolDevice)
+		}
+	}()
+
+	return f("/dev/mapper/" + dmName(l.vg.Name(), *l.pool+"_tmeta"))
+}
+
+// thinID returns the device id of this thin volume in its pool.
+func (l *LogicalVolume) thinID(ctx context.Context) (uint64, error) {
+	type thinIDReport struct {
+		Report []struct {
+			LV []struct {
+				ThinID string `json:"thin_id"`
+			} `json:"lv"`
+		} `json:"report"`
+	}
+
+	res := new(thinIDReport)
+	err := callLVMInto(ctx, res, verbosityLVMStateNoUpdate,
+		"lvs", l.fullname, "-o", "thin_id", "--reportformat", "json")
+	if IsLVMNotFound(err) {
+		return 0, errors.Join(ErrNotFound, err)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(res.Report) == 0 || len(res.Report[0].LV) == 0 {
+		return 0, ErrNotFound
+	}
+	return strconv.ParseUint(res.Report[0].LV[0].ThinID, 10, 64)
+}
+
+// parseThinDump parses the XML output of thin_dump and returns the mapped
+// ranges of the thin device with the given id, sorted and merged.
+func parseThinDump(r io.Reader, thinID uint64) ([]Range, error) {
+	var blockSize uint64
+	var inDevice bool
+	var ranges []Range
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse thin_dump output: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			attrs := uintAttrs(t)
+			switch t.Name.Local {
+			case "superblock":
+				blockSize = attrs["data_block_size"] * sectorSize
+			case "device":
+				inDevice = attrs["dev_id"] == thinID
+			case "range_mapping":
+				if inDevice {
+					ranges = append(ranges, Range{
+						Offset: attrs["origin_begin"] * blockSize,
+						Length: attrs["length"] * blockSize,
+					})
+				}
+			case "single_mapping":
+				if inDevice {
+					ranges = append(ranges, Range{Offset: attrs["origin_block"] * blockSize, Length: blockSize})
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "device" {
+				inDevice = false
+			}
+		}
+	}
+	if blockSize == 0 && len(ranges) > 0 {
+		return nil, errors.New("failed to parse thin_dump output: data block size is missing")
+	}
+	return MergeRanges(ranges), nil
+}
+
+// parseThinDelta parses the XML output of thin_delta and returns the ranges
+// which are not the same between the two thin devices, sorted and merged.
+func parseThinDelta(r io.Reader) ([]Range, error) {
+	var blockSize uint64
+	var ranges []Range
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse thin_delta output: %w", err)
+		}
+
+		t, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		attrs := uintAttrs(t)
+		switch t.Name.Local {
+		case "superblock":
+			blockSize = attrs["data_block_size"] * sectorSize
+		case "different", "left_only", "right_only":
+			ranges = append(ranges, Range{
+				Offset: attrs["begin"] * blockSize,
+				Length: attrs["length"] * blockSize,
+			})
+		}
+	}
+	if blockSize == 0 && len(ranges) > 0 {
+		return nil, errors.New("failed to parse thin_delta output: data block size is missing")
+	}
+	return MergeRanges(ranges), nil
+}
+
+// uintAttrs returns the attributes of the element which are unsigned integers.
+// Other attributes such as uuid are not needed and are skipped.
+func uintAttrs(e xml.StartElement) map[string]uint64 {
+	attrs := make(map[string]uint64, len(e.Attr))
+	for _, attr := range e.Attr {
+		if v, err := strconv.ParseUint(attr.Value, 10, 64); err == nil {
+			attrs[attr.Name.Local] = v
+		}
+	}
+	return attrs
+}
+
+// dmName returns the device-mapper name of a logical volume, in which dashes
+// in the volume group and logical volume names are escaped by doubling them.
+func dmName(vgName, lvName string) string {
+	return strings.ReplaceAll(vgName, "-", "--") + "-" + strings.ReplaceAll(lvName, "-", "--")
+}
+
+// MergeRanges sorts the ranges by offset and merges adjacent or overlapping ones.
+func MergeRanges(ranges []Range) []Range {
+	slices.SortFunc(ranges, func(a, b Range) int {
+		return cmp.Compare(a.Offset, b.Offset)
+	})
+	var merged []Range
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && merged[n-1].Offset+merged[n-1].Length >= r.Offset {
+			merged[n-1].Length = max(merged[n-1].Length, r.Offset+r.Length-merged[n-1].Offset)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,116 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseThinDump(t *testing.T) {
+	// data_block_size is 128 sectors, i.e. 64KiB.
+	const dump = `<superblock uuid="" time="1" transaction="2" version="2" data_block_size="128" nr_data_blocks="1600">
+  <device dev_id="1" mapped_blocks="5" transaction="0" creation_time="0" snap_time="1">
+    <range_mapping origin_begin="0" data_begin="0" length="2" time="0"/>
+    <single_mapping origin_block="2" data_block="10" time="0"/>
+    <single_mapping origin_block="10" data_block="11" time="0"/>
+    <range_mapping origin_begin="5" data_begin="20" length="3" time="1"/>
+  </device>
+  <device dev_id="2" mapped_blocks="1" transaction="1" creation_time="1" snap_time="1">
+    <single_mapping origin_block="100" data_block="30" time="1"/>
+  </device>
+</superblock>`
+
+	const block = 128 * 512
+	tests := []struct {
+		name   string
+		thinID uint64
+		want   []Range
+	}{
+		{"device 1", 1, []Range{
+			{Offset: 0, Length: 3 * block},
+			{Offset: 5 * block, Length: 3 * block},
+			{Offset: 10 * block, Length: block},
+		}},
+		{"device 2", 2, []Range{{Offset: 100 * block, Length: block}}},
+		{"missing device", 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseThinDump(strings.NewReader(dump), tt.thinID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseThinDump() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseThinDump(strings.NewReader("<superblock"), 1); err == nil {
+		t.Error("parseThinDump() should fail for broken output")
+	}
+	if _, err := parseThinDump(strings.NewReader(`<device dev_id="1"><single_mapping origin_block="0"/></device>`), 1); err == nil {
+		t.Error("parseThinDump() should fail without data block size")
+	}
+}
+
+func TestParseThinDelta(t *testing.T) {
+	const delta = `<superblock uuid="" time="2" transaction="3" data_block_size="128" nr_data_blocks="1600">
+  <diff left="1" right="2">
+    <same begin="0" length="3"/>
+    <different begin="3" length="1"/>
+    <right_only begin="4" length="2"/>
+    <same begin="6" length="4"/>
+    <left_only begin="10" length="1"/>
+  </diff>
+</superblock>`
+
+	const block = 128 * 512
+	got, err := parseThinDelta(strings.NewReader(delta))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Range{
+		{Offset: 3 * block, Length: 3 * block},
+		{Offset: 10 * block, Length: block},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseThinDelta() = %v, want %v", got, want)
+	}
+
+	got, err = parseThinDelta(strings.NewReader(`<superblock data_block_size="128"><diff left="1" right="2"><same begin="0" length="3"/></diff></superblock>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("parseThinDelta() = %v, want nil for identical volumes", got)
+	}
+}
+
+func TestMergeRanges(t *testing.T) {
+	got := MergeRanges([]Range{
+		{Offset: 100, Length: 10},
+		{Offset: 0, Length: 10},
+		{Offset: 10, Length: 5},
+		{Offset: 105, Length: 2},
+		{Offset: 50, Length: 10},
+		{Offset: 55, Length: 10},
+	})
+	want := []Range{
+		{Offset: 0, Length: 15},
+		{Offset: 50, Length: 15},
+		{Offset: 100, Length: 10},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeRanges() = %v, want %v", got, want)
+	}
+	if got := MergeRanges(nil); got != nil {
+		t.Errorf("MergeRanges(nil) = %v, want nil", got)
+	}
+}
+
+func TestDMName(t *testing.T) {
+	if got := dmName("my-vg", "pool-0_tmeta"); got != "my--vg-pool--0_tmeta" {
+		t.Errorf("dmName() = %s", got)
+	}
+}
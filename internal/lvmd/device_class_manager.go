@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sync/atomic"
 
 	"github.com/topolvm/topolvm"
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
@@ -13,7 +14,9 @@ import (
 var ErrDeviceClassNotFound = errors.New("device-class not found")
 
 const (
-	defaultSpareGB = 10
+	defaultSpareGB           = 10
+	defaultCOWReservePercent = 100
+	defaultCacheSizePercent  = 10
 )
 
 // This regexp is based on the following validation:
@@ -32,6 +35,43 @@ func GetSpare(dc *lvmdTypes.DeviceClass) uint64 {
 	return *dc.SpareGB << 30
 }
 
+// GetCOWReserve returns the size in bytes of the copy-on-write area to be reserved
+// for a thick snapshot of a volume of originSize bytes in the device-class
+func GetCOWReserve(dc *lvmdTypes.DeviceClass, originSize uint64) uint64 {
+	percent := uint64(defaultCOWReservePercent)
+	if dc.ThickSnapshotConfig != nil {
+		percent = uint64(dc.ThickSnapshotConfig.COWReservePercent)
+	}
+	// split the multiplication to avoid overflowing on huge volumes
+	reserve := originSize/100*percent + (originSize%100*percent+99)/100
+	sectorSize := uint64(topolvm.MinimumSectorSize)
+	return (reserve + sectorSize - 1) / sectorSize * sectorSize
+}
+
+// GetCacheSize returns the size in bytes of the cache volume to be created
+// for a volume of originSize bytes in the device-class
+func GetCacheSize(dc *lvmdTypes.DeviceClass, originSize uint64) uint64 {
+	percent := getCacheSizePercent(dc)
+	size := originSize/100*percent + (originSize%100*percent+99)/100
+	sectorSize := uint64(topolvm.MinimumSectorSize)
+	return (size + sectorSize - 1) / sectorSize * sectorSize
+}
+
+func getCacheSizePercent(dc *lvmdTypes.DeviceClass) uint64 {
+	if dc.CacheConfig == nil || dc.CacheConfig.SizePercent == 0 {
+		return defaultCacheSizePercent
+	}
+	return uint64(dc.CacheConfig.SizePercent)
+}
+
+// GetCacheMode returns the cache mode of the device-class
+func GetCacheMode(dc *lvmdTypes.DeviceClass) lvmdTypes.CacheMode {
+	if dc.CacheConfig == nil || dc.CacheConfig.Mode == "" {
+		return lvmdTypes.CacheModeWritethrough
+	}
+	return dc.CacheConfig.Mode
+}
+
 // ValidateDeviceClasses validates device-classes
 func ValidateDeviceClasses(deviceClasses []*lvmdTypes.DeviceClass) error {
 	if len(deviceClasses) < 1 {
@@ -82,11 +122,66 @@ func ValidateDeviceClasses(deviceClasses []*lvmdTypes.DeviceClass) error {
 			if dc.ThinPoolConfig.OverprovisionRatio < 1.0 {
 				return fmt.Errorf("overprovision ratio for thin pool %s in device class %s should be 1.0 or more", dc.ThinPoolConfig.Name, dc.Name)
 			}
+			if dc.ThinPoolConfig.SizePercent > 95 {
+				return fmt.Errorf("size percent for thin pool %s in device class %s should be between 1 and 95", dc.ThinPoolConfig.Name, dc.Name)
+			}
+			if ae := dc.ThinPoolConfig.AutoExtend; ae != nil {
+				if ae.DataThresholdPercent > 99 || ae.MetadataThresholdPercent > 99 {
+					return fmt.Errorf("auto-extend threshold percent for thin pool %s in device class %s should be between 1 and 99", dc.ThinPoolConfig.Name, dc.Name)
+				}
+				if ae.DataStepPercent > 100 || ae.MetadataStepPercent > 100 {
+					return fmt.Errorf("auto-extend step percent for thin pool %s in device class %s should be between 1 and 100", dc.ThinPoolConfig.Name, dc.Name)
+				}
+			}
 			// combination of volumegroup and thinpool should be unique across device classes
 			// so the key 'name' shouldn't appear twice to verify it's uniqueness
 			name = name + "/" + dc.ThinPoolConfig.Name
 		}
 
+		// thick snapshot validation, ignore any thicksnapshotconfig if Type is TypeThin
+		if dc.Type != lvmdTypes.TypeThin && dc.ThickSnapshotConfig != nil {
+			percent := dc.ThickSnapshotConfig.COWReservePercent
+			if percent < 1 || percent > 100 {
+				return fmt.Errorf("cow reserve percent for thick snapshots in device class %s should be between 1 and 100", dc.Name)
+			}
+		}
+
+		// cache validation
+		if dc.CacheConfig != nil {
+			if dc.Type == lvmdTypes.TypeThin {
+				return fmt.Errorf("cache is not supported for thin device class: %s", dc.Name)
+			}
+			if len(dc.CacheConfig.PhysicalVolumes) == 0 {
+				return fmt.Errorf("cache physical volumes should not be empty: %s", dc.Name)
+			}
+			switch dc.CacheConfig.Mode {
+			case "", lvmdTypes.CacheModeWritethrough, lvmdTypes.CacheModeWriteback, lvmdTypes.CacheModeWritecache:
+			default:
+				return fmt.Errorf("cache 'mode' of device-class can be one of '%[1]s', '%[2]s' or '%[3]s' or empty to default to '%[1]s': %[4]s",
+					lvmdTypes.CacheModeWritethrough, lvmdTypes.CacheModeWriteback, lvmdTypes.CacheModeWritecache, dc.Name)
+			}
+			if dc.CacheConfig.SizePercent > 100 {
+				return fmt.Errorf("cache size percent in device class %s should be between 1 and 100", dc.Name)
+			}
+		}
+
+		// device selector validation
+		if sel := dc.DeviceSelector; sel != nil {
+			if len(sel.Paths) == 0 && len(sel.IDs) == 0 {
+				return fmt.Errorf("device selector should have paths or ids: %s", dc.Name)
+			}
+			if sel.MinSizeGB != nil && sel.MaxSizeGB != nil && *sel.MinSizeGB > *sel.MaxSizeGB {
+				return fmt.Errorf("min-size-gb of device selector should not be larger than max-size-gb: %s", dc.Name)
+			}
+		}
+
+		// RAID validation
+		if dc.RAIDConfig != nil {
+			if err := validateRAIDConfig(dc); err != nil {
+				return err
+			}
+		}
+
 		if vgNames[name] {
 			return fmt.Errorf("duplicate volumegroup/thinpool name: %s, %s", dc.Name, name)
 		}
@@ -103,8 +198,52 @@ func ValidateDeviceClasses(deviceClasses []*lvmdTypes.DeviceClass) error {
 	return nil
 }
 
+func validateRAIDConfig(dc *lvmdTypes.DeviceClass) error {
+	if dc.Type == lvmdTypes.TypeThin {
+		return fmt.Errorf("raid is not supported for thin device class: %s", dc.Name)
+	}
+	if dc.CacheConfig != nil {
+		return fmt.Errorf("raid and cache cannot be used together: %s", dc.Name)
+	}
+	if dc.Stripe != nil {
+		return fmt.Errorf("stripe cannot be used with raid, use raid.stripes instead: %s", dc.Name)
+	}
+
+	rc := dc.RAIDConfig
+	switch rc.Level {
+	case lvmdTypes.RAIDLevel1:
+		if rc.Stripes != 0 {
+			return fmt.Errorf("raid.stripes cannot be used with %s: %s", rc.Level, dc.Name)
+		}
+	case lvmdTypes.RAIDLevel5, lvmdTypes.RAIDLevel6:
+		if rc.Mirrors != 0 {
+			return fmt.Errorf("raid.mirrors cannot be used with %s: %s", rc.Level, dc.Name)
+		}
+		if rc.Level == lvmdTypes.RAIDLevel5 && rc.Stripes == 1 {
+			return fmt.Errorf("raid.stripes of %s should be 2 or more: %s", rc.Level, dc.Name)
+		}
+		if rc.Level == lvmdTypes.RAIDLevel6 && rc.Stripes != 0 && rc.Stripes < 3 {
+			return fmt.Errorf("raid.stripes of %s should be 3 or more: %s", rc.Level, dc.Name)
+		}
+	case lvmdTypes.RAIDLevel10:
+		if rc.Stripes == 1 {
+			return fmt.Errorf("raid.stripes of %s should be 2 or more: %s", rc.Level, dc.Name)
+		}
+	default:
+		return fmt.Errorf("raid 'level' of device-class can be one of '%s', '%s', '%s' or '%s': %s",
+			lvmdTypes.RAIDLevel1, lvmdTypes.RAIDLevel5, lvmdTypes.RAIDLevel6, lvmdTypes.RAIDLevel10, dc.Name)
+	}
+	return nil
+}
+
 // DeviceClassManager maps between device-classes and volume groups.
+// The device-classes can be replaced while lvmd is running.
 type DeviceClassManager struct {
+	classes atomic.Pointer[deviceClassSet]
+}
+
+// deviceClassSet is a set of device-classes, which is never modified once created.
+type deviceClassSet struct {
 	defaultDeviceClass        *lvmdTypes.DeviceClass
 	deviceClassByName         map[string]*lvmdTypes.DeviceClass
 	deviceClassByVGName       map[string]*lvmdTypes.DeviceClass
@@ -113,15 +252,21 @@ type DeviceClassManager struct {
 
 // NewDeviceClassManager creates a new DeviceClassManager
 func NewDeviceClassManager(deviceClasses []*lvmdTypes.DeviceClass) *DeviceClassManager {
-	dcm := DeviceClassManager{}
-	dcm.deviceClassByName = make(map[string]*lvmdTypes.DeviceClass)
-	dcm.deviceClassByVGName = make(map[string]*lvmdTypes.DeviceClass)
-	dcm.deviceClassByThinPoolName = make(map[string]*lvmdTypes.DeviceClass)
+	dcm := &DeviceClassManager{}
+	dcm.classes.Store(newDeviceClassSet(deviceClasses))
+	return dcm
+}
+
+func newDeviceClassSet(deviceClasses []*lvmdTypes.DeviceClass) *deviceClassSet {
+	dcs := deviceClassSet{}
+	dcs.deviceClassByName = make(map[string]*lvmdTypes.DeviceClass)
+	dcs.deviceClassByVGName = make(map[string]*lvmdTypes.DeviceClass)
+	dcs.deviceClassByThinPoolName = make(map[string]*lvmdTypes.DeviceClass)
 	for _, dc := range deviceClasses {
 		if dc.Default {
-			dcm.defaultDeviceClass = dc
+			dcs.defaultDeviceClass = dc
 		}
-		dcm.deviceClassByName[dc.Name] = dc
+		dcs.deviceClassByName[dc.Name] = dc
 
 		// device-class has two targets and at a time it can only be in one of
 		// "deviceClassByVGName" or "deviceClassByThinPoolName" maps
@@ -130,40 +275,60 @@ func NewDeviceClassManager(deviceClasses []*lvmdTypes.DeviceClass) *DeviceClassM
 			// device-class target is volumegroup and any logical volume referring to
 			// this device-class will have thick logical volumes
 			dc.Type = lvmdTypes.TypeThick
-			dcm.deviceClassByVGName[dc.VolumeGroup] = dc
+			dcs.deviceClassByVGName[dc.VolumeGroup] = dc
 		case lvmdTypes.TypeThin:
 			// we can't store pool name alone as there can be of thinpool with same name
 			// but on a different vg, so combination of vg and thinpool should be unique
-			dcm.deviceClassByThinPoolName[dc.VolumeGroup+"/"+dc.ThinPoolConfig.Name] = dc
+			dcs.deviceClassByThinPoolName[dc.VolumeGroup+"/"+dc.ThinPoolConfig.Name] = dc
 		}
 	}
-	return &dcm
+	return &dcs
+}
+
+// current returns the current set of the device-classes.
+func (m *DeviceClassManager) current() *deviceClassSet {
+	return m.classes.Load()
 }
 
 // DeviceClass returns the device-class by its name
-func (m DeviceClassManager) DeviceClass(dcName string) (*lvmdTypes.DeviceClass, error) {
-	if dcName == topolvm.DefaultDeviceClassName && m.defaultDeviceClass != nil {
-		return m.defaultDeviceClass, nil
+func (m *DeviceClassManager) DeviceClass(dcName string) (*lvmdTypes.DeviceClass, error) {
+	dcs := m.current()
+	if dcName == topolvm.DefaultDeviceClassName && dcs.defaultDeviceClass != nil {
+		return dcs.defaultDeviceClass, nil
 	}
-	if v, ok := m.deviceClassByName[dcName]; ok {
+	if v, ok := dcs.deviceClassByName[dcName]; ok {
 		return v, nil
 	}
 	return nil, ErrDeviceClassNotFound
 }
 
 // FindDeviceClassByVGName returns the device-class with the volume group name
-func (m DeviceClassManager) FindDeviceClassByVGName(vgName string) (*lvmdTypes.DeviceClass, error) {
-	if v, ok := m.deviceClassByVGName[vgName]; ok {
+func (m *DeviceClassManager) FindDeviceClassByVGName(vgName string) (*lvmdTypes.DeviceClass, error) {
+	if v, ok := m.current().deviceClassByVGName[vgName]; ok {
 		return v, nil
 	}
 	return nil, ErrDeviceClassNotFound
 }
 
 // FindDeviceClassByThinPoolName returns the device-class with volume group and pool combination
-func (m DeviceClassManager) FindDeviceClassByThinPoolName(vgName string, poolName string) (*lvmdTypes.DeviceClass, error) {
+func (m *DeviceClassManager) FindDeviceClassByThinPoolName(vgName string, poolName string) (*lvmdTypes.DeviceClass, error) {
 	name := vgName + "/" + poolName
-	if v, ok := m.deviceClassByThinPoolName[name]; ok {
+	if v, ok := m.current().deviceClassByThinPoolName[name]; ok {
 		return v, nil
 	}
 	return nil, ErrDeviceClassNotFound
 }
+
+// hasMonitoredDeviceClass returns true if any device-class has cache volumes or an auto-extended thin pool,
+// whose state changes without any notification
+func (m *DeviceClassManager) hasMonitoredDeviceClass() bool {
+	for _, dc := range m.current().deviceClassByName {
+		if dc.CacheConfig != nil {
+			return true
+		}
+		if dc.Type == lvmdTypes.TypeThin && dc.ThinPoolConfig != nil && dc.ThinPoolConfig.AutoExtend != nil {
+			return true
+		}
+	}
+	return false
+}
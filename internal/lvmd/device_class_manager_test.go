@@ -11,6 +11,8 @@ func TestValidateDeviceClasses(t *testing.T) {
 	stripe := uint(2)
 	opRatio := float64(10.0)
 	wrongOpRatio := float64(0.5)
+	size50gb := uint64(50)
+	size100gb := uint64(100)
 
 	cases := []struct {
 		deviceClasses []*lvmdTypes.DeviceClass
@@ -173,6 +175,30 @@ func TestValidateDeviceClasses(t *testing.T) {
 			},
 			valid: true,
 		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "thick-snapshot",
+					VolumeGroup: "vg0",
+					Default:     true,
+					ThickSnapshotConfig: &lvmdTypes.ThickSnapshotConfig{
+						COWReservePercent: 20,
+					},
+				},
+				{
+					// ThickSnapshotConfig should be ignored if Type is TypeThin
+					Name:        "thin",
+					VolumeGroup: "vg0",
+					Type:        lvmdTypes.TypeThin,
+					ThinPoolConfig: &lvmdTypes.ThinPoolConfig{
+						Name:               "pool0",
+						OverprovisionRatio: opRatio,
+					},
+					ThickSnapshotConfig: &lvmdTypes.ThickSnapshotConfig{},
+				},
+			},
+			valid: true,
+		},
 		{
 			deviceClasses: []*lvmdTypes.DeviceClass{
 				{
@@ -372,6 +398,326 @@ func TestValidateDeviceClasses(t *testing.T) {
 			},
 			valid: false,
 		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// cow reserve percent should be 1 or more
+				{
+					Name:                "dev0",
+					VolumeGroup:         "vg0",
+					Default:             true,
+					ThickSnapshotConfig: &lvmdTypes.ThickSnapshotConfig{},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// cow reserve percent should be 100 or less
+				{
+					Name:        "dev0",
+					VolumeGroup: "vg0",
+					Default:     true,
+					Type:        lvmdTypes.TypeThick,
+					ThickSnapshotConfig: &lvmdTypes.ThickSnapshotConfig{
+						COWReservePercent: 150,
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "cached",
+					VolumeGroup: "vg0",
+					Default:     true,
+					CacheConfig: &lvmdTypes.CacheConfig{
+						PhysicalVolumes: []string{"/dev/nvme0n1"},
+						Mode:            lvmdTypes.CacheModeWriteback,
+						SizePercent:     20,
+					},
+				},
+			},
+			valid: true,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// cache is not supported for thin device-classes
+				{
+					Name:        "thin",
+					VolumeGroup: "vg0",
+					Default:     true,
+					Type:        lvmdTypes.TypeThin,
+					ThinPoolConfig: &lvmdTypes.ThinPoolConfig{
+						Name:               "pool0",
+						OverprovisionRatio: opRatio,
+					},
+					CacheConfig: &lvmdTypes.CacheConfig{
+						PhysicalVolumes: []string{"/dev/nvme0n1"},
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// cache physical volumes should not be empty
+				{
+					Name:        "dev0",
+					VolumeGroup: "vg0",
+					Default:     true,
+					CacheConfig: &lvmdTypes.CacheConfig{},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// unknown cache mode
+				{
+					Name:        "dev0",
+					VolumeGroup: "vg0",
+					Default:     true,
+					CacheConfig: &lvmdTypes.CacheConfig{
+						PhysicalVolumes: []string{"/dev/nvme0n1"},
+						Mode:            "writearound",
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// cache size percent should be 100 or less
+				{
+					Name:        "dev0",
+					VolumeGroup: "vg0",
+					Default:     true,
+					CacheConfig: &lvmdTypes.CacheConfig{
+						PhysicalVolumes: []string{"/dev/nvme0n1"},
+						SizePercent:     150,
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "mirror",
+					VolumeGroup: "vg0",
+					Default:     true,
+					RAIDConfig:  &lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel1, Mirrors: 2},
+				},
+				{
+					Name:        "parity",
+					VolumeGroup: "vg1",
+					RAIDConfig:  &lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel6},
+				},
+				{
+					Name:        "striped-mirror",
+					VolumeGroup: "vg2",
+					StripeSize:  "64",
+					RAIDConfig:  &lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel10, Stripes: 3},
+				},
+			},
+			valid: true,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// unknown raid level
+				{
+					Name:        "dev0",
+					VolumeGroup: "vg0",
+					Default:     true,
+					RAIDConfig:  &lvmdTypes.RAIDConfig{Level: "raid0"},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// stripes cannot be used with raid1
+				{
+					Name:        "dev0",
+					VolumeGroup: "vg0",
+					Default:     true,
+					RAIDConfig:  &lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel1, Stripes: 2},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// raid6 needs 3 stripes or more
+				{
+					Name:        "dev0",
+					VolumeGroup: "vg0",
+					Default:     true,
+					RAIDConfig:  &lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel6, Stripes: 2},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// stripe should be configured in raid.stripes
+				{
+					Name:        "dev0",
+					VolumeGroup: "vg0",
+					Default:     true,
+					Stripe:      &stripe,
+					RAIDConfig:  &lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel5},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// raid and cache cannot be used together
+				{
+					Name:        "dev0",
+					VolumeGroup: "vg0",
+					Default:     true,
+					RAIDConfig:  &lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel1},
+					CacheConfig: &lvmdTypes.CacheConfig{
+						PhysicalVolumes: []string{"/dev/nvme0n1"},
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "provisioned",
+					VolumeGroup: "vg0",
+					Default:     true,
+					DeviceSelector: &lvmdTypes.DeviceSelector{
+						Paths:     []string{"/dev/disk/by-path/pci-0000:00:1f.2-ata-*"},
+						MinSizeGB: &size50gb,
+						MaxSizeGB: &size100gb,
+					},
+				},
+				{
+					Name:        "provisioned-thin",
+					VolumeGroup: "vg0",
+					Type:        lvmdTypes.TypeThin,
+					ThinPoolConfig: &lvmdTypes.ThinPoolConfig{
+						Name:               "pool0",
+						OverprovisionRatio: opRatio,
+						SizePercent:        80,
+					},
+					DeviceSelector: &lvmdTypes.DeviceSelector{
+						IDs: []string{"nvme-eui.0025388b91b3f2f5"},
+					},
+				},
+			},
+			valid: true,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// device selector should have paths or ids
+				{
+					Name:           "dev0",
+					VolumeGroup:    "vg0",
+					Default:        true,
+					DeviceSelector: &lvmdTypes.DeviceSelector{MinSizeGB: &size50gb},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// min size should not be larger than max size
+				{
+					Name:        "dev0",
+					VolumeGroup: "vg0",
+					Default:     true,
+					DeviceSelector: &lvmdTypes.DeviceSelector{
+						Paths:     []string{"/dev/sd*"},
+						MinSizeGB: &size100gb,
+						MaxSizeGB: &size50gb,
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// thin pool size percent should be 95 or less
+				{
+					Name:        "thin",
+					VolumeGroup: "vg0",
+					Default:     true,
+					Type:        lvmdTypes.TypeThin,
+					ThinPoolConfig: &lvmdTypes.ThinPoolConfig{
+						Name:               "pool0",
+						OverprovisionRatio: opRatio,
+						SizePercent:        100,
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// auto-extended thin pool
+				{
+					Name:        "thin",
+					VolumeGroup: "vg0",
+					Default:     true,
+					Type:        lvmdTypes.TypeThin,
+					ThinPoolConfig: &lvmdTypes.ThinPoolConfig{
+						Name:               "pool0",
+						OverprovisionRatio: opRatio,
+						AutoExtend: &lvmdTypes.ThinPoolAutoExtendConfig{
+							DataThresholdPercent: 70,
+							DataStepPercent:      50,
+						},
+					},
+				},
+			},
+			valid: true,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// auto-extend threshold should be less than 100
+				{
+					Name:        "thin",
+					VolumeGroup: "vg0",
+					Default:     true,
+					Type:        lvmdTypes.TypeThin,
+					ThinPoolConfig: &lvmdTypes.ThinPoolConfig{
+						Name:               "pool0",
+						OverprovisionRatio: opRatio,
+						AutoExtend: &lvmdTypes.ThinPoolAutoExtendConfig{
+							MetadataThresholdPercent: 100,
+						},
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				// auto-extend step should be 100 or less
+				{
+					Name:        "thin",
+					VolumeGroup: "vg0",
+					Default:     true,
+					Type:        lvmdTypes.TypeThin,
+					ThinPoolConfig: &lvmdTypes.ThinPoolConfig{
+						Name:               "pool0",
+						OverprovisionRatio: opRatio,
+						AutoExtend: &lvmdTypes.ThinPoolAutoExtendConfig{
+							DataStepPercent: 200,
+						},
+					},
+				},
+			},
+			valid: false,
+		},
 	}
 
 	for i, c := range cases {
@@ -460,7 +806,7 @@ func TestDeviceClassManager(t *testing.T) {
 		}
 	}
 
-	dc = manager.defaultDeviceClass
+	dc = manager.current().defaultDeviceClass
 	if dc == nil {
 		t.Fatal("default not found")
 	}
@@ -479,3 +825,47 @@ func TestDeviceClassManager(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestGetCOWReserve(t *testing.T) {
+	cases := []struct {
+		config     *lvmdTypes.ThickSnapshotConfig
+		originSize uint64
+		expected   uint64
+	}{
+		{nil, 1 << 30, 1 << 30},
+		{&lvmdTypes.ThickSnapshotConfig{COWReservePercent: 100}, 1 << 30, 1 << 30},
+		{&lvmdTypes.ThickSnapshotConfig{COWReservePercent: 50}, 1 << 30, 1 << 29},
+		// rounded up to a multiple of the sector size
+		{&lvmdTypes.ThickSnapshotConfig{COWReservePercent: 10}, 1 << 30, 107376640},
+		{&lvmdTypes.ThickSnapshotConfig{COWReservePercent: 1}, 4096, 4096},
+		// no overflow on huge volumes
+		{&lvmdTypes.ThickSnapshotConfig{COWReservePercent: 100}, 1 << 62, 1 << 62},
+	}
+
+	for i, c := range cases {
+		dc := &lvmdTypes.DeviceClass{Name: "dc", VolumeGroup: "vg", ThickSnapshotConfig: c.config}
+		if actual := GetCOWReserve(dc, c.originSize); actual != c.expected {
+			t.Errorf("%d: expected %d, but got %d", i, c.expected, actual)
+		}
+	}
+}
+
+func TestGetCacheSize(t *testing.T) {
+	cases := []struct {
+		config     *lvmdTypes.CacheConfig
+		originSize uint64
+		expected   uint64
+	}{
+		// defaults to 10 percent, rounded up to a multiple of the sector size
+		{&lvmdTypes.CacheConfig{}, 1 << 30, 107376640},
+		{&lvmdTypes.CacheConfig{SizePercent: 50}, 1 << 30, 1 << 29},
+		{&lvmdTypes.CacheConfig{SizePercent: 100}, 1 << 62, 1 << 62},
+	}
+
+	for i, c := range cases {
+		dc := &lvmdTypes.DeviceClass{Name: "dc", VolumeGroup: "vg", CacheConfig: c.config}
+		if actual := GetCacheSize(dc, c.originSize); actual != c.expected {
+			t.Errorf("%d: expected %d, but got %d", i, c.expected, actual)
+		}
+	}
+}
@@ -14,11 +14,14 @@ import (
 )
 
 // NewEmbeddedServiceClients creates clients locally calling instead of using gRPC.
+// The returned ConfigReloader replaces the device-classes and the lvcreate-option-classes of the clients.
 func NewEmbeddedServiceClients(ctx context.Context, dcmapper *DeviceClassManager, ocmapper *LvcreateOptionClassManager) (
 	proto.LVServiceClient,
 	proto.VGServiceClient,
+	*ConfigReloader,
 ) {
-	vgServiceServerInstance, notifier := NewVGService(dcmapper)
+	extender := NewThinPoolExtender(dcmapper)
+	vgServiceServerInstance, notifier := NewVGService(dcmapper, ocmapper, extender)
 	lvServiceServerInstance := NewLVService(dcmapper, ocmapper, notifier)
 
 	caller := &embeddedServiceClients{
@@ -32,6 +35,9 @@ func NewEmbeddedServiceClients(ctx context.Context, dcmapper *DeviceClassManager
 		}
 	}()
 
+	StartRAIDRepairer(ctx, dcmapper, notifier)
+	extender.Start(ctx, notifier)
+
 	go func() {
 		ticker := time.NewTicker(10 * time.Minute)
 		for {
@@ -45,7 +51,7 @@ func NewEmbeddedServiceClients(ctx context.Context, dcmapper *DeviceClassManager
 		}
 	}()
 
-	return caller, caller
+	return caller, caller, NewConfigReloader(dcmapper, ocmapper, notifier)
 }
 
 // embeddedServiceClients is a struct holding indirections to the local lvmd server.
@@ -147,10 +153,32 @@ func (l *embeddedServiceClients) ResizeLV(ctx context.Context, in *proto.ResizeL
 	return l.lvServiceServer.ResizeLV(ctx, in)
 }
 
+func (l *embeddedServiceClients) ModifyLV(ctx context.Context, in *proto.ModifyLVRequest, _ ...grpc.CallOption) (*proto.Empty, error) {
+	return l.lvServiceServer.ModifyLV(ctx, in)
+}
+
+func (l *embeddedServiceClients) ImportLV(ctx context.Context, in *proto.ImportLVRequest, _ ...grpc.CallOption) (*proto.ImportLVResponse, error) {
+	return l.lvServiceServer.ImportLV(ctx, in)
+}
+
 func (l *embeddedServiceClients) CreateLVSnapshot(ctx context.Context, in *proto.CreateLVSnapshotRequest, _ ...grpc.CallOption) (*proto.CreateLVSnapshotResponse, error) {
 	return l.lvServiceServer.CreateLVSnapshot(ctx, in)
 }
 
+// ReadLV returns a local implementation of the LVService_ReadLVClient interface that relays the messages sent by the local server.
+func (l *embeddedServiceClients) ReadLV(ctx context.Context, in *proto.ReadLVRequest, _ ...grpc.CallOption) (proto.LVService_ReadLVClient, error) {
+	return newEmbeddedServerStream(ctx, func(s *embeddedServerStream[proto.ReadLVResponse]) error {
+		return l.lvServiceServer.ReadLV(in, s)
+	}), nil
+}
+
+// WriteLV returns a local implementation of the LVService_WriteLVClient interface that relays the messages to the local server.
+func (l *embeddedServiceClients) WriteLV(ctx context.Context, _ ...grpc.CallOption) (proto.LVService_WriteLVClient, error) {
+	return newEmbeddedClientStream(ctx, func(s *embeddedClientStream[proto.WriteLVRequest, proto.WriteLVResponse]) error {
+		return l.lvServiceServer.WriteLV(s)
+	}), nil
+}
+
 func (l *embeddedServiceClients) GetLVList(ctx context.Context, in *proto.GetLVListRequest, _ ...grpc.CallOption) (*proto.GetLVListResponse, error) {
 	return l.vgServiceServer.GetLVList(ctx, in)
 }
@@ -0,0 +1,238 @@
+package lvmd
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// cloneMessage deep copies a message passed through an embedded stream.
+// Unlike gRPC, which serializes a message when it is sent, the embedded streams
+// pass messages by reference, so a sender reusing its buffers would otherwise
+// overwrite messages which are not yet received.
+func cloneMessage[T any](m *T) *T {
+	return any(gproto.Clone(any(m).(gproto.Message))).(*T)
+}
+
+// embeddedServerStream is a local implementation of both grpc.ServerStreamingClient and
+// grpc.ServerStreamingServer that relays the messages sent by a server-streaming handler to the local caller.
+type embeddedServerStream[Res any] struct {
+	ctx  context.Context
+	msgs chan *Res
+	done chan struct{}
+	err  error
+}
+
+// newEmbeddedServerStream starts the handler with a new stream and returns the stream to be used by the caller.
+func newEmbeddedServerStream[Res any](ctx context.Context, handler func(*embeddedServerStream[Res]) error) *embeddedServerStream[Res] {
+	s := &embeddedServerStream[Res]{
+		ctx:  ctx,
+		msgs: make(chan *Res),
+		done: make(chan struct{}),
+	}
+	go func() {
+		s.err = handler(s)
+		close(s.done)
+	}()
+	return s
+}
+
+// SetHeader is stubbed out to satisfy the grpc.ServerStream interface.
+func (s *embeddedServerStream[Res]) SetHeader(metadata.MD) error { return nil }
+
+// SendHeader is stubbed out to satisfy the grpc.ServerStream interface.
+func (s *embeddedServerStream[Res]) SendHeader(metadata.MD) error { return nil }
+
+// SetTrailer is stubbed out to satisfy the grpc.ServerStream interface.
+func (s *embeddedServerStream[Res]) SetTrailer(metadata.MD) {}
+
+// Header is stubbed out to satisfy the grpc.ClientStream interface.
+func (s *embeddedServerStream[Res]) Header() (metadata.MD, error) { return nil, nil }
+
+// Trailer is stubbed out to satisfy the grpc.ClientStream interface.
+func (s *embeddedServerStream[Res]) Trailer() metadata.MD { return nil }
+
+// CloseSend is stubbed out to satisfy the grpc.ClientStream interface.
+func (s *embeddedServerStream[Res]) CloseSend() error { return nil }
+
+// Context returns the caller context, which is shared with the handler.
+func (s *embeddedServerStream[Res]) Context() context.Context { return s.ctx }
+
+// Send is used by the handler to send a message to the caller.
+func (s *embeddedServerStream[Res]) Send(m *Res) error {
+	select {
+	case s.msgs <- cloneMessage(m):
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// Recv is used by the caller to receive a message. It returns io.EOF after the handler returned successfully.
+func (s *embeddedServerStream[Res]) Recv() (*Res, error) {
+	select {
+	case m := <-s.msgs:
+		return m, nil
+	case <-s.done:
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+// SendMsg is used to send messages both as a grpc.ClientStream and as a grpc.ServerStream.
+func (s *embeddedServerStream[Res]) SendMsg(m any) error {
+	msg, ok := m.(*Res)
+	if !ok {
+		return status.Error(codes.Internal, "unexpected message type")
+	}
+	return s.Send(msg)
+}
+
+// RecvMsg is used to receive messages both as a grpc.ClientStream and as a grpc.ServerStream.
+func (s *embeddedServerStream[Res]) RecvMsg(m any) error {
+	msg, ok := m.(*Res)
+	if !ok {
+		return status.Error(codes.Internal, "unexpected message type")
+	}
+	received, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	gproto.Merge(any(msg).(gproto.Message), any(received).(gproto.Message))
+	return nil
+}
+
+// embeddedClientStream is a local implementation of both grpc.ClientStreamingClient and
+// grpc.ClientStreamingServer that relays the messages sent by the local caller to a client-streaming handler.
+type embeddedClientStream[Req any, Res any] struct {
+	ctx       context.Context
+	msgs      chan *Req
+	closeOnce sync.Once
+	done      chan struct{}
+	res       *Res
+	err       error
+}
+
+// newEmbeddedClientStream starts the handler with a new stream and returns the stream to be used by the caller.
+func newEmbeddedClientStream[Req any, Res any](ctx context.Context, handler func(*embeddedClientStream[Req, Res]) error) *embeddedClientStream[Req, Res] {
+	s := &embeddedClientStream[Req, Res]{
+		ctx:  ctx,
+		msgs: make(chan *Req),
+		done: make(chan struct{}),
+	}
+	go func() {
+		s.err = handler(s)
+		close(s.done)
+	}()
+	return s
+}
+
+// SetHeader is stubbed out to satisfy the grpc.ServerStream interface.
+func (s *embeddedClientStream[Req, Res]) SetHeader(metadata.MD) error { return nil }
+
+// SendHeader is stubbed out to satisfy the grpc.ServerStream interface.
+func (s *embeddedClientStream[Req, Res]) SendHeader(metadata.MD) error { return nil }
+
+// SetTrailer is stubbed out to satisfy the grpc.ServerStream interface.
+func (s *embeddedClientStream[Req, Res]) SetTrailer(metadata.MD) {}
+
+// Header is stubbed out to satisfy the grpc.ClientStream interface.
+func (s *embeddedClientStream[Req, Res]) Header() (metadata.MD, error) { return nil, nil }
+
+// Trailer is stubbed out to satisfy the grpc.ClientStream interface.
+func (s *embeddedClientStream[Req, Res]) Trailer() metadata.MD { return nil }
+
+// Context returns the caller context, which is shared with the handler.
+func (s *embeddedClientStream[Req, Res]) Context() context.Context { return s.ctx }
+
+// CloseSend tells the handler that no more messages will be sent.
+func (s *embeddedClientStream[Req, Res]) CloseSend() error {
+	s.closeOnce.Do(func() { close(s.msgs) })
+	return nil
+}
+
+// Send is used by the caller to send a message to the handler.
+// Like gRPC, it returns io.EOF if the handler has already returned, and the
+// actual result can be retrieved with CloseAndRecv.
+func (s *embeddedClientStream[Req, Res]) Send(m *Req) error {
+	select {
+	case s.msgs <- cloneMessage(m):
+		return nil
+	case <-s.done:
+		return io.EOF
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// CloseAndRecv closes the sending side and waits for the response of the handler.
+func (s *embeddedClientStream[Req, Res]) CloseAndRecv() (*Res, error) {
+	if err := s.CloseSend(); err != nil {
+		return nil, err
+	}
+	select {
+	case <-s.done:
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.res == nil {
+		return nil, status.Error(codes.Internal, "handler returned without a response")
+	}
+	return s.res, nil
+}
+
+// Recv is used by the handler to receive a message. It returns io.EOF after the caller closed the sending side.
+func (s *embeddedClientStream[Req, Res]) Recv() (*Req, error) {
+	select {
+	case m, ok := <-s.msgs:
+		if !ok {
+			return nil, io.EOF
+		}
+		return m, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+// SendAndClose is used by the handler to send the response.
+func (s *embeddedClientStream[Req, Res]) SendAndClose(m *Res) error {
+	s.res = cloneMessage(m)
+	return nil
+}
+
+// SendMsg is used to send messages both as a grpc.ClientStream and as a grpc.ServerStream.
+func (s *embeddedClientStream[Req, Res]) SendMsg(m any) error {
+	switch msg := m.(type) {
+	case *Req:
+		return s.Send(msg)
+	case *Res:
+		return s.SendAndClose(msg)
+	}
+	return status.Error(codes.Internal, "unexpected message type")
+}
+
+// RecvMsg is used to receive messages as a grpc.ServerStream.
+func (s *embeddedClientStream[Req, Res]) RecvMsg(m any) error {
+	msg, ok := m.(*Req)
+	if !ok {
+		return status.Error(codes.Internal, "unexpected message type")
+	}
+	received, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	gproto.Merge(any(msg).(gproto.Message), any(received).(gproto.Message))
+	return nil
+}
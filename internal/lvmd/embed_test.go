@@ -1,11 +1,16 @@
 package lvmd
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
 	"testing"
 
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestNewEmbeddedServiceClients(t *testing.T) {
@@ -38,7 +43,7 @@ func TestNewEmbeddedServiceClients(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			_, vgclient := NewEmbeddedServiceClients(ctx, NewDeviceClassManager(tt.deviceClasses), NewLvcreateOptionClassManager(nil))
+			_, vgclient, _ := NewEmbeddedServiceClients(ctx, NewDeviceClassManager(tt.deviceClasses), NewLvcreateOptionClassManager(nil))
 
 			watchClient, err := vgclient.Watch(ctx, &proto.Empty{}, nil)
 			if err != nil {
@@ -65,3 +70,91 @@ func TestNewEmbeddedServiceClients(t *testing.T) {
 		})
 	}
 }
+
+func TestEmbeddedServerStream(t *testing.T) {
+	ctx := context.Background()
+	stream := newEmbeddedServerStream(ctx, func(s *embeddedServerStream[proto.ReadLVResponse]) error {
+		// the buffer is reused like ReadLV does
+		buf := make([]byte, 1)
+		for i := range 3 {
+			buf[0] = byte(i)
+			if err := s.Send(&proto.ReadLVResponse{Offset: uint64(i), Data: buf}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	var received []*proto.ReadLVResponse
+	for {
+		res, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, res)
+	}
+	if len(received) != 3 {
+		t.Fatalf("unexpected number of messages: %d", len(received))
+	}
+	for i, res := range received {
+		if res.Offset != uint64(i) || !bytes.Equal(res.Data, []byte{byte(i)}) {
+			t.Errorf("unexpected message %d: %v", i, res)
+		}
+	}
+
+	stream = newEmbeddedServerStream(ctx, func(s *embeddedServerStream[proto.ReadLVResponse]) error {
+		return status.Error(codes.NotFound, "not found")
+	})
+	if _, err := stream.Recv(); status.Code(err) != codes.NotFound {
+		t.Errorf("the error of the handler should be returned: %v", err)
+	}
+}
+
+func TestEmbeddedClientStream(t *testing.T) {
+	ctx := context.Background()
+	stream := newEmbeddedClientStream(ctx, func(s *embeddedClientStream[proto.WriteLVRequest, proto.WriteLVResponse]) error {
+		var written uint64
+		for {
+			req, err := s.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			written += uint64(len(req.Data))
+		}
+		return s.SendAndClose(&proto.WriteLVResponse{WrittenBytes: written})
+	})
+	for range 3 {
+		if err := stream.Send(&proto.WriteLVRequest{Data: make([]byte, 10)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.WrittenBytes != 30 {
+		t.Errorf("unexpected written bytes: %d", res.WrittenBytes)
+	}
+
+	stream = newEmbeddedClientStream(ctx, func(s *embeddedClientStream[proto.WriteLVRequest, proto.WriteLVResponse]) error {
+		return status.Error(codes.OutOfRange, "out of range")
+	})
+	for {
+		// Send eventually returns io.EOF after the handler returned
+		if err := stream.Send(&proto.WriteLVRequest{}); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.OutOfRange {
+		t.Errorf("the error of the handler should be returned: %v", err)
+	}
+}
@@ -1,24 +1,46 @@
 package lvmd
 
 import (
+	"sort"
+	"sync/atomic"
+
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
 )
 
+// LvcreateOptionClassManager maps the names to the lvcreate-option-classes.
+// The lvcreate-option-classes can be replaced while lvmd is running.
 type LvcreateOptionClassManager struct {
-	LvcreateOptionClassByName map[string]*lvmdTypes.LvcreateOptionClass
+	classes atomic.Pointer[map[string]*lvmdTypes.LvcreateOptionClass]
 }
 
 // NewLvcreateOptionClassManager creates a new LvcreateOptionClassManager
 func NewLvcreateOptionClassManager(LvcreateOptionClasses []*lvmdTypes.LvcreateOptionClass) *LvcreateOptionClassManager {
-	cm := LvcreateOptionClassManager{}
-	cm.LvcreateOptionClassByName = make(map[string]*lvmdTypes.LvcreateOptionClass)
+	cm := &LvcreateOptionClassManager{}
+	cm.replace(LvcreateOptionClasses)
+	return cm
+}
+
+// replace replaces all the lvcreate-option-classes at once.
+func (m *LvcreateOptionClassManager) replace(LvcreateOptionClasses []*lvmdTypes.LvcreateOptionClass) {
+	byName := make(map[string]*lvmdTypes.LvcreateOptionClass)
 	for _, c := range LvcreateOptionClasses {
-		cm.LvcreateOptionClassByName[c.Name] = c
+		byName[c.Name] = c
+	}
+	m.classes.Store(&byName)
+}
+
+// names returns the sorted names of the lvcreate-option-classes.
+func (m *LvcreateOptionClassManager) names() []string {
+	byName := *m.classes.Load()
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
 	}
-	return &cm
+	sort.Strings(names)
+	return names
 }
 
 // LvcreateOptionClassClass returns the lvcreate-option-class by its name
-func (m LvcreateOptionClassManager) LvcreateOptionClass(name string) *lvmdTypes.LvcreateOptionClass {
-	return m.LvcreateOptionClassByName[name]
+func (m *LvcreateOptionClassManager) LvcreateOptionClass(name string) *lvmdTypes.LvcreateOptionClass {
+	return (*m.classes.Load())[name]
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/topolvm/topolvm/internal/lvmd/command"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
@@ -13,6 +15,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+const (
+	// thickCopyTargetSuffix is appended to the name of a volume while data is copied into it.
+	thickCopyTargetSuffix = "-copying"
+	// thickCopySourceSuffix is appended to the name of the temporary snapshot used as a copy source.
+	thickCopySourceSuffix = "-copysrc"
+
+	// lvDataChunkSize is the maximum size of the data in a single ReadLV or WriteLV message.
+	lvDataChunkSize = 1 << 20
+)
+
 // NewLVService creates a new LVServiceServer
 func NewLVService(dcmapper *DeviceClassManager, ocmapper *LvcreateOptionClassManager, notifyFunc func()) proto.LVServiceServer {
 	return &lvService{
@@ -123,6 +135,26 @@ func (s *lvService) RemoveLV(ctx context.Context, req *proto.RemoveLVRequest) (*
 		return nil, err
 	}
 
+	// LVM removes thick snapshots together with their origin, so refuse to remove
+	// the origin until all of its snapshots have been deleted.
+	if dc.Type == lvmdTypes.TypeThick {
+		lv, err := vg.FindVolume(ctx, req.GetName())
+		if errors.Is(err, command.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.DeviceClass)
+		} else if err != nil {
+			logger.Error(err, "failed to find volume", "name", req.GetName())
+			return nil, err
+		}
+		snapshots, err := lv.ThickSnapshots(ctx)
+		if err != nil {
+			logger.Error(err, "failed to list snapshots of volume", "name", req.GetName())
+			return nil, err
+		}
+		if len(snapshots) > 0 {
+			return nil, status.Errorf(codes.FailedPrecondition, "logical volume %s has %d snapshots which must be removed first", req.GetName(), len(snapshots))
+		}
+	}
+
 	if err := vg.RemoveVolume(ctx, req.GetName()); errors.Is(err, command.ErrNotFound) {
 		return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.DeviceClass)
 	} else if err != nil {
@@ -148,7 +180,7 @@ func (s *lvService) CreateLVSnapshot(ctx context.Context, req *proto.CreateLVSna
 	case lvmdTypes.TypeThin:
 		snapType = "thin-snapshot"
 	case lvmdTypes.TypeThick:
-		return nil, status.Error(codes.Unimplemented, "device class is not thin. Thick snapshots are not implemented yet")
+		snapType = "thick-snapshot"
 	default:
 		return nil, status.Errorf(codes.InvalidArgument, "invalid device class type %v", string(dc.Type))
 	}
@@ -170,6 +202,10 @@ func (s *lvService) CreateLVSnapshot(ctx context.Context, req *proto.CreateLVSna
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
+	if dc.Type == lvmdTypes.TypeThick {
+		return s.createThickSnapshot(ctx, dc, vg, sourceLV, req)
+	}
+
 	if !sourceLV.IsThin() {
 		return nil, status.Error(codes.Unimplemented, "snapshot can be created for only thin volumes")
 	}
@@ -265,6 +301,193 @@ func (s *lvService) CreateLVSnapshot(ctx context.Context, req *proto.CreateLVSna
 	}, nil
 }
 
+// createThickSnapshot creates a new volume from a thickly-provisioned source volume.
+// Read-only snapshots of a regular volume are classic copy-on-write snapshots.
+// Since LVM cannot take snapshots of such snapshots and a writable copy-on-write
+// snapshot would not outlive its origin, all other requests (restoring a snapshot
+// or cloning a volume) create an independent volume and copy the data into it.
+func (s *lvService) createThickSnapshot(ctx context.Context, dc *lvmdTypes.DeviceClass, vg *command.VolumeGroup,
+	sourceLV *command.LogicalVolume, req *proto.CreateLVSnapshotRequest) (*proto.CreateLVSnapshotResponse, error) {
+	logger := log.FromContext(ctx).WithValues("name", req.GetName())
+
+	if sourceLV.IsThin() {
+		return nil, status.Errorf(codes.InvalidArgument, "source logical volume %s is not a thick volume", sourceLV.Name())
+	}
+	if sourceLV.IsThickSnapshot() {
+		attr, err := command.ParsedLVAttr(sourceLV.Attr())
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if attr.State == command.StateInvalidSnapshot {
+			logger.Error(command.ErrInvalidSnapshot, "source snapshot is invalid", "sourceVolume", sourceLV.Name())
+			return nil, status.Errorf(codes.FailedPrecondition, "source snapshot %s is invalid, its copy-on-write area was exhausted", sourceLV.Name())
+		}
+	}
+
+	sizeOnCreation := sourceLV.Size()
+	desiredSize := uint64(req.GetSizeBytes())
+	if desiredSize == 0 {
+		desiredSize = sizeOnCreation
+	}
+
+	free, err := vg.Free()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get free bytes: %v", err)
+	}
+	// copy-on-write areas of a cached device-class are kept off the cache physical volumes
+	cowFree, cowPVs := free, []string(nil)
+	if dc.CacheConfig != nil {
+		space, err := getCacheSpace(ctx, vg, dc)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get free bytes: %v", err)
+		}
+		free = space.Free()
+		cowFree, cowPVs = space.originFree, space.originPVs
+	}
+
+	var snapLV *command.LogicalVolume
+	if req.GetAccessType() == "ro" && !sourceLV.IsSnapshot() {
+		if desiredSize != sizeOnCreation {
+			return nil, status.Errorf(codes.OutOfRange, "requested size %v differs from source logical volume: %v, thick snapshots cannot be resized", desiredSize, sizeOnCreation)
+		}
+		cowSize := GetCOWReserve(dc, sizeOnCreation)
+		if cowFree < cowSize {
+			logger.Error(err, "not enough space left on VG", "free", cowFree, "cowSize", cowSize)
+			return nil, status.Errorf(codes.ResourceExhausted, "no enough space left on VG: free=%d, cowSize=%d", cowFree, cowSize)
+		}
+
+		logger.Info("lvservice req", "cowSize", cowSize, "sourceVol", sourceLV.Name(), "snapType", "thick-snapshot")
+		if err := sourceLV.Snapshot(ctx, req.GetName(), cowSize, req.GetTags(), cowPVs...); err != nil {
+			logger.Error(err, "failed to create snapshot volume")
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		snapLV, err = vg.FindVolume(ctx, req.GetName())
+		if err != nil {
+			logger.Error(err, "failed to get snapshot after creation")
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	} else {
+		if sizeOnCreation > desiredSize && !sourceLV.IsSnapshot() {
+			return nil, status.Errorf(codes.OutOfRange, "requested size %v is smaller than source logical volume: %v", desiredSize, sizeOnCreation)
+		}
+		snapLV, err = s.copyThickVolume(ctx, dc, vg, sourceLV, desiredSize, free, cowPVs, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := snapLV.Activate(ctx, req.GetAccessType()); err != nil {
+		logger.Error(err, "failed to activate snapshot volume")
+		if err := vg.RemoveVolume(ctx, req.GetName()); err != nil {
+			logger.Error(err, "failed to delete snapshot after activation failed")
+		} else {
+			logger.Info("deleted a snapshot")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	s.notify()
+
+	logger.Info(
+		"created a new thick snapshot LV",
+		"size", snapLV.Size(),
+		"cowSize", snapLV.COWSize(),
+		"accessType", req.GetAccessType(),
+		"sourceID", sourceLV.Name(),
+	)
+
+	return &proto.CreateLVSnapshotResponse{
+		Snapshot: &proto.LogicalVolume{
+			Name:      snapLV.Name(),
+			SizeBytes: int64(snapLV.Size()),
+			DevMajor:  snapLV.MajorNumber(),
+			DevMinor:  snapLV.MinorNumber(),
+		},
+	}, nil
+}
+
+// copyThickVolume creates a thick volume of the desired size and copies the data of sourceLV into it.
+// A regular source volume may be in use, so its data is read from a temporary snapshot to get a
+// consistent copy. The target is created under a temporary name and renamed only after the copy
+// has completed, so an interrupted copy is never mistaken for a finished volume.
+// The copy-on-write area of the temporary snapshot is allocated from cowPVs if given.
+func (s *lvService) copyThickVolume(ctx context.Context, dc *lvmdTypes.DeviceClass, vg *command.VolumeGroup,
+	sourceLV *command.LogicalVolume, desiredSize, free uint64, cowPVs []string, req *proto.CreateLVSnapshotRequest) (*command.LogicalVolume, error) {
+	logger := log.FromContext(ctx).WithValues("name", req.GetName())
+
+	tmpName := req.GetName() + thickCopyTargetSuffix
+	srcName := req.GetName() + thickCopySourceSuffix
+	// clean up leftovers of an interrupted previous attempt
+	for _, name := range []string{tmpName, srcName} {
+		if err := vg.RemoveVolume(ctx, name); err != nil && !errors.Is(err, command.ErrNotFound) {
+			logger.Error(err, "failed to remove leftover volume", "leftover", name)
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	required := desiredSize
+	var cowSize uint64
+	if !sourceLV.IsSnapshot() {
+		cowSize = GetCOWReserve(dc, sourceLV.Size())
+		required += cowSize
+	}
+	if free < required {
+		logger.Error(nil, "not enough space left on VG", "free", free, "required", required)
+		return nil, status.Errorf(codes.ResourceExhausted, "no enough space left on VG: free=%d, desiredSize=%d", free, required)
+	}
+
+	copySource := sourceLV
+	if !sourceLV.IsSnapshot() {
+		if err := sourceLV.Snapshot(ctx, srcName, cowSize, nil, cowPVs...); err != nil {
+			logger.Error(err, "failed to create temporary snapshot of source volume")
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		defer func() {
+			if err := vg.RemoveVolume(ctx, srcName); err != nil {
+				logger.Error(err, "failed to remove temporary snapshot of source volume", "snapshot", srcName)
+			}
+		}()
+		var err error
+		copySource, err = vg.FindVolume(ctx, srcName)
+		if err != nil {
+			logger.Error(err, "failed to get temporary snapshot after creation")
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	var stripe uint
+	if dc.Stripe != nil {
+		stripe = *dc.Stripe
+	}
+	if err := thickPoolForVolumeGroup(vg, dc).CreateVolume(ctx, tmpName, desiredSize, req.GetTags(), stripe, dc.StripeSize, dc.LVCreateOptions); err != nil {
+		logger.Error(err, "failed to create volume", "requested", desiredSize)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	target, err := vg.FindVolume(ctx, tmpName)
+	if err != nil {
+		logger.Error(err, "failed to find volume after creation")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// the origin of a snapshot may have been expanded after the snapshot was taken,
+	// in which case the snapshot can be larger than the requested size.
+	size := min(copySource.Size(), target.Size())
+	logger.Info("copying thick volume", "source", copySource.Name(), "size", size)
+	if err := copyVolume(ctx, copySource.Path(), target.Path(), size); err != nil {
+		logger.Error(err, "failed to copy volume data", "source", copySource.Name())
+		if err := vg.RemoveVolume(ctx, tmpName); err != nil {
+			logger.Error(err, "failed to delete volume after copy failed")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if err := target.Rename(ctx, req.GetName()); err != nil {
+		logger.Error(err, "failed to rename volume after copy")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return target, nil
+}
+
 func (s *lvService) ResizeLV(ctx context.Context, req *proto.ResizeLVRequest) (*proto.ResizeLVResponse, error) {
 	logger := log.FromContext(ctx).WithValues("name", req.GetName())
 
@@ -307,22 +530,54 @@ func (s *lvService) ResizeLV(ctx context.Context, req *proto.ResizeLVRequest) (*
 		"free", free,
 	)
 
-	if free < (requested - current) {
+	if lv.IsThickSnapshot() {
+		return nil, status.Errorf(codes.FailedPrecondition, "logical volume %s is a thick snapshot and cannot be resized", req.GetName())
+	}
+
+	var snapshots []*command.LogicalVolume
+	var cowGrowth uint64
+	if dc.Type == lvmdTypes.TypeThick {
+		snapshots, err = lv.ThickSnapshots(ctx)
+		if err != nil {
+			logger.Error(err, "failed to list snapshots of volume")
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		// grow the copy-on-write areas along with the origin so that the reserve keeps
+		// covering the same fraction of the origin
+		for _, snap := range snapshots {
+			if cowSize := GetCOWReserve(dc, requested); cowSize > snap.COWSize() {
+				cowGrowth += cowSize - snap.COWSize()
+			}
+		}
+	}
+
+	if free < (requested-current)+cowGrowth {
 		logger.Error(err, "no enough space left on VG",
 			"requested", requested,
 			"current", current,
+			"cowGrowth", cowGrowth,
 			"free", free,
 		)
-		return nil, status.Errorf(codes.ResourceExhausted, "no enough space left on VG: free=%d, requested=%d", free, requested-current)
+		return nil, status.Errorf(codes.ResourceExhausted, "no enough space left on VG: free=%d, requested=%d", free, requested-current+cowGrowth)
 	}
 
-	err = lv.Resize(ctx, requested)
+	switch {
+	case dc.CacheConfig != nil:
+		err = s.resizeCachedVolume(ctx, dc, lv, snapshots, requested)
+	case len(snapshots) > 0:
+		err = s.resizeThickOrigin(ctx, dc, lv, snapshots, requested)
+	default:
+		err = lv.Resize(ctx, requested)
+	}
 	if err != nil {
 		logger.Error(err, "failed to resize LV",
 			"requested", requested,
 			"current", current,
 			"free", free,
 		)
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	s.notify()
@@ -331,3 +586,409 @@ func (s *lvService) ResizeLV(ctx context.Context, req *proto.ResizeLVRequest) (*
 
 	return &proto.ResizeLVResponse{SizeBytes: int64(lv.Size())}, nil
 }
+
+// resizeThickOrigin resizes a thick volume which has thick snapshots.
+// LVM resizes such origins only while they are inactive, so the volume cannot
+// be resized while it is in use. The copy-on-write areas of the snapshots are
+// grown to the reserve of the new origin size as well.
+func (s *lvService) resizeThickOrigin(ctx context.Context, dc *lvmdTypes.DeviceClass, lv *command.LogicalVolume,
+	snapshots []*command.LogicalVolume, requested uint64) error {
+	logger := log.FromContext(ctx).WithValues("name", lv.Name())
+
+	attr, err := command.ParsedLVAttr(lv.Attr())
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if attr.Open == command.OpenTrue {
+		return status.Errorf(codes.FailedPrecondition, "logical volume %s has %d snapshots and can be resized only while it is not in use", lv.Name(), len(snapshots))
+	}
+
+	if err := lv.Deactivate(ctx); err != nil {
+		logger.Error(err, "failed to deactivate LV before resize")
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer func() {
+		// activating the origin also activates its snapshots
+		if err := lv.Activate(ctx, "rw"); err != nil {
+			logger.Error(err, "failed to activate LV after resize")
+		}
+	}()
+
+	if err := lv.Resize(ctx, requested); err != nil {
+		return err
+	}
+
+	cowSize := GetCOWReserve(dc, requested)
+	for _, snap := range snapshots {
+		attr, err := command.ParsedLVAttr(snap.Attr())
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		// an invalid snapshot cannot be recovered by growing it
+		if attr.State == command.StateInvalidSnapshot || snap.COWSize() >= cowSize {
+			continue
+		}
+		if err := snap.ResizeCOW(ctx, cowSize); err != nil {
+			logger.Error(err, "failed to resize copy-on-write area of snapshot", "snapshot", snap.Name())
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+	return nil
+}
+
+// resizeCachedVolume resizes a volume of a cached device-class. The origin is extended onto the
+// physical volumes other than the cache physical volumes, and the cache volume is replaced with
+// one which keeps the configured ratio to the new size. LVM does not allow detaching the cache
+// of an origin of thick snapshots, so such volumes cannot be resized.
+func (s *lvService) resizeCachedVolume(ctx context.Context, dc *lvmdTypes.DeviceClass, lv *command.LogicalVolume,
+	snapshots []*command.LogicalVolume, requested uint64) error {
+	if len(snapshots) > 0 {
+		return status.Errorf(codes.FailedPrecondition, "logical volume %s is cached and has %d snapshots, it cannot be resized", lv.Name(), len(snapshots))
+	}
+
+	vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+	if err != nil {
+		return err
+	}
+	space, err := getCacheSpace(ctx, vg, dc)
+	if err != nil {
+		return err
+	}
+	// keep the cache mode of the volume, which may have been changed by ModifyLV
+	mode := string(GetCacheMode(dc))
+	if mode != command.CacheModeWritecache && lv.IsCached() {
+		current, err := lv.CacheMode(ctx)
+		if err != nil {
+			return err
+		}
+		if current != "" {
+			mode = current
+		}
+	}
+	return lv.ResizeCached(ctx, requested, space.originPVs, GetCacheSize(dc, requested), space.cachePVs, mode)
+}
+
+func (s *lvService) ImportLV(ctx context.Context, req *proto.ImportLVRequest) (*proto.ImportLVResponse, error) {
+	logger := log.FromContext(ctx).WithValues("name", req.GetName())
+
+	dc, err := s.dcmapper.DeviceClass(req.DeviceClass)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.DeviceClass)
+	}
+	if vg := req.GetVolumeGroup(); vg != "" && vg != dc.VolumeGroup {
+		return nil, status.Errorf(codes.InvalidArgument, "volume group %s is not the volume group %s of device class %s", vg, dc.VolumeGroup, req.DeviceClass)
+	}
+	pool, err := storagePoolForDeviceClass(ctx, dc)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get pool from device class: %v", err)
+	}
+	lv, err := pool.FindVolume(ctx, req.GetName())
+	if errors.Is(err, command.ErrNotFound) {
+		logger.Error(err, "logical volume is not found")
+		return nil, status.Errorf(codes.NotFound, "logical volume %s is not found", req.GetName())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find volume")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if dc.Type == lvmdTypes.TypeThick && lv.IsThin() {
+		return nil, status.Errorf(codes.FailedPrecondition, "logical volume %s is a thin volume but device class %s is thick", req.GetName(), req.DeviceClass)
+	}
+
+	logger.Info("lvservice request - ImportLV", "tags", req.GetTags())
+
+	if err := lv.AddTags(ctx, req.GetTags()); err != nil {
+		logger.Error(err, "failed to add tags", "tags", req.GetTags())
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	s.notify()
+
+	logger.Info("imported an existing LV", "size", lv.Size())
+
+	return &proto.ImportLVResponse{
+		Volume: &proto.LogicalVolume{
+			Name:      lv.Name(),
+			SizeBytes: int64(lv.Size()),
+			DevMajor:  lv.MajorNumber(),
+			DevMinor:  lv.MinorNumber(),
+			Tags:      lv.Tags(),
+			Path:      lv.Path(),
+			Attr:      lv.Attr(),
+		},
+	}, nil
+}
+
+func (s *lvService) ModifyLV(ctx context.Context, req *proto.ModifyLVRequest) (*proto.Empty, error) {
+	logger := log.FromContext(ctx).WithValues("name", req.GetName())
+
+	dc, err := s.dcmapper.DeviceClass(req.DeviceClass)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.DeviceClass)
+	}
+	pool, err := storagePoolForDeviceClass(ctx, dc)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get pool from device class: %v", err)
+	}
+	lv, err := pool.FindVolume(ctx, req.GetName())
+	if errors.Is(err, command.ErrNotFound) {
+		logger.Error(err, "logical volume is not found")
+		return nil, status.Errorf(codes.NotFound, "logical volume %s is not found", req.GetName())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find volume")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	logger.Info(
+		"lvservice request - ModifyLV",
+		"lvcreateOptionClass", req.GetLvcreateOptionClass(),
+		"cacheMode", req.GetCacheMode(),
+	)
+
+	if name := req.GetLvcreateOptionClass(); name != "" {
+		oc := s.ocmapper.LvcreateOptionClass(name)
+		if oc == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported lvcreate-option-class target: %s", name)
+		}
+		// the options of lvcreate cannot be applied to an existing volume,
+		// so only the options for lvchange are applied
+		if len(oc.LvchangeOptions) > 0 {
+			if err := lv.Change(ctx, oc.LvchangeOptions); err != nil {
+				logger.Error(err, "failed to change LV", "lvchangeOptions", oc.LvchangeOptions)
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+		}
+	}
+
+	if mode := req.GetCacheMode(); mode != "" {
+		if mode != string(lvmdTypes.CacheModeWritethrough) && mode != string(lvmdTypes.CacheModeWriteback) {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported cache mode: %s", mode)
+		}
+		if !lv.IsCached() || GetCacheMode(dc) == lvmdTypes.CacheModeWritecache {
+			return nil, status.Errorf(codes.FailedPrecondition, "logical volume %s is not cached by dm-cache", req.GetName())
+		}
+		if err := lv.ChangeCacheMode(ctx, mode); err != nil {
+			logger.Error(err, "failed to change cache mode", "cacheMode", mode)
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	s.notify()
+
+	logger.Info("modified a LV")
+
+	return &proto.Empty{}, nil
+}
+
+func (s *lvService) ReadLV(req *proto.ReadLVRequest, stream proto.LVService_ReadLVServer) error {
+	ctx := stream.Context()
+	logger := log.FromContext(ctx).WithValues("name", req.GetName())
+
+	dc, err := s.dcmapper.DeviceClass(req.DeviceClass)
+	if err != nil {
+		return status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.DeviceClass)
+	}
+	vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	lv, err := vg.FindVolume(ctx, req.GetName())
+	if errors.Is(err, command.ErrNotFound) {
+		return status.Errorf(codes.NotFound, "logical volume %s is not found", req.GetName())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find volume")
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	var base *command.LogicalVolume
+	if req.GetBaseName() != "" {
+		base, err = vg.FindVolume(ctx, req.GetBaseName())
+		if errors.Is(err, command.ErrNotFound) {
+			return status.Errorf(codes.NotFound, "base logical volume %s is not found", req.GetBaseName())
+		}
+		if err != nil {
+			logger.Error(err, "failed to find base volume")
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	ranges := []command.Range{{Offset: 0, Length: lv.Size()}}
+	switch {
+	case base != nil && base.IsThin() && lv.IsThin():
+		changed, err := lv.ChangedRanges(ctx, base)
+		if err != nil {
+			// the thin-provisioning-tools may be missing on the host, sending everything is still correct
+			logger.Error(err, "failed to get changed ranges of thin volume, sending the whole volume")
+		} else {
+			ranges = changed
+		}
+	case base == nil && lv.IsThin():
+		allocated, err := lv.AllocatedRanges(ctx)
+		if err != nil {
+			logger.Error(err, "failed to get allocated ranges of thin volume, sending the whole volume")
+		} else {
+			ranges = allocated
+		}
+	}
+	if alignment := req.GetAlignment(); alignment > 0 {
+		ranges = alignRanges(ranges, alignment, lv.Size())
+	}
+	var total uint64
+	for _, r := range ranges {
+		total += r.Length
+	}
+
+	f, err := os.Open(lv.Path())
+	if err != nil {
+		logger.Error(err, "failed to open volume")
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer f.Close()
+
+	logger.Info("start reading LV", "size", lv.Size(), "total", total, "ranges", len(ranges))
+	if err := stream.Send(&proto.ReadLVResponse{SizeBytes: lv.Size(), TotalBytes: total}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, lvDataChunkSize)
+	for _, r := range ranges {
+		end := min(r.Offset+r.Length, lv.Size())
+		for offset := r.Offset; offset < end; {
+			n := min(uint64(len(buf)), end-offset)
+			if _, err := f.ReadAt(buf[:n], int64(offset)); err != nil {
+				logger.Error(err, "failed to read volume", "offset", offset)
+				return status.Error(codes.Internal, err.Error())
+			}
+			if err := stream.Send(&proto.ReadLVResponse{Offset: offset, Data: buf[:n]}); err != nil {
+				return err
+			}
+			offset += n
+		}
+	}
+
+	logger.Info("finished reading LV", "total", total)
+	return nil
+}
+
+func (s *lvService) WriteLV(stream proto.LVService_WriteLVServer) error {
+	ctx := stream.Context()
+
+	req, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "no data is sent")
+	}
+	if err != nil {
+		return err
+	}
+	logger := log.FromContext(ctx).WithValues("name", req.GetName())
+
+	dc, err := s.dcmapper.DeviceClass(req.DeviceClass)
+	if err != nil {
+		return status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.DeviceClass)
+	}
+	vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	lv, err := vg.FindVolume(ctx, req.GetName())
+	if errors.Is(err, command.ErrNotFound) {
+		return status.Errorf(codes.NotFound, "logical volume %s is not found", req.GetName())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find volume")
+		return status.Error(codes.Internal, err.Error())
+	}
+	sourceSize := req.GetSizeBytes()
+	if sourceSize > lv.Size() {
+		return status.Errorf(codes.OutOfRange, "source size %d exceeds the size of logical volume %s: %d", sourceSize, lv.Name(), lv.Size())
+	}
+
+	f, err := os.OpenFile(lv.Path(), os.O_WRONLY, 0)
+	if err != nil {
+		logger.Error(err, "failed to open volume")
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer f.Close()
+
+	// Unprovisioned regions of thin volumes read as zeros, but thick volumes
+	// may contain stale data, so regions which are not sent have to be zeroed.
+	// A thin volume is expected to be newly created for every transfer attempt.
+	zeroFill := !lv.IsThin()
+	var next, written uint64
+	fill := func(end uint64) error {
+		if !zeroFill {
+			return nil
+		}
+		zeros := make([]byte, min(lvDataChunkSize, end-next))
+		for offset := next; offset < end; {
+			n := min(uint64(len(zeros)), end-offset)
+			if _, err := f.WriteAt(zeros[:n], int64(offset)); err != nil {
+				return err
+			}
+			offset += n
+		}
+		return nil
+	}
+
+	logger.Info("start writing LV", "size", lv.Size(), "sourceSize", sourceSize)
+	for {
+		data := req.GetData()
+		if len(data) > 0 {
+			offset := req.GetOffset()
+			if offset < next {
+				return status.Errorf(codes.InvalidArgument, "data at offset %d is not sent in ascending order", offset)
+			}
+			if offset+uint64(len(data)) > sourceSize {
+				return status.Errorf(codes.OutOfRange, "data at offset %d exceeds the source size %d", offset, sourceSize)
+			}
+			if offset > next {
+				if err := fill(offset); err != nil {
+					logger.Error(err, "failed to zero volume", "offset", next)
+					return status.Error(codes.Internal, err.Error())
+				}
+			}
+			if _, err := f.WriteAt(data, int64(offset)); err != nil {
+				logger.Error(err, "failed to write volume", "offset", offset)
+				return status.Error(codes.Internal, err.Error())
+			}
+			next = offset + uint64(len(data))
+			written += uint64(len(data))
+		}
+
+		req, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if next < sourceSize {
+		if err := fill(sourceSize); err != nil {
+			logger.Error(err, "failed to zero volume", "offset", next)
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+	if err := f.Sync(); err != nil {
+		logger.Error(err, "failed to sync volume")
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	logger.Info("finished writing LV", "written", written)
+	return stream.SendAndClose(&proto.WriteLVResponse{WrittenBytes: written})
+}
+
+// alignRanges expands the ranges to multiples of alignment within size, and merges overlapping ones.
+func alignRanges(ranges []command.Range, alignment, size uint64) []command.Range {
+	aligned := make([]command.Range, 0, len(ranges))
+	for _, r := range ranges {
+		begin := r.Offset / alignment * alignment
+		end := min((r.Offset+r.Length+alignment-1)/alignment*alignment, size)
+		if begin >= end {
+			continue
+		}
+		aligned = append(aligned, command.Range{Offset: begin, Length: end - begin})
+	}
+	return command.MergeRanges(aligned)
+}
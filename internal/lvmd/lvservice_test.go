@@ -3,8 +3,10 @@ package lvmd
 import (
 	"context"
 	"errors"
+	"os"
 	"os/exec"
 	"path"
+	"reflect"
 	"testing"
 
 	"github.com/go-logr/logr/testr"
@@ -415,3 +417,188 @@ func TestLVService_ThinSnapshots(t *testing.T) {
 		t.Errorf(`testsnaptag1 not present on snapshot`)
 	}
 }
+
+func TestLVService_ThickSnapshots(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), testr.New(t))
+	lvService, count, vg, _ := setupLVService(ctx, t)
+
+	// create sourceVolume
+	var originalSizeBytes int64 = 512 << 20 // 512 MiB
+	_, err := lvService.CreateLV(context.Background(), &proto.CreateLVRequest{
+		Name:        "sourceVol",
+		DeviceClass: lvServiceTestThickDC,
+		SizeBytes:   originalSizeBytes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vg.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+	sourceLV, err := vg.FindVolume(ctx, "sourceVol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello thick snapshot")
+	if err := os.WriteFile(sourceLV.Path(), data, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// create snapshot of sourceVol
+	snapRes, err := lvService.CreateLVSnapshot(context.Background(), &proto.CreateLVSnapshotRequest{
+		Name:         "snap1",
+		DeviceClass:  lvServiceTestThickDC,
+		SourceVolume: "sourceVol",
+		SizeBytes:    originalSizeBytes,
+		AccessType:   "ro",
+		Tags:         []string{"testsnaptag1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *count != 2 {
+		t.Errorf("is not notified: %d", count)
+	}
+	if snapRes.GetSnapshot().GetSizeBytes() != originalSizeBytes {
+		t.Errorf(`snapRes.Snapshot.SizeBytes != %d: %d`, originalSizeBytes, snapRes.GetSnapshot().GetSizeBytes())
+	}
+
+	if err := vg.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+	snapLV, err := vg.FindVolume(ctx, "snap1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snapLV.IsThickSnapshot() {
+		t.Error("snap1 should be a thick snapshot")
+	}
+	if snapLV.COWSize() < uint64(originalSizeBytes) {
+		t.Errorf("unexpected cow size: %d", snapLV.COWSize())
+	}
+	if snapLV.Tags()[0] != "testsnaptag1" {
+		t.Errorf(`testsnaptag1 not present on snapshot`)
+	}
+
+	// overwrite the origin, the snapshot keeps the original data
+	if err := os.WriteFile(sourceLV.Path(), []byte("overwritten"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// restore the created snapshot to a new logical volume.
+	restoreRes, err := lvService.CreateLVSnapshot(context.Background(), &proto.CreateLVSnapshotRequest{
+		Name:         "restoredsnap1",
+		DeviceClass:  lvServiceTestThickDC,
+		SourceVolume: "snap1",
+		SizeBytes:    originalSizeBytes,
+		AccessType:   "rw",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *count != 3 {
+		t.Errorf("is not notified: %d", count)
+	}
+	if restoreRes.GetSnapshot().GetName() != "restoredsnap1" {
+		t.Errorf(`restoreRes.Snapshot.Name != "restoredsnap1": %s`, restoreRes.GetSnapshot().GetName())
+	}
+
+	if err := vg.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+	restoredLV, err := vg.FindVolume(ctx, "restoredsnap1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restoredLV.IsSnapshot() {
+		t.Error("restored volume should not depend on the snapshot")
+	}
+	restored := make([]byte, len(data))
+	f, err := os.Open(restoredLV.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Read(restored); err != nil {
+		t.Fatal(err)
+	}
+	if string(restored) != string(data) {
+		t.Errorf("restored data does not match: %s", restored)
+	}
+	for _, name := range []string{"restoredsnap1" + thickCopyTargetSuffix, "restoredsnap1" + thickCopySourceSuffix} {
+		if _, err := vg.FindVolume(ctx, name); !errors.Is(err, command.ErrNotFound) {
+			t.Errorf("temporary volume %s should be removed: %v", name, err)
+		}
+	}
+
+	// the origin cannot be removed while it has snapshots
+	_, err = lvService.RemoveLV(context.Background(), &proto.RemoveLVRequest{
+		Name:        "sourceVol",
+		DeviceClass: lvServiceTestThickDC,
+	})
+	if code := status.Code(err); code != codes.FailedPrecondition {
+		t.Errorf(`code is not codes.FailedPrecondition: %s`, code)
+	}
+
+	// resizing the origin grows the copy-on-write area of its snapshots
+	_, err = lvService.ResizeLV(context.Background(), &proto.ResizeLVRequest{
+		Name:        "sourceVol",
+		DeviceClass: lvServiceTestThickDC,
+		SizeBytes:   768 << 20, // 768 MiB
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vg.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+	snapLV, err = vg.FindVolume(ctx, "snap1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapLV.COWSize() < 768<<20 {
+		t.Errorf("cow size should be grown: %d", snapLV.COWSize())
+	}
+
+	_, err = lvService.ResizeLV(context.Background(), &proto.ResizeLVRequest{
+		Name:        "snap1",
+		DeviceClass: lvServiceTestThickDC,
+		SizeBytes:   1 << 30,
+	})
+	if code := status.Code(err); code != codes.FailedPrecondition {
+		t.Errorf(`code is not codes.FailedPrecondition: %s`, code)
+	}
+
+	_, err = lvService.RemoveLV(context.Background(), &proto.RemoveLVRequest{
+		Name:        "snap1",
+		DeviceClass: lvServiceTestThickDC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = lvService.RemoveLV(context.Background(), &proto.RemoveLVRequest{
+		Name:        "sourceVol",
+		DeviceClass: lvServiceTestThickDC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAlignRanges(t *testing.T) {
+	got := alignRanges([]command.Range{
+		{Offset: 10, Length: 5},
+		{Offset: 100, Length: 200},
+		{Offset: 250, Length: 10},
+		{Offset: 990, Length: 20},
+	}, 100, 1000)
+	want := []command.Range{
+		{Offset: 0, Length: 300},
+		{Offset: 900, Length: 100},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("alignRanges() = %v, want %v", got, want)
+	}
+}
@@ -2,7 +2,9 @@ package lvmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/topolvm/topolvm/internal/lvmd/command"
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
@@ -22,7 +24,7 @@ func storagePoolForDeviceClass(ctx context.Context, dc *lvmdTypes.DeviceClass) (
 	}
 	switch dc.Type {
 	case lvmdTypes.TypeThick:
-		return &volumeGroupAdapter{vg}, nil
+		return thickPoolForVolumeGroup(vg, dc), nil
 	case lvmdTypes.TypeThin:
 		pool, err := vg.FindPool(ctx, dc.ThinPoolConfig.Name)
 		if err != nil {
@@ -34,6 +36,17 @@ func storagePoolForDeviceClass(ctx context.Context, dc *lvmdTypes.DeviceClass) (
 	return nil, fmt.Errorf("unsupported device class target: %s", dc.Type)
 }
 
+// thickPoolForVolumeGroup returns the storagePool to create the volumes of a thick device-class in its volume group.
+func thickPoolForVolumeGroup(vg *command.VolumeGroup, dc *lvmdTypes.DeviceClass) storagePool {
+	switch {
+	case dc.CacheConfig != nil:
+		return &cachedVolumeGroupAdapter{vg, dc}
+	case dc.RAIDConfig != nil:
+		return &raidVolumeGroupAdapter{vg, dc}
+	}
+	return &volumeGroupAdapter{vg}
+}
+
 type thinPoolAdapter struct {
 	*command.ThinPool
 	overprovisionRatio float64
@@ -55,3 +68,93 @@ type volumeGroupAdapter struct {
 func (vg *volumeGroupAdapter) Free(_ context.Context) (uint64, error) {
 	return vg.VolumeGroup.Free()
 }
+
+// raidVolumeGroupAdapter creates RAID volumes with the layout of the device-class.
+// The free space is the usable size of a RAID volume, not the raw free space of the volume group.
+type raidVolumeGroupAdapter struct {
+	*command.VolumeGroup
+	dc *lvmdTypes.DeviceClass
+}
+
+func (vg *raidVolumeGroupAdapter) Free(ctx context.Context) (uint64, error) {
+	free, err := raidFree(ctx, vg.VolumeGroup, vg.dc)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get free space: %w", err)
+	}
+	return free, nil
+}
+
+func (vg *raidVolumeGroupAdapter) CreateVolume(ctx context.Context, name string, size uint64, tags []string, _ uint, stripeSize string, lvcreateOptions []string) error {
+	stripe, raidArgs := raidOptions(vg.dc.RAIDConfig)
+	return vg.VolumeGroup.CreateVolume(ctx, name, size, tags, stripe, stripeSize, append(raidArgs, lvcreateOptions...))
+}
+
+// cachedVolumeGroupAdapter creates volumes on the physical volumes other than the cache physical volumes
+// of the device-class, and attaches a cache volume created on the cache physical volumes to each of them.
+type cachedVolumeGroupAdapter struct {
+	*command.VolumeGroup
+	dc *lvmdTypes.DeviceClass
+}
+
+func (vg *cachedVolumeGroupAdapter) Free(ctx context.Context) (uint64, error) {
+	space, err := getCacheSpace(ctx, vg.VolumeGroup, vg.dc)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get free space: %w", err)
+	}
+	return space.Free(), nil
+}
+
+func (vg *cachedVolumeGroupAdapter) CreateVolume(ctx context.Context, name string, size uint64, tags []string, stripe uint, stripeSize string, lvcreateOptions []string) error {
+	space, err := getCacheSpace(ctx, vg.VolumeGroup, vg.dc)
+	if err != nil {
+		return err
+	}
+	return vg.CreateCachedVolume(ctx, name, size, tags, stripe, stripeSize, lvcreateOptions,
+		space.originPVs, GetCacheSize(vg.dc, size), space.cachePVs, string(GetCacheMode(vg.dc)))
+}
+
+// cacheSpace is the space of the volume group of a cached device-class. The space of the physical
+// volumes for the origin volumes and for the cache volumes is accounted separately.
+type cacheSpace struct {
+	originPVs  []string
+	originFree uint64
+	cachePVs   []string
+	cacheSize  uint64
+	cacheFree  uint64
+	// sizePercent is the size of a cache volume in percent of its origin volume
+	sizePercent uint64
+}
+
+func getCacheSpace(ctx context.Context, vg *command.VolumeGroup, dc *lvmdTypes.DeviceClass) (*cacheSpace, error) {
+	pvs, err := vg.ListPhysicalVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	space := &cacheSpace{sizePercent: getCacheSizePercent(dc)}
+	for _, pv := range pvs {
+		if pv.Missing {
+			continue
+		}
+		if slices.Contains(dc.CacheConfig.PhysicalVolumes, pv.Name) {
+			space.cachePVs = append(space.cachePVs, pv.Name)
+			space.cacheSize += pv.Size
+			space.cacheFree += pv.Free
+		} else {
+			space.originPVs = append(space.originPVs, pv.Name)
+			space.originFree += pv.Free
+		}
+	}
+	if len(space.cachePVs) == 0 {
+		return nil, fmt.Errorf("none of the cache physical volumes of device-class %s are in volume group %s", dc.Name, vg.Name())
+	}
+	if len(space.originPVs) == 0 {
+		return nil, errors.New("volume group " + vg.Name() + " has no physical volumes other than the cache physical volumes")
+	}
+	return space, nil
+}
+
+// Free returns the size of the largest volume which can be created together with its cache volume.
+func (s *cacheSpace) Free() uint64 {
+	return min(s.originFree, s.cacheFree/s.sizePercent*100+s.cacheFree%s.sizePercent*100/s.sizePercent)
+}
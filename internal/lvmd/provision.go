@@ -0,0 +1,290 @@
+package lvmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const defaultThinPoolSizePercent = 90
+
+// hostRoot is the directory under which the /dev and /sys trees of the host are found.
+var hostRoot = "/"
+
+// Provision creates or extends the volume groups and the thin pools of the device-classes which have a device-selector.
+// A volume group is created from the selected devices if it does not exist, and is extended with the selected devices
+// which are not in it yet. A thin pool is created with its size-percent of the volume group if it does not exist, and
+// is extended to it if the volume group has grown. Devices which carry any signature, such as a filesystem, a partition
+// table or a physical volume of another volume group, are never touched.
+// If dryRun is true, the changes are only logged.
+func Provision(ctx context.Context, deviceClasses []*lvmdTypes.DeviceClass, dryRun bool) error {
+	logger := log.FromContext(ctx)
+
+	var vgNames []string
+	byVG := make(map[string][]*lvmdTypes.DeviceClass)
+	for _, dc := range deviceClasses {
+		if dc.DeviceSelector == nil {
+			continue
+		}
+		if _, ok := byVG[dc.VolumeGroup]; !ok {
+			vgNames = append(vgNames, dc.VolumeGroup)
+		}
+		byVG[dc.VolumeGroup] = append(byVG[dc.VolumeGroup], dc)
+	}
+	if len(vgNames) == 0 {
+		return nil
+	}
+
+	run := func(msg string, f func() error, keysAndValues ...any) error {
+		if dryRun {
+			logger.Info("dry-run: "+msg, keysAndValues...)
+			return nil
+		}
+		logger.Info(msg, keysAndValues...)
+		return f()
+	}
+
+	vgs, err := command.ListVolumeGroups(ctx)
+	if err != nil {
+		return err
+	}
+	claimed := make(map[string]string)
+	for _, vgName := range vgNames {
+		var devices []string
+		for _, dc := range byVG[vgName] {
+			selected, err := selectDevices(dc.DeviceSelector)
+			if err != nil {
+				return fmt.Errorf("failed to select devices of device-class %s: %w", dc.Name, err)
+			}
+			devices = append(devices, selected...)
+		}
+		slices.Sort(devices)
+		devices = slices.Compact(devices)
+		for _, device := range devices {
+			if other, ok := claimed[device]; ok {
+				return fmt.Errorf("device %s is selected for both volume groups %s and %s", device, other, vgName)
+			}
+			claimed[device] = vgName
+		}
+
+		vg, err := command.SearchVolumeGroupList(vgs, vgName)
+		if err != nil && !errors.Is(err, command.ErrNotFound) {
+			return err
+		}
+		members := make(map[string]bool)
+		if vg != nil {
+			pvs, err := vg.ListPhysicalVolumes(ctx)
+			if err != nil {
+				return err
+			}
+			for _, pv := range pvs {
+				if pv.Missing {
+					continue
+				}
+				device, err := resolveDevice(pv.Name)
+				if err != nil {
+					return err
+				}
+				members[device] = true
+			}
+		}
+
+		var newDevices []string
+		for _, device := range devices {
+			if members[device] {
+				continue
+			}
+			signatures, err := command.ListSignatures(ctx, device)
+			if err != nil {
+				return err
+			}
+			if len(signatures) > 0 {
+				logger.Info("skipping device with existing signatures", "device", device, "signatures", signatures, "volume_group", vgName)
+				continue
+			}
+			newDevices = append(newDevices, device)
+		}
+
+		switch {
+		case vg == nil && len(newDevices) == 0:
+			return fmt.Errorf("no device is available to create volume group %s", vgName)
+		case vg == nil:
+			err = run("creating volume group", func() error {
+				return command.CreateVolumeGroup(ctx, vgName, newDevices)
+			}, "volume_group", vgName, "devices", newDevices)
+		case len(newDevices) > 0:
+			err = run("extending volume group", func() error {
+				return vg.Extend(ctx, newDevices)
+			}, "volume_group", vgName, "devices", newDevices)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, dc := range byVG[vgName] {
+			if dc.Type != lvmdTypes.TypeThin || dc.ThinPoolConfig == nil {
+				continue
+			}
+			if dryRun && vg == nil {
+				logger.Info("dry-run: creating thin pool", "volume_group", vgName, "thinpool", dc.ThinPoolConfig.Name,
+					"size_percent", getThinPoolSizePercent(dc.ThinPoolConfig))
+				continue
+			}
+			if err := provisionThinPool(ctx, vgName, dc.ThinPoolConfig, run); err != nil {
+				return fmt.Errorf("failed to provision thin pool of device-class %s: %w", dc.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// provisionThinPool creates the thin pool or extends it to its size-percent of the volume group.
+// The size is rounded down to the extents of the volume group and is limited by its free space.
+func provisionThinPool(ctx context.Context, vgName string, config *lvmdTypes.ThinPoolConfig,
+	run func(string, func() error, ...any) error) error {
+	vg, err := command.FindVolumeGroup(ctx, vgName)
+	if err != nil {
+		return err
+	}
+	extentSize, err := vg.ExtentSize(ctx)
+	if err != nil {
+		return err
+	}
+	vgSize, err := vg.Size()
+	if err != nil {
+		return err
+	}
+	vgFree, err := vg.Free()
+	if err != nil {
+		return err
+	}
+	target := vgSize / extentSize * getThinPoolSizePercent(config) / 100 * extentSize
+
+	pool, err := vg.FindPool(ctx, config.Name)
+	if errors.Is(err, command.ErrNotFound) {
+		size := min(target, vgFree/extentSize*extentSize)
+		return run("creating thin pool", func() error {
+			_, err := vg.CreatePool(ctx, config.Name, size)
+			return err
+		}, "volume_group", vgName, "thinpool", config.Name, "size", size)
+	}
+	if err != nil {
+		return err
+	}
+
+	size := min(target, pool.Size()+vgFree/extentSize*extentSize)
+	if size <= pool.Size() {
+		return nil
+	}
+	return run("extending thin pool", func() error {
+		return pool.Resize(ctx, size)
+	}, "volume_group", vgName, "thinpool", config.Name, "current", pool.Size(), "size", size)
+}
+
+func getThinPoolSizePercent(config *lvmdTypes.ThinPoolConfig) uint64 {
+	if config.SizePercent == 0 {
+		return defaultThinPoolSizePercent
+	}
+	return uint64(config.SizePercent)
+}
+
+// selectDevices returns the paths of the devices matching the selector, with symbolic links resolved.
+func selectDevices(selector *lvmdTypes.DeviceSelector) ([]string, error) {
+	var candidates []string
+	for _, pattern := range selector.Paths {
+		matches, err := filepath.Glob(filepath.Join(hostRoot, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid device path pattern %s: %w", pattern, err)
+		}
+		for _, match := range matches {
+			candidates = append(candidates, hostPath(match))
+		}
+	}
+	for _, id := range selector.IDs {
+		path := filepath.Join("/dev/disk/by-id", id)
+		if _, err := os.Lstat(filepath.Join(hostRoot, path)); errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, path)
+	}
+
+	var devices []string
+	for _, candidate := range candidates {
+		device, err := resolveDevice(candidate)
+		if err != nil {
+			return nil, err
+		}
+		size, rotational, err := blockDeviceInfo(device)
+		if err != nil {
+			return nil, err
+		}
+		if selector.MinSizeGB != nil && size < *selector.MinSizeGB<<30 {
+			continue
+		}
+		if selector.MaxSizeGB != nil && size > *selector.MaxSizeGB<<30 {
+			continue
+		}
+		if selector.Rotational != nil && rotational != *selector.Rotational {
+			continue
+		}
+		devices = append(devices, device)
+	}
+	slices.Sort(devices)
+	return slices.Compact(devices), nil
+}
+
+// resolveDevice resolves the symbolic links of a device path, e.g. /dev/disk/by-id/*, into the path of the device node.
+func resolveDevice(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(filepath.Join(hostRoot, path))
+	if err != nil {
+		return "", err
+	}
+	return hostPath(resolved), nil
+}
+
+// hostPath returns the path on the host of a path under hostRoot.
+func hostPath(path string) string {
+	return filepath.Join("/", strings.TrimPrefix(path, hostRoot))
+}
+
+// blockDeviceInfo returns the size in bytes of a block device and whether it is rotational or not.
+func blockDeviceInfo(device string) (uint64, bool, error) {
+	sysDir, err := filepath.EvalSymlinks(filepath.Join(hostRoot, "sys/class/block", filepath.Base(device)))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, fmt.Errorf("%s is not a block device", device)
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(sysDir, "size"))
+	if err != nil {
+		return 0, false, err
+	}
+	sectors, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to parse size of %s: %w", device, err)
+	}
+
+	// partitions do not have the queue attributes, which are found on their disks
+	data, err = os.ReadFile(filepath.Join(sysDir, "queue/rotational"))
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = os.ReadFile(filepath.Join(sysDir, "../queue/rotational"))
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	// the size in sysfs is always in 512-byte sectors regardless of the logical block size
+	return sectors * 512, strings.TrimSpace(string(data)) == "1", nil
+}
@@ -0,0 +1,132 @@
+package lvmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+)
+
+func TestSelectDevices(t *testing.T) {
+	root := t.TempDir()
+	orig := hostRoot
+	hostRoot = root
+	t.Cleanup(func() { hostRoot = orig })
+
+	mkfile := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	symlink := func(target, path string) {
+		t.Helper()
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	disk := func(name string, sizeGB int, rotational bool) {
+		mkfile("dev/"+name, "")
+		mkfile("sys/class/block/"+name+"/size", strconv.Itoa(sizeGB<<30/512)+"\n")
+		if rotational {
+			mkfile("sys/class/block/"+name+"/queue/rotational", "1\n")
+		} else {
+			mkfile("sys/class/block/"+name+"/queue/rotational", "0\n")
+		}
+	}
+
+	disk("sda", 100, true)
+	disk("sdb", 500, true)
+	disk("nvme0n1", 200, false)
+	symlink("../../sda", "dev/disk/by-path/pci-0000:00:1f.2-ata-1")
+	symlink("../../sdb", "dev/disk/by-path/pci-0000:00:1f.2-ata-2")
+	symlink("../../nvme0n1", "dev/disk/by-id/nvme-eui.0001")
+	mkfile("dev/null", "")
+
+	size150gb := uint64(150)
+	rotational := true
+	nonRotational := false
+	cases := []struct {
+		name     string
+		selector lvmdTypes.DeviceSelector
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "path glob",
+			selector: lvmdTypes.DeviceSelector{Paths: []string{"/dev/disk/by-path/pci-0000:00:1f.2-ata-*"}},
+			expected: []string{"/dev/sda", "/dev/sdb"},
+		},
+		{
+			name:     "id",
+			selector: lvmdTypes.DeviceSelector{IDs: []string{"nvme-eui.0001", "nvme-eui.missing"}},
+			expected: []string{"/dev/nvme0n1"},
+		},
+		{
+			name: "duplicates are merged",
+			selector: lvmdTypes.DeviceSelector{
+				Paths: []string{"/dev/sd*", "/dev/disk/by-path/*"},
+				IDs:   []string{"nvme-eui.0001"},
+			},
+			expected: []string{"/dev/nvme0n1", "/dev/sda", "/dev/sdb"},
+		},
+		{
+			name:     "size filter",
+			selector: lvmdTypes.DeviceSelector{Paths: []string{"/dev/sd*", "/dev/nvme*"}, MinSizeGB: &size150gb},
+			expected: []string{"/dev/nvme0n1", "/dev/sdb"},
+		},
+		{
+			name:     "max size filter",
+			selector: lvmdTypes.DeviceSelector{Paths: []string{"/dev/sd*", "/dev/nvme*"}, MaxSizeGB: &size150gb},
+			expected: []string{"/dev/sda"},
+		},
+		{
+			name:     "rotational filter",
+			selector: lvmdTypes.DeviceSelector{Paths: []string{"/dev/sd*", "/dev/nvme*"}, Rotational: &rotational},
+			expected: []string{"/dev/sda", "/dev/sdb"},
+		},
+		{
+			name:     "non-rotational filter",
+			selector: lvmdTypes.DeviceSelector{Paths: []string{"/dev/sd*", "/dev/nvme*"}, Rotational: &nonRotational},
+			expected: []string{"/dev/nvme0n1"},
+		},
+		{
+			name:     "no match",
+			selector: lvmdTypes.DeviceSelector{Paths: []string{"/dev/vd*"}},
+		},
+		{
+			name:     "not a block device",
+			selector: lvmdTypes.DeviceSelector{Paths: []string{"/dev/null"}},
+			err:      true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			devices, err := selectDevices(&c.selector)
+			if c.err {
+				if err == nil {
+					t.Error("should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(devices, c.expected) {
+				t.Errorf("expected %v, but got %v", c.expected, devices)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package lvmd
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	defaultRAIDMirrors = 1
+	raidCheckInterval  = time.Minute
+)
+
+// raidLayout returns the number of stripes, the number of images and the number of images holding
+// distinct data of a RAID volume of the device-class. Parity and mirror images are not counted in
+// the last one, so the usable size of a volume is the size of an image multiplied by it.
+func raidLayout(rc *lvmdTypes.RAIDConfig) (stripes, images, dataImages uint) {
+	mirrors := raidMirrors(rc)
+	stripes = rc.Stripes
+	switch rc.Level {
+	case lvmdTypes.RAIDLevel1:
+		return 0, mirrors + 1, 1
+	case lvmdTypes.RAIDLevel5:
+		if stripes == 0 {
+			stripes = 2
+		}
+		return stripes, stripes + 1, stripes
+	case lvmdTypes.RAIDLevel6:
+		if stripes == 0 {
+			stripes = 3
+		}
+		return stripes, stripes + 2, stripes
+	case lvmdTypes.RAIDLevel10:
+		if stripes == 0 {
+			stripes = 2
+		}
+		return stripes, stripes * (mirrors + 1), stripes
+	}
+	return 0, 0, 0
+}
+
+func raidMirrors(rc *lvmdTypes.RAIDConfig) uint {
+	if rc.Mirrors == 0 {
+		return defaultRAIDMirrors
+	}
+	return rc.Mirrors
+}
+
+// raidOptions returns the number of stripes and the extra arguments to pass to lvcreate
+// to create a RAID volume of the device-class.
+func raidOptions(rc *lvmdTypes.RAIDConfig) (uint, []string) {
+	stripes, _, _ := raidLayout(rc)
+	args := []string{"--type", string(rc.Level)}
+	if rc.Level == lvmdTypes.RAIDLevel1 || rc.Level == lvmdTypes.RAIDLevel10 {
+		args = append(args, "--mirrors", strconv.FormatUint(uint64(raidMirrors(rc)), 10))
+	}
+	return stripes, args
+}
+
+// maxImageSize returns the size of the largest images which can be allocated for n images of a RAID
+// volume from physical volumes with the given free space. LVM does not place two images on the same
+// physical volume, so the physical volumes are split into n groups, one for each image. The groups
+// are balanced greedily, which may underestimate the size but never overestimates it.
+func maxImageSize(frees []uint64, n uint) uint64 {
+	if n == 0 || uint(len(frees)) < n {
+		return 0
+	}
+	frees = slices.Clone(frees)
+	slices.SortFunc(frees, func(a, b uint64) int {
+		return cmp.Compare(b, a)
+	})
+	groups := make([]uint64, n)
+	for _, free := range frees {
+		smallest := slices.Index(groups, slices.Min(groups))
+		groups[smallest] += free
+	}
+	return slices.Min(groups)
+}
+
+// raidFree returns the size of the largest RAID volume of the device-class which can be created in the volume group.
+func raidFree(ctx context.Context, vg *command.VolumeGroup, dc *lvmdTypes.DeviceClass) (uint64, error) {
+	pvs, err := vg.ListPhysicalVolumes(ctx)
+	if err != nil {
+		return 0, err
+	}
+	extentSize, err := vg.ExtentSize(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var frees []uint64
+	for _, pv := range pvs {
+		if !pv.Missing {
+			frees = append(frees, pv.Free)
+		}
+	}
+	_, images, dataImages := raidLayout(dc.RAIDConfig)
+	return raidVolumeSize(maxImageSize(frees, images), extentSize, dataImages), nil
+}
+
+// raidVolumeSize returns the usable size of a RAID volume whose image groups have imageGroupSize of free space.
+// Each image group also holds the rmeta subvolume of its image, which takes one extent.
+func raidVolumeSize(imageGroupSize, extentSize uint64, dataImages uint) uint64 {
+	if imageGroupSize <= extentSize {
+		return 0
+	}
+	return (imageGroupSize - extentSize) * uint64(dataImages)
+}
+
+// canReplaceImage returns true if a physical volume which is present and does not hold any other image of the volume
+// has the free space for a new image and its rmeta subvolume. LVM does not place two images on the same physical volume.
+func canReplaceImage(pvs []command.PhysicalVolume, usedDevices []string, imageSize, extentSize uint64) bool {
+	return slices.ContainsFunc(pvs, func(pv command.PhysicalVolume) bool {
+		return !pv.Missing && !slices.Contains(usedDevices, pv.Name) && pv.Free >= imageSize+extentSize
+	})
+}
+
+// StartRAIDRepairer starts checking the health of the volumes of RAID device-classes periodically until ctx is done.
+// A volume which lost images with a failed physical volume is repaired with `lvconvert --repair` once another physical
+// volume which can hold a new image is present, e.g. after the failed device was replaced and added to the volume group.
+// A volume which needs a refresh after transient write errors is refreshed.
+// The device-classes are looked up at every check, so that reloaded device-classes are also checked.
+func StartRAIDRepairer(ctx context.Context, manager *DeviceClassManager, notify func()) {
+	logger := log.FromContext(ctx).WithName("raid-repairer")
+	go func() {
+		ticker := time.NewTicker(raidCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, dc := range manager.current().deviceClassByName {
+					if dc.RAIDConfig == nil {
+						continue
+					}
+					repaired, err := repairRAIDVolumes(log.IntoContext(ctx, logger.WithValues("device-class", dc.Name)), dc)
+					if err != nil {
+						logger.Error(err, "failed to check RAID volumes", "device-class", dc.Name)
+					}
+					if repaired && notify != nil {
+						notify()
+					}
+				}
+			}
+		}
+	}()
+}
+
+// repairRAIDVolumes repairs or refreshes the degraded volumes of the device-class and returns true if any volume was repaired.
+func repairRAIDVolumes(ctx context.Context, dc *lvmdTypes.DeviceClass) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+	if err != nil {
+		return false, err
+	}
+	lvs, err := vg.ListVolumes(ctx)
+	if err != nil {
+		return false, err
+	}
+	pvs, err := vg.ListPhysicalVolumes(ctx)
+	if err != nil {
+		return false, err
+	}
+	extentSize, err := vg.ExtentSize(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, _, dataImages := raidLayout(dc.RAIDConfig)
+
+	var repaired bool
+	for _, lv := range lvs {
+		attr, err := command.ParsedLVAttr(lv.Attr())
+		if err != nil {
+			return repaired, err
+		}
+		if attr.VolumeType != command.VolumeTypeRAID && attr.VolumeType != command.VolumeTypeRAIDNoInitialSync {
+			continue
+		}
+
+		switch attr.VolumeHealth {
+		case command.VolumeHealthPartialActivation:
+			usedDevices, err := lv.ImageDevices(ctx)
+			if err != nil {
+				logger.Error(err, "failed to list the devices of RAID volume", "name", lv.Name())
+				continue
+			}
+			imageSize := lv.Size() / uint64(dataImages)
+			if !canReplaceImage(pvs, usedDevices, imageSize, extentSize) {
+				logger.Info("RAID volume is degraded, but no physical volume can replace the failed one", "name", lv.Name())
+				continue
+			}
+			logger.Info("repairing degraded RAID volume", "name", lv.Name())
+			if err := lv.RepairRAID(ctx); err != nil {
+				logger.Error(err, "failed to repair RAID volume", "name", lv.Name())
+				continue
+			}
+			repaired = true
+		case command.VolumeHealthRAIDRefreshNeeded:
+			logger.Info("refreshing RAID volume", "name", lv.Name())
+			if err := lv.Refresh(ctx); err != nil {
+				logger.Error(err, "failed to refresh RAID volume", "name", lv.Name())
+			}
+		}
+	}
+	return repaired, nil
+}
@@ -0,0 +1,115 @@
+package lvmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+)
+
+func TestRAIDOptions(t *testing.T) {
+	cases := []struct {
+		config     lvmdTypes.RAIDConfig
+		stripes    uint
+		images     uint
+		dataImages uint
+		args       []string
+	}{
+		{lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel1}, 0, 2, 1, []string{"--type", "raid1", "--mirrors", "1"}},
+		{lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel1, Mirrors: 2}, 0, 3, 1, []string{"--type", "raid1", "--mirrors", "2"}},
+		{lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel5}, 2, 3, 2, []string{"--type", "raid5"}},
+		{lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel6, Stripes: 4}, 4, 6, 4, []string{"--type", "raid6"}},
+		{lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel10}, 2, 4, 2, []string{"--type", "raid10", "--mirrors", "1"}},
+		{lvmdTypes.RAIDConfig{Level: lvmdTypes.RAIDLevel10, Stripes: 3, Mirrors: 2}, 3, 9, 3, []string{"--type", "raid10", "--mirrors", "2"}},
+	}
+
+	for _, c := range cases {
+		stripes, images, dataImages := raidLayout(&c.config)
+		if stripes != c.stripes || images != c.images || dataImages != c.dataImages {
+			t.Errorf("%+v: expected layout %d/%d/%d, but got %d/%d/%d",
+				c.config, c.stripes, c.images, c.dataImages, stripes, images, dataImages)
+		}
+		stripes, args := raidOptions(&c.config)
+		if stripes != c.stripes || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("%+v: expected options %d %v, but got %d %v", c.config, c.stripes, c.args, stripes, args)
+		}
+	}
+}
+
+func TestMaxImageSize(t *testing.T) {
+	cases := []struct {
+		name     string
+		frees    []uint64
+		images   uint
+		expected uint64
+	}{
+		{"even", []uint64{10, 10}, 2, 10},
+		{"bounded by the smallest group", []uint64{100, 1, 1}, 2, 2},
+		{"images cannot share a physical volume", []uint64{5, 5, 5}, 2, 5},
+		{"physical volumes are grouped", []uint64{8, 4, 4}, 2, 8},
+		{"not enough physical volumes", []uint64{100, 100}, 3, 0},
+		{"no physical volumes", nil, 2, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			frees := append([]uint64(nil), c.frees...)
+			if actual := maxImageSize(c.frees, c.images); actual != c.expected {
+				t.Errorf("expected %d, but got %d", c.expected, actual)
+			}
+			if !reflect.DeepEqual(frees, c.frees) {
+				t.Error("the given free space should not be modified")
+			}
+		})
+	}
+}
+
+func TestRAIDVolumeSize(t *testing.T) {
+	const extent = 4 << 20
+	cases := []struct {
+		name           string
+		imageGroupSize uint64
+		dataImages     uint
+		expected       uint64
+	}{
+		{"rmeta takes one extent of each image group", 10 * extent, 2, 18 * extent},
+		{"only rmeta fits", extent, 2, 0},
+		{"nothing fits", 0, 2, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := raidVolumeSize(c.imageGroupSize, extent, c.dataImages); actual != c.expected {
+				t.Errorf("expected %d, but got %d", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCanReplaceImage(t *testing.T) {
+	const extent = 4 << 20
+	pvs := []command.PhysicalVolume{
+		{Name: "/dev/sda", Free: 100 * extent},
+		{Name: "/dev/sdb", Free: 10 * extent, Missing: true},
+		{Name: "/dev/sdc", Free: 10 * extent},
+	}
+	cases := []struct {
+		name      string
+		used      []string
+		imageSize uint64
+		expected  bool
+	}{
+		{"free physical volume", []string{"/dev/sda", "[unknown]"}, 9 * extent, true},
+		{"rmeta does not fit", []string{"/dev/sda", "[unknown]"}, 10 * extent, false},
+		{"physical volume holding another image", []string{"/dev/sda", "/dev/sdc"}, extent, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := canReplaceImage(pvs, c.used, c.imageSize, extent); actual != c.expected {
+				t.Errorf("expected %v, but got %v", c.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package lvmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// configPollInterval is the interval to check if the config file is modified.
+var configPollInterval = 10 * time.Second
+
+// VerifyDeviceClasses checks that the volume groups and the thin pools of the device-classes exist.
+func VerifyDeviceClasses(ctx context.Context, deviceClasses []*lvmdTypes.DeviceClass) error {
+	vgs, err := command.ListVolumeGroups(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list volume groups: %w", err)
+	}
+	for _, dc := range deviceClasses {
+		vg, err := command.SearchVolumeGroupList(vgs, dc.VolumeGroup)
+		if err != nil {
+			return fmt.Errorf("volume group %s of device-class %s: %w", dc.VolumeGroup, dc.Name, err)
+		}
+		if dc.Type == lvmdTypes.TypeThin {
+			if _, err := vg.FindPool(ctx, dc.ThinPoolConfig.Name); err != nil {
+				return fmt.Errorf("thin pool %s of device-class %s: %w", dc.ThinPoolConfig.Name, dc.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ConfigReloader replaces the device-classes and the lvcreate-option-classes used by the running services.
+type ConfigReloader struct {
+	mu        sync.Mutex
+	dcManager *DeviceClassManager
+	ocManager *LvcreateOptionClassManager
+	notify    func()
+}
+
+// NewConfigReloader creates a ConfigReloader. notify is called after the classes are replaced
+// to let the subscribers of VGService.Watch know the capacities of the new device-classes.
+func NewConfigReloader(dcManager *DeviceClassManager, ocManager *LvcreateOptionClassManager, notify func()) *ConfigReloader {
+	return &ConfigReloader{
+		dcManager: dcManager,
+		ocManager: ocManager,
+		notify:    notify,
+	}
+}
+
+// Reload validates the device-classes, provisions their volume groups and thin pools, and replaces
+// the current classes with them. The current classes are kept if any of them fails.
+// A device-class having logical volumes cannot be removed, nor can its volume group or thin pool be changed.
+func (r *ConfigReloader) Reload(ctx context.Context, deviceClasses []*lvmdTypes.DeviceClass, lvcreateOptionClasses []*lvmdTypes.LvcreateOptionClass) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := ValidateDeviceClasses(deviceClasses); err != nil {
+		return err
+	}
+	next := newDeviceClassSet(deviceClasses)
+	for _, dc := range r.dcManager.current().deviceClassByName {
+		if sameTarget(dc, next.deviceClassByName[dc.Name]) {
+			continue
+		}
+		found, err := hasLogicalVolumes(ctx, dc)
+		if err != nil {
+			return fmt.Errorf("failed to list logical volumes of device-class %s: %w", dc.Name, err)
+		}
+		if found {
+			return fmt.Errorf("device-class %s has logical volumes, so it cannot be removed or moved to another volume group or thin pool", dc.Name)
+		}
+	}
+
+	if err := Provision(ctx, deviceClasses, false); err != nil {
+		return fmt.Errorf("failed to provision volume groups: %w", err)
+	}
+	if err := VerifyDeviceClasses(ctx, deviceClasses); err != nil {
+		return err
+	}
+
+	r.dcManager.classes.Store(next)
+	r.ocManager.replace(lvcreateOptionClasses)
+	log.FromContext(ctx).Info("device-classes reloaded", "device_classes", deviceClasses)
+	if r.notify != nil {
+		r.notify()
+	}
+	return nil
+}
+
+// sameTarget returns true if next creates the logical volumes where dc does.
+func sameTarget(dc, next *lvmdTypes.DeviceClass) bool {
+	if next == nil || dc.VolumeGroup != next.VolumeGroup || dc.Type != next.Type {
+		return false
+	}
+	if dc.Type == lvmdTypes.TypeThin {
+		return dc.ThinPoolConfig.Name == next.ThinPoolConfig.Name
+	}
+	return true
+}
+
+// hasLogicalVolumes returns true if any logical volume is listed by GetLVList for the device-class.
+func hasLogicalVolumes(ctx context.Context, dc *lvmdTypes.DeviceClass) (bool, error) {
+	pool, err := storagePoolForDeviceClass(ctx, dc)
+	if errors.Is(err, command.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	lvs, err := pool.ListVolumes(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, lv := range lvs {
+		if dc.Type == lvmdTypes.TypeThick && lv.IsThin() {
+			continue
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// WatchConfigFile calls reload with the content of the config file when it is modified or SIGHUP is received,
+// until ctx is done. The file is polled rather than watched with inotify, because a mounted ConfigMap is updated
+// by replacing a symbolic link. A failed reload is not retried until the file is modified again or SIGHUP is received.
+func WatchConfigFile(ctx context.Context, path string, reload func(ctx context.Context, data []byte) error) {
+	logger := log.FromContext(ctx).WithName("config-watcher").WithValues("file_name", path)
+	last, err := os.ReadFile(path)
+	if err != nil {
+		logger.Error(err, "failed to read config file")
+	}
+
+	// SIGHUP is subscribed before returning, so that it never terminates the process.
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(sighup)
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		for {
+			var force bool
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-sighup:
+				logger.Info("received SIGHUP")
+				force = true
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				logger.Error(err, "failed to read config file")
+				continue
+			}
+			if !force && bytes.Equal(data, last) {
+				continue
+			}
+			last = data
+			if err := reload(ctx, data); err != nil {
+				logger.Error(err, "failed to reload config file, keeping the current configuration")
+			}
+		}
+	}()
+}
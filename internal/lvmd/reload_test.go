@@ -0,0 +1,137 @@
+package lvmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+)
+
+func TestConfigReloaderRejectsInvalidDeviceClasses(t *testing.T) {
+	dcm := NewDeviceClassManager([]*lvmdTypes.DeviceClass{{Name: "dc1", VolumeGroup: "vg1", Default: true}})
+	ocm := NewLvcreateOptionClassManager([]*lvmdTypes.LvcreateOptionClass{{Name: "oc1"}})
+	var notified bool
+	r := NewConfigReloader(dcm, ocm, func() { notified = true })
+
+	err := r.Reload(context.Background(), []*lvmdTypes.DeviceClass{
+		{Name: "dc1", VolumeGroup: "vg1", Default: true},
+		{Name: "dc1", VolumeGroup: "vg2"},
+	}, nil)
+	if err == nil {
+		t.Fatal("duplicated device-classes should be rejected")
+	}
+	if notified {
+		t.Error("subscribers should not be notified")
+	}
+	if _, err := dcm.DeviceClass("dc1"); err != nil {
+		t.Errorf("the current device-classes should be kept: %v", err)
+	}
+	if _, err := dcm.FindDeviceClassByVGName("vg2"); err == nil {
+		t.Error("the invalid device-classes should not be applied")
+	}
+	if ocm.LvcreateOptionClass("oc1") == nil {
+		t.Error("the current lvcreate-option-classes should be kept")
+	}
+}
+
+func TestSameTarget(t *testing.T) {
+	thin := func(vg, pool string) *lvmdTypes.DeviceClass {
+		return &lvmdTypes.DeviceClass{
+			Name:           "dc",
+			VolumeGroup:    vg,
+			Type:           lvmdTypes.TypeThin,
+			ThinPoolConfig: &lvmdTypes.ThinPoolConfig{Name: pool},
+		}
+	}
+	thick := func(vg string) *lvmdTypes.DeviceClass {
+		return &lvmdTypes.DeviceClass{Name: "dc", VolumeGroup: vg, Type: lvmdTypes.TypeThick}
+	}
+
+	testCases := []struct {
+		name string
+		dc   *lvmdTypes.DeviceClass
+		next *lvmdTypes.DeviceClass
+		want bool
+	}{
+		{name: "removed", dc: thick("vg1"), next: nil, want: false},
+		{name: "same volume group", dc: thick("vg1"), next: thick("vg1"), want: true},
+		{name: "another volume group", dc: thick("vg1"), next: thick("vg2"), want: false},
+		{name: "same thin pool", dc: thin("vg1", "pool1"), next: thin("vg1", "pool1"), want: true},
+		{name: "another thin pool", dc: thin("vg1", "pool1"), next: thin("vg1", "pool2"), want: false},
+		{name: "thin to thick", dc: thin("vg1", "pool1"), next: thick("vg1"), want: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sameTarget(tc.dc, tc.next); got != tc.want {
+				t.Errorf("sameTarget() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLvcreateOptionClassManagerReplace(t *testing.T) {
+	m := NewLvcreateOptionClassManager([]*lvmdTypes.LvcreateOptionClass{{Name: "oc1"}})
+	m.replace([]*lvmdTypes.LvcreateOptionClass{{Name: "oc2"}, {Name: "oc3"}})
+	if m.LvcreateOptionClass("oc1") != nil {
+		t.Error("oc1 should be removed")
+	}
+	if names := m.names(); len(names) != 2 || names[0] != "oc2" || names[1] != "oc3" {
+		t.Errorf("unexpected names: %v", names)
+	}
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	orig := configPollInterval
+	configPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { configPollInterval = orig })
+
+	path := filepath.Join(t.TempDir(), "lvmd.yaml")
+	if err := os.WriteFile(path, []byte("v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan string, 10)
+	WatchConfigFile(ctx, path, func(_ context.Context, data []byte) error {
+		reloaded <- string(data)
+		return nil
+	})
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-reloaded:
+			if got != want {
+				t.Errorf("reloaded %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("not reloaded with %q", want)
+		}
+	}
+	expectNone := func() {
+		t.Helper()
+		select {
+		case got := <-reloaded:
+			t.Errorf("unexpectedly reloaded %q", got)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	expectNone()
+
+	if err := os.WriteFile(path, []byte("v2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	expect("v2")
+	expectNone()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	expect("v2")
+}
@@ -0,0 +1,201 @@
+package lvmd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	defaultAutoExtendThresholdPercent = 80
+	defaultAutoExtendStepPercent      = 20
+
+	// maxThinPoolMetadataSize is slightly below the maximum size of the metadata of dm-thin, about 15.81 GiB.
+	maxThinPoolMetadataSize = 16179 << 20
+)
+
+// thinPoolCheckInterval is the interval to check the usage of auto-extended thin pools.
+var thinPoolCheckInterval = 30 * time.Second
+
+// thinPoolExtensions is the number of times the data and the metadata of a thin pool have been extended.
+type thinPoolExtensions struct {
+	data     uint64
+	metadata uint64
+}
+
+// ThinPoolExtender extends the auto-extended thin pools and counts the extensions for VGService.Watch.
+type ThinPoolExtender struct {
+	dcManager *DeviceClassManager
+
+	// mu serializes the extension of thin pools and protects extensions.
+	mu         sync.Mutex
+	extensions map[string]*thinPoolExtensions
+}
+
+// NewThinPoolExtender creates a ThinPoolExtender for the device-classes of manager.
+func NewThinPoolExtender(manager *DeviceClassManager) *ThinPoolExtender {
+	return &ThinPoolExtender{
+		dcManager:  manager,
+		extensions: make(map[string]*thinPoolExtensions),
+	}
+}
+
+// Start starts checking the usage of the auto-extended thin pools periodically until ctx is done,
+// regardless of whether VGService.Watch is called. notify is called when any thin pool is extended.
+// The device-classes are looked up at every check, so that reloaded device-classes are also checked.
+func (e *ThinPoolExtender) Start(ctx context.Context, notify func()) {
+	logger := log.FromContext(ctx).WithName("thinpool-extender")
+	go func() {
+		ticker := time.NewTicker(thinPoolCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if e.extendAll(log.IntoContext(ctx, logger)) && notify != nil {
+					notify()
+				}
+			}
+		}
+	}()
+}
+
+// extendAll extends the auto-extended thin pools whose usage is over the thresholds.
+// It returns true if any thin pool is extended. Failures are logged to retry at the next check.
+func (e *ThinPoolExtender) extendAll(ctx context.Context) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var extended bool
+	for _, dc := range e.dcManager.current().deviceClassByName {
+		if dc.Type != lvmdTypes.TypeThin || dc.ThinPoolConfig == nil || dc.ThinPoolConfig.AutoExtend == nil {
+			continue
+		}
+		vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed to find volume group", "volume_group", dc.VolumeGroup)
+			continue
+		}
+		data, metadata, err := autoExtendThinPool(ctx, vg, dc)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed to extend thin pool",
+				"volume_group", dc.VolumeGroup, "thinpool", dc.ThinPoolConfig.Name)
+		}
+		if !data && !metadata {
+			continue
+		}
+		ext, ok := e.extensions[dc.Name]
+		if !ok {
+			ext = &thinPoolExtensions{}
+			e.extensions[dc.Name] = ext
+		}
+		if data {
+			ext.data++
+		}
+		if metadata {
+			ext.metadata++
+		}
+		extended = true
+	}
+	return extended
+}
+
+// Extensions returns the number of times the data and the metadata of the thin pool
+// of the device-class have been extended. It returns zeros for a nil ThinPoolExtender.
+func (e *ThinPoolExtender) Extensions(dcName string) (uint64, uint64) {
+	if e == nil {
+		return 0, 0
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ext, ok := e.extensions[dcName]
+	if !ok {
+		return 0, 0
+	}
+	return ext.data, ext.metadata
+}
+
+// autoExtendThinPool extends the data and the metadata of the thin pool of a device-class from the free space of
+// its volume group when their usage is over the thresholds. It returns whether the data and the metadata are extended.
+func autoExtendThinPool(ctx context.Context, vg *command.VolumeGroup, dc *lvmdTypes.DeviceClass) (bool, bool, error) {
+	logger := log.FromContext(ctx).WithValues("volume_group", vg.Name(), "thinpool", dc.ThinPoolConfig.Name)
+	config := dc.ThinPoolConfig.AutoExtend
+
+	pool, err := vg.FindPool(ctx, dc.ThinPoolConfig.Name)
+	if err != nil {
+		return false, false, err
+	}
+	tpu, err := pool.Usage(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	dataOver := tpu.DataPercent >= float64(percentOrDefault(config.DataThresholdPercent, defaultAutoExtendThresholdPercent))
+	metadataOver := tpu.MetadataPercent >= float64(percentOrDefault(config.MetadataThresholdPercent, defaultAutoExtendThresholdPercent))
+	if !dataOver && !metadataOver {
+		return false, false, nil
+	}
+
+	extentSize, err := vg.ExtentSize(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	free, err := vg.Free()
+	if err != nil {
+		return false, false, err
+	}
+
+	var dataExtended, metadataExtended bool
+	if dataOver {
+		current := pool.Size()
+		size := extendedSize(current, percentOrDefault(config.DataStepPercent, defaultAutoExtendStepPercent), extentSize, free)
+		if size > current {
+			logger.Info("extending thin pool data", "data_percent", tpu.DataPercent, "current", current, "size", size)
+			if err := pool.Resize(ctx, size); err != nil {
+				return false, false, err
+			}
+			free -= size - current
+			dataExtended = true
+		} else {
+			logger.Info("no free space to extend thin pool data", "data_percent", tpu.DataPercent)
+		}
+	}
+
+	if metadataOver {
+		current, err := pool.MetadataSize(ctx)
+		if err != nil {
+			return dataExtended, false, err
+		}
+		size := extendedSize(current, percentOrDefault(config.MetadataStepPercent, defaultAutoExtendStepPercent), extentSize, free)
+		size = min(size, maxThinPoolMetadataSize/extentSize*extentSize)
+		if size > current {
+			logger.Info("extending thin pool metadata", "metadata_percent", tpu.MetadataPercent, "current", current, "size", size)
+			if err := pool.ResizeMetadata(ctx, size); err != nil {
+				return dataExtended, false, err
+			}
+			metadataExtended = true
+		} else {
+			logger.Info("no free space to extend thin pool metadata", "metadata_percent", tpu.MetadataPercent)
+		}
+	}
+	return dataExtended, metadataExtended, nil
+}
+
+// extendedSize returns the size extended by stepPercent of the current size, at least by one extent.
+// The size is rounded up to the extents of the volume group and is limited by its free space.
+func extendedSize(current, stepPercent, extentSize, free uint64) uint64 {
+	step := current/100*stepPercent + (current%100*stepPercent+99)/100
+	step = (step + extentSize - 1) / extentSize * extentSize
+	return current + min(max(step, extentSize), free/extentSize*extentSize)
+}
+
+func percentOrDefault(percent uint, defaultPercent uint64) uint64 {
+	if percent == 0 {
+		return defaultPercent
+	}
+	return uint64(percent)
+}
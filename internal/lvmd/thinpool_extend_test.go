@@ -0,0 +1,28 @@
+package lvmd
+
+import "testing"
+
+func TestExtendedSize(t *testing.T) {
+	const extent = 4 << 20
+	cases := []struct {
+		name        string
+		current     uint64
+		stepPercent uint64
+		free        uint64
+		expected    uint64
+	}{
+		{"step of percent", 100 * extent, 20, 100 * extent, 120 * extent},
+		{"rounded up to extents", 10 * extent, 15, 100 * extent, 12 * extent},
+		{"at least one extent", extent, 1, 100 * extent, 2 * extent},
+		{"limited by free space", 100 * extent, 50, 10*extent + 1, 110 * extent},
+		{"no free extent", 100 * extent, 20, extent - 1, 100 * extent},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := extendedSize(c.current, c.stepPercent, extent, c.free)
+			if actual != c.expected {
+				t.Errorf("expected %d, actual %d", c.expected, actual)
+			}
+		})
+	}
+}
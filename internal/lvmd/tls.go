@@ -0,0 +1,240 @@
+package lvmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var tlsLogger = log.Log.WithName("lvmd").WithName("tls")
+
+// restrictedMethods are the methods of LVService modifying logical volumes or reading their data.
+var restrictedMethods = map[string]bool{
+	proto.LVService_CreateLV_FullMethodName:         true,
+	proto.LVService_RemoveLV_FullMethodName:         true,
+	proto.LVService_ResizeLV_FullMethodName:         true,
+	proto.LVService_ModifyLV_FullMethodName:         true,
+	proto.LVService_CreateLVSnapshot_FullMethodName: true,
+	proto.LVService_ImportLV_FullMethodName:         true,
+	proto.LVService_WriteLV_FullMethodName:          true,
+	proto.LVService_ReadLV_FullMethodName:           true,
+}
+
+// certificateReloader loads a key pair and CA certificates from files, and reloads them when the files are updated.
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu       sync.Mutex
+	modTimes [3]time.Time
+	cert     *tls.Certificate
+	pool     *x509.CertPool
+}
+
+func newCertificateReloader(certFile, keyFile, caFile string) (*certificateReloader, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, errors.New("cert-file, key-file and ca-file are required")
+	}
+	r := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+	}
+	if _, _, err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load returns the certificates, reloading them if any of the files has been modified.
+// The previous certificates are kept if the files cannot be loaded, e.g. while they are being rotated.
+func (r *certificateReloader) load() (*tls.Certificate, *x509.CertPool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var modTimes [3]time.Time
+	for i, f := range []string{r.certFile, r.keyFile, r.caFile} {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return r.previous(err)
+		}
+		modTimes[i] = fi.ModTime()
+	}
+	if r.cert != nil && modTimes == r.modTimes {
+		return r.cert, r.pool, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return r.previous(err)
+	}
+	ca, err := os.ReadFile(r.caFile)
+	if err != nil {
+		return r.previous(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return r.previous(fmt.Errorf("no certificate is found in %s", r.caFile))
+	}
+
+	if r.cert != nil {
+		tlsLogger.Info("reloaded certificates", "cert_file", r.certFile, "ca_file", r.caFile)
+	}
+	r.cert, r.pool, r.modTimes = &cert, pool, modTimes
+	return r.cert, r.pool, nil
+}
+
+func (r *certificateReloader) previous(err error) (*tls.Certificate, *x509.CertPool, error) {
+	if r.cert == nil {
+		return nil, nil, err
+	}
+	tlsLogger.Error(err, "failed to reload certificates, using the previous ones", "cert_file", r.certFile, "ca_file", r.caFile)
+	return r.cert, r.pool, nil
+}
+
+// NewServerTLSConfig returns the TLS configuration of lvmd requiring client certificates signed by the CA.
+// The certificates are reloaded when the files are updated.
+func NewServerTLSConfig(config *lvmdTypes.TLSConfig) (*tls.Config, error) {
+	r, err := newCertificateReloader(config.CertFile, config.KeyFile, config.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool, err := r.load()
+			if err != nil {
+				return nil, err
+			}
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   []string{"h2"},
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    pool,
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+			}, nil
+		},
+	}, nil
+}
+
+// NewClientTLSConfig returns the TLS configuration to connect to lvmd with the client certificate.
+// The certificate of lvmd has to be valid for serverName, which may be a DNS name or an IP address.
+// If serverName is empty, the server name sent in the TLS handshake is used instead, and the connection
+// is rejected if there is none, e.g. when ServerName of the returned configuration is left empty or is
+// replaced with an IP address. The certificates are reloaded when the files are updated.
+func NewClientTLSConfig(certFile, keyFile, caFile, serverName string) (*tls.Config, error) {
+	r, err := newCertificateReloader(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _, err := r.load()
+			return cert, err
+		},
+		// The server certificate is verified in VerifyConnection with the latest CA certificates instead,
+		// as RootCAs cannot be replaced once the connection is configured.
+		// serverName is preferred to cs.ServerName, which is empty when lvmd is dialed by an IP address.
+		InsecureSkipVerify: true, //nolint:gosec
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("lvmd presented no certificate")
+			}
+			name := serverName
+			if name == "" {
+				name = cs.ServerName
+			}
+			if name == "" {
+				return errors.New("no server name to verify the certificate of lvmd")
+			}
+			_, pool, err := r.load()
+			if err != nil {
+				return err
+			}
+			opts := x509.VerifyOptions{
+				Roots:         pool,
+				DNSName:       name,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err = cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}, nil
+}
+
+// NewTCPServer returns a gRPC server with mutual TLS, which allows only the clients having one of
+// config.MutatingClients as the identity to call the methods of LVService modifying logical volumes or reading their data.
+func NewTCPServer(config *lvmdTypes.TLSConfig) (*grpc.Server, error) {
+	tlsConfig, err := NewServerTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	a := &authorizer{identities: config.MutatingClients}
+	return grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.ChainUnaryInterceptor(a.unary),
+		grpc.ChainStreamInterceptor(a.stream),
+	), nil
+}
+
+type authorizer struct {
+	identities []string
+}
+
+func (a *authorizer) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := a.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authorizer) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize returns an error if the method is restricted and the client is not allowed to call it.
+func (a *authorizer) authorize(ctx context.Context, method string) error {
+	if !restrictedMethods[method] {
+		return nil
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "no peer information")
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return status.Error(codes.Unauthenticated, "no verified client certificate")
+	}
+	cert := info.State.VerifiedChains[0][0]
+	for _, id := range append([]string{cert.Subject.CommonName}, cert.DNSNames...) {
+		if id != "" && slices.Contains(a.identities, id) {
+			return nil
+		}
+	}
+	tlsLogger.Info("denied request", "method", method, "client", cert.Subject.CommonName)
+	return status.Errorf(codes.PermissionDenied, "client %q is not allowed to call %s", cert.Subject.CommonName, method)
+}
@@ -0,0 +1,289 @@
+package lvmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// testIssued is the number of the certificates issued by testCA.
+var testIssued int
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue writes the certificate for the common name signed by the CA, its key and the CA certificate into dir.
+// The common name is also set as the IP address SAN if it is an IP address, otherwise as the DNS name SAN.
+func (ca *testCA) issue(t *testing.T, dir, commonName string) *lvmdTypes.TLSConfig {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if ip := net.ParseIP(commonName); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{commonName}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &lvmdTypes.TLSConfig{
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+	// The files are dated in the future, so they are reloaded even if rewritten within the resolution of the clock.
+	testIssued++
+	modTime := time.Now().Add(time.Duration(testIssued) * time.Minute)
+	for file, data := range map[string][]byte{
+		config.CertFile: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		config.KeyFile:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		config.CAFile:   ca.pem,
+	} {
+		if err := os.WriteFile(file, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return config
+}
+
+type testLVService struct {
+	proto.UnimplementedLVServiceServer
+}
+
+func (s testLVService) CreateLV(context.Context, *proto.CreateLVRequest) (*proto.CreateLVResponse, error) {
+	return &proto.CreateLVResponse{}, nil
+}
+
+func TestTCPServer(t *testing.T) {
+	ca := newTestCA(t)
+	serverConfig := ca.issue(t, t.TempDir(), "lvmd")
+	serverConfig.MutatingClients = []string{"node1"}
+
+	server, err := NewTCPServer(serverConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto.RegisterLVServiceServer(server, testLVService{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+
+	clientTLSConfig := func(t *testing.T, config *lvmdTypes.TLSConfig) *tls.Config {
+		t.Helper()
+		tlsConfig, err := NewClientTLSConfig(config.CertFile, config.KeyFile, config.CAFile, "lvmd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tlsConfig
+	}
+	dial := func(t *testing.T, tlsConfig *tls.Config) proto.LVServiceClient {
+		t.Helper()
+		conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		return proto.NewLVServiceClient(conn)
+	}
+	ctx := context.Background()
+
+	readLV := func(client proto.LVServiceClient) error {
+		stream, err := client.ReadLV(ctx, &proto.ReadLVRequest{})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		return err
+	}
+
+	node1 := dial(t, clientTLSConfig(t, ca.issue(t, t.TempDir(), "node1")))
+	if _, err := node1.CreateLV(ctx, &proto.CreateLVRequest{}); err != nil {
+		t.Errorf("node1 should be allowed to create LV: %v", err)
+	}
+	if err := readLV(node1); status.Code(err) != codes.Unimplemented {
+		t.Errorf("node1 should be allowed to call ReadLV: %v", err)
+	}
+
+	node2Dir := t.TempDir()
+	node2TLSConfig := clientTLSConfig(t, ca.issue(t, node2Dir, "node2"))
+	node2 := dial(t, node2TLSConfig)
+	if _, err := node2.CreateLV(ctx, &proto.CreateLVRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("node2 should not be allowed to create LV: %v", err)
+	}
+	if err := readLV(node2); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("node2 should not be allowed to call ReadLV: %v", err)
+	}
+
+	other := dial(t, clientTLSConfig(t, newTestCA(t).issue(t, t.TempDir(), "node1")))
+	if _, err := other.CreateLV(ctx, &proto.CreateLVRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("client of another CA should be rejected: %v", err)
+	}
+
+	// The rotated certificate is used for new connections.
+	ca.issue(t, node2Dir, "node1")
+	node2 = dial(t, node2TLSConfig)
+	if _, err := node2.CreateLV(ctx, &proto.CreateLVRequest{}); err != nil {
+		t.Errorf("rotated certificate should be used: %v", err)
+	}
+}
+
+func TestClientVerifiesServerName(t *testing.T) {
+	ca := newTestCA(t)
+	clientConfig := ca.issue(t, t.TempDir(), "node1")
+	ctx := context.Background()
+
+	createLV := func(t *testing.T, serverCommonName, serverName string) error {
+		t.Helper()
+		serverConfig := ca.issue(t, t.TempDir(), serverCommonName)
+		serverConfig.MutatingClients = []string{"node1"}
+		server, err := NewTCPServer(serverConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proto.RegisterLVServiceServer(server, testLVService{})
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() { _ = server.Serve(lis) }()
+		defer server.Stop()
+
+		// lvmd is dialed by the IP address, so the TLS handshake carries no server name.
+		tlsConfig, err := NewClientTLSConfig(clientConfig.CertFile, clientConfig.KeyFile, clientConfig.CAFile, serverName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = conn.Close() }()
+		_, err = proto.NewLVServiceClient(conn).CreateLV(ctx, &proto.CreateLVRequest{})
+		return err
+	}
+
+	if err := createLV(t, "127.0.0.1", "127.0.0.1"); err != nil {
+		t.Errorf("certificate for the IP address should be accepted: %v", err)
+	}
+	if err := createLV(t, "lvmd.example.com", "127.0.0.1"); status.Code(err) != codes.Unavailable {
+		t.Errorf("certificate for another host should be rejected: %v", err)
+	}
+	if err := createLV(t, "node2", "127.0.0.1"); status.Code(err) != codes.Unavailable {
+		t.Errorf("client certificate of another node should be rejected: %v", err)
+	}
+	if err := createLV(t, "node2", ""); status.Code(err) != codes.Unavailable {
+		t.Errorf("certificate should be rejected when there is no server name to verify: %v", err)
+	}
+}
+
+func TestCertificateReloader(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	config := ca.issue(t, dir, "node1")
+
+	r, err := newCertificateReloader(config.CertFile, config.KeyFile, config.CAFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _, err := r.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca.issue(t, dir, "node2")
+	cert2, _, err := r.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert2 == cert {
+		t.Error("the certificate should be reloaded")
+	}
+
+	// The previous certificate is used while the files are broken.
+	if err := os.WriteFile(config.CertFile, []byte("broken"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert3, _, err := r.load()
+	if err != nil || cert3 != cert2 {
+		t.Errorf("the previous certificate should be used: %v", err)
+	}
+
+	if _, err := newCertificateReloader(config.CertFile, config.KeyFile, config.CAFile); err == nil {
+		t.Error("broken certificate should not be loaded")
+	}
+	if _, err := newCertificateReloader("", config.KeyFile, config.CAFile); err == nil {
+		t.Error("missing cert-file should be rejected")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/topolvm/topolvm/internal/lvmd/command"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
@@ -12,10 +13,17 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// NewVGService creates a VGServiceServer
-func NewVGService(manager *DeviceClassManager) (proto.VGServiceServer, func()) {
+// monitorInterval is the interval to send the statistics of the caches of cached device-classes
+// and the usage of auto-extended thin pools, as they change without any notification.
+const monitorInterval = 30 * time.Second
+
+// NewVGService creates a VGServiceServer. The numbers of the extensions of the thin pools are reported
+// from extender, which may be nil if no thin pool is extended automatically.
+func NewVGService(manager *DeviceClassManager, ocManager *LvcreateOptionClassManager, extender *ThinPoolExtender) (proto.VGServiceServer, func()) {
 	svc := &vgService{
 		dcManager: manager,
+		ocManager: ocManager,
+		extender:  extender,
 		watchers:  make(map[int]chan struct{}),
 	}
 
@@ -25,6 +33,8 @@ func NewVGService(manager *DeviceClassManager) (proto.VGServiceServer, func()) {
 type vgService struct {
 	proto.UnimplementedVGServiceServer
 	dcManager *DeviceClassManager
+	ocManager *LvcreateOptionClassManager
+	extender  *ThinPoolExtender
 
 	// mu protects watcherCounter and watchers. must take it when use them.
 	mu             sync.Mutex
@@ -98,7 +108,9 @@ func (s *vgService) send(server proto.VGService_WatchServer) error {
 	if err != nil {
 		return err
 	}
-	res := &proto.WatchResponse{}
+	res := &proto.WatchResponse{
+		LvcreateOptionClasses: s.ocManager.names(),
+	}
 	for _, vg := range vgs {
 		vgFree, err := vg.Free()
 		if err != nil {
@@ -149,6 +161,8 @@ func (s *vgService) send(server proto.VGService_WatchServer) error {
 			// size bytes of the thinpool
 			tpi.SizeBytes = tpu.SizeBytes
 
+			tpi.DataExtensions, tpi.MetadataExtensions = s.extender.Extensions(dc.Name)
+
 			// include thinpoolitem in the response
 			res.Items = append(res.Items, &proto.WatchItem{
 				DeviceClass: dc.Name,
@@ -163,6 +177,20 @@ func (s *vgService) send(server proto.VGService_WatchServer) error {
 			continue
 		}
 
+		// cached and RAID device-classes report the free space usable for their volumes
+		vgFree, err = thickPoolForVolumeGroup(vg, dc).Free(server.Context())
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+
+		var cache *proto.CacheItem
+		if dc.CacheConfig != nil {
+			cache, err = getCacheItem(server.Context(), vg, dc)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+		}
+
 		spare := GetSpare(dc)
 		if vgFree < spare {
 			vgFree = 0
@@ -178,11 +206,39 @@ func (s *vgService) send(server proto.VGService_WatchServer) error {
 			DeviceClass: dc.Name,
 			FreeBytes:   vgFree,
 			SizeBytes:   vgSize,
+			Cache:       cache,
 		})
 	}
 	return server.Send(res)
 }
 
+// getCacheItem returns the space of the cache physical volumes of a cached device-class and the statistics of its caches.
+func getCacheItem(ctx context.Context, vg *command.VolumeGroup, dc *lvmdTypes.DeviceClass) (*proto.CacheItem, error) {
+	space, err := getCacheSpace(ctx, vg, dc)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := vg.CacheStats(ctx, GetCacheMode(dc) == lvmdTypes.CacheModeWritecache)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &proto.CacheItem{
+		SizeBytes: space.cacheSize,
+		FreeBytes: space.cacheFree,
+	}
+	for _, s := range stats {
+		item.ReadHits += s.ReadHits
+		item.ReadMisses += s.ReadMisses
+		item.WriteHits += s.WriteHits
+		item.WriteMisses += s.WriteMisses
+		item.UsedBlocks += s.UsedBlocks
+		item.DirtyBlocks += s.DirtyBlocks
+		item.TotalBlocks += s.TotalBlocks
+	}
+	return item, nil
+}
+
 func (s *vgService) addWatcher(ch chan struct{}) int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -218,6 +274,10 @@ func (s *vgService) Watch(_ *proto.Empty, server proto.VGService_WatchServer) er
 	num := s.addWatcher(ch)
 	defer s.removeWatcher(num)
 
+	// the ticker always runs, as device-classes to be monitored may be added by reloading the configuration.
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+
 	// Initial notification on startup
 	if err := s.send(server); err != nil {
 		return err
@@ -231,6 +291,13 @@ func (s *vgService) Watch(_ *proto.Empty, server proto.VGService_WatchServer) er
 			if err := s.send(server); err != nil {
 				return err
 			}
+		case <-ticker.C:
+			if !s.dcManager.hasMonitoredDeviceClass() {
+				continue
+			}
+			if err := s.send(server); err != nil {
+				return err
+			}
 		}
 	}
 }
@@ -0,0 +1,47 @@
+package lvmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+const copyBufferSize = 4 << 20
+
+// copyVolume copies the first size bytes of the block device at src to the block device at dst.
+// The context is checked between chunks so that a long-running copy can be aborted.
+func copyVolume(ctx context.Context, src, dst string, size uint64) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open source device %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open target device %s: %w", dst, err)
+	}
+	defer out.Close()
+
+	buf := make([]byte, copyBufferSize)
+	var copied uint64
+	for copied < size {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n := min(uint64(len(buf)), size-copied)
+		if _, err := io.ReadFull(in, buf[:n]); err != nil {
+			return fmt.Errorf("failed to read from %s at offset %d: %w", src, copied, err)
+		}
+		if _, err := out.Write(buf[:n]); err != nil {
+			return fmt.Errorf("failed to write to %s at offset %d: %w", dst, copied, err)
+		}
+		copied += n
+	}
+
+	if err := out.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", dst, err)
+	}
+	return out.Close()
+}
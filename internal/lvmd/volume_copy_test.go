@@ -0,0 +1,54 @@
+package lvmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestCopyVolume(t *testing.T) {
+	dir := t.TempDir()
+	src := path.Join(dir, "src")
+	dst := path.Join(dir, "dst")
+
+	// not a multiple of the buffer size to exercise the last partial chunk
+	size := uint64(copyBufferSize*2 + 12345)
+	data := make([]byte, size+4096)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, make([]byte, size+4096), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyVolume(context.Background(), src, dst, size); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(copied[:size], data[:size]) {
+		t.Error("copied data does not match the source")
+	}
+	if !bytes.Equal(copied[size:], make([]byte, 4096)) {
+		t.Error("data beyond the requested size should not be copied")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := copyVolume(ctx, src, dst, size); err == nil {
+		t.Error("copy should be aborted by a canceled context")
+	}
+
+	if err := copyVolume(context.Background(), src, dst, size+8192); err == nil {
+		t.Error("copy should fail when the source is smaller than the requested size")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"os"
+	"strings"
 
 	"google.golang.org/grpc"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -11,26 +12,22 @@ import (
 
 type gRPCServerRunner struct {
 	srv            *grpc.Server
-	sockFile       string
+	endpoint       string
 	leaderElection bool
 }
 
 var _ manager.LeaderElectionRunnable = gRPCServerRunner{}
 
 // NewGRPCRunner creates controller-runtime's manager.Runnable for a gRPC server.
-// The server will listen on UNIX domain socket at sockFile.
+// The server will listen on endpoint. See Listen for the format.
 // If leaderElection is true, the server will run only when it is elected as leader.
-func NewGRPCRunner(srv *grpc.Server, sockFile string, leaderElection bool) manager.Runnable {
-	return gRPCServerRunner{srv, sockFile, leaderElection}
+func NewGRPCRunner(srv *grpc.Server, endpoint string, leaderElection bool) manager.Runnable {
+	return gRPCServerRunner{srv, endpoint, leaderElection}
 }
 
 // Start implements controller-runtime's manager.Runnable.
 func (r gRPCServerRunner) Start(ctx context.Context) error {
-	err := os.Remove(r.sockFile)
-	if err != nil && !os.IsNotExist(err) {
-		return err
-	}
-	lis, err := net.Listen("unix", r.sockFile)
+	lis, err := Listen(r.endpoint)
 	if err != nil {
 		return err
 	}
@@ -47,3 +44,17 @@ func (r gRPCServerRunner) Start(ctx context.Context) error {
 func (r gRPCServerRunner) NeedLeaderElection() bool {
 	return r.leaderElection
 }
+
+// Listen listens on endpoint, which is either "tcp://<host>:<port>", "unix://<path>" or a path of UNIX domain socket.
+// An existing UNIX domain socket file is removed before listening.
+func Listen(endpoint string) (net.Listener, error) {
+	if address, ok := strings.CutPrefix(endpoint, "tcp://"); ok {
+		return net.Listen("tcp", address)
+	}
+	sockFile := strings.TrimPrefix(endpoint, "unix://")
+	err := os.Remove(sockFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return net.Listen("unix", sockFile)
+}
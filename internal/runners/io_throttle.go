@@ -0,0 +1,99 @@
+package runners
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/iothrottle"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var itLogger = ctrl.Log.WithName("runners").WithName("io_throttler")
+
+type ioThrottler struct {
+	client   client.Client
+	nodeName string
+	interval time.Duration
+}
+
+var _ manager.LeaderElectionRunnable = ioThrottler{}
+
+// NewIOThrottler creates controller-runtime's manager.Runnable which applies the IO limits of the logical volumes
+// to the pods using them every interval. This re-applies the limits after topolvm-node or the node restarts,
+// and applies the limits changed by VolumeAttributesClasses to the running pods.
+func NewIOThrottler(client client.Client, nodeName string, interval time.Duration) manager.Runnable {
+	return ioThrottler{client, nodeName, interval}
+}
+
+// Start implements controller-runtime's manager.Runnable.
+func (t ioThrottler) Start(ctx context.Context) error {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.apply(ctx); err != nil {
+			itLogger.Error(err, "failed to apply IO limits")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements controller-runtime's manager.LeaderElectionRunnable.
+func (t ioThrottler) NeedLeaderElection() bool {
+	return false
+}
+
+func (t ioThrottler) apply(ctx context.Context) error {
+	publications, err := iothrottle.Publications()
+	if err != nil {
+		return err
+	}
+	if len(publications) == 0 {
+		return nil
+	}
+
+	lvList := new(topolvmv1.LogicalVolumeList)
+	if err := t.client.List(ctx, lvList); err != nil {
+		return err
+	}
+	// the names of LogicalVolumes are the same as those of PersistentVolumes
+	lvs := make(map[string]*topolvmv1.LogicalVolume)
+	for i := range lvList.Items {
+		lv := &lvList.Items[i]
+		if lv.Spec.NodeName != t.nodeName || lv.Status.VolumeID == "" {
+			continue
+		}
+		lvs[lv.Name] = lv
+	}
+
+	var errs []error
+	for _, p := range publications {
+		lv, ok := lvs[p.VolumeName]
+		if !ok {
+			continue
+		}
+		major, minor, err := iothrottle.DeviceOf(p.TargetPath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		err = iothrottle.Apply(p.PodUID, major, minor, lv.Spec.IOLimits)
+		if errors.Is(err, iothrottle.ErrPodCgroupNotFound) {
+			// the pod is being deleted
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -3,7 +3,9 @@ package runners
 import (
 	"context"
 	"io"
+	"math"
 	"strconv"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/topolvm/topolvm"
@@ -11,8 +13,11 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -39,6 +44,20 @@ type NodeMetrics struct {
 	OverProvisionBytes uint64
 	DeviceClass        string
 	DeviceClassType    string
+	Cache              *CacheMetrics
+}
+
+// CacheMetrics is a set of metrics of the caches of a cached device-class.
+type CacheMetrics struct {
+	SizeBytes   uint64
+	FreeBytes   uint64
+	ReadHits    uint64
+	ReadMisses  uint64
+	WriteHits   uint64
+	WriteMisses uint64
+	UsedBlocks  uint64
+	DirtyBlocks uint64
+	TotalBlocks uint64
 }
 
 // thinPoolMetricsExporter is the subset of metricsExporter corresponding to the deviceclass target
@@ -47,6 +66,20 @@ type thinPoolMetricsExporter struct {
 	dataPercent      *prometheus.GaugeVec
 	metadataPercent  *prometheus.GaugeVec
 	opAvailableBytes *prometheus.GaugeVec
+	extensions       *prometheus.CounterVec
+}
+
+// cacheMetricsExporter is the subset of metricsExporter corresponding to the cache of the deviceclass
+type cacheMetricsExporter struct {
+	sizeBytes      *prometheus.GaugeVec
+	availableBytes *prometheus.GaugeVec
+	readHits       *prometheus.GaugeVec
+	readMisses     *prometheus.GaugeVec
+	writeHits      *prometheus.GaugeVec
+	writeMisses    *prometheus.GaugeVec
+	usedBlocks     *prometheus.GaugeVec
+	dirtyBlocks    *prometheus.GaugeVec
+	totalBlocks    *prometheus.GaugeVec
 }
 
 type metricsExporter struct {
@@ -56,13 +89,25 @@ type metricsExporter struct {
 	availableBytes *prometheus.GaugeVec
 	sizeBytes      *prometheus.GaugeVec
 	thinPool       *thinPoolMetricsExporter
+	cache          *cacheMetricsExporter
+	recorder       events.EventRecorder
+
+	// extensions is the last number of the automatic extensions of thin pools reported by lvmd for each device-class.
+	extensions map[string]thinPoolExtensions
+}
+
+// thinPoolExtensions is the number of times the data and the metadata of a thin pool have been extended.
+type thinPoolExtensions struct {
+	data     uint64
+	metadata uint64
 }
 
 var _ manager.LeaderElectionRunnable = &metricsExporter{}
 
 // NewMetricsExporter creates controller-runtime's manager.Runnable to run
-// a metrics exporter for a node.
-func NewMetricsExporter(vgServiceClient proto.VGServiceClient, client client.Client, nodeName string) manager.Runnable {
+// a metrics exporter for a node. The automatic extensions of thin pools are
+// recorded as events of the node with recorder.
+func NewMetricsExporter(vgServiceClient proto.VGServiceClient, client client.Client, nodeName string, recorder events.EventRecorder) manager.Runnable {
 	// metrics available under volumegroup subsystem
 	availableBytes := prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace:   metricsNamespace,
@@ -113,6 +158,25 @@ func NewMetricsExporter(vgServiceClient proto.VGServiceClient, client client.Cli
 		ConstLabels: prometheus.Labels{"node": nodeName},
 	}, []string{"device_class"})
 
+	extensions := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   "thinpool",
+		Name:        "extensions_total",
+		Help:        "LVM VG Thin Pool automatic extensions count",
+		ConstLabels: prometheus.Labels{"node": nodeName},
+	}, []string{"device_class", "target"})
+
+	// metrics available under cache subsystem
+	cacheGauge := func(name, help string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   "cache",
+			Name:        name,
+			Help:        help,
+			ConstLabels: prometheus.Labels{"node": nodeName},
+		}, []string{"device_class"})
+	}
+
 	return &metricsExporter{
 		client:         client,
 		nodeName:       nodeName,
@@ -124,7 +188,21 @@ func NewMetricsExporter(vgServiceClient proto.VGServiceClient, client client.Cli
 			dataPercent:      dataPercent,
 			metadataPercent:  metadataPercent,
 			opAvailableBytes: opAvailableBytes,
+			extensions:       extensions,
 		},
+		cache: &cacheMetricsExporter{
+			sizeBytes:      cacheGauge("size_bytes", "LVM cache physical volumes size bytes"),
+			availableBytes: cacheGauge("available_bytes", "LVM cache physical volumes available bytes"),
+			readHits:       cacheGauge("read_hits", "LVM cache read hits summed over the cached volumes"),
+			readMisses:     cacheGauge("read_misses", "LVM cache read misses summed over the cached volumes"),
+			writeHits:      cacheGauge("write_hits", "LVM cache write hits summed over the cached volumes"),
+			writeMisses:    cacheGauge("write_misses", "LVM cache write misses summed over the cached volumes"),
+			usedBlocks:     cacheGauge("used_blocks", "LVM cache used blocks summed over the cached volumes"),
+			dirtyBlocks:    cacheGauge("dirty_blocks", "LVM cache dirty blocks summed over the cached volumes"),
+			totalBlocks:    cacheGauge("total_blocks", "LVM cache total blocks summed over the cached volumes"),
+		},
+		recorder:   recorder,
+		extensions: make(map[string]thinPoolExtensions),
 	}
 }
 
@@ -136,6 +214,16 @@ func (m *metricsExporter) getCollectors() []prometheus.Collector {
 		m.thinPool.dataPercent,
 		m.thinPool.metadataPercent,
 		m.thinPool.opAvailableBytes,
+		m.thinPool.extensions,
+		m.cache.sizeBytes,
+		m.cache.availableBytes,
+		m.cache.readHits,
+		m.cache.readMisses,
+		m.cache.writeHits,
+		m.cache.writeMisses,
+		m.cache.usedBlocks,
+		m.cache.dirtyBlocks,
+		m.cache.totalBlocks,
 	}
 }
 
@@ -180,6 +268,19 @@ func (m *metricsExporter) Start(ctx context.Context) error {
 					m.thinPool.metadataPercent.WithLabelValues(met.DeviceClass).Set(met.MetadataPercent)
 					m.thinPool.opAvailableBytes.WithLabelValues(met.DeviceClass).Set(float64(met.OverProvisionBytes))
 				}
+
+				if met.Cache != nil {
+					// metrics for cache subsystem exclusively
+					m.cache.sizeBytes.WithLabelValues(met.DeviceClass).Set(float64(met.Cache.SizeBytes))
+					m.cache.availableBytes.WithLabelValues(met.DeviceClass).Set(float64(met.Cache.FreeBytes))
+					m.cache.readHits.WithLabelValues(met.DeviceClass).Set(float64(met.Cache.ReadHits))
+					m.cache.readMisses.WithLabelValues(met.DeviceClass).Set(float64(met.Cache.ReadMisses))
+					m.cache.writeHits.WithLabelValues(met.DeviceClass).Set(float64(met.Cache.WriteHits))
+					m.cache.writeMisses.WithLabelValues(met.DeviceClass).Set(float64(met.Cache.WriteMisses))
+					m.cache.usedBlocks.WithLabelValues(met.DeviceClass).Set(float64(met.Cache.UsedBlocks))
+					m.cache.dirtyBlocks.WithLabelValues(met.DeviceClass).Set(float64(met.Cache.DirtyBlocks))
+					m.cache.totalBlocks.WithLabelValues(met.DeviceClass).Set(float64(met.Cache.TotalBlocks))
+				}
 			}
 		}
 	}()
@@ -222,12 +323,26 @@ func (m *metricsExporter) updateNode(ctx context.Context, wc proto.VGService_Wat
 					OverProvisionBytes: item.ThinPool.OverprovisionBytes,
 				}
 			} else {
-				ch <- NodeMetrics{
+				met := NodeMetrics{
 					DeviceClass:     item.DeviceClass,
 					FreeBytes:       item.FreeBytes,
 					SizeBytes:       item.SizeBytes,
 					DeviceClassType: TypeThick,
 				}
+				if item.Cache != nil {
+					met.Cache = &CacheMetrics{
+						SizeBytes:   item.Cache.SizeBytes,
+						FreeBytes:   item.Cache.FreeBytes,
+						ReadHits:    item.Cache.ReadHits,
+						ReadMisses:  item.Cache.ReadMisses,
+						WriteHits:   item.Cache.WriteHits,
+						WriteMisses: item.Cache.WriteMisses,
+						UsedBlocks:  item.Cache.UsedBlocks,
+						DirtyBlocks: item.Cache.DirtyBlocks,
+						TotalBlocks: item.Cache.TotalBlocks,
+					}
+				}
+				ch <- met
 			}
 		}
 
@@ -242,20 +357,37 @@ func (m *metricsExporter) updateNode(ctx context.Context, wc proto.VGService_Wat
 			meLogger.Info("node is deleting")
 			break
 		}
+
+		for _, item := range res.Items {
+			if item.ThinPool != nil {
+				m.recordExtensions(&nodeMetadata, item)
+			}
+		}
 		nodeMetadata2 := nodeMetadata.DeepCopy()
 
 		controllerutil.AddFinalizer(nodeMetadata2, topolvm.GetNodeFinalizer())
 
 		nodeMetadata2.Annotations[topolvm.GetCapacityKeyPrefix()+topolvm.DefaultDeviceClassAnnotationName] = strconv.FormatUint(res.FreeBytes, 10)
+		nodeMetadata2.Annotations[topolvm.GetLvcreateOptionClassesKey()] = strings.Join(res.LvcreateOptionClasses, ",")
+		// the device-classes may be removed by reloading the configuration of LVMd.
+		removeStaleAnnotations(nodeMetadata2.Annotations, res.Items)
 		for _, item := range res.Items {
 			var freeSize uint64
 			if item.ThinPool != nil {
 				freeSize = item.ThinPool.OverprovisionBytes
+				// the usage is rounded to an integer not to patch the node on every write to the thin pool.
+				nodeMetadata2.Annotations[topolvm.GetThinPoolDataPercentKeyPrefix()+item.DeviceClass] = formatPercent(item.ThinPool.DataPercent)
+				nodeMetadata2.Annotations[topolvm.GetThinPoolMetadataPercentKeyPrefix()+item.DeviceClass] = formatPercent(item.ThinPool.MetadataPercent)
 			} else {
 				freeSize = item.FreeBytes
 			}
 			nodeMetadata2.Annotations[topolvm.GetCapacityKeyPrefix()+item.DeviceClass] = strconv.FormatUint(freeSize, 10)
 		}
+		// the watch is also notified periodically for the statistics of caches and the usage of
+		// auto-extended thin pools, so the node is patched only when the annotations have changed.
+		if equality.Semantic.DeepEqual(nodeMetadata.ObjectMeta, nodeMetadata2.ObjectMeta) {
+			continue
+		}
 		if err := m.client.Patch(ctx, nodeMetadata2, client.MergeFrom(&nodeMetadata)); err != nil {
 			return err
 		}
@@ -263,3 +395,65 @@ func (m *metricsExporter) updateNode(ctx context.Context, wc proto.VGService_Wat
 
 	return nil
 }
+
+// removeStaleAnnotations removes the annotations of the device-classes which are no longer reported by LVMd,
+// so that the scheduler does not choose the node for them.
+func removeStaleAnnotations(annotations map[string]string, items []*proto.WatchItem) {
+	thin := make(map[string]bool)
+	for _, item := range items {
+		thin[item.DeviceClass] = item.ThinPool != nil
+	}
+	for key := range annotations {
+		if dc, ok := strings.CutPrefix(key, topolvm.GetCapacityKeyPrefix()); ok {
+			if _, found := thin[dc]; !found && dc != topolvm.DefaultDeviceClassAnnotationName {
+				delete(annotations, key)
+			}
+		}
+		for _, prefix := range []string{topolvm.GetThinPoolDataPercentKeyPrefix(), topolvm.GetThinPoolMetadataPercentKeyPrefix()} {
+			if dc, ok := strings.CutPrefix(key, prefix); ok && !thin[dc] {
+				delete(annotations, key)
+			}
+		}
+	}
+}
+
+func formatPercent(percent float64) string {
+	return strconv.FormatInt(int64(math.Round(percent)), 10)
+}
+
+// recordExtensions counts the automatic extensions of the thin pool of a device-class since the last report,
+// and records them as events of the node. The numbers reported by lvmd are reset when it restarts.
+// The events of the extensions made before the first report have been recorded by the previous exporter.
+func (m *metricsExporter) recordExtensions(node runtime.Object, item *proto.WatchItem) {
+	last, seen := m.extensions[item.DeviceClass]
+	current := thinPoolExtensions{
+		data:     item.ThinPool.DataExtensions,
+		metadata: item.ThinPool.MetadataExtensions,
+	}
+	m.extensions[item.DeviceClass] = current
+
+	delta := func(current, last uint64) uint64 {
+		if current < last {
+			return current
+		}
+		return current - last
+	}
+	for _, target := range []struct {
+		name  string
+		count uint64
+	}{
+		{"data", delta(current.data, last.data)},
+		{"metadata", delta(current.metadata, last.metadata)},
+	} {
+		if target.count == 0 {
+			continue
+		}
+		m.thinPool.extensions.WithLabelValues(item.DeviceClass, target.name).Add(float64(target.count))
+		if !seen {
+			continue
+		}
+		m.recorder.Eventf(node, nil, corev1.EventTypeNormal, "ThinPoolExtended", "ExtendThinPool",
+			"The %s of the thin pool of device-class %s has been extended; the thin pool size is %d bytes",
+			target.name, item.DeviceClass, item.ThinPool.SizeBytes)
+	}
+}
@@ -0,0 +1,46 @@
+package runners
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/topolvm/topolvm"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+)
+
+func TestRemoveStaleAnnotations(t *testing.T) {
+	capacity := topolvm.GetCapacityKeyPrefix()
+	data := topolvm.GetThinPoolDataPercentKeyPrefix()
+	metadata := topolvm.GetThinPoolMetadataPercentKeyPrefix()
+	annotations := map[string]string{
+		capacity + topolvm.DefaultDeviceClassAnnotationName: "1",
+		capacity + "ssd":     "1",
+		capacity + "hdd":     "1",
+		capacity + "thin":    "1",
+		data + "thin":        "1",
+		metadata + "thin":    "1",
+		capacity + "removed": "1",
+		data + "removed":     "1",
+		metadata + "removed": "1",
+		"example.com/foo":    "bar",
+	}
+
+	removeStaleAnnotations(annotations, []*proto.WatchItem{
+		{DeviceClass: "ssd"},
+		{DeviceClass: "hdd"},
+		{DeviceClass: "thin", ThinPool: &proto.ThinPoolItem{}},
+	})
+
+	expected := map[string]string{
+		capacity + topolvm.DefaultDeviceClassAnnotationName: "1",
+		capacity + "ssd":  "1",
+		capacity + "hdd":  "1",
+		capacity + "thin": "1",
+		data + "thin":     "1",
+		metadata + "thin": "1",
+		"example.com/foo": "bar",
+	}
+	if !reflect.DeepEqual(annotations, expected) {
+		t.Errorf("unexpected annotations: %v", annotations)
+	}
+}
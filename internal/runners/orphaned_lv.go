@@ -0,0 +1,355 @@
+package runners
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var olLogger = ctrl.Log.WithName("runners").WithName("orphaned_lv_collector")
+
+// OrphanedLVMode is the mode of the garbage collection of orphaned logical volumes.
+type OrphanedLVMode string
+
+const (
+	// OrphanedLVDisabled does not look for orphaned logical volumes.
+	OrphanedLVDisabled OrphanedLVMode = "disabled"
+	// OrphanedLVDryRun reports orphaned logical volumes without removing them.
+	OrphanedLVDryRun OrphanedLVMode = "dry-run"
+	// OrphanedLVEnforce removes orphaned logical volumes after the grace period.
+	OrphanedLVEnforce OrphanedLVMode = "enforce"
+)
+
+// ParseOrphanedLVMode parses the name of OrphanedLVMode.
+func ParseOrphanedLVMode(name string) (OrphanedLVMode, error) {
+	switch m := OrphanedLVMode(name); m {
+	case OrphanedLVDisabled, OrphanedLVDryRun, OrphanedLVEnforce:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid orphaned LV mode: %s", name)
+}
+
+// lvNamePattern matches the names of the logical volumes created by TopoLVM, which are the UIDs of
+// LogicalVolumes or LogicalVolumeBackups. The other logical volumes in the volume groups, such as
+// thin pools, temporary volumes, and volumes not managed by TopoLVM, are never regarded as orphaned.
+var lvNamePattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+type orphanedLVCollector struct {
+	client      client.Client
+	nodeName    string
+	vgService   proto.VGServiceClient
+	lvService   proto.LVServiceClient
+	recorder    events.EventRecorder
+	mode        OrphanedLVMode
+	interval    time.Duration
+	gracePeriod time.Duration
+
+	orphanedVolumes *prometheus.GaugeVec
+	missingVolumes  *prometheus.GaugeVec
+	removedVolumes  *prometheus.CounterVec
+
+	// orphans is the time when each orphaned logical volume was found first.
+	orphans map[string]time.Time
+	// missing is the set of the names of the LogicalVolumes whose logical volumes have been found missing.
+	missing map[string]struct{}
+}
+
+var _ manager.LeaderElectionRunnable = &orphanedLVCollector{}
+
+// NewOrphanedLVCollector creates controller-runtime's manager.Runnable which compares the logical volumes
+// on the node with LogicalVolumes every interval. Logical volumes without LogicalVolumes and LogicalVolumes
+// without logical volumes are reported as events and metrics. In OrphanedLVEnforce mode, the orphaned
+// logical volumes are removed once they have been found for gracePeriod.
+func NewOrphanedLVCollector(
+	vgServiceClient proto.VGServiceClient,
+	lvServiceClient proto.LVServiceClient,
+	client client.Client,
+	nodeName string,
+	recorder events.EventRecorder,
+	mode OrphanedLVMode,
+	interval, gracePeriod time.Duration,
+) manager.Runnable {
+	return &orphanedLVCollector{
+		client:      client,
+		nodeName:    nodeName,
+		vgService:   vgServiceClient,
+		lvService:   lvServiceClient,
+		recorder:    recorder,
+		mode:        mode,
+		interval:    interval,
+		gracePeriod: gracePeriod,
+		orphanedVolumes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   "volumegroup",
+			Name:        "orphaned_lvs",
+			Help:        "The number of logical volumes created by TopoLVM which have no LogicalVolume",
+			ConstLabels: prometheus.Labels{"node": nodeName},
+		}, []string{"device_class"}),
+		missingVolumes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   "volumegroup",
+			Name:        "missing_lvs",
+			Help:        "The number of LogicalVolumes whose logical volumes do not exist",
+			ConstLabels: prometheus.Labels{"node": nodeName},
+		}, []string{"device_class"}),
+		removedVolumes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   "volumegroup",
+			Name:        "orphaned_lvs_removed_total",
+			Help:        "The number of orphaned logical volumes removed by TopoLVM",
+			ConstLabels: prometheus.Labels{"node": nodeName},
+		}, []string{"device_class"}),
+		orphans: make(map[string]time.Time),
+		missing: make(map[string]struct{}),
+	}
+}
+
+// Start implements controller-runtime's manager.Runnable.
+func (c *orphanedLVCollector) Start(ctx context.Context) error {
+	collectors := []prometheus.Collector{c.orphanedVolumes, c.missingVolumes, c.removedVolumes}
+	for _, col := range collectors {
+		if err := metrics.Registry.Register(col); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		for _, col := range collectors {
+			metrics.Registry.Unregister(col)
+		}
+	}()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.collect(ctx, time.Now()); err != nil {
+			olLogger.Error(err, "failed to look for orphaned logical volumes")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements controller-runtime's manager.LeaderElectionRunnable.
+func (c *orphanedLVCollector) NeedLeaderElection() bool {
+	return false
+}
+
+// knownVolumes is the LogicalVolumes and the LogicalVolumeBackups at a point in time.
+type knownVolumes struct {
+	lvs  []topolvmv1.LogicalVolume
+	lvbs []topolvmv1.LogicalVolumeBackup
+}
+
+func (c *orphanedLVCollector) listKnownVolumes(ctx context.Context) (*knownVolumes, error) {
+	known := new(knownVolumes)
+	lvList := new(topolvmv1.LogicalVolumeList)
+	if err := c.client.List(ctx, lvList); err != nil {
+		return nil, err
+	}
+	known.lvs = lvList.Items
+
+	// LogicalVolumeBackup is not served in the legacy API group.
+	if !topolvm.UseLegacy() {
+		lvbList := new(topolvmv1.LogicalVolumeBackupList)
+		if err := c.client.List(ctx, lvbList); err != nil {
+			return nil, err
+		}
+		known.lvbs = lvbList.Items
+	}
+	return known, nil
+}
+
+func (c *orphanedLVCollector) collect(ctx context.Context, now time.Time) error {
+	var node v1.PartialObjectMetadata
+	node.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Node"))
+	if err := c.client.Get(ctx, types.NamespacedName{Name: c.nodeName}, &node); err != nil {
+		return err
+	}
+	deviceClasses := advertisedDeviceClasses(node.Annotations)
+	if len(deviceClasses) == 0 {
+		// The metrics exporter has not annotated the node yet.
+		return nil
+	}
+
+	// The custom resources are listed both before and after listing the logical volumes.
+	// Logical volumes created in between are known by the later list, and LogicalVolumes
+	// whose logical volumes have been removed in between are being deleted in the later list.
+	before, err := c.listKnownVolumes(ctx)
+	if err != nil {
+		return err
+	}
+	actual := make(map[string]string)
+	for _, dc := range deviceClasses {
+		resp, err := c.vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: dc})
+		if err != nil {
+			return err
+		}
+		for _, v := range resp.Volumes {
+			actual[v.Name] = dc
+		}
+	}
+	after, err := c.listKnownVolumes(ctx)
+	if err != nil {
+		return err
+	}
+
+	orphaned, missing := diffVolumes(c.nodeName, deviceClasses, actual, before, after)
+
+	c.orphanedVolumes.Reset()
+	c.missingVolumes.Reset()
+	for _, dc := range deviceClasses {
+		c.orphanedVolumes.WithLabelValues(dc).Set(0)
+		c.missingVolumes.WithLabelValues(dc).Set(0)
+	}
+
+	names := make([]string, 0, len(orphaned))
+	for name := range orphaned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for name := range c.orphans {
+		if _, ok := orphaned[name]; !ok {
+			delete(c.orphans, name)
+		}
+	}
+	for _, name := range names {
+		dc := orphaned[name]
+		c.orphanedVolumes.WithLabelValues(dc).Inc()
+		found, ok := c.orphans[name]
+		if !ok {
+			found = now
+			c.orphans[name] = now
+			olLogger.Info("found orphaned logical volume", "name", name, "device_class", dc, "mode", c.mode)
+			c.recorder.Eventf(&node, nil, corev1.EventTypeWarning, "OrphanedLogicalVolume", "FindOrphanedLogicalVolume",
+				"Logical volume %s of device-class %s has no LogicalVolume", name, dc)
+		}
+		if c.mode != OrphanedLVEnforce || now.Sub(found) < c.gracePeriod {
+			continue
+		}
+		if _, err := c.lvService.RemoveLV(ctx, &proto.RemoveLVRequest{Name: name, DeviceClass: dc}); err != nil {
+			olLogger.Error(err, "failed to remove orphaned logical volume", "name", name, "device_class", dc)
+			continue
+		}
+		delete(c.orphans, name)
+		c.orphanedVolumes.WithLabelValues(dc).Dec()
+		c.removedVolumes.WithLabelValues(dc).Inc()
+		olLogger.Info("removed orphaned logical volume", "name", name, "device_class", dc)
+		c.recorder.Eventf(&node, nil, corev1.EventTypeNormal, "OrphanedLogicalVolumeRemoved", "RemoveOrphanedLogicalVolume",
+			"Logical volume %s of device-class %s has been removed as it had no LogicalVolume for %s", name, dc, c.gracePeriod)
+	}
+
+	current := make(map[string]struct{}, len(missing))
+	for i := range missing {
+		lv := &missing[i]
+		current[lv.Name] = struct{}{}
+		c.missingVolumes.WithLabelValues(lv.Spec.DeviceClass).Inc()
+		if _, ok := c.missing[lv.Name]; ok {
+			continue
+		}
+		olLogger.Info("logical volume of LogicalVolume is missing", "name", lv.Name, "volume_id", lv.Status.VolumeID)
+		c.recorder.Eventf(lv, nil, corev1.EventTypeWarning, "LogicalVolumeMissing", "FindMissingLogicalVolume",
+			"Logical volume %s does not exist on node %s", lv.Status.VolumeID, c.nodeName)
+	}
+	c.missing = current
+	return nil
+}
+
+// advertisedDeviceClasses returns the sorted names of the device-classes in the capacity annotations of the node.
+func advertisedDeviceClasses(annotations map[string]string) []string {
+	var deviceClasses []string
+	for key := range annotations {
+		dc, ok := strings.CutPrefix(key, topolvm.GetCapacityKeyPrefix())
+		// The default device-class is annotated with its name as well.
+		if !ok || dc == topolvm.DefaultDeviceClassAnnotationName {
+			continue
+		}
+		deviceClasses = append(deviceClasses, dc)
+	}
+	sort.Strings(deviceClasses)
+	return deviceClasses
+}
+
+// diffVolumes compares the logical volumes on the node with the custom resources listed before and after them.
+// actual maps the names of the logical volumes to their device-classes. It returns the orphaned logical volumes
+// in the same form, and the LogicalVolumes on the node whose logical volumes are missing.
+func diffVolumes(
+	nodeName string,
+	deviceClasses []string,
+	actual map[string]string,
+	before, after *knownVolumes,
+) (map[string]string, []topolvmv1.LogicalVolume) {
+	known := make(map[string]struct{})
+	for _, volumes := range []*knownVolumes{before, after} {
+		for _, lv := range volumes.lvs {
+			known[string(lv.UID)] = struct{}{}
+			if lv.Status.VolumeID != "" {
+				known[lv.Status.VolumeID] = struct{}{}
+			}
+		}
+		for _, lvb := range volumes.lvbs {
+			known[string(lvb.UID)] = struct{}{}
+			if lvb.Status.SnapshotID != "" {
+				known[lvb.Status.SnapshotID] = struct{}{}
+			}
+		}
+	}
+
+	orphaned := make(map[string]string)
+	for name, dc := range actual {
+		if !lvNamePattern.MatchString(name) {
+			continue
+		}
+		if _, ok := known[name]; !ok {
+			orphaned[name] = dc
+		}
+	}
+
+	listed := make(map[string]struct{}, len(deviceClasses))
+	for _, dc := range deviceClasses {
+		listed[dc] = struct{}{}
+	}
+	alive := make(map[string]struct{}, len(after.lvs))
+	for _, lv := range after.lvs {
+		if lv.DeletionTimestamp == nil {
+			alive[lv.Name] = struct{}{}
+		}
+	}
+	var missing []topolvmv1.LogicalVolume
+	for _, lv := range before.lvs {
+		if lv.Spec.NodeName != nodeName || lv.Status.VolumeID == "" || lv.DeletionTimestamp != nil {
+			continue
+		}
+		// The logical volumes of the device-classes which are not advertised are not listed.
+		if _, ok := listed[lv.Spec.DeviceClass]; !ok && lv.Spec.DeviceClass != topolvm.DefaultDeviceClassName {
+			continue
+		}
+		if _, ok := alive[lv.Name]; !ok {
+			continue
+		}
+		if _, ok := actual[lv.Status.VolumeID]; !ok {
+			missing = append(missing, lv)
+		}
+	}
+	return orphaned, missing
+}
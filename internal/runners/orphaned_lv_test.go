@@ -0,0 +1,101 @@
+package runners
+
+import (
+	"testing"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func testLogicalVolume(name, uid, nodeName, volumeID string) topolvmv1.LogicalVolume {
+	return topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(uid)},
+		Spec:       topolvmv1.LogicalVolumeSpec{Name: name, NodeName: nodeName, DeviceClass: "ssd"},
+		Status:     topolvmv1.LogicalVolumeStatus{VolumeID: volumeID},
+	}
+}
+
+func TestAdvertisedDeviceClasses(t *testing.T) {
+	dcs := advertisedDeviceClasses(map[string]string{
+		topolvm.GetCapacityKeyPrefix() + topolvm.DefaultDeviceClassAnnotationName: "1",
+		topolvm.GetCapacityKeyPrefix() + "ssd":                                    "1",
+		topolvm.GetCapacityKeyPrefix() + "hdd":                                    "1",
+		"example.com/foo":                                                         "bar",
+	})
+	if len(dcs) != 2 || dcs[0] != "hdd" || dcs[1] != "ssd" {
+		t.Errorf("unexpected device-classes: %v", dcs)
+	}
+}
+
+func TestDiffVolumes(t *testing.T) {
+	const (
+		known     = "11111111-1111-1111-1111-111111111111"
+		orphan    = "22222222-2222-2222-2222-222222222222"
+		creating  = "33333333-3333-3333-3333-333333333333"
+		backup    = "44444444-4444-4444-4444-444444444444"
+		missing   = "55555555-5555-5555-5555-555555555555"
+		deleting  = "66666666-6666-6666-6666-666666666666"
+		otherNode = "77777777-7777-7777-7777-777777777777"
+	)
+	actual := map[string]string{
+		known:              "ssd",
+		orphan:             "ssd",
+		creating:           "ssd",
+		backup:             "ssd",
+		known + "-cache":   "ssd",
+		known + "-copysrc": "ssd",
+		"thinpool":         "ssd",
+		"root":             "ssd",
+	}
+	deletedLV := testLogicalVolume("deleting", deleting, "node1", deleting)
+	before := &knownVolumes{
+		lvs: []topolvmv1.LogicalVolume{
+			testLogicalVolume("known", known, "node1", known),
+			testLogicalVolume("missing", missing, "node1", missing),
+			testLogicalVolume("deleting", deleting, "node1", deleting),
+			testLogicalVolume("other", otherNode, "node2", otherNode),
+			testLogicalVolume("pending", "88888888-8888-8888-8888-888888888888", "node1", ""),
+		},
+		lvbs: []topolvmv1.LogicalVolumeBackup{{
+			Status: topolvmv1.LogicalVolumeBackupStatus{SnapshotID: backup},
+		}},
+	}
+	deletedLV.DeletionTimestamp = &metav1.Time{}
+	after := &knownVolumes{
+		lvs: []topolvmv1.LogicalVolume{
+			testLogicalVolume("known", known, "node1", known),
+			testLogicalVolume("missing", missing, "node1", missing),
+			deletedLV,
+			testLogicalVolume("other", otherNode, "node2", otherNode),
+			// created while the logical volumes were listed
+			testLogicalVolume("creating", creating, "node1", ""),
+		},
+	}
+
+	orphaned, missingLVs := diffVolumes("node1", []string{"ssd"}, actual, before, after)
+	if len(orphaned) != 1 || orphaned[orphan] != "ssd" {
+		t.Errorf("unexpected orphaned logical volumes: %v", orphaned)
+	}
+	if len(missingLVs) != 1 || missingLVs[0].Name != "missing" {
+		t.Errorf("unexpected missing logical volumes: %v", missingLVs)
+	}
+
+	// LogicalVolumes of the device-classes not listed are not regarded as missing.
+	_, missingLVs = diffVolumes("node1", []string{"hdd"}, map[string]string{}, before, after)
+	if len(missingLVs) != 0 {
+		t.Errorf("unexpected missing logical volumes: %v", missingLVs)
+	}
+}
+
+func TestParseOrphanedLVMode(t *testing.T) {
+	for _, name := range []string{"disabled", "dry-run", "enforce"} {
+		if _, err := ParseOrphanedLVMode(name); err != nil {
+			t.Errorf("%s should be valid: %v", name, err)
+		}
+	}
+	if _, err := ParseOrphanedLVMode("delete"); err == nil {
+		t.Error("delete should be invalid")
+	}
+}
@@ -0,0 +1,73 @@
+package runners
+
+import (
+	"context"
+	"net"
+
+	"github.com/topolvm/topolvm"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var transferLogger = ctrl.Log.WithName("runners").WithName("transfer_server")
+
+type transferServerRunner struct {
+	srv              *grpc.Server
+	client           client.Client
+	nodeName         string
+	bindAddress      string
+	advertiseAddress string
+}
+
+var _ manager.LeaderElectionRunnable = transferServerRunner{}
+
+// NewTransferServerRunner creates controller-runtime's manager.Runnable for the volume transfer gRPC server.
+// The server will listen on TCP at bindAddress, and advertiseAddress is published
+// as an annotation of the Node so that other nodes can connect to the server.
+func NewTransferServerRunner(srv *grpc.Server, client client.Client, nodeName, bindAddress, advertiseAddress string) manager.Runnable {
+	return transferServerRunner{srv, client, nodeName, bindAddress, advertiseAddress}
+}
+
+// Start implements controller-runtime's manager.Runnable.
+func (r transferServerRunner) Start(ctx context.Context) error {
+	lis, err := net.Listen("tcp", r.bindAddress)
+	if err != nil {
+		return err
+	}
+
+	var node metav1.PartialObjectMetadata
+	node.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Node"))
+	if err := r.client.Get(ctx, types.NamespacedName{Name: r.nodeName}, &node); err != nil {
+		_ = lis.Close()
+		return err
+	}
+	if node.Annotations[topolvm.GetTransferAddressKey()] != r.advertiseAddress {
+		node2 := node.DeepCopy()
+		if node2.Annotations == nil {
+			node2.Annotations = map[string]string{}
+		}
+		node2.Annotations[topolvm.GetTransferAddressKey()] = r.advertiseAddress
+		if err := r.client.Patch(ctx, node2, client.MergeFrom(&node)); err != nil {
+			_ = lis.Close()
+			return err
+		}
+	}
+	transferLogger.Info("serving volume transfer", "bind_address", r.bindAddress, "advertise_address", r.advertiseAddress)
+
+	go func() {
+		<-ctx.Done()
+		r.srv.GracefulStop()
+	}()
+
+	return r.srv.Serve(lis)
+}
+
+// NeedLeaderElection implements controller-runtime's manager.LeaderElectionRunnable.
+func (r transferServerRunner) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,113 @@
+package runners
+
+import (
+	"context"
+	"time"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"k8s.io/apimachinery/pkg/api/equality"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var vhLogger = ctrl.Log.WithName("runners").WithName("volume_health_checker")
+
+type volumeHealthChecker struct {
+	client    client.Client
+	nodeName  string
+	vgService proto.VGServiceClient
+	interval  time.Duration
+}
+
+var _ manager.LeaderElectionRunnable = volumeHealthChecker{}
+
+// NewVolumeHealthChecker creates controller-runtime's manager.Runnable which checks the health of
+// the logical volumes on the node every interval, and reports it in the status of the LogicalVolumes
+// so that topolvm-controller can serve the volume conditions.
+func NewVolumeHealthChecker(vgServiceClient proto.VGServiceClient, client client.Client, nodeName string, interval time.Duration) manager.Runnable {
+	return volumeHealthChecker{client, nodeName, vgServiceClient, interval}
+}
+
+// Start implements controller-runtime's manager.Runnable.
+func (c volumeHealthChecker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.check(ctx); err != nil {
+			vhLogger.Error(err, "failed to check the health of logical volumes")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements controller-runtime's manager.LeaderElectionRunnable.
+func (c volumeHealthChecker) NeedLeaderElection() bool {
+	return false
+}
+
+func (c volumeHealthChecker) check(ctx context.Context) error {
+	lvList := new(topolvmv1.LogicalVolumeList)
+	if err := c.client.List(ctx, lvList); err != nil {
+		return err
+	}
+
+	attrs := make(map[string]map[string]string)
+	for i := range lvList.Items {
+		lv := &lvList.Items[i]
+		if lv.Spec.NodeName != c.nodeName || lv.Status.VolumeID == "" || lv.DeletionTimestamp != nil {
+			continue
+		}
+
+		attrsOfDeviceClass, ok := attrs[lv.Spec.DeviceClass]
+		if !ok {
+			resp, err := c.vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: lv.Spec.DeviceClass})
+			if err != nil {
+				return err
+			}
+			attrsOfDeviceClass = make(map[string]string, len(resp.Volumes))
+			for _, v := range resp.Volumes {
+				attrsOfDeviceClass[v.Name] = v.Attr
+			}
+			attrs[lv.Spec.DeviceClass] = attrsOfDeviceClass
+		}
+
+		health := volumeHealth(attrsOfDeviceClass, string(lv.UID))
+		if equality.Semantic.DeepEqual(lv.Status.Health, health) {
+			continue
+		}
+		lv2 := lv.DeepCopy()
+		lv2.Status.Health = health
+		if err := c.client.Status().Patch(ctx, lv2, client.MergeFrom(lv)); err != nil {
+			return err
+		}
+		if health.Abnormal {
+			vhLogger.Info("logical volume is not healthy", "name", lv.Name, "message", health.Message)
+		}
+	}
+	return nil
+}
+
+// volumeHealth returns the health of a logical volume derived from its attributes.
+func volumeHealth(attrs map[string]string, name string) *topolvmv1.VolumeHealth {
+	attr, ok := attrs[name]
+	if !ok {
+		return &topolvmv1.VolumeHealth{Abnormal: true, Message: "logical volume is not found"}
+	}
+	parsed, err := command.ParsedLVAttr(attr)
+	if err != nil {
+		return &topolvmv1.VolumeHealth{Abnormal: true, Message: err.Error()}
+	}
+	if err := parsed.VerifyHealth(); err != nil {
+		return &topolvmv1.VolumeHealth{Abnormal: true, Message: err.Error()}
+	}
+	return &topolvmv1.VolumeHealth{Abnormal: false, Message: "volume is healthy and operating normally"}
+}
@@ -0,0 +1,181 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/topolvm/topolvm"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ExplainResult represents the result of the explain endpoint.
+type ExplainResult struct {
+	// Pod is the namespaced name of the pod.
+	Pod string `json:"pod"`
+	// Requested is the capacity requested by the pod for each device-class.
+	Requested map[string]int64 `json:"requested"`
+	// Nodes is the decisions for the nodes, sorted by the names of the nodes.
+	Nodes []NodeExplanation `json:"nodes"`
+}
+
+// NodeExplanation represents the decision of the scheduler extender for a node.
+type NodeExplanation struct {
+	// Name is the name of the node.
+	Name string `json:"name"`
+	// Passed is true if the node passes the predicate verb.
+	Passed bool `json:"passed"`
+	// Reason is the reason why the node is filtered out.
+	Reason string `json:"reason,omitempty"`
+	// Score is the score of the node by the prioritize verb.
+	Score int `json:"score"`
+	// DeviceClasses is the details for the device-classes requested by the pod.
+	DeviceClasses []DeviceClassExplanation `json:"deviceClasses"`
+}
+
+// DeviceClassExplanation represents the capacity and the score of a device-class on a node.
+type DeviceClassExplanation struct {
+	// DeviceClass is the name of the device-class.
+	DeviceClass string `json:"deviceClass"`
+	// Requested is the capacity requested by the pod in bytes.
+	Requested int64 `json:"requested"`
+	// Capacity is the capacity annotated on the node in bytes.
+	Capacity uint64 `json:"capacity"`
+	// Reserved is the capacity reserved for the other pods in bytes.
+	Reserved int64 `json:"reserved"`
+	// Available is the capacity available for the pod in bytes.
+	Available uint64 `json:"available"`
+	// ThinPoolDataPercent is the data usage of the thin pool if the device-class is thin.
+	ThinPoolDataPercent *float64 `json:"thinPoolDataPercent,omitempty"`
+	// ThinPoolMetadataPercent is the metadata usage of the thin pool if the device-class is thin.
+	ThinPoolMetadataPercent *float64 `json:"thinPoolMetadataPercent,omitempty"`
+	// Score is the score of the device-class.
+	Score int `json:"score"`
+}
+
+// explainNodes returns the decisions for the nodes in the same way as the predicate and prioritize verbs.
+func explainNodes(pod *corev1.Pod, nodes []corev1.Node, scoring ScoringOptions, thinPool ThinPoolOptions, reservedOf func(node string) map[string]int64) ExplainResult {
+	requested := extractRequestedSize(pod)
+	result := ExplainResult{
+		Pod:       types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}.String(),
+		Requested: requested,
+		Nodes:     make([]NodeExplanation, 0, len(nodes)),
+	}
+
+	dcs := make([]string, 0, len(requested))
+	for dc := range requested {
+		dcs = append(dcs, dc)
+	}
+	sort.Strings(dcs)
+
+	// the binpack strategy is scaled among the nodes passing the filter, as the prioritize verb only receives them.
+	passed := make([]corev1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		var reserved map[string]int64
+		if reservedOf != nil {
+			reserved = reservedOf(node.Name)
+		}
+		if FilterNode(node, requested, thinPool, reserved) == "" {
+			passed = append(passed, node)
+		}
+	}
+	maxRemaining := MaxRemaining(passed, requested, reservedOf)
+
+	for _, node := range nodes {
+		var reserved map[string]int64
+		if reservedOf != nil {
+			reserved = reservedOf(node.Name)
+		}
+		reason := FilterNode(node, requested, thinPool, reserved)
+		e := NodeExplanation{
+			Name:          node.Name,
+			Passed:        reason == "",
+			Reason:        reason,
+			Score:         ScoreNode(node, requested, scoring, thinPool, reserved, maxRemaining),
+			DeviceClasses: make([]DeviceClassExplanation, 0, len(dcs)),
+		}
+		for _, dc := range dcs {
+			d := DeviceClassExplanation{
+				DeviceClass: dc,
+				Requested:   requested[dc],
+				Reserved:    reserved[dc],
+			}
+			if val, ok := node.Annotations[topolvm.GetCapacityKeyPrefix()+dc]; ok {
+				d.Capacity, _ = strconv.ParseUint(val, 10, 64)
+				d.Available = subtractReserved(d.Capacity, d.Reserved)
+			}
+			if data, metadata, ok := thinPoolUsage(node, dc); ok {
+				d.ThinPoolDataPercent = &data
+				d.ThinPoolMetadataPercent = &metadata
+			}
+			d.Score, _ = scoreDeviceClass(node, dc, requested[dc], scoring, thinPool, d.Reserved, maxRemaining[dc])
+			e.DeviceClasses = append(e.DeviceClasses, d)
+		}
+		result.Nodes = append(result.Nodes, e)
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool {
+		return result.Nodes[i].Name < result.Nodes[j].Name
+	})
+	return result
+}
+
+// explain explains the decisions of the scheduler extender for a pod.
+//
+// The pod and the nodes are given as ExtenderArgs by POST, or the pod is given by
+// "namespace" and "name" query parameters by GET. In the latter case, the pod and
+// the nodes are read from the API server.
+func (s scheduler) explain(w http.ResponseWriter, r *http.Request) {
+	var input ExtenderArgs
+	switch r.Method {
+	case http.MethodPost:
+		reader := http.MaxBytesReader(w, r.Body, 10<<20)
+		if err := json.NewDecoder(reader).Decode(&input); err != nil || input.Pod == nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+	case http.MethodGet:
+		if s.reader == nil {
+			http.Error(w, "pods cannot be read from the API server", http.StatusNotImplemented)
+			return
+		}
+		query := r.URL.Query()
+		key := types.NamespacedName{Namespace: query.Get("namespace"), Name: query.Get("name")}
+		if key.Namespace == "" || key.Name == "" {
+			http.Error(w, "namespace and name are required", http.StatusBadRequest)
+			return
+		}
+		pod := &corev1.Pod{}
+		if err := s.reader.Get(r.Context(), key, pod); err != nil {
+			if apierrors.IsNotFound(err) {
+				http.Error(w, "pod not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		input.Pod = pod
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if input.Nodes == nil {
+		if s.reader == nil {
+			http.Error(w, "nodes are required", http.StatusBadRequest)
+			return
+		}
+		nodes := &corev1.NodeList{}
+		if err := s.reader.List(r.Context(), nodes); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		input.Nodes = nodes
+	}
+
+	result := explainNodes(input.Pod, input.Nodes.Items, s.scoring, s.thinPool, s.reservedOf(input.Pod))
+	w.Header().Set("content-type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/topolvm/topolvm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExplainNodes(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "ns",
+			Annotations: map[string]string{
+				topolvm.GetCapacityKeyPrefix() + deviceClass1: "3221225472", // 3Gi
+			},
+		},
+	}
+	nodes := []corev1.Node{
+		testNode("10.1.1.2", 2, 10, 10),
+		testNode("10.1.1.1", 5, 10, 10),
+		testThinNode("10.1.1.3", 100, "90", "50"),
+	}
+	reservedOf := func(node string) map[string]int64 {
+		if node == "10.1.1.1" {
+			return map[string]int64{deviceClass1: 1 << 30}
+		}
+		return nil
+	}
+
+	result := explainNodes(pod, nodes, ScoringOptions{DefaultDivisor: 1}, ThinPoolOptions{DataFilterPercent: 90}, reservedOf)
+	if result.Pod != "ns/pod1" {
+		t.Errorf("unexpected pod: %s", result.Pod)
+	}
+	if len(result.Nodes) != 3 {
+		t.Fatalf("unexpected nodes: %#v", result.Nodes)
+	}
+
+	n := result.Nodes[0]
+	if n.Name != "10.1.1.1" || !n.Passed || n.Score != 2 {
+		t.Errorf("unexpected explanation: %#v", n)
+	}
+	if len(n.DeviceClasses) != 1 {
+		t.Fatalf("unexpected device-classes: %#v", n.DeviceClasses)
+	}
+	d := n.DeviceClasses[0]
+	if d.DeviceClass != deviceClass1 || d.Requested != 3<<30 || d.Capacity != 5<<30 || d.Reserved != 1<<30 || d.Available != 4<<30 || d.Score != 2 {
+		t.Errorf("unexpected device-class explanation: %#v", d)
+	}
+	if d.ThinPoolDataPercent != nil || d.ThinPoolMetadataPercent != nil {
+		t.Errorf("thick device-class should not have thin pool usage: %#v", d)
+	}
+
+	n = result.Nodes[1]
+	if n.Name != "10.1.1.2" || n.Passed || n.Reason != "out of VG free space" {
+		t.Errorf("unexpected explanation: %#v", n)
+	}
+
+	n = result.Nodes[2]
+	if n.Name != "10.1.1.3" || n.Passed || n.Reason != "thin pool data usage is too high" {
+		t.Errorf("unexpected explanation: %#v", n)
+	}
+	d = n.DeviceClasses[0]
+	if d.ThinPoolDataPercent == nil || *d.ThinPoolDataPercent != 90 || d.ThinPoolMetadataPercent == nil || *d.ThinPoolMetadataPercent != 50 {
+		t.Errorf("unexpected thin pool usage: %#v", d)
+	}
+}
+
+func TestExplainByName(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "ns",
+			Annotations: map[string]string{
+				topolvm.GetCapacityKeyPrefix() + deviceClass1: "3221225472", // 3Gi
+			},
+		},
+	}
+	node1 := testNode("10.1.1.1", 2, 10, 10)
+	node2 := testNode("10.1.1.2", 5, 10, 10)
+	reader := fake.NewClientBuilder().WithObjects(pod, &node1, &node2).Build()
+
+	handler, err := NewExplainHandler(ScoringOptions{DefaultDivisor: 1}, ThinPoolOptions{}, nil, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/explain?namespace=ns&name=pod1", nil))
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("resp.StatusCode != http.StatusOK:", resp.StatusCode)
+	}
+	result := ExplainResult{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) != 2 || result.Nodes[0].Passed || !result.Nodes[1].Passed {
+		t.Errorf("unexpected result: %#v", result)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/explain?namespace=ns&name=missing", nil))
+	if code := w.Result().StatusCode; code != http.StatusNotFound {
+		t.Error("resp.StatusCode != http.StatusNotFound:", code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/explain", nil))
+	if code := w.Result().StatusCode; code != http.StatusBadRequest {
+		t.Error("resp.StatusCode != http.StatusBadRequest:", code)
+	}
+
+	// the explain handler does not serve the verbs of the extender
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/predicate", nil))
+	if code := w.Result().StatusCode; code != http.StatusNotFound {
+		t.Error("resp.StatusCode != http.StatusNotFound:", code)
+	}
+}
+
+func TestExplainNotServedByExtender(t *testing.T) {
+	handler, err := NewHandler(ScoringOptions{DefaultDivisor: 1}, ThinPoolOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/explain?namespace=ns&name=pod1", nil))
+	if code := w.Result().StatusCode; code != http.StatusNotFound {
+		t.Error("resp.StatusCode != http.StatusNotFound:", code)
+	}
+}
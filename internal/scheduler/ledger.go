@@ -0,0 +1,269 @@
+package scheduler
+
+import (
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/topolvm/topolvm"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Ledger keeps the capacity reserved for the pods admitted by the extender until their volumes are provisioned.
+//
+// The node annotations of the capacity are not updated until the logical volumes are created,
+// so the extender would admit too many pods to a node when they are created at once.
+// A pod admitted by the predicate verb reserves its requested capacity on the node scored best by
+// the prioritize verb, so that the next pods see the reservation right away. The reservation is moved to
+// the node selected for its PersistentVolumeClaims if kube-scheduler selects another node.
+//
+// The capacity annotations are refreshed a while after the LogicalVolumes are created, so the reservation is
+// released only when the LogicalVolumes of all the claims appear and the capacity of the node has dropped
+// by the reserved amount from the capacity when the node was selected, or when the TTL expires.
+type Ledger struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	now  func() time.Time
+	pods map[types.UID]*reservation
+}
+
+type reservation struct {
+	claims map[types.NamespacedName]struct{}
+	// pending is the claims whose LogicalVolumes have not appeared yet.
+	pending   map[types.NamespacedName]struct{}
+	requested map[string]int64
+	// node is empty until the pod is prioritized or a node is selected for the claims.
+	node string
+	// baseline is the capacity of the node for each device-class when the node was selected for the claims.
+	baseline map[string]int64
+	// expires is zero while the reservation is held by the scheduler plugin.
+	expires time.Time
+}
+
+// NewLedger returns a new Ledger whose reservations expire after ttl.
+func NewLedger(ttl time.Duration) *Ledger {
+	return &Ledger{
+		ttl:  ttl,
+		now:  time.Now,
+		pods: make(map[types.UID]*reservation),
+	}
+}
+
+// Admit records the capacity requested by the pod for each device-class.
+// The capacity is not reserved until the pod is prioritized or a node is selected for its claims.
+func (l *Ledger) Admit(pod *corev1.Pod, requested map[string]int64) {
+	claims := claimsOfPod(pod)
+	if len(requested) == 0 || len(claims) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r, ok := l.pods[pod.UID]
+	if !ok {
+		r = &reservation{claims: claims, pending: maps.Clone(claims)}
+		l.pods[pod.UID] = r
+	}
+	r.requested = requested
+	r.expires = l.now().Add(l.ttl)
+}
+
+// Reserve reserves the capacity requested by the pod on the node.
+// It is used by the scheduler plugin which knows the node at the Reserve extension point.
+// The reservation does not expire until it is refreshed or unreserved.
+func (l *Ledger) Reserve(pod *corev1.Pod, requested map[string]int64, node string) {
+	if len(requested) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	claims := claimsOfPod(pod)
+	l.pods[pod.UID] = &reservation{
+		claims:    claims,
+		pending:   maps.Clone(claims),
+		requested: requested,
+		node:      node,
+	}
+}
+
+// Unreserve releases the reservation of the pod.
+func (l *Ledger) Unreserve(uid types.UID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.pods, uid)
+}
+
+// Refresh makes the reservation of the pod expire after the TTL from now.
+func (l *Ledger) Refresh(uid types.UID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r, ok := l.pods[uid]; ok {
+		r.expires = l.now().Add(l.ttl)
+	}
+}
+
+// Tracks returns true if the claim belongs to a pod which has a reservation.
+func (l *Ledger) Tracks(claim types.NamespacedName) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, r := range l.pods {
+		if _, ok := r.claims[claim]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Charge reserves the capacity requested by the admitted pod on the node scored best by the extender.
+// The reservation held by the scheduler plugin is not changed.
+func (l *Ledger) Charge(uid types.UID, node string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r, ok := l.pods[uid]
+	if !ok || r.expires.IsZero() {
+		return
+	}
+	if r.node != node {
+		r.baseline = nil
+	}
+	r.node = node
+	r.expires = l.now().Add(l.ttl)
+}
+
+// SelectNode reserves the capacity requested by the pod of the claim on the node selected for the claim.
+// capacities is the current capacity of the node for each device-class, which is recorded as the baseline
+// to settle the reservation.
+func (l *Ledger) SelectNode(claim types.NamespacedName, node string, capacities map[string]int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, r := range l.pods {
+		if _, ok := r.claims[claim]; !ok || (r.node == node && r.baseline != nil) {
+			continue
+		}
+		if r.node != node {
+			r.node = node
+			r.expires = l.now().Add(l.ttl)
+		}
+		r.baseline = capacities
+	}
+}
+
+// Provision marks the claim as provisioned. The reservation of a pod is kept until it is settled.
+func (l *Ledger) Provision(claim types.NamespacedName) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, r := range l.pods {
+		if _, ok := r.pending[claim]; !ok {
+			continue
+		}
+		delete(r.pending, claim)
+		// the reservation of the scheduler plugin expires after the TTL as well once provisioned.
+		if len(r.pending) == 0 && r.expires.IsZero() {
+			r.expires = l.now().Add(l.ttl)
+		}
+	}
+}
+
+// Settle releases the reservations on the node whose claims are all provisioned, if the capacity of the node
+// has dropped by the reserved amount from the baseline for every device-class. capacities is the current
+// capacity of the node. A device-class whose capacity is unknown is regarded as settled.
+func (l *Ledger) Settle(node string, capacities map[string]int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for uid, r := range l.pods {
+		if r.node != node || len(r.pending) != 0 {
+			continue
+		}
+		settled := true
+		for dc, requested := range r.requested {
+			baseline, ok1 := r.baseline[dc]
+			capacity, ok2 := capacities[dc]
+			if ok1 && ok2 && capacity > baseline-requested {
+				settled = false
+				break
+			}
+		}
+		if settled {
+			delete(l.pods, uid)
+		}
+	}
+}
+
+// Release releases the claim, e.g. when it is deleted. The reservation of a pod is released when all of its claims are released.
+func (l *Ledger) Release(claim types.NamespacedName) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for uid, r := range l.pods {
+		delete(r.claims, claim)
+		delete(r.pending, claim)
+		if len(r.claims) == 0 {
+			delete(l.pods, uid)
+		}
+	}
+}
+
+// Reserved returns the capacity reserved on the node for each device-class, excluding the reservation of the pod.
+func (l *Ledger) Reserved(node string, exclude types.UID) map[string]int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	reserved := make(map[string]int64)
+	for uid, r := range l.pods {
+		if !r.expires.IsZero() && now.After(r.expires) {
+			delete(l.pods, uid)
+			continue
+		}
+		if uid == exclude || r.node != node {
+			continue
+		}
+		for dc, capacity := range r.requested {
+			reserved[dc] += capacity
+		}
+	}
+	return reserved
+}
+
+// claimsOfPod returns the PersistentVolumeClaims used by the pod, including those of generic ephemeral volumes.
+func claimsOfPod(pod *corev1.Pod) map[types.NamespacedName]struct{} {
+	claims := make(map[types.NamespacedName]struct{})
+	for _, v := range pod.Spec.Volumes {
+		switch {
+		case v.PersistentVolumeClaim != nil:
+			claims[types.NamespacedName{Namespace: pod.Namespace, Name: v.PersistentVolumeClaim.ClaimName}] = struct{}{}
+		case v.Ephemeral != nil:
+			claims[types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name + "-" + v.Name}] = struct{}{}
+		}
+	}
+	return claims
+}
+
+// nodeCapacities returns the capacity of the node for each device-class from its annotations.
+func nodeCapacities(node *corev1.Node) map[string]int64 {
+	capacities := make(map[string]int64)
+	for k, v := range node.Annotations {
+		if !strings.HasPrefix(k, topolvm.GetCapacityKeyPrefix()) {
+			continue
+		}
+		capacity, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		capacities[k[len(topolvm.GetCapacityKeyPrefix()):]] = capacity
+	}
+	return capacities
+}
@@ -0,0 +1,225 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func testPodWithClaims(uid, name string, claims ...string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:       types.UID(uid),
+			Name:      name,
+			Namespace: "ns",
+		},
+	}
+	for _, claim := range claims {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: "vol-" + claim,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+	}
+	return pod
+}
+
+func TestLedger(t *testing.T) {
+	now := time.Now()
+	ledger := NewLedger(time.Minute)
+	ledger.now = func() time.Time { return now }
+
+	pod1 := testPodWithClaims("uid1", "pod1", "pvc1", "pvc2")
+	pod2 := testPodWithClaims("uid2", "pod2", "pvc3")
+	pvc1 := types.NamespacedName{Namespace: "ns", Name: "pvc1"}
+	pvc2 := types.NamespacedName{Namespace: "ns", Name: "pvc2"}
+	pvc3 := types.NamespacedName{Namespace: "ns", Name: "pvc3"}
+
+	ledger.Admit(pod1, map[string]int64{deviceClass1: 1 << 30, deviceClass2: 2 << 30})
+	ledger.Admit(pod2, map[string]int64{deviceClass1: 3 << 30})
+	// a pod without claims does not reserve the capacity
+	ledger.Admit(testPodWithClaims("uid3", "pod3"), map[string]int64{deviceClass1: 5 << 30})
+	if !ledger.Tracks(pvc1) || !ledger.Tracks(pvc3) {
+		t.Fatal("claims of the admitted pods should be tracked")
+	}
+
+	// the capacity is not reserved until a node is selected
+	if reserved := ledger.Reserved("node1", ""); len(reserved) != 0 {
+		t.Errorf("unexpected reservation: %v", reserved)
+	}
+
+	ledger.SelectNode(pvc1, "node1", nil)
+	ledger.SelectNode(pvc3, "node1", nil)
+	expected := map[string]int64{deviceClass1: 4 << 30, deviceClass2: 2 << 30}
+	if reserved := ledger.Reserved("node1", ""); !reflect.DeepEqual(reserved, expected) {
+		t.Errorf("expected %v, but was %v", expected, reserved)
+	}
+	expected = map[string]int64{deviceClass1: 3 << 30}
+	if reserved := ledger.Reserved("node1", "uid1"); !reflect.DeepEqual(reserved, expected) {
+		t.Errorf("reservation of the pod should be excluded: expected %v, but was %v", expected, reserved)
+	}
+	if reserved := ledger.Reserved("node2", ""); len(reserved) != 0 {
+		t.Errorf("unexpected reservation: %v", reserved)
+	}
+
+	// the reservation is kept until all the claims of the pod are released
+	ledger.Release(pvc1)
+	if reserved := ledger.Reserved("node1", "uid2"); reserved[deviceClass1] != 1<<30 {
+		t.Errorf("reservation should be kept: %v", reserved)
+	}
+	ledger.Release(pvc2)
+	if reserved := ledger.Reserved("node1", "uid2"); len(reserved) != 0 {
+		t.Errorf("reservation should be released: %v", reserved)
+	}
+	if ledger.Tracks(pvc1) || ledger.Tracks(pvc2) {
+		t.Error("released claims should not be tracked")
+	}
+
+	// the reservation expires after the TTL
+	now = now.Add(2 * time.Minute)
+	if reserved := ledger.Reserved("node1", ""); len(reserved) != 0 {
+		t.Errorf("reservation should expire: %v", reserved)
+	}
+	if ledger.Tracks(pvc3) {
+		t.Error("claims of the expired reservation should not be tracked")
+	}
+}
+
+func TestLedger_Reserve(t *testing.T) {
+	ledger := NewLedger(time.Minute)
+	pod := testPodWithClaims("uid1", "pod1", "pvc1")
+	ledger.Reserve(pod, map[string]int64{deviceClass1: 1 << 30}, "node1")
+	if !ledger.Tracks(types.NamespacedName{Namespace: "ns", Name: "pvc1"}) {
+		t.Error("claims of the reserved pod should be tracked")
+	}
+
+	// the reservation does not expire until it is refreshed
+	now := time.Now().Add(time.Hour)
+	ledger.now = func() time.Time { return now }
+	if reserved := ledger.Reserved("node1", ""); reserved[deviceClass1] != 1<<30 {
+		t.Errorf("unexpected reservation: %v", reserved)
+	}
+	ledger.Unreserve(pod.UID)
+	if reserved := ledger.Reserved("node1", ""); len(reserved) != 0 {
+		t.Errorf("reservation should be released: %v", reserved)
+	}
+}
+
+func TestLedger_Charge(t *testing.T) {
+	ledger := NewLedger(time.Minute)
+	pvc1 := types.NamespacedName{Namespace: "ns", Name: "pvc1"}
+	pod := testPodWithClaims("uid1", "pod1", "pvc1")
+	ledger.Admit(pod, map[string]int64{deviceClass1: 1 << 30})
+
+	// the capacity is reserved on the best node as soon as the pod is prioritized
+	node, ok := bestNode([]HostPriority{{Host: "node1", Score: 3}, {Host: "node2", Score: 7}, {Host: "node3", Score: 7}})
+	if !ok || node != "node2" {
+		t.Fatalf("unexpected best node: %s", node)
+	}
+	ledger.Charge(pod.UID, node)
+	if reserved := ledger.Reserved("node2", ""); reserved[deviceClass1] != 1<<30 {
+		t.Errorf("capacity should be reserved on the best node: %v", reserved)
+	}
+
+	// the reservation moves to the node selected by kube-scheduler
+	ledger.SelectNode(pvc1, "node1", nil)
+	if reserved := ledger.Reserved("node2", ""); len(reserved) != 0 {
+		t.Errorf("reservation should be moved: %v", reserved)
+	}
+	if reserved := ledger.Reserved("node1", ""); reserved[deviceClass1] != 1<<30 {
+		t.Errorf("capacity should be reserved on the selected node: %v", reserved)
+	}
+
+	// the reservation of the scheduler plugin is not changed
+	plugin := testPodWithClaims("uid2", "pod2", "pvc2")
+	ledger.Reserve(plugin, map[string]int64{deviceClass1: 2 << 30}, "node3")
+	ledger.Charge(plugin.UID, "node2")
+	if reserved := ledger.Reserved("node3", pod.UID); reserved[deviceClass1] != 2<<30 {
+		t.Errorf("reservation of the plugin should be kept: %v", reserved)
+	}
+
+	if _, ok := bestNode(nil); ok {
+		t.Error("no node should be returned")
+	}
+}
+
+func TestLedger_Settle(t *testing.T) {
+	now := time.Now()
+	ledger := NewLedger(time.Minute)
+	ledger.now = func() time.Time { return now }
+	pvc1 := types.NamespacedName{Namespace: "ns", Name: "pvc1"}
+	pvc2 := types.NamespacedName{Namespace: "ns", Name: "pvc2"}
+	pod := testPodWithClaims("uid1", "pod1", "pvc1", "pvc2")
+	ledger.Admit(pod, map[string]int64{deviceClass1: 3 << 30})
+	ledger.SelectNode(pvc1, "node1", map[string]int64{deviceClass1: 10 << 30})
+
+	// the reservation is not settled until all the claims are provisioned
+	ledger.Provision(pvc1)
+	ledger.Settle("node1", map[string]int64{deviceClass1: 7 << 30})
+	if !ledger.Tracks(pvc1) {
+		t.Fatal("reservation should be kept until all the claims are provisioned")
+	}
+
+	// the reservation is kept while the capacity annotation is not refreshed
+	ledger.Provision(pvc2)
+	ledger.Settle("node1", map[string]int64{deviceClass1: 10 << 30})
+	if reserved := ledger.Reserved("node1", ""); reserved[deviceClass1] != 3<<30 {
+		t.Errorf("reservation should be kept until the capacity is refreshed: %v", reserved)
+	}
+	ledger.Settle("node1", map[string]int64{deviceClass1: 8 << 30})
+	if reserved := ledger.Reserved("node1", ""); reserved[deviceClass1] != 3<<30 {
+		t.Errorf("reservation should be kept until the capacity drops by the reserved amount: %v", reserved)
+	}
+	ledger.Settle("node2", map[string]int64{deviceClass1: 0})
+	if reserved := ledger.Reserved("node1", ""); reserved[deviceClass1] != 3<<30 {
+		t.Errorf("reservation should not be settled by another node: %v", reserved)
+	}
+
+	ledger.Settle("node1", map[string]int64{deviceClass1: 7 << 30})
+	if reserved := ledger.Reserved("node1", ""); len(reserved) != 0 {
+		t.Errorf("reservation should be released: %v", reserved)
+	}
+	if ledger.Tracks(pvc1) || ledger.Tracks(pvc2) {
+		t.Error("claims of the settled reservation should not be tracked")
+	}
+
+	// the provisioned reservation of the scheduler plugin expires after the TTL
+	plugin := testPodWithClaims("uid2", "pod2", "pvc3")
+	ledger.Reserve(plugin, map[string]int64{deviceClass1: 1 << 30}, "node1")
+	ledger.Provision(types.NamespacedName{Namespace: "ns", Name: "pvc3"})
+	now = now.Add(2 * time.Minute)
+	if reserved := ledger.Reserved("node1", ""); len(reserved) != 0 {
+		t.Errorf("reservation should expire: %v", reserved)
+	}
+}
+
+func TestClaimsOfPod(t *testing.T) {
+	pod := testPodWithClaims("uid1", "pod1", "pvc1")
+	pod.Spec.Volumes = append(pod.Spec.Volumes,
+		corev1.Volume{
+			Name: "generic",
+			VolumeSource: corev1.VolumeSource{
+				Ephemeral: &corev1.EphemeralVolumeSource{},
+			},
+		},
+		corev1.Volume{
+			Name: "empty",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	)
+
+	expected := map[types.NamespacedName]struct{}{
+		{Namespace: "ns", Name: "pvc1"}:         {},
+		{Namespace: "ns", Name: "pod1-generic"}: {},
+	}
+	if claims := claimsOfPod(pod); !reflect.DeepEqual(claims, expected) {
+		t.Errorf("expected %v, but was %v", expected, claims)
+	}
+}
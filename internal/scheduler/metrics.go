@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "topolvm"
+	metricsSubsystem = "scheduler"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "requests_total",
+		Help:      "The number of requests to the scheduler extender",
+	}, []string{"verb"})
+
+	requestDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "request_duration_seconds",
+		Help:      "The latency of requests to the scheduler extender in seconds",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 12),
+	}, []string{"verb"})
+
+	nodeRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "node_rejections_total",
+		Help:      "The number of nodes filtered out by the scheduler extender",
+	}, []string{"reason"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(requestsTotal, requestDurationSeconds, nodeRejectionsTotal)
+}
+
+// observeRequest records a request of the verb which started at start.
+func observeRequest(verb string, start time.Time) {
+	requestsTotal.WithLabelValues(verb).Inc()
+	requestDurationSeconds.WithLabelValues(verb).Observe(time.Since(start).Seconds())
+}
+
+// observeRejections records the reasons of the nodes filtered out.
+// The details after a colon, such as the value of a bad annotation, are dropped to bound the label values.
+func observeRejections(failedNodes FailedNodesMap) {
+	for _, reason := range failedNodes {
+		label, _, _ := strings.Cut(reason, ":")
+		nodeRejectionsTotal.WithLabelValues(label).Inc()
+	}
+}
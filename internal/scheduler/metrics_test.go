@@ -0,0 +1,19 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveRejections(t *testing.T) {
+	before := testutil.ToFloat64(nodeRejectionsTotal.WithLabelValues("bad capacity annotation"))
+	observeRejections(FailedNodesMap{
+		"node1": "bad capacity annotation: foo",
+		"node2": "bad capacity annotation: bar",
+	})
+	after := testutil.ToFloat64(nodeRejectionsTotal.WithLabelValues("bad capacity annotation"))
+	if after-before != 2 {
+		t.Errorf("the details of the reasons should be dropped from the label: %f", after-before)
+	}
+}
@@ -11,7 +11,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
-func filterNodes(nodes corev1.NodeList, requested map[string]int64) ExtenderFilterResult {
+// filterNodes filters out the nodes which do not have the requested capacity.
+// reservedOf returns the capacity reserved on a node for the other pods, and it may be nil.
+func filterNodes(nodes corev1.NodeList, requested map[string]int64, thinPool ThinPoolOptions, reservedOf func(node string) map[string]int64) ExtenderFilterResult {
 	if len(requested) == 0 {
 		return ExtenderFilterResult{
 			Nodes: &nodes,
@@ -25,7 +27,11 @@ func filterNodes(nodes corev1.NodeList, requested map[string]int64) ExtenderFilt
 		reason := &failedNodes[i]
 		node := nodes.Items[i]
 		go func() {
-			*reason = filterNode(node, requested)
+			var reserved map[string]int64
+			if reservedOf != nil {
+				reserved = reservedOf(node.Name)
+			}
+			*reason = FilterNode(node, requested, thinPool, reserved)
 			wg.Done()
 		}()
 	}
@@ -44,7 +50,8 @@ func filterNodes(nodes corev1.NodeList, requested map[string]int64) ExtenderFilt
 	return result
 }
 
-func filterNode(node corev1.Node, requested map[string]int64) string {
+// FilterNode returns the reason why the node cannot provide the requested capacity, or an empty string if it can.
+func FilterNode(node corev1.Node, requested map[string]int64, thinPool ThinPoolOptions, reserved map[string]int64) string {
 	for dc, required := range requested {
 		val, ok := node.Annotations[topolvm.GetCapacityKeyPrefix()+dc]
 		if !ok {
@@ -54,13 +61,28 @@ func filterNode(node corev1.Node, requested map[string]int64) string {
 		if err != nil {
 			return "bad capacity annotation: " + val
 		}
+		capacity = subtractReserved(capacity, reserved[dc])
 		if capacity < uint64(required) {
 			return "out of VG free space"
 		}
+		if reason := thinPool.filterReason(node, dc); reason != "" {
+			return reason
+		}
 	}
 	return ""
 }
 
+// subtractReserved returns the capacity which is not reserved.
+func subtractReserved(capacity uint64, reserved int64) uint64 {
+	if reserved <= 0 {
+		return capacity
+	}
+	if capacity < uint64(reserved) {
+		return 0
+	}
+	return capacity - uint64(reserved)
+}
+
 func extractRequestedSize(pod *corev1.Pod) map[string]int64 {
 	result := make(map[string]int64)
 	for k, v := range pod.Annotations {
@@ -89,7 +111,11 @@ func (s scheduler) predicate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	requested := extractRequestedSize(input.Pod)
-	result := filterNodes(*input.Nodes, requested)
+	result := filterNodes(*input.Nodes, requested, s.thinPool, s.reservedOf(input.Pod))
+	observeRejections(result.FailedNodes)
+	if s.ledger != nil && len(result.Nodes.Items) > 0 {
+		s.ledger.Admit(input.Pod, requested)
+	}
 	w.Header().Set("content-type", "application/json")
 	_ = json.NewEncoder(w).Encode(result)
 }
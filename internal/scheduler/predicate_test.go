@@ -119,7 +119,7 @@ func TestFilterNodes(t *testing.T) {
 	}
 
 	for _, tt := range testCases {
-		result := filterNodes(tt.nodes, tt.requested)
+		result := filterNodes(tt.nodes, tt.requested, ThinPoolOptions{}, nil)
 		if len(result.Nodes.Items) != len(tt.expect.Nodes.Items) {
 			t.Fatalf("not match length of filtered NodeList: expect=%d actual=%d", len(tt.expect.Nodes.Items), len(result.Nodes.Items))
 		}
@@ -136,6 +136,35 @@ func TestFilterNodes(t *testing.T) {
 	}
 }
 
+func TestFilterNodesWithReservation(t *testing.T) {
+	nodes := corev1.NodeList{
+		Items: []corev1.Node{
+			testNode("10.1.1.1", 5, 10, 10),
+			testNode("10.1.1.2", 5, 10, 10),
+		},
+	}
+	reservedOf := func(node string) map[string]int64 {
+		if node == "10.1.1.1" {
+			return map[string]int64{deviceClass1: 4 << 30}
+		}
+		return map[string]int64{deviceClass1: 10 << 30, deviceClass2: 1 << 30}
+	}
+
+	result := filterNodes(nodes, map[string]int64{deviceClass1: 1 << 30}, ThinPoolOptions{}, reservedOf)
+	if len(result.Nodes.Items) != 1 || result.Nodes.Items[0].Name != "10.1.1.1" {
+		t.Errorf("unexpected nodes: %v", result.Nodes.Items)
+	}
+	expected := FailedNodesMap{"10.1.1.2": "out of VG free space"}
+	if !reflect.DeepEqual(result.FailedNodes, expected) {
+		t.Errorf("not match FailedNodes: expect=%v actual=%v", expected, result.FailedNodes)
+	}
+
+	result = filterNodes(nodes, map[string]int64{deviceClass1: 2 << 30}, ThinPoolOptions{}, reservedOf)
+	if len(result.Nodes.Items) != 0 {
+		t.Errorf("unexpected nodes: %v", result.Nodes.Items)
+	}
+}
+
 func TestExtractRequestedSize(t *testing.T) {
 	testCases := []struct {
 		input    *corev1.Pod
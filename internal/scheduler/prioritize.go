@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 	"net/http"
 	"strconv"
@@ -12,6 +13,104 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
+// ScoringStrategy is the strategy to score nodes by the free capacity of a device-class.
+type ScoringStrategy string
+
+const (
+	// ScoringSpread prefers nodes with more free capacity in log scale.
+	ScoringSpread ScoringStrategy = "spread"
+	// ScoringBinpack prefers nodes with less free capacity remaining after the volumes are created,
+	// relative to the most remaining free capacity among the candidate nodes.
+	ScoringBinpack ScoringStrategy = "binpack"
+	// ScoringLinear prefers nodes with more free capacity in linear scale at byte precision.
+	ScoringLinear ScoringStrategy = "linear"
+)
+
+// ScoringOptions represents how to score nodes.
+type ScoringOptions struct {
+	// DefaultDivisor is the default divisor value.
+	DefaultDivisor float64
+	// Divisors is a mapping between device-class names and their divisors.
+	Divisors map[string]float64
+	// DefaultStrategy is the default scoring strategy. If empty, ScoringSpread is used.
+	DefaultStrategy ScoringStrategy
+	// Strategies is a mapping between device-class names and their scoring strategies.
+	Strategies map[string]ScoringStrategy
+	// Weights is a mapping between device-class names and their weights.
+	// If empty, the score of a node is the minimum score of the device-classes requested by a pod.
+	// Otherwise, it is the weighted average of them, and the weight of a device-class not in Weights is 1.
+	Weights map[string]float64
+}
+
+// Validate returns an error if the options are invalid.
+func (o ScoringOptions) Validate() error {
+	for _, divisor := range o.Divisors {
+		if divisor <= 0 {
+			return fmt.Errorf("invalid divisor: %f", divisor)
+		}
+	}
+	if err := validateStrategy(o.DefaultStrategy); err != nil {
+		return err
+	}
+	for _, strategy := range o.Strategies {
+		if err := validateStrategy(strategy); err != nil {
+			return err
+		}
+	}
+	for _, weight := range o.Weights {
+		if weight < 0 {
+			return fmt.Errorf("invalid weight: %f", weight)
+		}
+	}
+	return nil
+}
+
+func validateStrategy(strategy ScoringStrategy) error {
+	switch strategy {
+	case "", ScoringSpread, ScoringBinpack, ScoringLinear:
+		return nil
+	}
+	return fmt.Errorf("invalid scoring strategy: %s", strategy)
+}
+
+func (o ScoringOptions) divisor(dc string) float64 {
+	if v, ok := o.Divisors[dc]; ok {
+		return v
+	}
+	return o.DefaultDivisor
+}
+
+func (o ScoringOptions) strategy(dc string) ScoringStrategy {
+	if v, ok := o.Strategies[dc]; ok && v != "" {
+		return v
+	}
+	if o.DefaultStrategy != "" {
+		return o.DefaultStrategy
+	}
+	return ScoringSpread
+}
+
+func (o ScoringOptions) weight(dc string) float64 {
+	if v, ok := o.Weights[dc]; ok {
+		return v
+	}
+	return 1
+}
+
+// scoreCapacity returns the score of the free capacity of a device-class from 0 to 10.
+// maxRemaining is the most free capacity remaining after the requested capacity is allocated among the candidate nodes.
+func (o ScoringOptions) scoreCapacity(dc string, capacity uint64, requested int64, maxRemaining uint64) int {
+	divisor := o.divisor(dc)
+	switch o.strategy(dc) {
+	case ScoringBinpack:
+		return remainingToBinpackScore(subtractReserved(capacity, requested), maxRemaining)
+	case ScoringLinear:
+		return capacityToLinearScore(capacity, divisor)
+	default:
+		return capacityToScore(capacity, divisor)
+	}
+}
+
 func capacityToScore(capacity uint64, divisor float64) int {
 	gb := capacity >> 30
 
@@ -19,7 +118,6 @@ func capacityToScore(capacity uint64, divisor float64) int {
 	if gb == 0 {
 		// If there is a non-nil capacity but we dont have at least one gigabyte, we score it with one.
 		// This is because the capacityToScore precision is at the gigabyte level.
-		// TODO: introduce another scheduling algorithm for byte-level precision.
 		if capacity > 0 {
 			return 1
 		}
@@ -38,16 +136,72 @@ func capacityToScore(capacity uint64, divisor float64) int {
 	}
 }
 
-func scoreNodes(pod *corev1.Pod, nodes []corev1.Node, defaultDivisor float64, divisors map[string]float64) []HostPriority {
-	var dcs []string
-	for k := range pod.Annotations {
+// capacityToLinearScore returns the score in proportion to the capacity.
+// The score is 10 if the capacity is 1024GiB multiplied by divisor or more, which is the same as capacityToScore.
+func capacityToLinearScore(capacity uint64, divisor float64) int {
+	if capacity == 0 {
+		return 0
+	}
+	// Round up so that a non-nil capacity is scored with at least one as well as capacityToScore.
+	score := int(math.Ceil(float64(capacity) / (divisor * (1 << 40)) * 10))
+	if score > 10 {
+		return 10
+	}
+	return score
+}
+
+// remainingToBinpackScore returns the score in inverse proportion to the remaining capacity relative to maxRemaining.
+// The node with the most remaining capacity is scored 0, and the node with no remaining capacity is scored 10.
+func remainingToBinpackScore(remaining, maxRemaining uint64) int {
+	if remaining == 0 {
+		return 10
+	}
+	if remaining >= maxRemaining {
+		return 0
+	}
+	// Round up so that a non-nil remaining capacity is not scored as high as no remaining capacity.
+	return 10 - int(math.Ceil(float64(remaining)/float64(maxRemaining)*10))
+}
+
+// MaxRemaining returns the most free capacity remaining after the requested capacity is allocated
+// among the nodes for each device-class. It is the scale of the scores of the binpack strategy.
+func MaxRemaining(nodes []corev1.Node, requested map[string]int64, reservedOf func(node string) map[string]int64) map[string]uint64 {
+	result := make(map[string]uint64)
+	for _, node := range nodes {
+		var reserved map[string]int64
+		if reservedOf != nil {
+			reserved = reservedOf(node.Name)
+		}
+		for dc, req := range requested {
+			val, ok := node.Annotations[topolvm.GetCapacityKeyPrefix()+dc]
+			if !ok {
+				continue
+			}
+			capacity, err := strconv.ParseUint(val, 10, 64)
+			if err != nil {
+				continue
+			}
+			remaining := subtractReserved(subtractReserved(capacity, reserved[dc]), req)
+			if remaining > result[dc] {
+				result[dc] = remaining
+			}
+		}
+	}
+	return result
+}
+
+func scoreNodes(pod *corev1.Pod, nodes []corev1.Node, scoring ScoringOptions, thinPool ThinPoolOptions, reservedOf func(node string) map[string]int64) []HostPriority {
+	requested := make(map[string]int64)
+	for k, v := range pod.Annotations {
 		if strings.HasPrefix(k, topolvm.GetCapacityKeyPrefix()) {
-			dcs = append(dcs, k[len(topolvm.GetCapacityKeyPrefix()):])
+			capacity, _ := strconv.ParseInt(v, 10, 64)
+			requested[k[len(topolvm.GetCapacityKeyPrefix()):]] = capacity
 		}
 	}
-	if len(dcs) == 0 {
+	if len(requested) == 0 {
 		return nil
 	}
+	maxRemaining := MaxRemaining(nodes, requested, reservedOf)
 
 	result := make([]HostPriority, len(nodes))
 	wg := &sync.WaitGroup{}
@@ -56,7 +210,11 @@ func scoreNodes(pod *corev1.Pod, nodes []corev1.Node, defaultDivisor float64, di
 		r := &result[i]
 		item := nodes[i]
 		go func() {
-			score := scoreNode(item, dcs, defaultDivisor, divisors)
+			var reserved map[string]int64
+			if reservedOf != nil {
+				reserved = reservedOf(item.Name)
+			}
+			score := ScoreNode(item, requested, scoring, thinPool, reserved, maxRemaining)
 			*r = HostPriority{Host: item.Name, Score: score}
 			wg.Done()
 		}()
@@ -66,27 +224,60 @@ func scoreNodes(pod *corev1.Pod, nodes []corev1.Node, defaultDivisor float64, di
 	return result
 }
 
-func scoreNode(item corev1.Node, deviceClasses []string, defaultDivisor float64, divisors map[string]float64) int {
+// ScoreNode returns the score of the node for the capacity requested for each device-class.
+// The score of a thin device-class is lowered if the physical usage of the thin pool is above the threshold.
+// maxRemaining is the result of MaxRemaining for the candidate nodes.
+func ScoreNode(item corev1.Node, requested map[string]int64, scoring ScoringOptions, thinPool ThinPoolOptions, reserved map[string]int64, maxRemaining map[string]uint64) int {
 	minScore := math.MaxInt32
-	for _, dc := range deviceClasses {
-		if val, ok := item.Annotations[topolvm.GetCapacityKeyPrefix()+dc]; ok {
-			capacity, _ := strconv.ParseUint(val, 10, 64)
-			var divisor float64
-			if v, ok := divisors[dc]; ok {
-				divisor = v
-			} else {
-				divisor = defaultDivisor
-			}
-			score := capacityToScore(capacity, divisor)
-			if score < minScore {
-				minScore = score
-			}
+	var weightedSum, weightSum float64
+	for dc, req := range requested {
+		score, ok := scoreDeviceClass(item, dc, req, scoring, thinPool, reserved[dc], maxRemaining[dc])
+		if !ok {
+			continue
+		}
+		if score < minScore {
+			minScore = score
 		}
+		weight := scoring.weight(dc)
+		weightedSum += weight * float64(score)
+		weightSum += weight
 	}
 	if minScore == math.MaxInt32 {
-		minScore = 0
+		return 0
+	}
+	if len(scoring.Weights) == 0 {
+		return minScore
 	}
-	return minScore
+	if weightSum == 0 {
+		return 0
+	}
+	return int(math.Round(weightedSum / weightSum))
+}
+
+// scoreDeviceClass returns the score of the device-class on the node.
+// It returns false if the node does not have the capacity annotation of the device-class.
+func scoreDeviceClass(item corev1.Node, dc string, requested int64, scoring ScoringOptions, thinPool ThinPoolOptions, reserved int64, maxRemaining uint64) (int, bool) {
+	val, ok := item.Annotations[topolvm.GetCapacityKeyPrefix()+dc]
+	if !ok {
+		return 0, false
+	}
+	capacity, _ := strconv.ParseUint(val, 10, 64)
+	capacity = subtractReserved(capacity, reserved)
+	return int(float64(scoring.scoreCapacity(dc, capacity, requested, maxRemaining)) * thinPool.scoreFactor(item, dc)), true
+}
+
+// bestNode returns the first node with the highest score.
+func bestNode(priorities []HostPriority) (string, bool) {
+	var best *HostPriority
+	for i := range priorities {
+		if best == nil || priorities[i].Score > best.Score {
+			best = &priorities[i]
+		}
+	}
+	if best == nil {
+		return "", false
+	}
+	return best.Host, true
 }
 
 func (s scheduler) prioritize(w http.ResponseWriter, r *http.Request) {
@@ -99,7 +290,12 @@ func (s scheduler) prioritize(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result := scoreNodes(input.Pod, input.Nodes.Items, s.defaultDivisor, s.divisors)
+	result := scoreNodes(input.Pod, input.Nodes.Items, s.scoring, s.thinPool, s.reservedOf(input.Pod))
+	if s.ledger != nil && input.Pod != nil {
+		if node, ok := bestNode(result); ok {
+			s.ledger.Charge(input.Pod.UID, node)
+		}
+	}
 
 	w.Header().Set("content-type", "application/json")
 	_ = json.NewEncoder(w).Encode(result)
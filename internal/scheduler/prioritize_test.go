@@ -3,6 +3,7 @@ package scheduler
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/topolvm/topolvm"
@@ -80,13 +81,198 @@ func TestScoreNodes(t *testing.T) {
 		},
 	}
 
-	defaultDivisor := 2.0
-	divisors := map[string]float64{
-		deviceClass1: 4,
-		deviceClass2: 10,
+	scoring := ScoringOptions{
+		DefaultDivisor: 2.0,
+		Divisors: map[string]float64{
+			deviceClass1: 4,
+			deviceClass2: 10,
+		},
 	}
-	result := scoreNodes(pod, input, defaultDivisor, divisors)
+	result := scoreNodes(pod, input, scoring, ThinPoolOptions{}, nil)
 	if !reflect.DeepEqual(result, expected) {
 		t.Errorf("expected scoreNodes() to be %#v, but actual %#v", expected, result)
 	}
 }
+
+func TestCapacityToLinearScore(t *testing.T) {
+	testCases := []struct {
+		input   uint64
+		divisor float64
+		expect  int
+	}{
+		{0, 1, 0},
+		{1, 1, 1}, // even one byte will lead to a score of at least 1
+		{100 << 30, 1, 1},
+		{103 << 30, 1, 2}, // byte precision
+		{512 << 30, 1, 5},
+		{512 << 30, 2, 3},
+		{1 << 40, 1, 10},
+		{^uint64(0), 1, 10},
+	}
+
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("test: %d", i), func(t *testing.T) {
+			score := capacityToLinearScore(tt.input, tt.divisor)
+			if score != tt.expect {
+				t.Errorf("score incorrect: input=%d expect=%d actual=%d", tt.input, tt.expect, score)
+			}
+		})
+	}
+}
+
+func TestScoreNode(t *testing.T) {
+	node := testNode("10.1.1.1", 128, 512, 64)
+	requested := map[string]int64{
+		deviceClass1: 64 << 30,
+		deviceClass2: 64 << 30,
+	}
+
+	testCases := []struct {
+		name     string
+		scoring  ScoringOptions
+		reserved map[string]int64
+		expect   int
+	}{
+		{
+			name:    "spread takes the minimum",
+			scoring: ScoringOptions{DefaultDivisor: 1},
+			expect:  7, // min(log2(128), log2(512))
+		},
+		{
+			name:    "binpack prefers less remaining capacity",
+			scoring: ScoringOptions{DefaultDivisor: 1, DefaultStrategy: ScoringBinpack},
+			expect:  5, // min(10 - ceil((128 - 64) / 1024 * 10), 10 - ceil((512 - 64) / 1024 * 10))
+		},
+		{
+			name:     "binpack with reservation",
+			scoring:  ScoringOptions{DefaultDivisor: 1, DefaultStrategy: ScoringBinpack},
+			reserved: map[string]int64{deviceClass1: 64 << 30, deviceClass2: 448 << 30},
+			expect:   10,
+		},
+		{
+			name:     "binpack at byte precision",
+			scoring:  ScoringOptions{DefaultDivisor: 1, DefaultStrategy: ScoringBinpack},
+			reserved: map[string]int64{deviceClass1: 64<<30 - 1, deviceClass2: 448 << 30},
+			expect:   9, // one byte remains in deviceClass1
+		},
+		{
+			name: "strategy per device-class",
+			scoring: ScoringOptions{
+				DefaultDivisor: 1,
+				Strategies:     map[string]ScoringStrategy{deviceClass2: ScoringLinear},
+			},
+			expect: 5, // min(log2(128), 512 / 1024 * 10)
+		},
+		{
+			name: "weighted average",
+			scoring: ScoringOptions{
+				DefaultDivisor: 1,
+				Weights:        map[string]float64{deviceClass1: 3},
+			},
+			expect: 8, // (3 * log2(128) + 1 * log2(512)) / 4 = 7.5, rounded half away from zero
+		},
+		{
+			name: "zero weight",
+			scoring: ScoringOptions{
+				DefaultDivisor: 1,
+				Weights:        map[string]float64{deviceClass1: 0},
+			},
+			expect: 9,
+		},
+	}
+
+	// the most remaining capacity among the candidate nodes is 1TiB for both device-classes.
+	maxRemaining := map[string]uint64{deviceClass1: 1 << 40, deviceClass2: 1 << 40}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			score := ScoreNode(node, requested, tt.scoring, ThinPoolOptions{}, tt.reserved, maxRemaining)
+			if score != tt.expect {
+				t.Errorf("expect=%d actual=%d", tt.expect, score)
+			}
+		})
+	}
+}
+
+func TestScoreNodesBinpack(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				topolvm.GetCapacityKeyPrefix() + deviceClass1: strconv.Itoa(100 << 30),
+			},
+		},
+	}
+	// all the nodes have more than 1TiB free
+	input := []corev1.Node{
+		testNode("10.1.1.1", 2<<10, 0, 0),
+		testNode("10.1.1.2", 4<<10, 0, 0),
+		testNode("10.1.1.3", 8<<10, 0, 0),
+		testNode("10.1.1.4", 5<<10, 0, 0),
+	}
+	scoring := ScoringOptions{DefaultDivisor: 1, DefaultStrategy: ScoringBinpack}
+	reservedOf := func(node string) map[string]int64 {
+		if node == "10.1.1.4" {
+			return map[string]int64{deviceClass1: 1 << 40}
+		}
+		return nil
+	}
+
+	result := scoreNodes(pod, input, scoring, ThinPoolOptions{}, reservedOf)
+	expected := []HostPriority{
+		{Host: "10.1.1.1", Score: 7}, // 10 - ceil((2048 - 100) / (8192 - 100) * 10)
+		{Host: "10.1.1.2", Score: 5}, // 10 - ceil((4096 - 100) / (8192 - 100) * 10)
+		{Host: "10.1.1.3", Score: 0}, // the most remaining capacity
+		{Host: "10.1.1.4", Score: 5}, // the reservation is subtracted
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %#v, but actual %#v", expected, result)
+	}
+}
+
+func TestRemainingToBinpackScore(t *testing.T) {
+	testCases := []struct {
+		remaining    uint64
+		maxRemaining uint64
+		expect       int
+	}{
+		{0, 0, 10},
+		{0, 1 << 40, 10},
+		{1, 1 << 40, 9}, // even one byte remaining lowers the score
+		{1 << 40, 1 << 40, 0},
+		{512 << 30, 1 << 40, 5},
+		{4 << 40, 8 << 40, 5},
+		{4<<40 + 1, 8 << 40, 4},
+	}
+
+	for i, tt := range testCases {
+		t.Run(fmt.Sprintf("test: %d", i), func(t *testing.T) {
+			score := remainingToBinpackScore(tt.remaining, tt.maxRemaining)
+			if score != tt.expect {
+				t.Errorf("score incorrect: remaining=%d max=%d expect=%d actual=%d", tt.remaining, tt.maxRemaining, tt.expect, score)
+			}
+		})
+	}
+}
+
+func TestScoringOptionsValidate(t *testing.T) {
+	valid := ScoringOptions{
+		DefaultDivisor:  1,
+		DefaultStrategy: ScoringBinpack,
+		Strategies:      map[string]ScoringStrategy{deviceClass1: ScoringSpread, deviceClass2: ScoringLinear},
+		Weights:         map[string]float64{deviceClass1: 0},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalids := []ScoringOptions{
+		{Divisors: map[string]float64{deviceClass1: 0}},
+		{DefaultStrategy: "foo"},
+		{Strategies: map[string]ScoringStrategy{deviceClass1: "foo"}},
+		{Weights: map[string]float64{deviceClass1: -1}},
+	}
+	for _, o := range invalids {
+		if err := o.Validate(); err == nil {
+			t.Errorf("%v should be invalid", o)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// annSelectedNode is added to a PVC by kube-scheduler when a node is selected for the PVC.
+	annSelectedNode = "volume.kubernetes.io/selected-node"
+
+	provisionCheckInterval = 5 * time.Second
+)
+
+// ReservationReconciler watches the PersistentVolumeClaims of the pods admitted by the extender,
+// and updates the reservations of the Ledger.
+type ReservationReconciler struct {
+	client client.Client
+	ledger *Ledger
+}
+
+// NewReservationReconciler returns ReservationReconciler.
+func NewReservationReconciler(client client.Client, ledger *Ledger) *ReservationReconciler {
+	return &ReservationReconciler{
+		client: client,
+		ledger: ledger,
+	}
+}
+
+// Reconcile PVC
+func (r *ReservationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+	if !r.ledger.Tracks(req.NamespacedName) {
+		return ctrl.Result{}, nil
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.client.Get(ctx, req.NamespacedName, pvc)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		r.ledger.Release(req.NamespacedName)
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+
+	if pvc.DeletionTimestamp != nil {
+		r.ledger.Release(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	if node, ok := pvc.Annotations[annSelectedNode]; ok && node != "" {
+		capacities, err := r.nodeCapacities(ctx, node)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		r.ledger.SelectNode(req.NamespacedName, node, capacities)
+	}
+
+	// The name of the LogicalVolume is the same as the PersistentVolume.
+	if pvc.Spec.VolumeName != "" {
+		lv := &topolvmv1.LogicalVolume{}
+		err := r.client.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, lv)
+		switch {
+		case err == nil:
+			if lv.Status.VolumeID != "" {
+				r.ledger.Provision(req.NamespacedName)
+				capacities, err := r.nodeCapacities(ctx, lv.Spec.NodeName)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				r.ledger.Settle(lv.Spec.NodeName, capacities)
+				if !r.ledger.Tracks(req.NamespacedName) {
+					log.Info("released the reservation of provisioned volume", "name", pvc.Name, "namespace", pvc.Namespace)
+					return ctrl.Result{}, nil
+				}
+			}
+		case apierrors.IsNotFound(err):
+		default:
+			return ctrl.Result{}, err
+		}
+	}
+
+	// The capacity annotations of the node are updated after the LogicalVolume is created,
+	// so check it again until the reservation is released or expires.
+	return ctrl.Result{RequeueAfter: provisionCheckInterval}, nil
+}
+
+// nodeCapacities returns the capacity of the node for each device-class, or nil if the node is not found.
+func (r *ReservationReconciler) nodeCapacities(ctx context.Context, name string) (map[string]int64, error) {
+	node := &corev1.Node{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: name}, node)
+	switch {
+	case err == nil:
+		return nodeCapacities(node), nil
+	case apierrors.IsNotFound(err):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ReservationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return true },
+		DeleteFunc:  func(event.DeleteEvent) bool { return true },
+		UpdateFunc:  func(event.UpdateEvent) bool { return true },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("reservation").
+		WithEventFilter(pred).
+		For(&corev1.PersistentVolumeClaim{}).
+		Complete(r)
+}
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReservationReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := topolvmv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			Annotations: map[string]string{
+				topolvm.GetCapacityKeyPrefix() + deviceClass1: strconv.Itoa(10 << 30),
+			},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pvc1",
+			Namespace:   "ns",
+			Annotations: map[string]string{annSelectedNode: "node1"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(node, pvc).WithStatusSubresource(&topolvmv1.LogicalVolume{}).Build()
+
+	ledger := NewLedger(time.Minute)
+	ledger.Admit(testPodWithClaims("uid1", "pod1", "pvc1"), map[string]int64{deviceClass1: 3 << 30})
+	r := NewReservationReconciler(c, ledger)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "pvc1"}}
+	reconcile := func() {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the node is selected before the volume is provisioned
+	reconcile()
+	if reserved := ledger.Reserved("node1", ""); reserved[deviceClass1] != 3<<30 {
+		t.Fatalf("capacity should be reserved on the selected node: %v", reserved)
+	}
+
+	// the LogicalVolume appears before the capacity annotation of the node is refreshed
+	lv := &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv1"},
+		Spec:       topolvmv1.LogicalVolumeSpec{NodeName: "node1", DeviceClass: deviceClass1},
+	}
+	if err := c.Create(ctx, lv); err != nil {
+		t.Fatal(err)
+	}
+	lv.Status.VolumeID = "vol1"
+	if err := c.Status().Update(ctx, lv); err != nil {
+		t.Fatal(err)
+	}
+	pvc.Spec.VolumeName = "pv1"
+	if err := c.Update(ctx, pvc); err != nil {
+		t.Fatal(err)
+	}
+	reconcile()
+	if reserved := ledger.Reserved("node1", ""); reserved[deviceClass1] != 3<<30 {
+		t.Errorf("reservation should be kept until the capacity annotation is refreshed: %v", reserved)
+	}
+
+	// the capacity annotation is refreshed
+	node.Annotations[topolvm.GetCapacityKeyPrefix()+deviceClass1] = strconv.Itoa(7 << 30)
+	if err := c.Update(ctx, node); err != nil {
+		t.Fatal(err)
+	}
+	reconcile()
+	if reserved := ledger.Reserved("node1", ""); len(reserved) != 0 {
+		t.Errorf("reservation should be released: %v", reserved)
+	}
+	if ledger.Tracks(req.NamespacedName) {
+		t.Error("claim should not be tracked")
+	}
+}
@@ -1,21 +1,34 @@
 package scheduler
 
 import (
-	"fmt"
 	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 type scheduler struct {
-	defaultDivisor float64
-	divisors       map[string]float64
+	scoring  ScoringOptions
+	thinPool ThinPoolOptions
+	ledger   *Ledger
+	reader   client.Reader
 }
 
 func (s scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch r.URL.Path {
 	case "/predicate":
+		start := time.Now()
 		s.predicate(w, r)
+		observeRequest("predicate", start)
 	case "/prioritize":
+		start := time.Now()
 		s.prioritize(w, r)
+		observeRequest("prioritize", start)
+	case "/metrics":
+		promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
 	case "/status":
 		status(w, r)
 	default:
@@ -24,13 +37,45 @@ func (s scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // NewHandler return new http.Handler of the scheduler extender
-func NewHandler(defaultDiv float64, divisors map[string]float64) (http.Handler, error) {
-	for _, divisor := range divisors {
-		if divisor <= 0 {
-			return nil, fmt.Errorf("invalid divisor: %f", divisor)
+// The capacity reserved in ledger is subtracted from the capacity of nodes. ledger may be nil.
+func NewHandler(scoring ScoringOptions, thinPool ThinPoolOptions, ledger *Ledger) (http.Handler, error) {
+	if err := scoring.Validate(); err != nil {
+		return nil, err
+	}
+	if err := thinPool.Validate(); err != nil {
+		return nil, err
+	}
+	return scheduler{scoring: scoring, thinPool: thinPool, ledger: ledger}, nil
+}
+
+// NewExplainHandler returns new http.Handler which serves only "/explain".
+// It is served apart from the extender as it reads pods and nodes from the API server without authentication.
+// reader is used to read pods and nodes for the explain endpoint. reader may be nil.
+func NewExplainHandler(scoring ScoringOptions, thinPool ThinPoolOptions, ledger *Ledger, reader client.Reader) (http.Handler, error) {
+	if err := scoring.Validate(); err != nil {
+		return nil, err
+	}
+	if err := thinPool.Validate(); err != nil {
+		return nil, err
+	}
+	s := scheduler{scoring, thinPool, ledger, reader}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/explain" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
 		}
+		s.explain(w, r)
+	}), nil
+}
+
+// reservedOf returns the function which returns the capacity reserved on a node for the pods other than pod.
+func (s scheduler) reservedOf(pod *corev1.Pod) func(node string) map[string]int64 {
+	if s.ledger == nil || pod == nil {
+		return nil
+	}
+	return func(node string) map[string]int64 {
+		return s.ledger.Reserved(node, pod.UID)
 	}
-	return scheduler{defaultDiv, divisors}, nil
 }
 
 func status(w http.ResponseWriter, _ *http.Request) {
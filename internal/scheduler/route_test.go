@@ -45,9 +45,12 @@ var extenderArgs = ExtenderArgs{
 func testPredicate(t *testing.T) {
 	t.Parallel()
 
-	handler, err := NewHandler(1, map[string]float64{
-		"dc1": 1,
-	})
+	handler, err := NewHandler(ScoringOptions{
+		DefaultDivisor: 1,
+		Divisors: map[string]float64{
+			"dc1": 1,
+		},
+	}, ThinPoolOptions{}, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -92,9 +95,12 @@ func testPredicate(t *testing.T) {
 func testPrioritize(t *testing.T) {
 	t.Parallel()
 
-	handler, err := NewHandler(1, map[string]float64{
-		"dc1": 1,
-	})
+	handler, err := NewHandler(ScoringOptions{
+		DefaultDivisor: 1,
+		Divisors: map[string]float64{
+			"dc1": 1,
+		},
+	}, ThinPoolOptions{}, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/topolvm/topolvm"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ThinPoolOptions represents the thresholds of the physical usage of thin pools in percent.
+// The capacity of a thin device-class is the free space with overprovisioning,
+// so a thin pool that is almost full physically may still have enough capacity.
+// The usage is read from the annotations of nodes published by topolvm-node. A threshold of zero is disabled.
+type ThinPoolOptions struct {
+	// DataFilterPercent filters out nodes whose thin pool data usage is at or above the threshold.
+	DataFilterPercent float64 `json:"data-filter-percent"`
+	// MetadataFilterPercent filters out nodes whose thin pool metadata usage is at or above the threshold.
+	MetadataFilterPercent float64 `json:"metadata-filter-percent"`
+	// DataScorePercent lowers the score of nodes whose thin pool data usage is above the threshold.
+	DataScorePercent float64 `json:"data-score-percent"`
+	// MetadataScorePercent lowers the score of nodes whose thin pool metadata usage is above the threshold.
+	MetadataScorePercent float64 `json:"metadata-score-percent"`
+}
+
+// Validate returns an error if any of the thresholds is out of range.
+func (o ThinPoolOptions) Validate() error {
+	for _, threshold := range []float64{o.DataFilterPercent, o.MetadataFilterPercent, o.DataScorePercent, o.MetadataScorePercent} {
+		if threshold < 0 || threshold > 100 {
+			return fmt.Errorf("invalid thin pool threshold: %f", threshold)
+		}
+	}
+	return nil
+}
+
+// thinPoolUsage returns the data and metadata usage of the thin pool of the device-class on the node.
+// It returns false if the device-class is not thin or the usage is not published.
+func thinPoolUsage(node corev1.Node, dc string) (data, metadata float64, ok bool) {
+	dataVal, ok1 := node.Annotations[topolvm.GetThinPoolDataPercentKeyPrefix()+dc]
+	metadataVal, ok2 := node.Annotations[topolvm.GetThinPoolMetadataPercentKeyPrefix()+dc]
+	if !ok1 || !ok2 {
+		return 0, 0, false
+	}
+	data, err1 := strconv.ParseFloat(dataVal, 64)
+	metadata, err2 := strconv.ParseFloat(metadataVal, 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return data, metadata, true
+}
+
+// filterReason returns the reason to filter out the node, or an empty string if the thin pool of the device-class has room.
+func (o ThinPoolOptions) filterReason(node corev1.Node, dc string) string {
+	data, metadata, ok := thinPoolUsage(node, dc)
+	if !ok {
+		return ""
+	}
+	if o.DataFilterPercent > 0 && data >= o.DataFilterPercent {
+		return "thin pool data usage is too high"
+	}
+	if o.MetadataFilterPercent > 0 && metadata >= o.MetadataFilterPercent {
+		return "thin pool metadata usage is too high"
+	}
+	return ""
+}
+
+// scoreFactor returns the factor from 0 to 1 to lower the score of the device-class on the node.
+// The factor decreases linearly from 1 at the threshold to 0 at 100 percent of the usage.
+func (o ThinPoolOptions) scoreFactor(node corev1.Node, dc string) float64 {
+	data, metadata, ok := thinPoolUsage(node, dc)
+	if !ok {
+		return 1
+	}
+	factor := func(usage, threshold float64) float64 {
+		if threshold <= 0 || usage <= threshold {
+			return 1
+		}
+		if usage >= 100 {
+			return 0
+		}
+		return (100 - usage) / (100 - threshold)
+	}
+	return min(factor(data, o.DataScorePercent), factor(metadata, o.MetadataScorePercent))
+}
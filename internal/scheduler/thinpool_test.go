@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"math"
+	"testing"
+
+	"github.com/topolvm/topolvm"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func testThinNode(name string, capGb int64, dataPercent, metadataPercent string) corev1.Node {
+	node := testNode(name, capGb, capGb, capGb)
+	node.Annotations[topolvm.GetThinPoolDataPercentKeyPrefix()+deviceClass1] = dataPercent
+	node.Annotations[topolvm.GetThinPoolMetadataPercentKeyPrefix()+deviceClass1] = metadataPercent
+	return node
+}
+
+func TestThinPoolOptionsFilterReason(t *testing.T) {
+	thinPool := ThinPoolOptions{DataFilterPercent: 90, MetadataFilterPercent: 80}
+
+	testCases := []struct {
+		node   corev1.Node
+		dc     string
+		expect string
+	}{
+		{testThinNode("node1", 100, "50", "50"), deviceClass1, ""},
+		{testThinNode("node1", 100, "90", "50"), deviceClass1, "thin pool data usage is too high"},
+		{testThinNode("node1", 100, "50", "85"), deviceClass1, "thin pool metadata usage is too high"},
+		// thick device-classes do not have the usage
+		{testThinNode("node1", 100, "95", "95"), deviceClass2, ""},
+		{testThinNode("node1", 100, "foo", "95"), deviceClass1, ""},
+	}
+	for i, tt := range testCases {
+		if reason := thinPool.filterReason(tt.node, tt.dc); reason != tt.expect {
+			t.Errorf("%d: expect=%q actual=%q", i, tt.expect, reason)
+		}
+	}
+
+	if reason := (ThinPoolOptions{}).filterReason(testThinNode("node1", 100, "100", "100"), deviceClass1); reason != "" {
+		t.Errorf("zero thresholds should be disabled: %q", reason)
+	}
+
+	// the thin pool is checked after the capacity
+	node := testThinNode("node1", 100, "95", "50")
+	if reason := FilterNode(node, map[string]int64{deviceClass1: 1 << 30}, thinPool, nil); reason != "thin pool data usage is too high" {
+		t.Errorf("unexpected reason: %q", reason)
+	}
+}
+
+func TestThinPoolOptionsScoreFactor(t *testing.T) {
+	thinPool := ThinPoolOptions{DataScorePercent: 80, MetadataScorePercent: 60}
+
+	testCases := []struct {
+		node   corev1.Node
+		expect float64
+	}{
+		{testThinNode("node1", 100, "50", "50"), 1},
+		{testThinNode("node1", 100, "90", "50"), 0.5},
+		{testThinNode("node1", 100, "50", "90"), 0.25},
+		{testThinNode("node1", 100, "90", "90"), 0.25},
+		{testThinNode("node1", 100, "100", "50"), 0},
+	}
+	for i, tt := range testCases {
+		if factor := thinPool.scoreFactor(tt.node, deviceClass1); math.Abs(factor-tt.expect) > 1e-9 {
+			t.Errorf("%d: expect=%f actual=%f", i, tt.expect, factor)
+		}
+	}
+
+	// the score of log2(512) is lowered by half
+	node := testThinNode("node1", 512, "90", "50")
+	score := ScoreNode(node, map[string]int64{deviceClass1: 1 << 30}, ScoringOptions{DefaultDivisor: 1}, thinPool, nil, nil)
+	if score != 4 {
+		t.Errorf("unexpected score: %d", score)
+	}
+}
+
+func TestThinPoolOptionsValidate(t *testing.T) {
+	if err := (ThinPoolOptions{DataFilterPercent: 100, MetadataScorePercent: 50}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (ThinPoolOptions{DataFilterPercent: 101}).Validate(); err == nil {
+		t.Error("threshold over 100 should be invalid")
+	}
+	if err := (ThinPoolOptions{MetadataScorePercent: -1}).Validate(); err == nil {
+		t.Error("negative threshold should be invalid")
+	}
+}
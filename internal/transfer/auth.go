@@ -0,0 +1,51 @@
+package transfer
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	metadataTarget = "topolvm-transfer-target"
+	metadataToken  = "topolvm-transfer-token"
+)
+
+// NewToken returns a random token to authenticate a transfer.
+// The node of the target LogicalVolume creates a new token for every attempt of the transfer.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns the hash of the token to be recorded in the status of the target LogicalVolume.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// WithCredentials returns a context to read the source of the target LogicalVolume with the token.
+func WithCredentials(ctx context.Context, target, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, metadataTarget, target, metadataToken, token)
+}
+
+// credentialsFromContext returns the target LogicalVolume and the token sent by the client.
+func credentialsFromContext(ctx context.Context) (target, token string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", ""
+	}
+	if v := md.Get(metadataTarget); len(v) > 0 {
+		target = v[0]
+	}
+	if v := md.Get(metadataToken); len(v) > 0 {
+		token = v[0]
+	}
+	return target, token
+}
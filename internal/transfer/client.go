@@ -0,0 +1,120 @@
+package transfer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/topolvm/topolvm"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Dialer connects to the transfer server on a node.
+// The returned io.Closer must be closed after use.
+type Dialer func(ctx context.Context, nodeName string) (proto.LVServiceClient, io.Closer, error)
+
+// NewDialer returns a Dialer which finds the address of the transfer server from the annotation of the Node,
+// and connects to it with mutual TLS. If tlsConfig has no ServerName, the certificate of the server is verified
+// with the name of the Node. If tlsConfig is nil, the Dialer always fails.
+func NewDialer(c client.Reader, tlsConfig *tls.Config) Dialer {
+	return func(ctx context.Context, nodeName string) (proto.LVServiceClient, io.Closer, error) {
+		if tlsConfig == nil {
+			return nil, nil, errors.New("TLS is not configured for the volume transfer")
+		}
+		var node metav1.PartialObjectMetadata
+		node.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Node"))
+		if err := c.Get(ctx, types.NamespacedName{Name: nodeName}, &node); err != nil {
+			return nil, nil, err
+		}
+		address := node.Annotations[topolvm.GetTransferAddressKey()]
+		if address == "" {
+			return nil, nil, fmt.Errorf("transfer server is not enabled on node %s", nodeName)
+		}
+
+		config := tlsConfig
+		if config.ServerName == "" {
+			config = tlsConfig.Clone()
+			config.ServerName = nodeName
+		}
+		conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+		if err != nil {
+			return nil, nil, err
+		}
+		return proto.NewLVServiceClient(conn), conn, nil
+	}
+}
+
+// Copy reads the logical volume specified by src with the source service and writes its data to
+// the logical volume named dstName in dstDeviceClass with the destination service.
+// progress is called with the number of bytes to be transferred and transferred so far every time data is written.
+// It returns the number of bytes written.
+func Copy(
+	ctx context.Context,
+	source proto.LVServiceClient,
+	src *proto.ReadLVRequest,
+	destination proto.LVServiceClient,
+	dstName, dstDeviceClass string,
+	progress func(total, transferred uint64),
+) (uint64, error) {
+	// canceling the context aborts the write stream when reading fails midway.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reader, err := source.ReadLV(ctx, src)
+	if err != nil {
+		return 0, err
+	}
+	header, err := reader.Recv()
+	if err != nil {
+		return 0, fmt.Errorf("failed to receive the size of the source: %w", err)
+	}
+	total := header.GetTotalBytes()
+	progress(total, 0)
+
+	writer, err := destination.WriteLV(ctx)
+	if err != nil {
+		return 0, err
+	}
+	err = writer.Send(&proto.WriteLVRequest{
+		Name:        dstName,
+		DeviceClass: dstDeviceClass,
+		SizeBytes:   header.GetSizeBytes(),
+	})
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	var transferred uint64
+	for err == nil {
+		var msg *proto.ReadLVResponse
+		msg, err = reader.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return transferred, fmt.Errorf("failed to read the source: %w", err)
+		}
+		// io.EOF means that the destination has aborted, the cause is returned by CloseAndRecv.
+		err = writer.Send(&proto.WriteLVRequest{Offset: msg.GetOffset(), Data: msg.GetData()})
+		if err == nil {
+			transferred += uint64(len(msg.GetData()))
+			progress(total, transferred)
+		} else if !errors.Is(err, io.EOF) {
+			return transferred, err
+		}
+	}
+
+	res, err := writer.CloseAndRecv()
+	if err != nil {
+		return transferred, err
+	}
+	return res.GetWrittenBytes(), nil
+}
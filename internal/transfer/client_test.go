@@ -0,0 +1,114 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeReadClient struct {
+	grpc.ClientStream
+	msgs []*proto.ReadLVResponse
+	err  error
+}
+
+func (c *fakeReadClient) Recv() (*proto.ReadLVResponse, error) {
+	if len(c.msgs) == 0 {
+		if c.err != nil {
+			return nil, c.err
+		}
+		return nil, io.EOF
+	}
+	msg := c.msgs[0]
+	c.msgs = c.msgs[1:]
+	return msg, nil
+}
+
+type fakeWriteClient struct {
+	grpc.ClientStream
+	first   *proto.WriteLVRequest
+	data    []byte
+	written uint64
+}
+
+func (c *fakeWriteClient) Send(req *proto.WriteLVRequest) error {
+	if c.first == nil {
+		c.first = req
+		c.data = make([]byte, req.SizeBytes)
+		return nil
+	}
+	copy(c.data[req.Offset:], req.Data)
+	c.written += uint64(len(req.Data))
+	return nil
+}
+
+func (c *fakeWriteClient) CloseAndRecv() (*proto.WriteLVResponse, error) {
+	return &proto.WriteLVResponse{WrittenBytes: c.written}, nil
+}
+
+type fakeLVService struct {
+	proto.LVServiceClient
+	reader  *fakeReadClient
+	writer  *fakeWriteClient
+	request *proto.ReadLVRequest
+}
+
+func (s *fakeLVService) ReadLV(_ context.Context, in *proto.ReadLVRequest, _ ...grpc.CallOption) (proto.LVService_ReadLVClient, error) {
+	s.request = in
+	return s.reader, nil
+}
+
+func (s *fakeLVService) WriteLV(_ context.Context, _ ...grpc.CallOption) (proto.LVService_WriteLVClient, error) {
+	return s.writer, nil
+}
+
+func TestCopy(t *testing.T) {
+	source := &fakeLVService{reader: &fakeReadClient{msgs: []*proto.ReadLVResponse{
+		{SizeBytes: 16, TotalBytes: 6},
+		{Offset: 2, Data: []byte("abc")},
+		{Offset: 10, Data: []byte("xyz")},
+	}}}
+	destination := &fakeLVService{writer: &fakeWriteClient{}}
+
+	var lastTotal, lastTransferred uint64
+	written, err := Copy(context.Background(), source, &proto.ReadLVRequest{Name: "src", DeviceClass: "ssd"},
+		destination, "dst", "hdd", func(total, transferred uint64) {
+			lastTotal, lastTransferred = total, transferred
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 6 {
+		t.Errorf("unexpected written bytes: %d", written)
+	}
+	if lastTotal != 6 || lastTransferred != 6 {
+		t.Errorf("unexpected progress: total=%d transferred=%d", lastTotal, lastTransferred)
+	}
+	if source.request.Name != "src" || source.request.DeviceClass != "ssd" {
+		t.Errorf("unexpected read request: %v", source.request)
+	}
+	first := destination.writer.first
+	if first.Name != "dst" || first.DeviceClass != "hdd" || first.SizeBytes != 16 {
+		t.Errorf("unexpected first write request: %v", first)
+	}
+	if want := []byte("\x00\x00abc\x00\x00\x00\x00\x00xyz\x00\x00\x00"); !bytes.Equal(destination.writer.data, want) {
+		t.Errorf("unexpected data: %q", destination.writer.data)
+	}
+
+	source = &fakeLVService{reader: &fakeReadClient{
+		msgs: []*proto.ReadLVResponse{{SizeBytes: 16, TotalBytes: 16}},
+		err:  status.Error(codes.Internal, "read error"),
+	}}
+	destination = &fakeLVService{writer: &fakeWriteClient{}}
+	_, err = Copy(context.Background(), source, &proto.ReadLVRequest{Name: "src"},
+		destination, "dst", "", func(_, _ uint64) {})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("the error of the source should be returned: %v", err)
+	}
+}
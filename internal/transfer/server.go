@@ -0,0 +1,168 @@
+package transfer
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var serverLogger = ctrl.Log.WithName("transfer").WithName("server")
+
+type server struct {
+	proto.UnimplementedLVServiceServer
+
+	client    client.Reader
+	apiReader client.Reader
+	nodeName  string
+	lvService proto.LVServiceClient
+}
+
+// NewServer returns a LVServiceServer that serves the data of the logical volumes on this node to other nodes.
+// Only ReadLV is implemented, and it is allowed only for the source of a volume which is being restored or
+// cloned on another node. The device class in a request is ignored and taken from the LogicalVolume instead.
+// Every request must carry the name of the pending LogicalVolume and the token whose hash is recorded in its status.
+// The pending LogicalVolume is read with apiReader, as the hash is recorded just before the request.
+func NewServer(client client.Reader, apiReader client.Reader, nodeName string, lvService proto.LVServiceClient) proto.LVServiceServer {
+	return &server{
+		client:    client,
+		apiReader: apiReader,
+		nodeName:  nodeName,
+		lvService: lvService,
+	}
+}
+
+// ReadLV relays the data of a logical volume read by the local lvmd.
+// A volume which may be written is read from a temporary snapshot, so that the copy is crash-consistent.
+func (s *server) ReadLV(req *proto.ReadLVRequest, stream proto.LVService_ReadLVServer) error {
+	ctx := stream.Context()
+	logger := serverLogger.WithValues("volume_id", req.GetName())
+
+	source, target, err := s.findTransferSource(ctx, req.GetName())
+	if err != nil {
+		logger.Error(err, "rejected ReadLV request")
+		return err
+	}
+
+	name := source.Status.VolumeID
+	if source.Spec.AccessType != "ro" {
+		name, err = s.createSnapshot(ctx, source, target)
+		if err != nil {
+			logger.Error(err, "failed to create snapshot to transfer", "name", source.Name)
+			return err
+		}
+		defer s.removeSnapshot(ctx, name, source.Spec.DeviceClass)
+	}
+
+	res, err := s.lvService.ReadLV(ctx, &proto.ReadLVRequest{
+		Name:        name,
+		DeviceClass: source.Spec.DeviceClass,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("start transferring LV", "name", source.Name, "target", target.Name)
+	for {
+		msg, err := res.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			logger.Error(err, "failed to read LV", "name", source.Name)
+			return err
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+	logger.Info("finished transferring LV", "name", source.Name, "target", target.Name)
+	return nil
+}
+
+// snapshotName returns the name of the temporary snapshot to transfer the source to the target.
+func snapshotName(target *topolvmv1.LogicalVolume) string {
+	return "transfer-" + string(target.UID)
+}
+
+// createSnapshot creates a read-only snapshot of the source for the transfer, and returns its name.
+// The snapshot left by an interrupted transfer to the same target is replaced.
+func (s *server) createSnapshot(ctx context.Context, source, target *topolvmv1.LogicalVolume) (string, error) {
+	name := snapshotName(target)
+	_, err := s.lvService.RemoveLV(ctx, &proto.RemoveLVRequest{Name: name, DeviceClass: source.Spec.DeviceClass})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return "", err
+	}
+	_, err = s.lvService.CreateLVSnapshot(ctx, &proto.CreateLVSnapshotRequest{
+		Name:         name,
+		DeviceClass:  source.Spec.DeviceClass,
+		SourceVolume: source.Status.VolumeID,
+		AccessType:   "ro",
+	})
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// removeSnapshot removes the temporary snapshot even if the request has been canceled.
+func (s *server) removeSnapshot(ctx context.Context, name, deviceClass string) {
+	ctx = context.WithoutCancel(ctx)
+	_, err := s.lvService.RemoveLV(ctx, &proto.RemoveLVRequest{Name: name, DeviceClass: deviceClass})
+	if err != nil && status.Code(err) != codes.NotFound {
+		serverLogger.Error(err, "failed to remove snapshot to transfer", "name", name)
+	}
+}
+
+// findTransferSource returns the LogicalVolume on this node having the volume ID and the pending LogicalVolume
+// on another node named in the request, if the former is the source of the latter and the request has its token.
+func (s *server) findTransferSource(ctx context.Context, volumeID string) (*topolvmv1.LogicalVolume, *topolvmv1.LogicalVolume, error) {
+	if volumeID == "" {
+		return nil, nil, status.Error(codes.InvalidArgument, "volume ID is not given")
+	}
+	targetName, token := credentialsFromContext(ctx)
+	if targetName == "" || token == "" {
+		return nil, nil, status.Error(codes.Unauthenticated, "no credentials are given")
+	}
+
+	lvList := new(topolvmv1.LogicalVolumeList)
+	if err := s.client.List(ctx, lvList); err != nil {
+		return nil, nil, status.Error(codes.Internal, err.Error())
+	}
+	var source *topolvmv1.LogicalVolume
+	for i := range lvList.Items {
+		lv := &lvList.Items[i]
+		if lv.Status.VolumeID == volumeID && lv.Spec.NodeName == s.nodeName {
+			source = lv
+			break
+		}
+	}
+	if source == nil {
+		return nil, nil, status.Errorf(codes.NotFound, "logical volume %s is not found on node %s", volumeID, s.nodeName)
+	}
+
+	target := new(topolvmv1.LogicalVolume)
+	if err := s.apiReader.Get(ctx, client.ObjectKey{Name: targetName}, target); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, status.Errorf(codes.PermissionDenied, "logical volume %s is not found", targetName)
+		}
+		return nil, nil, status.Error(codes.Internal, err.Error())
+	}
+	if target.Spec.Source != source.Name || target.Spec.SourceKind == topolvmv1.SourceKindLogicalVolumeBackup ||
+		target.Spec.NodeName == s.nodeName || target.Status.VolumeID != "" || target.DeletionTimestamp != nil {
+		return nil, nil, status.Errorf(codes.PermissionDenied, "logical volume %s is not the source of pending logical volume %s", source.Name, target.Name)
+	}
+	if target.Status.Transfer == nil || target.Status.Transfer.TokenHash == "" ||
+		subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(target.Status.Transfer.TokenHash)) != 1 {
+		return nil, nil, status.Errorf(codes.Unauthenticated, "invalid token to transfer logical volume %s to %s", source.Name, target.Name)
+	}
+	return source, target, nil
+}
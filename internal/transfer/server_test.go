@@ -0,0 +1,179 @@
+package transfer
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testToken = "secret-token"
+
+func newLogicalVolume(name, nodeName, source, volumeID string) *topolvmv1.LogicalVolume {
+	return &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name + "-uid")},
+		Spec: topolvmv1.LogicalVolumeSpec{
+			Name:        name,
+			NodeName:    nodeName,
+			DeviceClass: "ssd",
+			Source:      source,
+		},
+		Status: topolvmv1.LogicalVolumeStatus{VolumeID: volumeID},
+	}
+}
+
+// newPendingLogicalVolume returns a LogicalVolume waiting for the transfer with testToken.
+func newPendingLogicalVolume(name, nodeName, source string) *topolvmv1.LogicalVolume {
+	lv := newLogicalVolume(name, nodeName, source, "")
+	lv.Status.Transfer = &topolvmv1.TransferStatus{SourceNodeName: "node1", TokenHash: HashToken(testToken)}
+	return lv
+}
+
+func newTestClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := topolvmv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func TestFindTransferSource(t *testing.T) {
+	c := newTestClient(t,
+		newLogicalVolume("snapshot", "node1", "", "snapshot-id"),
+		newPendingLogicalVolume("restoring", "node2", "snapshot"),
+		newLogicalVolume("other", "node1", "", "other-id"),
+		newLogicalVolume("restored", "node2", "other", "restored-id"),
+		newLogicalVolume("local", "node1", "", "local-id"),
+		newPendingLogicalVolume("local-clone", "node1", "local"),
+		newLogicalVolume("no-token", "node2", "snapshot", ""),
+	)
+	s := &server{client: c, apiReader: c, nodeName: "node1"}
+
+	tests := []struct {
+		name     string
+		volumeID string
+		md       []string
+		wantCode codes.Code
+	}{
+		{"valid token", "snapshot-id", []string{metadataTarget, "restoring", metadataToken, testToken}, codes.OK},
+		{"invalid token", "snapshot-id", []string{metadataTarget, "restoring", metadataToken, "wrong-token"}, codes.Unauthenticated},
+		{"no token", "snapshot-id", []string{metadataTarget, "restoring"}, codes.Unauthenticated},
+		{"no credentials", "snapshot-id", nil, codes.Unauthenticated},
+		{"no token hash", "snapshot-id", []string{metadataTarget, "no-token", metadataToken, testToken}, codes.Unauthenticated},
+		{"unknown target", "snapshot-id", []string{metadataTarget, "unknown", metadataToken, testToken}, codes.PermissionDenied},
+		{"other source", "other-id", []string{metadataTarget, "restoring", metadataToken, testToken}, codes.PermissionDenied},
+		{"completed target", "other-id", []string{metadataTarget, "restored", metadataToken, testToken}, codes.PermissionDenied},
+		{"local target", "local-id", []string{metadataTarget, "local-clone", metadataToken, testToken}, codes.PermissionDenied},
+		{"source on other node", "restored-id", []string{metadataTarget, "restoring", metadataToken, testToken}, codes.NotFound},
+		{"unknown source", "unknown", []string{metadataTarget, "restoring", metadataToken, testToken}, codes.NotFound},
+		{"no volume ID", "", []string{metadataTarget, "restoring", metadataToken, testToken}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tt.md...))
+			source, target, err := s.findTransferSource(ctx, tt.volumeID)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("unexpected code: %s, err: %v", code, err)
+			}
+			if err == nil && (source.Status.VolumeID != tt.volumeID || target.Name != tt.md[1]) {
+				t.Errorf("unexpected LogicalVolumes: %s, %s", source.Name, target.Name)
+			}
+		})
+	}
+}
+
+type fakeReadServer struct {
+	grpc.ServerStream
+	ctx  context.Context
+	msgs []*proto.ReadLVResponse
+}
+
+func (s *fakeReadServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeReadServer) Send(msg *proto.ReadLVResponse) error {
+	s.msgs = append(s.msgs, msg)
+	return nil
+}
+
+// snapshotLVService records the snapshots created and removed for transfers.
+type snapshotLVService struct {
+	*fakeLVService
+	created []string
+	removed []string
+}
+
+func (s *snapshotLVService) CreateLVSnapshot(_ context.Context, in *proto.CreateLVSnapshotRequest, _ ...grpc.CallOption) (*proto.CreateLVSnapshotResponse, error) {
+	if in.AccessType != "ro" {
+		return nil, status.Errorf(codes.InvalidArgument, "unexpected access type: %s", in.AccessType)
+	}
+	s.created = append(s.created, in.SourceVolume+":"+in.Name)
+	return &proto.CreateLVSnapshotResponse{}, nil
+}
+
+func (s *snapshotLVService) RemoveLV(_ context.Context, in *proto.RemoveLVRequest, _ ...grpc.CallOption) (*proto.Empty, error) {
+	s.removed = append(s.removed, in.Name)
+	return &proto.Empty{}, nil
+}
+
+func TestReadLVFromSnapshot(t *testing.T) {
+	snapshot := newLogicalVolume("snapshot", "node1", "", "snapshot-id")
+	snapshot.Spec.AccessType = "ro"
+	c := newTestClient(t,
+		snapshot,
+		newPendingLogicalVolume("restoring", "node2", "snapshot"),
+		newLogicalVolume("volume", "node1", "", "volume-id"),
+		newPendingLogicalVolume("cloning", "node2", "volume"),
+	)
+
+	tests := []struct {
+		name     string
+		volumeID string
+		target   string
+		wantRead string
+		wantSnap []string
+	}{
+		{"read-only source", "snapshot-id", "restoring", "snapshot-id", nil},
+		{"writable source", "volume-id", "cloning", "transfer-cloning-uid", []string{"volume-id:transfer-cloning-uid"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lvService := &snapshotLVService{fakeLVService: &fakeLVService{reader: &fakeReadClient{
+				msgs: []*proto.ReadLVResponse{{SizeBytes: 4, TotalBytes: 4}, {Data: []byte("data")}},
+			}}}
+			s := &server{client: c, apiReader: c, nodeName: "node1", lvService: lvService}
+			stream := &fakeReadServer{ctx: metadata.NewIncomingContext(context.Background(),
+				metadata.Pairs(metadataTarget, tt.target, metadataToken, testToken))}
+
+			if err := s.ReadLV(&proto.ReadLVRequest{Name: tt.volumeID}, stream); err != nil {
+				t.Fatal(err)
+			}
+			if len(stream.msgs) != 2 {
+				t.Errorf("unexpected number of messages: %d", len(stream.msgs))
+			}
+			if lvService.request.Name != tt.wantRead {
+				t.Errorf("unexpected volume is read: %s", lvService.request.Name)
+			}
+			if !slices.Equal(lvService.created, tt.wantSnap) {
+				t.Errorf("unexpected snapshots: %v", lvService.created)
+			}
+			// a leftover snapshot is removed before creating it, and the snapshot is removed after reading it.
+			if len(tt.wantSnap) != 0 && len(lvService.removed) != 2 {
+				t.Errorf("snapshot should be removed: %v", lvService.removed)
+			}
+		})
+	}
+}
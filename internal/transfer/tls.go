@@ -0,0 +1,24 @@
+package transfer
+
+import (
+	"crypto/tls"
+
+	"github.com/topolvm/topolvm/internal/lvmd"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+)
+
+// NewTLSConfigs returns the TLS configurations of the transfer server and of the client connecting to
+// the transfer servers on other nodes. Both present the same certificate, and require the certificate
+// of the peer to be signed by the CA. The certificates are reloaded when the files are updated.
+// serverName is the name in the certificates of the servers; the name of the Node is used if empty.
+func NewTLSConfigs(certFile, keyFile, caFile, serverName string) (*tls.Config, *tls.Config, error) {
+	serverConfig, err := lvmd.NewServerTLSConfig(&lvmdTypes.TLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: caFile})
+	if err != nil {
+		return nil, nil, err
+	}
+	clientConfig, err := lvmd.NewClientTLSConfig(certFile, keyFile, caFile, serverName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return serverConfig, clientConfig, nil
+}
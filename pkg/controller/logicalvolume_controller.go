@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"crypto/tls"
+
 	internalController "github.com/topolvm/topolvm/internal/controller"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -15,6 +17,39 @@ func SetupLogicalVolumeReconcilerWithServices(
 	vgService proto.VGServiceClient,
 	lvService proto.LVServiceClient,
 ) error {
-	reconciler := internalController.NewLogicalVolumeReconcilerWithServices(client, nodeName, vgService, lvService)
+	return SetupLogicalVolumeReconcilerWithTransfer(mgr, client, nodeName, vgService, lvService, nil, "", "")
+}
+
+// SetupLogicalVolumeReconcilerWithTransfer creates LogicalVolumeReconciler which restores or clones volumes
+// across nodes by connecting to the transfer servers on other nodes with transferTLSConfig, and sets up with manager.
+// Volumes are restored from the backups whose credentials Secrets are in backupNamespace.
+// The filesystems frozen for group snapshots are recorded in freezeStateFile to be thawed on restart.
+func SetupLogicalVolumeReconcilerWithTransfer(
+	mgr ctrl.Manager,
+	client client.Client,
+	nodeName string,
+	vgService proto.VGServiceClient,
+	lvService proto.LVServiceClient,
+	transferTLSConfig *tls.Config,
+	backupNamespace string,
+	freezeStateFile string,
+) error {
+	reconciler := internalController.NewLogicalVolumeReconcilerWithServices(client, nodeName, vgService, lvService,
+		transferTLSConfig, backupNamespace, freezeStateFile)
+	return reconciler.SetupWithManager(mgr)
+}
+
+// SetupLogicalVolumeBackupReconciler creates LogicalVolumeBackupReconciler and sets up with manager.
+// Only the credentials Secrets in backupNamespace can be used by the LogicalVolumeBackups.
+func SetupLogicalVolumeBackupReconciler(
+	mgr ctrl.Manager,
+	client client.Client,
+	apiReader client.Reader,
+	nodeName string,
+	backupNamespace string,
+	vgService proto.VGServiceClient,
+	lvService proto.LVServiceClient,
+) error {
+	reconciler := internalController.NewLogicalVolumeBackupReconciler(client, apiReader, nodeName, backupNamespace, vgService, lvService)
 	return reconciler.SetupWithManager(mgr)
 }
@@ -0,0 +1,13 @@
+package controller
+
+import (
+	internalController "github.com/topolvm/topolvm/internal/controller"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetupLogicalVolumeMigrationReconciler creates LogicalVolumeMigrationReconciler and sets up with manager.
+func SetupLogicalVolumeMigrationReconciler(mgr ctrl.Manager, client client.Client, apiReader client.Reader) error {
+	reconciler := internalController.NewLogicalVolumeMigrationReconciler(client, apiReader)
+	return reconciler.SetupWithManager(mgr)
+}
@@ -8,6 +8,10 @@ import (
 // It allows starting a new controller server even without access to the package internals.
 var NewControllerServer = internalDriver.NewControllerServer
 
+// ControllerServer is an externally consumable wrapper.
+// It serves the CSI controller service and the CSI group controller service.
+type ControllerServer = internalDriver.ControllerServer
+
 // ControllerServerSettings is an externally consumable wrapper.
 // It is used to configure the controller server.
 type ControllerServerSettings = internalDriver.ControllerServerSettings
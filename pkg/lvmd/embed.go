@@ -8,6 +8,14 @@ import (
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
 )
 
+// ReloadFunc replaces the device-classes and the lvcreate-option-classes of the embedded lvmd.
+// The current classes are kept if the new ones are invalid, or a device-class having logical volumes is removed.
+type ReloadFunc func(
+	ctx context.Context,
+	deviceClasses []*lvmdTypes.DeviceClass,
+	LvcreateOptionClasses []*lvmdTypes.LvcreateOptionClass,
+) error
+
 func NewEmbeddedServiceClients(
 	ctx context.Context,
 	deviceClasses []*lvmdTypes.DeviceClass,
@@ -15,9 +23,25 @@ func NewEmbeddedServiceClients(
 ) (
 	proto.LVServiceClient,
 	proto.VGServiceClient,
+) {
+	lvService, vgService, _ := NewEmbeddedServiceClientsWithReloader(ctx, deviceClasses, LvcreateOptionClasses)
+	return lvService, vgService
+}
+
+// NewEmbeddedServiceClientsWithReloader is the same as NewEmbeddedServiceClients,
+// but also returns the function to reload the device-classes and the lvcreate-option-classes.
+func NewEmbeddedServiceClientsWithReloader(
+	ctx context.Context,
+	deviceClasses []*lvmdTypes.DeviceClass,
+	LvcreateOptionClasses []*lvmdTypes.LvcreateOptionClass,
+) (
+	proto.LVServiceClient,
+	proto.VGServiceClient,
+	ReloadFunc,
 ) {
 	dcManager := internalLvmd.NewDeviceClassManager(deviceClasses)
 	lvOptionClassManager := internalLvmd.NewLvcreateOptionClassManager(LvcreateOptionClasses)
 
-	return internalLvmd.NewEmbeddedServiceClients(ctx, dcManager, lvOptionClassManager)
+	lvService, vgService, reloader := internalLvmd.NewEmbeddedServiceClients(ctx, dcManager, lvOptionClassManager)
+	return lvService, vgService, reloader.Reload
 }
@@ -568,6 +568,455 @@ func (x *ResizeLVResponse) GetSizeBytes() int64 {
 	return 0
 }
 
+// Represents the input for ModifyLV.
+// The volume must already exist. Empty fields are left unchanged.
+type ModifyLVRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Name                string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The logical volume name.
+	DeviceClass         string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	LvcreateOptionClass string                 `protobuf:"bytes,3,opt,name=lvcreate_option_class,json=lvcreateOptionClass,proto3" json:"lvcreate_option_class,omitempty"` // The lvcreate-option-class whose lvchange-options are applied.
+	CacheMode           string                 `protobuf:"bytes,4,opt,name=cache_mode,json=cacheMode,proto3" json:"cache_mode,omitempty"`                                 // The cache mode, either "writethrough" or "writeback".
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ModifyLVRequest) Reset() {
+	*x = ModifyLVRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModifyLVRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModifyLVRequest) ProtoMessage() {}
+
+func (x *ModifyLVRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModifyLVRequest.ProtoReflect.Descriptor instead.
+func (*ModifyLVRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ModifyLVRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ModifyLVRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+func (x *ModifyLVRequest) GetLvcreateOptionClass() string {
+	if x != nil {
+		return x.LvcreateOptionClass
+	}
+	return ""
+}
+
+func (x *ModifyLVRequest) GetCacheMode() string {
+	if x != nil {
+		return x.CacheMode
+	}
+	return ""
+}
+
+// Represents the input for ImportLV.
+type ImportLVRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The name of the existing logical volume.
+	DeviceClass   string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	VolumeGroup   string                 `protobuf:"bytes,3,opt,name=volume_group,json=volumeGroup,proto3" json:"volume_group,omitempty"` // The volume group of the logical volume. If set, it must be the volume group of the device class.
+	Tags          []string               `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`                                  // Tags to add to the logical volume.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportLVRequest) Reset() {
+	*x = ImportLVRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportLVRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportLVRequest) ProtoMessage() {}
+
+func (x *ImportLVRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportLVRequest.ProtoReflect.Descriptor instead.
+func (*ImportLVRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ImportLVRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ImportLVRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+func (x *ImportLVRequest) GetVolumeGroup() string {
+	if x != nil {
+		return x.VolumeGroup
+	}
+	return ""
+}
+
+func (x *ImportLVRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+// Represents the response of ImportLV.
+type ImportLVResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Volume        *LogicalVolume         `protobuf:"bytes,1,opt,name=volume,proto3" json:"volume,omitempty"` // Information of the imported volume.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportLVResponse) Reset() {
+	*x = ImportLVResponse{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportLVResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportLVResponse) ProtoMessage() {}
+
+func (x *ImportLVResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportLVResponse.ProtoReflect.Descriptor instead.
+func (*ImportLVResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ImportLVResponse) GetVolume() *LogicalVolume {
+	if x != nil {
+		return x.Volume
+	}
+	return nil
+}
+
+// Represents the input for ReadLV.
+type ReadLVRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The logical volume name.
+	DeviceClass   string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	BaseName      string                 `protobuf:"bytes,3,opt,name=base_name,json=baseName,proto3" json:"base_name,omitempty"` // If set, only the regions changed since this thin snapshot in the same thin pool are sent.
+	Alignment     uint64                 `protobuf:"varint,4,opt,name=alignment,proto3" json:"alignment,omitempty"`              // If set, the regions to be sent are expanded to multiples of this size in bytes.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadLVRequest) Reset() {
+	*x = ReadLVRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadLVRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadLVRequest) ProtoMessage() {}
+
+func (x *ReadLVRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadLVRequest.ProtoReflect.Descriptor instead.
+func (*ReadLVRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ReadLVRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ReadLVRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+func (x *ReadLVRequest) GetBaseName() string {
+	if x != nil {
+		return x.BaseName
+	}
+	return ""
+}
+
+func (x *ReadLVRequest) GetAlignment() uint64 {
+	if x != nil {
+		return x.Alignment
+	}
+	return 0
+}
+
+// Represents the stream output from ReadLV.
+//
+// The first message carries the sizes only, the following messages carry the data.
+type ReadLVResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SizeBytes     uint64                 `protobuf:"varint,1,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`    // Size of the logical volume in bytes.
+	TotalBytes    uint64                 `protobuf:"varint,2,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"` // Total amount of data to be sent in bytes. Unallocated or unchanged regions of thin volumes are not sent.
+	Offset        uint64                 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`                           // Offset of the data in the logical volume in bytes.
+	Data          []byte                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`                                // The data.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadLVResponse) Reset() {
+	*x = ReadLVResponse{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadLVResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadLVResponse) ProtoMessage() {}
+
+func (x *ReadLVResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadLVResponse.ProtoReflect.Descriptor instead.
+func (*ReadLVResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ReadLVResponse) GetSizeBytes() uint64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *ReadLVResponse) GetTotalBytes() uint64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *ReadLVResponse) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ReadLVResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Represents the stream input for WriteLV.
+//
+// The first message must carry the name, the device class and the size of the source.
+// The data must be sent in ascending order of the offsets.
+type WriteLVRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The logical volume name.
+	DeviceClass   string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	SizeBytes     uint64                 `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // Size of the source in bytes. Regions not sent are zeroed up to this size on thick volumes.
+	Offset        uint64                 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`                        // Offset of the data in the logical volume in bytes.
+	Data          []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`                             // The data.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteLVRequest) Reset() {
+	*x = WriteLVRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteLVRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteLVRequest) ProtoMessage() {}
+
+func (x *WriteLVRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteLVRequest.ProtoReflect.Descriptor instead.
+func (*WriteLVRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WriteLVRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WriteLVRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+func (x *WriteLVRequest) GetSizeBytes() uint64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *WriteLVRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *WriteLVRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Represents the response of WriteLV.
+type WriteLVResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WrittenBytes  uint64                 `protobuf:"varint,1,opt,name=written_bytes,json=writtenBytes,proto3" json:"written_bytes,omitempty"` // Amount of data written in bytes.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteLVResponse) Reset() {
+	*x = WriteLVResponse{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteLVResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteLVResponse) ProtoMessage() {}
+
+func (x *WriteLVResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteLVResponse.ProtoReflect.Descriptor instead.
+func (*WriteLVResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WriteLVResponse) GetWrittenBytes() uint64 {
+	if x != nil {
+		return x.WrittenBytes
+	}
+	return 0
+}
+
 // Represents the response of GetLVList.
 type GetLVListResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -578,7 +1027,7 @@ type GetLVListResponse struct {
 
 func (x *GetLVListResponse) Reset() {
 	*x = GetLVListResponse{}
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[9]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -590,7 +1039,7 @@ func (x *GetLVListResponse) String() string {
 func (*GetLVListResponse) ProtoMessage() {}
 
 func (x *GetLVListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[9]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -603,7 +1052,7 @@ func (x *GetLVListResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetLVListResponse.ProtoReflect.Descriptor instead.
 func (*GetLVListResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{9}
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *GetLVListResponse) GetVolumes() []*LogicalVolume {
@@ -623,7 +1072,7 @@ type GetFreeBytesResponse struct {
 
 func (x *GetFreeBytesResponse) Reset() {
 	*x = GetFreeBytesResponse{}
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[10]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -635,7 +1084,7 @@ func (x *GetFreeBytesResponse) String() string {
 func (*GetFreeBytesResponse) ProtoMessage() {}
 
 func (x *GetFreeBytesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[10]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -648,7 +1097,7 @@ func (x *GetFreeBytesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetFreeBytesResponse.ProtoReflect.Descriptor instead.
 func (*GetFreeBytesResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{10}
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *GetFreeBytesResponse) GetFreeBytes() uint64 {
@@ -667,7 +1116,7 @@ type GetLVListRequest struct {
 
 func (x *GetLVListRequest) Reset() {
 	*x = GetLVListRequest{}
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[11]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -679,7 +1128,7 @@ func (x *GetLVListRequest) String() string {
 func (*GetLVListRequest) ProtoMessage() {}
 
 func (x *GetLVListRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[11]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -692,7 +1141,7 @@ func (x *GetLVListRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetLVListRequest.ProtoReflect.Descriptor instead.
 func (*GetLVListRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{11}
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *GetLVListRequest) GetDeviceClass() string {
@@ -711,7 +1160,7 @@ type GetFreeBytesRequest struct {
 
 func (x *GetFreeBytesRequest) Reset() {
 	*x = GetFreeBytesRequest{}
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[12]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -723,7 +1172,7 @@ func (x *GetFreeBytesRequest) String() string {
 func (*GetFreeBytesRequest) ProtoMessage() {}
 
 func (x *GetFreeBytesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[12]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -736,7 +1185,7 @@ func (x *GetFreeBytesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetFreeBytesRequest.ProtoReflect.Descriptor instead.
 func (*GetFreeBytesRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{12}
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *GetFreeBytesRequest) GetDeviceClass() string {
@@ -748,16 +1197,17 @@ func (x *GetFreeBytesRequest) GetDeviceClass() string {
 
 // Represents the stream output from Watch.
 type WatchResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	FreeBytes     uint64                 `protobuf:"varint,1,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"` // Free space of the default volume group in bytes. In the case of thin pools, free space on the thinpool with overprovision in bytes.
-	Items         []*WatchItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	FreeBytes             uint64                 `protobuf:"varint,1,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"` // Free space of the default volume group in bytes. In the case of thin pools, free space on the thinpool with overprovision in bytes.
+	Items                 []*WatchItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	LvcreateOptionClasses []string               `protobuf:"bytes,3,rep,name=lvcreate_option_classes,json=lvcreateOptionClasses,proto3" json:"lvcreate_option_classes,omitempty"` // Names of the lvcreate-option-classes.
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
 }
 
 func (x *WatchResponse) Reset() {
 	*x = WatchResponse{}
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[13]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -769,7 +1219,7 @@ func (x *WatchResponse) String() string {
 func (*WatchResponse) ProtoMessage() {}
 
 func (x *WatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[13]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -782,7 +1232,7 @@ func (x *WatchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
 func (*WatchResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{13}
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *WatchResponse) GetFreeBytes() uint64 {
@@ -799,6 +1249,13 @@ func (x *WatchResponse) GetItems() []*WatchItem {
 	return nil
 }
 
+func (x *WatchResponse) GetLvcreateOptionClasses() []string {
+	if x != nil {
+		return x.LvcreateOptionClasses
+	}
+	return nil
+}
+
 // Represents the details of thinpool.
 type ThinPoolItem struct {
 	state              protoimpl.MessageState `protogen:"open.v1"`
@@ -806,13 +1263,15 @@ type ThinPoolItem struct {
 	MetadataPercent    float64                `protobuf:"fixed64,2,opt,name=metadata_percent,json=metadataPercent,proto3" json:"metadata_percent,omitempty"`         // Metadata percent occupied on the thinpool, used for monitoring.
 	OverprovisionBytes uint64                 `protobuf:"varint,3,opt,name=overprovision_bytes,json=overprovisionBytes,proto3" json:"overprovision_bytes,omitempty"` // Free space on the thinpool with overprovision, used for annotating node.
 	SizeBytes          uint64                 `protobuf:"varint,4,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`                            // Physical data space size of the thinpool.
+	DataExtensions     uint64                 `protobuf:"varint,5,opt,name=data_extensions,json=dataExtensions,proto3" json:"data_extensions,omitempty"`             // Number of times the data space has been extended automatically since lvmd started.
+	MetadataExtensions uint64                 `protobuf:"varint,6,opt,name=metadata_extensions,json=metadataExtensions,proto3" json:"metadata_extensions,omitempty"` // Number of times the metadata space has been extended automatically since lvmd started.
 	unknownFields      protoimpl.UnknownFields
 	sizeCache          protoimpl.SizeCache
 }
 
 func (x *ThinPoolItem) Reset() {
 	*x = ThinPoolItem{}
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[14]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -824,7 +1283,7 @@ func (x *ThinPoolItem) String() string {
 func (*ThinPoolItem) ProtoMessage() {}
 
 func (x *ThinPoolItem) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[14]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -837,7 +1296,7 @@ func (x *ThinPoolItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ThinPoolItem.ProtoReflect.Descriptor instead.
 func (*ThinPoolItem) Descriptor() ([]byte, []int) {
-	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{14}
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *ThinPoolItem) GetDataPercent() float64 {
@@ -868,6 +1327,129 @@ func (x *ThinPoolItem) GetSizeBytes() uint64 {
 	return 0
 }
 
+func (x *ThinPoolItem) GetDataExtensions() uint64 {
+	if x != nil {
+		return x.DataExtensions
+	}
+	return 0
+}
+
+func (x *ThinPoolItem) GetMetadataExtensions() uint64 {
+	if x != nil {
+		return x.MetadataExtensions
+	}
+	return 0
+}
+
+// Represents the cache volumes of a cached device class. The statistics are summed over the cached volumes.
+type CacheItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SizeBytes     uint64                 `protobuf:"varint,1,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`       // Size of the cache physical volumes in bytes.
+	FreeBytes     uint64                 `protobuf:"varint,2,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"`       // Free space on the cache physical volumes in bytes.
+	ReadHits      uint64                 `protobuf:"varint,3,opt,name=read_hits,json=readHits,proto3" json:"read_hits,omitempty"`          // Read hits of the caches, used for monitoring.
+	ReadMisses    uint64                 `protobuf:"varint,4,opt,name=read_misses,json=readMisses,proto3" json:"read_misses,omitempty"`    // Read misses of the caches, used for monitoring.
+	WriteHits     uint64                 `protobuf:"varint,5,opt,name=write_hits,json=writeHits,proto3" json:"write_hits,omitempty"`       // Write hits of the caches, used for monitoring.
+	WriteMisses   uint64                 `protobuf:"varint,6,opt,name=write_misses,json=writeMisses,proto3" json:"write_misses,omitempty"` // Write misses of the caches, used for monitoring.
+	UsedBlocks    uint64                 `protobuf:"varint,7,opt,name=used_blocks,json=usedBlocks,proto3" json:"used_blocks,omitempty"`    // Used blocks of the caches, used for monitoring.
+	DirtyBlocks   uint64                 `protobuf:"varint,8,opt,name=dirty_blocks,json=dirtyBlocks,proto3" json:"dirty_blocks,omitempty"` // Dirty blocks of the caches, used for monitoring.
+	TotalBlocks   uint64                 `protobuf:"varint,9,opt,name=total_blocks,json=totalBlocks,proto3" json:"total_blocks,omitempty"` // Total blocks of the caches, used for monitoring.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CacheItem) Reset() {
+	*x = CacheItem{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CacheItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CacheItem) ProtoMessage() {}
+
+func (x *CacheItem) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CacheItem.ProtoReflect.Descriptor instead.
+func (*CacheItem) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *CacheItem) GetSizeBytes() uint64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *CacheItem) GetFreeBytes() uint64 {
+	if x != nil {
+		return x.FreeBytes
+	}
+	return 0
+}
+
+func (x *CacheItem) GetReadHits() uint64 {
+	if x != nil {
+		return x.ReadHits
+	}
+	return 0
+}
+
+func (x *CacheItem) GetReadMisses() uint64 {
+	if x != nil {
+		return x.ReadMisses
+	}
+	return 0
+}
+
+func (x *CacheItem) GetWriteHits() uint64 {
+	if x != nil {
+		return x.WriteHits
+	}
+	return 0
+}
+
+func (x *CacheItem) GetWriteMisses() uint64 {
+	if x != nil {
+		return x.WriteMisses
+	}
+	return 0
+}
+
+func (x *CacheItem) GetUsedBlocks() uint64 {
+	if x != nil {
+		return x.UsedBlocks
+	}
+	return 0
+}
+
+func (x *CacheItem) GetDirtyBlocks() uint64 {
+	if x != nil {
+		return x.DirtyBlocks
+	}
+	return 0
+}
+
+func (x *CacheItem) GetTotalBlocks() uint64 {
+	if x != nil {
+		return x.TotalBlocks
+	}
+	return 0
+}
+
 // Represents the response corresponding to device class targets.
 type WatchItem struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -875,13 +1457,14 @@ type WatchItem struct {
 	DeviceClass   string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
 	SizeBytes     uint64                 `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // Size of volume group in bytes.
 	ThinPool      *ThinPoolItem          `protobuf:"bytes,4,opt,name=thin_pool,json=thinPool,proto3" json:"thin_pool,omitempty"`
+	Cache         *CacheItem             `protobuf:"bytes,5,opt,name=cache,proto3" json:"cache,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *WatchItem) Reset() {
 	*x = WatchItem{}
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[15]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -893,7 +1476,7 @@ func (x *WatchItem) String() string {
 func (*WatchItem) ProtoMessage() {}
 
 func (x *WatchItem) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[15]
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -906,7 +1489,7 @@ func (x *WatchItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WatchItem.ProtoReflect.Descriptor instead.
 func (*WatchItem) Descriptor() ([]byte, []int) {
-	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{15}
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *WatchItem) GetFreeBytes() uint64 {
@@ -937,6 +1520,13 @@ func (x *WatchItem) GetThinPool() *ThinPoolItem {
 	return nil
 }
 
+func (x *WatchItem) GetCache() *CacheItem {
+	if x != nil {
+		return x.Cache
+	}
+	return nil
+}
+
 var File_pkg_lvmd_proto_lvmd_proto protoreflect.FileDescriptor
 
 const file_pkg_lvmd_proto_lvmd_proto_rawDesc = "" +
@@ -982,7 +1572,41 @@ const file_pkg_lvmd_proto_lvmd_proto_rawDesc = "" +
 	"\fdevice_class\x18\x03 \x01(\tR\vdeviceClassJ\x04\b\x02\x10\x03\"1\n" +
 	"\x10ResizeLVResponse\x12\x1d\n" +
 	"\n" +
-	"size_bytes\x18\x01 \x01(\x03R\tsizeBytes\"C\n" +
+	"size_bytes\x18\x01 \x01(\x03R\tsizeBytes\"\x9b\x01\n" +
+	"\x0fModifyLVRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x122\n" +
+	"\x15lvcreate_option_class\x18\x03 \x01(\tR\x13lvcreateOptionClass\x12\x1d\n" +
+	"\n" +
+	"cache_mode\x18\x04 \x01(\tR\tcacheMode\"\x7f\n" +
+	"\x0fImportLVRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x12!\n" +
+	"\fvolume_group\x18\x03 \x01(\tR\vvolumeGroup\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\"@\n" +
+	"\x10ImportLVResponse\x12,\n" +
+	"\x06volume\x18\x01 \x01(\v2\x14.proto.LogicalVolumeR\x06volume\"\x81\x01\n" +
+	"\rReadLVRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x12\x1b\n" +
+	"\tbase_name\x18\x03 \x01(\tR\bbaseName\x12\x1c\n" +
+	"\talignment\x18\x04 \x01(\x04R\talignment\"|\n" +
+	"\x0eReadLVResponse\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x01 \x01(\x04R\tsizeBytes\x12\x1f\n" +
+	"\vtotal_bytes\x18\x02 \x01(\x04R\n" +
+	"totalBytes\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\"\x92\x01\n" +
+	"\x0eWriteLVRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x03 \x01(\x04R\tsizeBytes\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x04R\x06offset\x12\x12\n" +
+	"\x04data\x18\x05 \x01(\fR\x04data\"6\n" +
+	"\x0fWriteLVResponse\x12#\n" +
+	"\rwritten_bytes\x18\x01 \x01(\x04R\fwrittenBytes\"C\n" +
 	"\x11GetLVListResponse\x12.\n" +
 	"\avolumes\x18\x01 \x03(\v2\x14.proto.LogicalVolumeR\avolumes\"5\n" +
 	"\x14GetFreeBytesResponse\x12\x1d\n" +
@@ -991,29 +1615,52 @@ const file_pkg_lvmd_proto_lvmd_proto_rawDesc = "" +
 	"\x10GetLVListRequest\x12!\n" +
 	"\fdevice_class\x18\x01 \x01(\tR\vdeviceClass\"8\n" +
 	"\x13GetFreeBytesRequest\x12!\n" +
-	"\fdevice_class\x18\x01 \x01(\tR\vdeviceClass\"V\n" +
+	"\fdevice_class\x18\x01 \x01(\tR\vdeviceClass\"\x8e\x01\n" +
 	"\rWatchResponse\x12\x1d\n" +
 	"\n" +
 	"free_bytes\x18\x01 \x01(\x04R\tfreeBytes\x12&\n" +
-	"\x05items\x18\x02 \x03(\v2\x10.proto.WatchItemR\x05items\"\xac\x01\n" +
+	"\x05items\x18\x02 \x03(\v2\x10.proto.WatchItemR\x05items\x126\n" +
+	"\x17lvcreate_option_classes\x18\x03 \x03(\tR\x15lvcreateOptionClasses\"\x86\x02\n" +
 	"\fThinPoolItem\x12!\n" +
 	"\fdata_percent\x18\x01 \x01(\x01R\vdataPercent\x12)\n" +
 	"\x10metadata_percent\x18\x02 \x01(\x01R\x0fmetadataPercent\x12/\n" +
 	"\x13overprovision_bytes\x18\x03 \x01(\x04R\x12overprovisionBytes\x12\x1d\n" +
 	"\n" +
-	"size_bytes\x18\x04 \x01(\x04R\tsizeBytes\"\x9e\x01\n" +
+	"size_bytes\x18\x04 \x01(\x04R\tsizeBytes\x12'\n" +
+	"\x0fdata_extensions\x18\x05 \x01(\x04R\x0edataExtensions\x12/\n" +
+	"\x13metadata_extensions\x18\x06 \x01(\x04R\x12metadataExtensions\"\xb0\x02\n" +
+	"\tCacheItem\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x01 \x01(\x04R\tsizeBytes\x12\x1d\n" +
+	"\n" +
+	"free_bytes\x18\x02 \x01(\x04R\tfreeBytes\x12\x1b\n" +
+	"\tread_hits\x18\x03 \x01(\x04R\breadHits\x12\x1f\n" +
+	"\vread_misses\x18\x04 \x01(\x04R\n" +
+	"readMisses\x12\x1d\n" +
+	"\n" +
+	"write_hits\x18\x05 \x01(\x04R\twriteHits\x12!\n" +
+	"\fwrite_misses\x18\x06 \x01(\x04R\vwriteMisses\x12\x1f\n" +
+	"\vused_blocks\x18\a \x01(\x04R\n" +
+	"usedBlocks\x12!\n" +
+	"\fdirty_blocks\x18\b \x01(\x04R\vdirtyBlocks\x12!\n" +
+	"\ftotal_blocks\x18\t \x01(\x04R\vtotalBlocks\"\xc6\x01\n" +
 	"\tWatchItem\x12\x1d\n" +
 	"\n" +
 	"free_bytes\x18\x01 \x01(\x04R\tfreeBytes\x12!\n" +
 	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x12\x1d\n" +
 	"\n" +
 	"size_bytes\x18\x03 \x01(\x04R\tsizeBytes\x120\n" +
-	"\tthin_pool\x18\x04 \x01(\v2\x13.proto.ThinPoolItemR\bthinPool2\x8c\x02\n" +
+	"\tthin_pool\x18\x04 \x01(\v2\x13.proto.ThinPoolItemR\bthinPool\x12&\n" +
+	"\x05cache\x18\x05 \x01(\v2\x10.proto.CacheItemR\x05cache2\xf0\x03\n" +
 	"\tLVService\x12;\n" +
 	"\bCreateLV\x12\x16.proto.CreateLVRequest\x1a\x17.proto.CreateLVResponse\x120\n" +
 	"\bRemoveLV\x12\x16.proto.RemoveLVRequest\x1a\f.proto.Empty\x12;\n" +
-	"\bResizeLV\x12\x16.proto.ResizeLVRequest\x1a\x17.proto.ResizeLVResponse\x12S\n" +
-	"\x10CreateLVSnapshot\x12\x1e.proto.CreateLVSnapshotRequest\x1a\x1f.proto.CreateLVSnapshotResponse2\xc3\x01\n" +
+	"\bResizeLV\x12\x16.proto.ResizeLVRequest\x1a\x17.proto.ResizeLVResponse\x120\n" +
+	"\bModifyLV\x12\x16.proto.ModifyLVRequest\x1a\f.proto.Empty\x12S\n" +
+	"\x10CreateLVSnapshot\x12\x1e.proto.CreateLVSnapshotRequest\x1a\x1f.proto.CreateLVSnapshotResponse\x12;\n" +
+	"\bImportLV\x12\x16.proto.ImportLVRequest\x1a\x17.proto.ImportLVResponse\x127\n" +
+	"\x06ReadLV\x12\x14.proto.ReadLVRequest\x1a\x15.proto.ReadLVResponse0\x01\x12:\n" +
+	"\aWriteLV\x12\x15.proto.WriteLVRequest\x1a\x16.proto.WriteLVResponse(\x012\xc3\x01\n" +
 	"\tVGService\x12>\n" +
 	"\tGetLVList\x12\x17.proto.GetLVListRequest\x1a\x18.proto.GetLVListResponse\x12G\n" +
 	"\fGetFreeBytes\x12\x1a.proto.GetFreeBytesRequest\x1a\x1b.proto.GetFreeBytesResponse\x12-\n" +
@@ -1031,7 +1678,7 @@ func file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP() []byte {
 	return file_pkg_lvmd_proto_lvmd_proto_rawDescData
 }
 
-var file_pkg_lvmd_proto_lvmd_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_pkg_lvmd_proto_lvmd_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
 var file_pkg_lvmd_proto_lvmd_proto_goTypes = []any{
 	(*Empty)(nil),                    // 0: proto.Empty
 	(*LogicalVolume)(nil),            // 1: proto.LogicalVolume
@@ -1042,39 +1689,57 @@ var file_pkg_lvmd_proto_lvmd_proto_goTypes = []any{
 	(*CreateLVSnapshotResponse)(nil), // 6: proto.CreateLVSnapshotResponse
 	(*ResizeLVRequest)(nil),          // 7: proto.ResizeLVRequest
 	(*ResizeLVResponse)(nil),         // 8: proto.ResizeLVResponse
-	(*GetLVListResponse)(nil),        // 9: proto.GetLVListResponse
-	(*GetFreeBytesResponse)(nil),     // 10: proto.GetFreeBytesResponse
-	(*GetLVListRequest)(nil),         // 11: proto.GetLVListRequest
-	(*GetFreeBytesRequest)(nil),      // 12: proto.GetFreeBytesRequest
-	(*WatchResponse)(nil),            // 13: proto.WatchResponse
-	(*ThinPoolItem)(nil),             // 14: proto.ThinPoolItem
-	(*WatchItem)(nil),                // 15: proto.WatchItem
+	(*ModifyLVRequest)(nil),          // 9: proto.ModifyLVRequest
+	(*ImportLVRequest)(nil),          // 10: proto.ImportLVRequest
+	(*ImportLVResponse)(nil),         // 11: proto.ImportLVResponse
+	(*ReadLVRequest)(nil),            // 12: proto.ReadLVRequest
+	(*ReadLVResponse)(nil),           // 13: proto.ReadLVResponse
+	(*WriteLVRequest)(nil),           // 14: proto.WriteLVRequest
+	(*WriteLVResponse)(nil),          // 15: proto.WriteLVResponse
+	(*GetLVListResponse)(nil),        // 16: proto.GetLVListResponse
+	(*GetFreeBytesResponse)(nil),     // 17: proto.GetFreeBytesResponse
+	(*GetLVListRequest)(nil),         // 18: proto.GetLVListRequest
+	(*GetFreeBytesRequest)(nil),      // 19: proto.GetFreeBytesRequest
+	(*WatchResponse)(nil),            // 20: proto.WatchResponse
+	(*ThinPoolItem)(nil),             // 21: proto.ThinPoolItem
+	(*CacheItem)(nil),                // 22: proto.CacheItem
+	(*WatchItem)(nil),                // 23: proto.WatchItem
 }
 var file_pkg_lvmd_proto_lvmd_proto_depIdxs = []int32{
 	1,  // 0: proto.CreateLVResponse.volume:type_name -> proto.LogicalVolume
 	1,  // 1: proto.CreateLVSnapshotResponse.snapshot:type_name -> proto.LogicalVolume
-	1,  // 2: proto.GetLVListResponse.volumes:type_name -> proto.LogicalVolume
-	15, // 3: proto.WatchResponse.items:type_name -> proto.WatchItem
-	14, // 4: proto.WatchItem.thin_pool:type_name -> proto.ThinPoolItem
-	2,  // 5: proto.LVService.CreateLV:input_type -> proto.CreateLVRequest
-	4,  // 6: proto.LVService.RemoveLV:input_type -> proto.RemoveLVRequest
-	7,  // 7: proto.LVService.ResizeLV:input_type -> proto.ResizeLVRequest
-	5,  // 8: proto.LVService.CreateLVSnapshot:input_type -> proto.CreateLVSnapshotRequest
-	11, // 9: proto.VGService.GetLVList:input_type -> proto.GetLVListRequest
-	12, // 10: proto.VGService.GetFreeBytes:input_type -> proto.GetFreeBytesRequest
-	0,  // 11: proto.VGService.Watch:input_type -> proto.Empty
-	3,  // 12: proto.LVService.CreateLV:output_type -> proto.CreateLVResponse
-	0,  // 13: proto.LVService.RemoveLV:output_type -> proto.Empty
-	8,  // 14: proto.LVService.ResizeLV:output_type -> proto.ResizeLVResponse
-	6,  // 15: proto.LVService.CreateLVSnapshot:output_type -> proto.CreateLVSnapshotResponse
-	9,  // 16: proto.VGService.GetLVList:output_type -> proto.GetLVListResponse
-	10, // 17: proto.VGService.GetFreeBytes:output_type -> proto.GetFreeBytesResponse
-	13, // 18: proto.VGService.Watch:output_type -> proto.WatchResponse
-	12, // [12:19] is the sub-list for method output_type
-	5,  // [5:12] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	1,  // 2: proto.ImportLVResponse.volume:type_name -> proto.LogicalVolume
+	1,  // 3: proto.GetLVListResponse.volumes:type_name -> proto.LogicalVolume
+	23, // 4: proto.WatchResponse.items:type_name -> proto.WatchItem
+	21, // 5: proto.WatchItem.thin_pool:type_name -> proto.ThinPoolItem
+	22, // 6: proto.WatchItem.cache:type_name -> proto.CacheItem
+	2,  // 7: proto.LVService.CreateLV:input_type -> proto.CreateLVRequest
+	4,  // 8: proto.LVService.RemoveLV:input_type -> proto.RemoveLVRequest
+	7,  // 9: proto.LVService.ResizeLV:input_type -> proto.ResizeLVRequest
+	9,  // 10: proto.LVService.ModifyLV:input_type -> proto.ModifyLVRequest
+	5,  // 11: proto.LVService.CreateLVSnapshot:input_type -> proto.CreateLVSnapshotRequest
+	10, // 12: proto.LVService.ImportLV:input_type -> proto.ImportLVRequest
+	12, // 13: proto.LVService.ReadLV:input_type -> proto.ReadLVRequest
+	14, // 14: proto.LVService.WriteLV:input_type -> proto.WriteLVRequest
+	18, // 15: proto.VGService.GetLVList:input_type -> proto.GetLVListRequest
+	19, // 16: proto.VGService.GetFreeBytes:input_type -> proto.GetFreeBytesRequest
+	0,  // 17: proto.VGService.Watch:input_type -> proto.Empty
+	3,  // 18: proto.LVService.CreateLV:output_type -> proto.CreateLVResponse
+	0,  // 19: proto.LVService.RemoveLV:output_type -> proto.Empty
+	8,  // 20: proto.LVService.ResizeLV:output_type -> proto.ResizeLVResponse
+	0,  // 21: proto.LVService.ModifyLV:output_type -> proto.Empty
+	6,  // 22: proto.LVService.CreateLVSnapshot:output_type -> proto.CreateLVSnapshotResponse
+	11, // 23: proto.LVService.ImportLV:output_type -> proto.ImportLVResponse
+	13, // 24: proto.LVService.ReadLV:output_type -> proto.ReadLVResponse
+	15, // 25: proto.LVService.WriteLV:output_type -> proto.WriteLVResponse
+	16, // 26: proto.VGService.GetLVList:output_type -> proto.GetLVListResponse
+	17, // 27: proto.VGService.GetFreeBytes:output_type -> proto.GetFreeBytesResponse
+	20, // 28: proto.VGService.Watch:output_type -> proto.WatchResponse
+	18, // [18:29] is the sub-list for method output_type
+	7,  // [7:18] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_pkg_lvmd_proto_lvmd_proto_init() }
@@ -1088,7 +1753,7 @@ func file_pkg_lvmd_proto_lvmd_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_lvmd_proto_lvmd_proto_rawDesc), len(file_pkg_lvmd_proto_lvmd_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   16,
+			NumMessages:   24,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
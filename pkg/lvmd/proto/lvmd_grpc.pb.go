@@ -29,7 +29,11 @@ const (
 	LVService_CreateLV_FullMethodName         = "/proto.LVService/CreateLV"
 	LVService_RemoveLV_FullMethodName         = "/proto.LVService/RemoveLV"
 	LVService_ResizeLV_FullMethodName         = "/proto.LVService/ResizeLV"
+	LVService_ModifyLV_FullMethodName         = "/proto.LVService/ModifyLV"
 	LVService_CreateLVSnapshot_FullMethodName = "/proto.LVService/CreateLVSnapshot"
+	LVService_ImportLV_FullMethodName         = "/proto.LVService/ImportLV"
+	LVService_ReadLV_FullMethodName           = "/proto.LVService/ReadLV"
+	LVService_WriteLV_FullMethodName          = "/proto.LVService/WriteLV"
 )
 
 // LVServiceClient is the client API for LVService service.
@@ -44,7 +48,15 @@ type LVServiceClient interface {
 	RemoveLV(ctx context.Context, in *RemoveLVRequest, opts ...grpc.CallOption) (*Empty, error)
 	// Resize a logical volume.
 	ResizeLV(ctx context.Context, in *ResizeLVRequest, opts ...grpc.CallOption) (*ResizeLVResponse, error)
+	// Change the properties of a logical volume.
+	ModifyLV(ctx context.Context, in *ModifyLVRequest, opts ...grpc.CallOption) (*Empty, error)
 	CreateLVSnapshot(ctx context.Context, in *CreateLVSnapshotRequest, opts ...grpc.CallOption) (*CreateLVSnapshotResponse, error)
+	// Bring an existing logical volume under the management of TopoLVM without changing its data.
+	ImportLV(ctx context.Context, in *ImportLVRequest, opts ...grpc.CallOption) (*ImportLVResponse, error)
+	// Stream the data of a logical volume. Only allocated regions are sent for thin volumes.
+	ReadLV(ctx context.Context, in *ReadLVRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReadLVResponse], error)
+	// Write streamed data to a logical volume.
+	WriteLV(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WriteLVRequest, WriteLVResponse], error)
 }
 
 type lVServiceClient struct {
@@ -85,6 +97,16 @@ func (c *lVServiceClient) ResizeLV(ctx context.Context, in *ResizeLVRequest, opt
 	return out, nil
 }
 
+func (c *lVServiceClient) ModifyLV(ctx context.Context, in *ModifyLVRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, LVService_ModifyLV_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *lVServiceClient) CreateLVSnapshot(ctx context.Context, in *CreateLVSnapshotRequest, opts ...grpc.CallOption) (*CreateLVSnapshotResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(CreateLVSnapshotResponse)
@@ -95,6 +117,48 @@ func (c *lVServiceClient) CreateLVSnapshot(ctx context.Context, in *CreateLVSnap
 	return out, nil
 }
 
+func (c *lVServiceClient) ImportLV(ctx context.Context, in *ImportLVRequest, opts ...grpc.CallOption) (*ImportLVResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportLVResponse)
+	err := c.cc.Invoke(ctx, LVService_ImportLV_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lVServiceClient) ReadLV(ctx context.Context, in *ReadLVRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReadLVResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LVService_ServiceDesc.Streams[0], LVService_ReadLV_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReadLVRequest, ReadLVResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LVService_ReadLVClient = grpc.ServerStreamingClient[ReadLVResponse]
+
+func (c *lVServiceClient) WriteLV(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WriteLVRequest, WriteLVResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LVService_ServiceDesc.Streams[1], LVService_WriteLV_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WriteLVRequest, WriteLVResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LVService_WriteLVClient = grpc.ClientStreamingClient[WriteLVRequest, WriteLVResponse]
+
 // LVServiceServer is the server API for LVService service.
 // All implementations must embed UnimplementedLVServiceServer
 // for forward compatibility.
@@ -107,7 +171,15 @@ type LVServiceServer interface {
 	RemoveLV(context.Context, *RemoveLVRequest) (*Empty, error)
 	// Resize a logical volume.
 	ResizeLV(context.Context, *ResizeLVRequest) (*ResizeLVResponse, error)
+	// Change the properties of a logical volume.
+	ModifyLV(context.Context, *ModifyLVRequest) (*Empty, error)
 	CreateLVSnapshot(context.Context, *CreateLVSnapshotRequest) (*CreateLVSnapshotResponse, error)
+	// Bring an existing logical volume under the management of TopoLVM without changing its data.
+	ImportLV(context.Context, *ImportLVRequest) (*ImportLVResponse, error)
+	// Stream the data of a logical volume. Only allocated regions are sent for thin volumes.
+	ReadLV(*ReadLVRequest, grpc.ServerStreamingServer[ReadLVResponse]) error
+	// Write streamed data to a logical volume.
+	WriteLV(grpc.ClientStreamingServer[WriteLVRequest, WriteLVResponse]) error
 	mustEmbedUnimplementedLVServiceServer()
 }
 
@@ -127,9 +199,21 @@ func (UnimplementedLVServiceServer) RemoveLV(context.Context, *RemoveLVRequest)
 func (UnimplementedLVServiceServer) ResizeLV(context.Context, *ResizeLVRequest) (*ResizeLVResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ResizeLV not implemented")
 }
+func (UnimplementedLVServiceServer) ModifyLV(context.Context, *ModifyLVRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ModifyLV not implemented")
+}
 func (UnimplementedLVServiceServer) CreateLVSnapshot(context.Context, *CreateLVSnapshotRequest) (*CreateLVSnapshotResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateLVSnapshot not implemented")
 }
+func (UnimplementedLVServiceServer) ImportLV(context.Context, *ImportLVRequest) (*ImportLVResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportLV not implemented")
+}
+func (UnimplementedLVServiceServer) ReadLV(*ReadLVRequest, grpc.ServerStreamingServer[ReadLVResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ReadLV not implemented")
+}
+func (UnimplementedLVServiceServer) WriteLV(grpc.ClientStreamingServer[WriteLVRequest, WriteLVResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WriteLV not implemented")
+}
 func (UnimplementedLVServiceServer) mustEmbedUnimplementedLVServiceServer() {}
 func (UnimplementedLVServiceServer) testEmbeddedByValue()                   {}
 
@@ -205,6 +289,24 @@ func _LVService_ResizeLV_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LVService_ModifyLV_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModifyLVRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LVServiceServer).ModifyLV(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LVService_ModifyLV_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LVServiceServer).ModifyLV(ctx, req.(*ModifyLVRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _LVService_CreateLVSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateLVSnapshotRequest)
 	if err := dec(in); err != nil {
@@ -223,6 +325,42 @@ func _LVService_CreateLVSnapshot_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LVService_ImportLV_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportLVRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LVServiceServer).ImportLV(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LVService_ImportLV_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LVServiceServer).ImportLV(ctx, req.(*ImportLVRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LVService_ReadLV_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadLVRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LVServiceServer).ReadLV(m, &grpc.GenericServerStream[ReadLVRequest, ReadLVResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LVService_ReadLVServer = grpc.ServerStreamingServer[ReadLVResponse]
+
+func _LVService_WriteLV_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LVServiceServer).WriteLV(&grpc.GenericServerStream[WriteLVRequest, WriteLVResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LVService_WriteLVServer = grpc.ClientStreamingServer[WriteLVRequest, WriteLVResponse]
+
 // LVService_ServiceDesc is the grpc.ServiceDesc for LVService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -242,12 +380,31 @@ var LVService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ResizeLV",
 			Handler:    _LVService_ResizeLV_Handler,
 		},
+		{
+			MethodName: "ModifyLV",
+			Handler:    _LVService_ModifyLV_Handler,
+		},
 		{
 			MethodName: "CreateLVSnapshot",
 			Handler:    _LVService_CreateLVSnapshot_Handler,
 		},
+		{
+			MethodName: "ImportLV",
+			Handler:    _LVService_ImportLV_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadLV",
+			Handler:       _LVService_ReadLV_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WriteLV",
+			Handler:       _LVService_WriteLV_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/lvmd/proto/lvmd.proto",
 }
 
@@ -0,0 +1,9 @@
+package lvmd
+
+import (
+	internalLvmd "github.com/topolvm/topolvm/internal/lvmd"
+)
+
+// Provision creates or extends the volume groups and the thin pools of the device-classes
+// which have a device-selector. If dryRun is true, the changes are only logged.
+var Provision = internalLvmd.Provision
@@ -0,0 +1,9 @@
+package lvmd
+
+import (
+	internalLvmd "github.com/topolvm/topolvm/internal/lvmd"
+)
+
+// WatchConfigFile calls reload with the content of the config file when it is modified or SIGHUP is received,
+// until ctx is done.
+var WatchConfigFile = internalLvmd.WatchConfigFile
@@ -0,0 +1,13 @@
+package lvmd
+
+import (
+	"crypto/tls"
+
+	internalLvmd "github.com/topolvm/topolvm/internal/lvmd"
+)
+
+// NewClientTLSConfig returns the TLS configuration to connect to lvmd serving on TCP with mutual TLS.
+// The certificates are reloaded when the files are updated.
+func NewClientTLSConfig(certFile, keyFile, caFile, serverName string) (*tls.Config, error) {
+	return internalLvmd.NewClientTLSConfig(certFile, keyFile, caFile, serverName)
+}
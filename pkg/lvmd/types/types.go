@@ -7,12 +7,85 @@ const (
 	TypeThick = DeviceType("thick")
 )
 
+type CacheMode string
+
+const (
+	CacheModeWriteback    = CacheMode("writeback")
+	CacheModeWritethrough = CacheMode("writethrough")
+	CacheModeWritecache   = CacheMode("writecache")
+)
+
+type RAIDLevel string
+
+const (
+	RAIDLevel1  = RAIDLevel("raid1")
+	RAIDLevel5  = RAIDLevel("raid5")
+	RAIDLevel6  = RAIDLevel("raid6")
+	RAIDLevel10 = RAIDLevel("raid10")
+)
+
 // ThinPoolConfig holds the configuration of thin pool in a volume group
 type ThinPoolConfig struct {
 	// Name of thinpool
 	Name string `json:"name"`
 	// OverprovisionRatio signifies the upper bound multiplier for allowing logical volume creation in this pool
 	OverprovisionRatio float64 `json:"overprovision-ratio"`
+	// SizePercent is the size of the thin pool in percent of the volume group when the thin pool is provisioned by lvmd
+	SizePercent uint `json:"size-percent"`
+	// AutoExtend holds the configuration for extending the thin pool automatically
+	AutoExtend *ThinPoolAutoExtendConfig `json:"auto-extend"`
+}
+
+// ThinPoolAutoExtendConfig holds the configuration for extending a thin pool from the free space of its volume group
+type ThinPoolAutoExtendConfig struct {
+	// DataThresholdPercent is the usage of the data space in percent at which the data space is extended
+	DataThresholdPercent uint `json:"data-threshold-percent"`
+	// DataStepPercent is the amount by which the data space is extended, in percent of its current size
+	DataStepPercent uint `json:"data-step-percent"`
+	// MetadataThresholdPercent is the usage of the metadata space in percent at which the metadata space is extended
+	MetadataThresholdPercent uint `json:"metadata-threshold-percent"`
+	// MetadataStepPercent is the amount by which the metadata space is extended, in percent of its current size
+	MetadataStepPercent uint `json:"metadata-step-percent"`
+}
+
+// ThickSnapshotConfig holds the configuration of thick (copy-on-write) snapshots in a volume group
+type ThickSnapshotConfig struct {
+	// COWReservePercent is the size of the copy-on-write area reserved for each snapshot, in percent of the origin volume size
+	COWReservePercent uint `json:"cow-reserve-percent"`
+}
+
+// CacheConfig holds the configuration of the cache volumes of logical volumes in a volume group
+type CacheConfig struct {
+	// PhysicalVolumes are the fast physical volumes in the volume group on which cache volumes are created
+	PhysicalVolumes []string `json:"physical-volumes"`
+	// Mode is the cache mode, supports 'writethrough' (default), 'writeback' or 'writecache'
+	Mode CacheMode `json:"mode"`
+	// SizePercent is the size of the cache volume created for each logical volume, in percent of the logical volume size
+	SizePercent uint `json:"size-percent"`
+}
+
+// RAIDConfig holds the configuration of RAID logical volumes in a volume group
+type RAIDConfig struct {
+	// Level is the RAID level, supports 'raid1', 'raid5', 'raid6' or 'raid10'
+	Level RAIDLevel `json:"level"`
+	// Mirrors is the number of additional copies of the data for 'raid1' and 'raid10'
+	Mirrors uint `json:"mirrors"`
+	// Stripes is the number of data stripes for 'raid5', 'raid6' and 'raid10'
+	Stripes uint `json:"stripes"`
+}
+
+// DeviceSelector selects the raw block devices from which lvmd provisions the volume group of a device-class
+type DeviceSelector struct {
+	// Paths are glob patterns of device paths, e.g. /dev/disk/by-path/pci-0000:00:1f.2-ata-*
+	Paths []string `json:"paths"`
+	// IDs are names of devices in /dev/disk/by-id
+	IDs []string `json:"ids"`
+	// MinSizeGB is the minimum size of the devices in GiB
+	MinSizeGB *uint64 `json:"min-size-gb"`
+	// MaxSizeGB is the maximum size of the devices in GiB
+	MaxSizeGB *uint64 `json:"max-size-gb"`
+	// Rotational selects only rotational devices if true, or only non-rotational devices if false
+	Rotational *bool `json:"rotational"`
 }
 
 // DeviceClass maps between device-classes and target for logical volume creation
@@ -36,6 +109,14 @@ type DeviceClass struct {
 	Type DeviceType `json:"type"`
 	// ThinPoolConfig holds the configuration for thinpool in this volume group corresponding to the device-class
 	ThinPoolConfig *ThinPoolConfig `json:"thin-pool"`
+	// ThickSnapshotConfig holds the configuration for thick snapshots in this volume group corresponding to the device-class
+	ThickSnapshotConfig *ThickSnapshotConfig `json:"thick-snapshot"`
+	// CacheConfig holds the configuration for cache volumes in this volume group corresponding to the device-class
+	CacheConfig *CacheConfig `json:"cache"`
+	// RAIDConfig holds the configuration for RAID logical volumes in this volume group corresponding to the device-class
+	RAIDConfig *RAIDConfig `json:"raid"`
+	// DeviceSelector selects the devices from which the volume group and the thin pool of the device-class are provisioned
+	DeviceSelector *DeviceSelector `json:"device-selector"`
 }
 
 type LvcreateOptionClass struct {
@@ -43,4 +124,20 @@ type LvcreateOptionClass struct {
 	Name string `json:"name"`
 	// Options are extra arguments to pass to lvcreate
 	Options []string `json:"options"`
+	// LvchangeOptions are extra arguments to pass to lvchange when an existing volume is switched to this class
+	LvchangeOptions []string `json:"lvchange-options"`
+}
+
+// TLSConfig holds the configuration of mutual TLS for lvmd serving on TCP
+type TLSConfig struct {
+	// CertFile is the path of the PEM encoded certificate of lvmd
+	CertFile string `json:"cert-file"`
+	// KeyFile is the path of the PEM encoded private key of lvmd
+	KeyFile string `json:"key-file"`
+	// CAFile is the path of the PEM encoded CA certificates to verify the client certificates
+	CAFile string `json:"ca-file"`
+	// MutatingClients are the identities of the clients allowed to call the methods of LVService modifying logical volumes
+	// or reading their data.
+	// An identity matches the common name or one of the DNS names of a client certificate.
+	MutatingClients []string `json:"mutating-clients"`
 }